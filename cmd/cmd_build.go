@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
+	"github.com/nanovms/ops/types"
 	"github.com/spf13/cobra"
 )
 
@@ -12,12 +15,12 @@ import (
 func BuildCommand() *cobra.Command {
 	var cmdBuild = &cobra.Command{
 		Use:   "build [ELF file]",
-		Short: "Build an image from ELF",
-		Args:  cobra.MinimumNArgs(1),
+		Short: "Build an image from ELF or from a multi-stage build file",
 		Run:   buildCommandHandler,
 	}
 
 	persistentFlags := cmdBuild.PersistentFlags()
+	persistentFlags.StringP("file", "f", "", "multi-stage build file (Opsfile)")
 
 	PersistConfigCommandFlags(persistentFlags)
 	PersistBuildImageCommandFlags(persistentFlags)
@@ -39,26 +42,73 @@ func buildCommandHandler(cmd *cobra.Command, args []string) {
 
 	c := lepton.NewConfig()
 
-	c.Program = args[0]
-	checkProgramExists(c.Program)
-
 	mergeConfigContainer := NewMergeConfigContainer(configFlags, globalFlags, nightlyFlags, nanosVersionFlags, buildImageFlags)
-	err := mergeConfigContainer.Merge(c)
+	providerFlags := NewProviderCommandFlags(flags)
+
+	var imagePath string
+	var err error
+
+	opsfile, _ := flags.GetString("file")
+	if opsfile != "" {
+		if imagePath, err = buildOpsfile(opsfile, c, globalFlags.ShowDebug, mergeConfigContainer, providerFlags.TargetCloud); err != nil {
+			exitWithError(err.Error())
+		}
+	} else {
+		if len(args) == 0 {
+			exitForCmd(cmd, "ELF file or build file required")
+		}
+
+		c.Program = args[0]
+		checkProgramExists(c.Program)
+
+		err = mergeConfigContainer.Merge(c)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+
+		p, ctx, err := getProviderAndContext(c, providerFlags.TargetCloud)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+
+		if imagePath, err = p.BuildImage(ctx); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Printf("Bootable image file:%s\n", imagePath)
+	if c.SBOM != "" {
+		fmt.Printf("SBOM file:%s\n", lepton.SBOMPath(c.RunConfig.ImageName, c.SBOM))
+	}
+}
+
+// buildOpsfile builds an image from a multi-stage build file; flags are merged into the configuration
+// produced by the build file
+func buildOpsfile(file string, c *types.Config, verbose bool, mergeConfigContainer *MergeConfigContainer, targetCloud string) (string, error) {
+	f, err := ReadOpsfile(file)
 	if err != nil {
-		exitWithError(err.Error())
+		return "", err
 	}
 
-	providerFlags := NewProviderCommandFlags(flags)
+	tmpDir, err := os.MkdirTemp("", "ops-build-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
 
-	p, ctx, err := getProviderAndContext(c, providerFlags.TargetCloud)
+	pkgPath, err := f.Build(filepath.Dir(file), tmpDir, c, false, verbose)
 	if err != nil {
-		exitWithError(err.Error())
+		return "", err
 	}
 
-	var imagePath string
+	err = mergeConfigContainer.Merge(c)
+	if err != nil {
+		return "", err
+	}
 
-	if imagePath, err = p.BuildImage(ctx); err != nil {
-		log.Fatal(err)
+	p, ctx, err := getProviderAndContext(c, targetCloud)
+	if err != nil {
+		return "", err
 	}
-	fmt.Printf("Bootable image file:%s\n", imagePath)
+
+	return p.BuildImageWithPackage(ctx, pkgPath)
 }
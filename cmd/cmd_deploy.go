@@ -29,6 +29,7 @@ func DeployCommand() *cobra.Command {
 	PersistCreateInstanceFlags(persistentFlags)
 	PersistNightlyCommandFlags(persistentFlags)
 	PersistNanosVersionCommandFlags(persistentFlags)
+	PersistDeployCommandFlags(persistentFlags)
 
 	return cmdDeploy
 }
@@ -44,6 +45,11 @@ func deployCommandHandler(cmd *cobra.Command, args []string) {
 	pkgFlags := NewPkgCommandFlags(flags)
 	buildImageFlags := NewBuildImageCommandFlags(flags)
 	createInstanceFlags := NewCreateInstanceCommandFlags(flags)
+	deployFlags := NewDeployCommandFlags(flags)
+
+	if err := deployFlags.Validate(); err != nil {
+		exitWithError(err.Error())
+	}
 
 	c := lepton.NewConfig()
 
@@ -68,10 +74,26 @@ func deployCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, lepton.ConfigCapabilities(c)...)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		exitWithError(err.Error())
 	}
+	defer cancelOnInterrupt()()
+
+	if deployFlags.Strategy != "" {
+		ctx.Config().CloudConfig.Tags = append(ctx.Config().CloudConfig.Tags, types.Tag{Key: "image", Value: c.CloudConfig.ImageName})
+
+		d := newDeployment(p, ctx, deployFlags, time.Now())
+		err = d.run(func() error {
+			return buildDeployImage(p, ctx, pkgFlags)
+		})
+		if err != nil {
+			exitWithError(err.Error())
+		}
+		return
+	}
 
 	// Delete image with the same name
 	images, err := p.GetImages(ctx, "")
@@ -88,21 +110,7 @@ func deployCommandHandler(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Build image
-	var keypath string
-	if pkgFlags.Package != "" {
-		keypath, err = p.BuildImageWithPackage(ctx, pkgFlags.PackagePath())
-		if err != nil {
-			exitWithError(err.Error())
-		}
-	} else {
-		keypath, err = p.BuildImage(ctx)
-		if err != nil {
-			exitWithError("failed building image: " + err.Error())
-		}
-	}
-
-	err = p.CreateImage(ctx, keypath)
+	err = buildDeployImage(p, ctx, pkgFlags)
 	if err != nil {
 		exitWithError(err.Error())
 	}
@@ -135,3 +143,22 @@ func deployCommandHandler(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+// buildDeployImage builds and creates the deployed image
+func buildDeployImage(p lepton.Provider, ctx *lepton.Context, pkgFlags *PkgCommandFlags) error {
+	var keypath string
+	var err error
+	if pkgFlags.Package != "" {
+		keypath, err = p.BuildImageWithPackage(ctx, pkgFlags.PackagePath())
+		if err != nil {
+			return err
+		}
+	} else {
+		keypath, err = p.BuildImage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed building image: %w", err)
+		}
+	}
+
+	return p.CreateImage(ctx, keypath)
+}
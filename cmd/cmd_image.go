@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,7 +27,7 @@ func ImageCommands() *cobra.Command {
 	var cmdImage = &cobra.Command{
 		Use:       "image",
 		Short:     "manage nanos images",
-		ValidArgs: []string{"create", "list", "delete", "resize", "sync", "cat", "cp", "ls", "search", "tree", "env", "mirror"},
+		ValidArgs: []string{"create", "list", "delete", "resize", "sync", "cat", "cp", "ls", "search", "tree", "env", "mirror", "put", "rm", "fsck", "export", "diff", "sign", "verify"},
 		Args:      cobra.OnlyValidArgs,
 	}
 
@@ -38,6 +40,13 @@ func ImageCommands() *cobra.Command {
 	cmdImage.AddCommand(imageResizeCommand())
 	cmdImage.AddCommand(imageSyncCommand())
 	cmdImage.AddCommand(imageCopyCommand())
+	cmdImage.AddCommand(imagePutCommand())
+	cmdImage.AddCommand(imageRmCommand())
+	cmdImage.AddCommand(imageFsckCommand())
+	cmdImage.AddCommand(imageExportCommand())
+	cmdImage.AddCommand(imageDiffCommand())
+	cmdImage.AddCommand(imageSignCommand())
+	cmdImage.AddCommand(imageVerifyCommand())
 	cmdImage.AddCommand(imageCatCommand())
 	cmdImage.AddCommand(imageLsCommand())
 	cmdImage.AddCommand(imageTreeCommand())
@@ -104,6 +113,7 @@ func imageCreateCommandHandler(cmd *cobra.Command, args []string) {
 	if err != nil {
 		exitWithError(err.Error())
 	}
+	defer cancelOnInterrupt()()
 
 	var keypath string
 	if pkgFlags.Package != "" {
@@ -392,6 +402,7 @@ func imageResizeCommandHandler(cmd *cobra.Command, args []string) {
 	}
 
 	targetCloud, _ := cmd.Flags().GetString("target-cloud")
+	requireCapabilities(targetCloud, api.CapabilityImageResize)
 	p, err := provider.CloudProvider(targetCloud, &c.CloudConfig)
 	if err != nil {
 		exitWithError(err.Error())
@@ -418,11 +429,8 @@ func imageSyncCommand() *cobra.Command {
 
 func imageSyncCommandHandler(cmd *cobra.Command, args []string) {
 	image := args[0]
-	// TODO only accepts onprem for now, implement for other source providers later
 	source, _ := cmd.Flags().GetString("source-cloud")
-	if source != onprem.ProviderName {
-		exitWithError(source + " sync not yet implemented")
-	}
+	requireCapabilities(source, api.CapabilityImageSync)
 
 	config, _ := cmd.Flags().GetString("config")
 	conf := &types.Config{}
@@ -595,6 +603,326 @@ func imageCopyDir(reader *fs.Reader, src, dest string, dereference bool) error {
 	return nil
 }
 
+func imagePutCommand() *cobra.Command {
+	var cmdPut = &cobra.Command{
+		Use:   "put <image_name> <src>... <dest>",
+		Short: "copy files from local filesystem to image",
+		Run:   imagePutCommandHandler,
+		Args:  cobra.MinimumNArgs(3),
+	}
+	flags := cmdPut.PersistentFlags()
+	flags.StringP("mode", "m", "", "set permission bits (octal) of copied files")
+	return cmdPut
+}
+
+func imagePutCommandHandler(cmd *cobra.Command, args []string) {
+	writer := getLocalImageWriter(cmd.Flags(), args)
+	defer writer.Close()
+	imagePut(cmd, args, writer)
+}
+
+// imagePut copies local files to the filesystem of an image or volume, whose changes are only
+// committed if every file is copied
+func imagePut(cmd *cobra.Command, args []string, writer *fs.Writer) {
+	destPath := args[len(args)-1]
+	var destDir bool
+	fileInfo, err := writer.Stat(destPath)
+	if (err == nil) && fileInfo.IsDir() {
+		destDir = true
+	}
+	if (len(args) > 3) && !destDir {
+		exitWithError(fmt.Sprintf("Destination '%s' is not a directory", destPath))
+	}
+	var mode uint64
+	modeFlag, _ := cmd.Flags().GetString("mode")
+	if modeFlag != "" {
+		mode, err = strconv.ParseUint(modeFlag, 8, 32)
+		if err != nil || (os.FileMode(mode) & ^os.ModePerm) != 0 {
+			exitWithError(fmt.Sprintf("Invalid mode '%s'", modeFlag))
+		}
+	}
+	var failed bool
+	for _, srcPath := range args[1 : len(args)-1] {
+		var dest string
+		if destDir {
+			dest = path.Join(destPath, filepath.Base(srcPath))
+		} else {
+			dest = destPath
+		}
+		err = writer.WriteFile(srcPath, dest)
+		if (err == nil) && (modeFlag != "") {
+			err = writer.Chmod(dest, os.FileMode(mode))
+		}
+		if err != nil {
+			log.Errorf("Cannot copy '%s' to '%s': %v", srcPath, dest, err)
+			failed = true
+		}
+	}
+	commitWriter(writer, failed)
+}
+
+func imageRmCommand() *cobra.Command {
+	var cmdRm = &cobra.Command{
+		Use:   "rm <image_name> <path>...",
+		Short: "remove files and directories from image",
+		Run:   imageRmCommandHandler,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	flags := cmdRm.PersistentFlags()
+	flags.BoolP("recursive", "r", false, "remove directories and their contents recursively")
+	return cmdRm
+}
+
+func imageRmCommandHandler(cmd *cobra.Command, args []string) {
+	writer := getLocalImageWriter(cmd.Flags(), args)
+	defer writer.Close()
+	imageRm(cmd, args, writer)
+}
+
+// imageRm removes files from the filesystem of an image or volume, whose changes are only committed
+// if every file is removed
+func imageRm(cmd *cobra.Command, args []string, writer *fs.Writer) {
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	var failed bool
+	for _, filePath := range args[1:] {
+		var err error
+		if recursive {
+			err = writer.RemoveAll(filePath)
+		} else {
+			err = writer.Remove(filePath)
+		}
+		if err != nil {
+			log.Errorf("Cannot remove '%s': %v", filePath, err)
+			failed = true
+		}
+	}
+	commitWriter(writer, failed)
+}
+
+// commitWriter commits the changes made to a filesystem, or exits with an error leaving the
+// filesystem unchanged if some change failed
+func commitWriter(writer *fs.Writer, failed bool) {
+	if failed {
+		writer.Close()
+		exitWithError("Filesystem left unchanged")
+	}
+	err := writer.Commit()
+	if err != nil {
+		writer.Close()
+		exitWithError(err.Error())
+	}
+}
+
+func imageFsckCommand() *cobra.Command {
+	var cmdFsck = &cobra.Command{
+		Use:   "fsck <image_name>",
+		Short: "check consistency of image filesystem",
+		Run:   imageFsckCommandHandler,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmdFsck.PersistentFlags()
+	flags.BoolP("repair", "", false, "drop invalid entries and rewrite the filesystem log")
+	return cmdFsck
+}
+
+func imageFsckCommandHandler(cmd *cobra.Command, args []string) {
+	imageFsck(cmd, getLocalImagePath(cmd.Flags(), args[0]))
+}
+
+func imageFsck(cmd *cobra.Command, imagePath string) {
+	repair, _ := cmd.Flags().GetBool("repair")
+	var report *fs.CheckReport
+	var err error
+	if repair {
+		report, err = fs.Repair(imagePath)
+	} else {
+		report, err = fs.Check(imagePath)
+	}
+	if report != nil {
+		fmt.Printf("Log extensions: %d\n", report.LogExtensions)
+		fmt.Printf("Directories: %d, files: %d, symbolic links: %d\n", report.Directories, report.Files, report.Symlinks)
+		fmt.Printf("File data: %s\n", api.Bytes2Human(int64(report.DataSize)))
+		if len(report.OrphanedExtents) > 0 {
+			fmt.Printf("Orphaned extents: %d (%s)\n", len(report.OrphanedExtents),
+				api.Bytes2Human(int64(report.OrphanedSize())))
+		}
+		for _, e := range report.Errors {
+			fmt.Println(e)
+		}
+	}
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	if report.Repaired {
+		fmt.Printf("Filesystem repaired (%d errors)\n", len(report.Errors))
+	} else if len(report.Errors) > 0 {
+		exitWithError(fmt.Sprintf("Filesystem has %d errors", len(report.Errors)))
+	}
+}
+
+func imageExportCommand() *cobra.Command {
+	var cmdExport = &cobra.Command{
+		Use:   "export <image_name>",
+		Short: "export image filesystem to tar archive",
+		Run:   imageExportCommandHandler,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmdExport.PersistentFlags()
+	flags.StringP("output", "o", "", "output file (default <image_name>.tar, - for standard output)")
+	return cmdExport
+}
+
+func imageExportCommandHandler(cmd *cobra.Command, args []string) {
+	reader := getLocalImageReader(cmd.Flags(), args)
+	defer reader.Close()
+	imageExport(cmd, args, reader)
+}
+
+func imageExport(cmd *cobra.Command, args []string, reader *fs.Reader) {
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = strings.Split(path.Base(args[0]), ".")[0] + ".tar"
+	}
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			exitWithError(fmt.Sprintf("Cannot create output file: %v", err))
+		}
+		defer f.Close()
+		w = f
+	}
+	err := reader.ExportTar(w)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot export filesystem: %v", err))
+	}
+}
+
+func imageDiffCommand() *cobra.Command {
+	var cmdDiff = &cobra.Command{
+		Use:   "diff <image_a> <image_b>",
+		Short: "compare the filesystem contents of two images",
+		Run:   imageDiffCommandHandler,
+		Args:  cobra.ExactArgs(2),
+	}
+	flags := cmdDiff.PersistentFlags()
+	flags.BoolP("bootfs", "", false, "use boot filesystem")
+	return cmdDiff
+}
+
+func imageDiffCommandHandler(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	readerA := getLocalImageReader(flags, args[0:1])
+	defer readerA.Close()
+	readerB := getLocalImageReader(flags, args[1:2])
+	defer readerB.Close()
+	diff, err := fs.Diff(readerA, readerB)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot compare images: %v", err))
+	}
+
+	jsonOutput, _ := flags.GetBool("json")
+	if jsonOutput {
+		json.NewEncoder(os.Stdout).Encode(diff)
+		return
+	}
+	for _, attr := range diff.Root {
+		switch {
+		case attr.Old == "":
+			fmt.Printf("+ %s=%s\n", attr.Name, attr.New)
+		case attr.New == "":
+			fmt.Printf("- %s=%s\n", attr.Name, attr.Old)
+		default:
+			fmt.Printf("~ %s: %s -> %s\n", attr.Name, attr.Old, attr.New)
+		}
+	}
+	for _, file := range diff.Files {
+		switch {
+		case file.Old == nil:
+			fmt.Printf("+ %s %s\n", file.Path, imageDiffEntry(file.New))
+		case file.New == nil:
+			fmt.Printf("- %s %s\n", file.Path, imageDiffEntry(file.Old))
+		default:
+			fmt.Printf("~ %s %s -> %s\n", file.Path, imageDiffEntry(file.Old), imageDiffEntry(file.New))
+		}
+	}
+}
+
+func imageDiffEntry(entry *fs.DiffEntry) string {
+	switch entry.Type {
+	case "file":
+		desc := fmt.Sprintf("(%s", api.Bytes2Human(entry.Size))
+		if entry.SHA256 != "" {
+			desc += ", sha256 " + entry.SHA256[:12]
+		}
+		return desc + ")"
+	case "symlink":
+		return "(symlink to " + entry.Target + ")"
+	default:
+		return "(" + entry.Type + ")"
+	}
+}
+
+func imageSignCommand() *cobra.Command {
+	var cmdSign = &cobra.Command{
+		Use:   "sign <image_name>",
+		Short: "write a detached signature of an image",
+		Run:   imageSignCommandHandler,
+		Args:  cobra.ExactArgs(1),
+	}
+	flags := cmdSign.PersistentFlags()
+	flags.StringP("key", "k", "", "PEM private key (Ed25519, ECDSA or RSA)")
+	return cmdSign
+}
+
+func imageSignCommandHandler(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	imagePath := getLocalImagePath(flags, args[0])
+	key := readImageKey(flags)
+	sig, err := api.SignImage(imagePath, key)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	fmt.Printf("Image digest: %s\n", sig.ImageDigest)
+	fmt.Printf("Signature file: %s\n", api.ImageSignaturePath(imagePath))
+}
+
+func imageVerifyCommand() *cobra.Command {
+	var cmdVerify = &cobra.Command{
+		Use:   "verify <image_name>",
+		Short: "verify the signature of an image",
+		Run:   imageVerifyCommandHandler,
+		Args:  cobra.ExactArgs(1),
+	}
+	flags := cmdVerify.PersistentFlags()
+	flags.StringP("key", "k", "", "PEM public key")
+	return cmdVerify
+}
+
+func imageVerifyCommandHandler(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	imagePath := getLocalImagePath(flags, args[0])
+	key := readImageKey(flags)
+	sig, err := api.VerifyImage(imagePath, key)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	fmt.Printf("Image digest: %s\n", sig.ImageDigest)
+	fmt.Printf("Signature verified (%s)\n", sig.Algorithm)
+}
+
+func readImageKey(flags *pflag.FlagSet) []byte {
+	keyPath, _ := flags.GetString("key")
+	if keyPath == "" {
+		exitWithError("Key file not specified")
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot read key file: %v", err))
+	}
+	return key
+}
+
 func imageLsCommand() *cobra.Command {
 	var cmdLs = &cobra.Command{
 		Use:   "ls <image_name> [<path>]",
@@ -823,6 +1151,35 @@ func imageEnvCommandHandler(cmd *cobra.Command, args []string) {
 }
 
 func getLocalImageReader(flags *pflag.FlagSet, args []string) *fs.Reader {
+	imageName := args[0]
+	imagePath := getLocalImagePath(flags, imageName)
+
+	var reader *fs.Reader
+	var err error
+	bootFS, _ := flags.GetBool("bootfs")
+
+	switch {
+	case bootFS:
+		reader, err = fs.NewReaderBootFS(imagePath)
+	default:
+		reader, err = fs.NewReader(imagePath)
+	}
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot load image %s: %v", imageName, err))
+	}
+	return reader
+}
+
+func getLocalImageWriter(flags *pflag.FlagSet, args []string) *fs.Writer {
+	imageName := args[0]
+	writer, err := fs.NewWriter(getLocalImagePath(flags, imageName))
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot load image %s: %v", imageName, err))
+	}
+	return writer
+}
+
+func getLocalImagePath(flags *pflag.FlagSet, imageName string) string {
 	c := api.NewConfig()
 	configFlags := NewConfigCommandFlags(flags)
 	globalFlags := NewGlobalCommandFlags(flags)
@@ -835,7 +1192,6 @@ func getLocalImageReader(flags *pflag.FlagSet, args []string) *fs.Reader {
 	if c.CloudConfig.Platform != onprem.ProviderName {
 		exitWithError("Image subcommand not implemented yet for cloud images")
 	}
-	imageName := args[0]
 	imagePath := path.Join(api.LocalImageDir, imageName)
 	if _, err := os.Stat(imagePath); err != nil {
 		if err != nil {
@@ -846,20 +1202,7 @@ func getLocalImageReader(flags *pflag.FlagSet, args []string) *fs.Reader {
 			}
 		}
 	}
-
-	var reader *fs.Reader
-	bootFS, _ := flags.GetBool("bootfs")
-
-	switch {
-	case bootFS:
-		reader, err = fs.NewReaderBootFS(imagePath)
-	default:
-		reader, err = fs.NewReader(imagePath)
-	}
-	if err != nil {
-		exitWithError(fmt.Sprintf("Cannot load image %s: %v", imageName, err))
-	}
-	return reader
+	return imagePath
 }
 
 func imageMirrorCommand() *cobra.Command {
@@ -892,12 +1235,14 @@ func imageMirrorHandler(cmd *cobra.Command, args []string) {
 	// the first argument for the command can be considered as the zone
 	c.CloudConfig.Zone = args[1]
 
+	requireCapabilities(c.CloudConfig.Platform, api.CapabilityMirror)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		exitWithError(err.Error())
 	}
 
-	mirrorer, ok := p.(api.Mirrorer)
+	mirrorer, ok := api.UnwrapProvider(p).(api.Mirrorer)
 	if !ok {
 		exitWithError(fmt.Sprintf("mirroring images for cloud provider %s is not yet implemented by ops", c.CloudConfig.Platform))
 	}
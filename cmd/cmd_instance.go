@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/provider/onprem"
 	"github.com/nanovms/ops/types"
 
 	"github.com/spf13/cobra"
@@ -20,7 +21,7 @@ func InstanceCommands() *cobra.Command {
 	var cmdInstance = &cobra.Command{
 		Use:       "instance",
 		Short:     "manage nanos instances",
-		ValidArgs: []string{"create", "list", "delete", "stop", "start", "stats", "reboot", "logs"},
+		ValidArgs: []string{"create", "list", "delete", "stop", "start", "stats", "reboot", "logs", "snapshot", "restore"},
 		Args:      cobra.OnlyValidArgs,
 	}
 
@@ -35,6 +36,9 @@ func InstanceCommands() *cobra.Command {
 	cmdInstance.AddCommand(instanceStartCommand())
 	cmdInstance.AddCommand(instanceRebootCommand())
 	cmdInstance.AddCommand(instanceLogsCommand())
+	cmdInstance.AddCommand(instanceSnapshotCommand())
+	cmdInstance.AddCommand(instanceRestoreCommand())
+	cmdInstance.AddCommand(instanceSuperviseCommand())
 
 	return cmdInstance
 }
@@ -59,8 +63,13 @@ func instanceCreateCommand() *cobra.Command {
 	cmdInstanceCreate.PersistentFlags().Bool("bridged", false, "bridge [local only]")
 	cmdInstanceCreate.PersistentFlags().StringP("tap", "", "", "tap interface [local only]")
 	cmdInstanceCreate.PersistentFlags().StringP("ip-address", "", "", "static ip address [local only]")
+	cmdInstanceCreate.PersistentFlags().StringArray("allow-source", nil, "address or CIDR allowed to reach the ports of the instance on its tap interface [local only]")
+	cmdInstanceCreate.PersistentFlags().Bool("unfiltered-ports", false, "create the instance with all its ports reachable if the firewall of its tap interface cannot be set up [local only]")
 	cmdInstanceCreate.PersistentFlags().StringP("memory", "m", "", "RAM size [local only]")
 	cmdInstanceCreate.PersistentFlags().Bool("qmp", false, "qmp [local only]")
+	cmdInstanceCreate.PersistentFlags().String("hypervisor", "", "hypervisor running the instance: qemu, firecracker, cloud-hypervisor [local only]")
+	cmdInstanceCreate.PersistentFlags().String("restart", "", "restart policy of the instance: no, on-failure, always [local only]")
+	cmdInstanceCreate.PersistentFlags().Int("restart-max-retries", 0, "maximum number of restarts with the on-failure restart policy, unlimited if 0 [local only]")
 
 	return cmdInstanceCreate
 }
@@ -100,6 +109,18 @@ func instanceCreateCommandHandler(cmd *cobra.Command, args []string) {
 		c.RunConfig.NetMask = "255.255.255.0" // stubbed
 	}
 
+	// local only
+	allowedSources, _ := cmd.Flags().GetStringArray("allow-source")
+	if len(allowedSources) != 0 {
+		c.RunConfig.AllowedSources = allowedSources
+	}
+
+	// local only
+	unfilteredPorts, _ := cmd.Flags().GetBool("unfiltered-ports")
+	if unfilteredPorts {
+		c.RunConfig.UnfilteredPorts = true
+	}
+
 	// local only
 	qmp, _ := cmd.Flags().GetBool("qmp")
 	if qmp {
@@ -112,6 +133,22 @@ func instanceCreateCommandHandler(cmd *cobra.Command, args []string) {
 		c.RunConfig.Memory = mem
 	}
 
+	// local only
+	hypervisor, _ := cmd.Flags().GetString("hypervisor")
+	if hypervisor != "" {
+		c.RunConfig.Hypervisor = hypervisor
+	}
+
+	// local only
+	restart, _ := cmd.Flags().GetString("restart")
+	if restart != "" {
+		c.RunConfig.Restart = restart
+	}
+	restartMaxRetries, _ := cmd.Flags().GetInt("restart-max-retries")
+	if restartMaxRetries != 0 {
+		c.RunConfig.RestartMaxRetries = restartMaxRetries
+	}
+
 	if instanceName != "" {
 		c.RunConfig.InstanceName = instanceName
 	}
@@ -127,10 +164,13 @@ func instanceCreateCommandHandler(cmd *cobra.Command, args []string) {
 		c.RunConfig.InstanceGroup = instanceGroup
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, lepton.ConfigCapabilities(c)...)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		exitForCmd(cmd, err.Error())
 	}
+	defer cancelOnInterrupt()()
 
 	if c.Kernel == "" {
 		version, err := getCurrentVersion()
@@ -205,6 +245,8 @@ func instanceStatsCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, lepton.CapabilityInstanceStats)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		exitForCmd(cmd, err.Error())
@@ -289,6 +331,8 @@ func instanceRebootCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, lepton.CapabilityInstanceReboot)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		exitForCmd(cmd, err.Error())
@@ -327,6 +371,91 @@ func instanceStopCommandHandler(cmd *cobra.Command, args []string) {
 	}
 }
 
+func instanceSnapshotCommand() *cobra.Command {
+	var cmdInstanceSnapshot = &cobra.Command{
+		Use:   "snapshot <instance_name> [<snapshot_name>]",
+		Short: "save the state of a running onprem instance into a snapshot",
+		Run:   instanceSnapshotCommandHandler,
+		Args:  cobra.RangeArgs(1, 2),
+	}
+	return cmdInstanceSnapshot
+}
+
+func instanceSnapshotCommandHandler(cmd *cobra.Command, args []string) {
+	snapshotName := ""
+	if len(args) > 1 {
+		snapshotName = args[1]
+	}
+
+	c, err := getInstanceCommandDefaultConfig(cmd)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	p, ctx, err := getProviderAndContext(c, onprem.ProviderName)
+	if err != nil {
+		exitForCmd(cmd, err.Error())
+	}
+
+	snapshotName, err = lepton.UnwrapProvider(p).(*onprem.OnPrem).SnapshotInstance(ctx, args[0], snapshotName)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	fmt.Printf("snapshot %s created\n", snapshotName)
+}
+
+func instanceRestoreCommand() *cobra.Command {
+	var cmdInstanceRestore = &cobra.Command{
+		Use:   "restore <snapshot_name> [<instance_name>]",
+		Short: "launch an onprem instance from a snapshot",
+		Run:   instanceRestoreCommandHandler,
+		Args:  cobra.RangeArgs(1, 2),
+	}
+	return cmdInstanceRestore
+}
+
+func instanceRestoreCommandHandler(cmd *cobra.Command, args []string) {
+	instanceName := ""
+	if len(args) > 1 {
+		instanceName = args[1]
+	}
+
+	c, err := getInstanceCommandDefaultConfig(cmd)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	p, ctx, err := getProviderAndContext(c, onprem.ProviderName)
+	if err != nil {
+		exitForCmd(cmd, err.Error())
+	}
+
+	_, err = lepton.UnwrapProvider(p).(*onprem.OnPrem).RestoreSnapshot(ctx, args[0], instanceName)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+}
+
+// instanceSuperviseCommand runs the supervisor of an onprem instance with a restart policy, which is
+// started by instance create
+func instanceSuperviseCommand() *cobra.Command {
+	var cmdInstanceSupervise = &cobra.Command{
+		Use:    "supervise <instance_name|instance_id>",
+		Short:  "run the supervisor of an onprem instance",
+		Run:    instanceSuperviseCommandHandler,
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+	}
+	return cmdInstanceSupervise
+}
+
+func instanceSuperviseCommandHandler(cmd *cobra.Command, args []string) {
+	err := onprem.NewProvider().SuperviseInstance(args[0])
+	if err != nil {
+		exitWithError(err.Error())
+	}
+}
+
 func instanceLogsCommand() *cobra.Command {
 	var watch bool
 	var cmdLogsCommand = &cobra.Command{
@@ -351,6 +480,11 @@ func instanceLogsCommandHandler(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, lepton.CapabilityInstanceLogs)
+	if watch {
+		requireCapabilities(c.CloudConfig.Platform, lepton.CapabilityLogsWatch)
+	}
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		exitForCmd(cmd, err.Error())
@@ -1,16 +1,24 @@
-// currently for linux only and relies on bridgetools and dnsmasq
+// currently for linux only and relies on bridgetools; addresses are leased by a
+// DHCP server run by ops for each network
 
 package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/network"
+	"github.com/nanovms/ops/network/dhcp"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -28,6 +36,7 @@ func NetworkCommands() *cobra.Command {
 	cmdNetwork.AddCommand(networkCreateCommand())
 	cmdNetwork.AddCommand(networkListCommand())
 	cmdNetwork.AddCommand(networkDeleteCommand())
+	cmdNetwork.AddCommand(networkDHCPCommand())
 
 	return cmdNetwork
 }
@@ -41,7 +50,7 @@ func networkCreateCommand() *cobra.Command {
 	}
 
 	cmdNetworkCreate.PersistentFlags().StringP("bridgename", "", "", "bridge name")
-	cmdNetworkCreate.PersistentFlags().StringP("subnet", "", "", "subnet")
+	cmdNetworkCreate.PersistentFlags().StringP("subnet", "", "", "address of the bridge in its subnet, eg: 192.168.33.1/24; a subnet which is not used by other networks if empty")
 
 	return cmdNetworkCreate
 }
@@ -67,12 +76,9 @@ func createBridgedNetwork(bn string, subnet string) {
 		bridge = bn
 	}
 
-	// mv me elsewhere
-
-	// option; also break out class-c to provide range
-	network := "192.168.33.1/24"
-	if subnet != "" {
-		network = subnet
+	network := subnet
+	if network == "" {
+		network = allocateSubnet(readNetworks())
 	}
 
 	// get device info - "ip link show dev br0"
@@ -105,18 +111,12 @@ func createBridgedNetwork(bn string, subnet string) {
 			fmt.Println(err)
 		}
 
-		// stubbed to /24 for now
-		rz := strings.Split(network, "/")
-		rz = strings.Split(rz[0], ".")
-		tzr := rz[0] + "." + rz[1] + "." + rz[2]
-
-		ecmd = exec.Command("sudo", "dnsmasq", "--bind-interfaces", "--interface="+bridge, "--except-interface=lo", "--leasefile-ro", "--dhcp-range="+tzr+".2,"+tzr+".251,12")
-		out, err = ecmd.CombinedOutput()
+		pid, err := startDHCPServer(bridge, network)
 		if err != nil {
 			fmt.Println(err)
+		} else {
+			fmt.Printf("leasing addresses of %s on %s (dhcp server pid %d)\n", network, bridge, pid)
 		}
-
-		fmt.Println(string(out))
 	}
 
 	opshome := lepton.GetOpsHome()
@@ -155,6 +155,26 @@ func networkListCommand() *cobra.Command {
 }
 
 func networkListCommandHandler(cmd *cobra.Command, args []string) {
+	networks := readNetworks()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Network", "Leases"})
+	table.SetRowLine(true)
+
+	for _, i := range networks {
+		var rows []string
+		rows = append(rows, i.Name)
+		rows = append(rows, i.Network)
+		rows = append(rows, strconv.Itoa(activeLeases(i.Name)))
+
+		table.Append(rows)
+	}
+
+	table.Render()
+}
+
+// readNetworks returns the networks created by ops
+func readNetworks() []Network {
 	// context me
 	opshome := lepton.GetOpsHome()
 	networksPath := path.Join(opshome, "networks")
@@ -166,6 +186,11 @@ func networkListCommandHandler(cmd *cobra.Command, args []string) {
 	networks := []Network{}
 
 	for _, f := range files {
+		// the leases, the pid and the log of the DHCP server of networks are kept next to them
+		if f.IsDir() || path.Ext(f.Name()) == ".leases" || path.Ext(f.Name()) == ".pid" || path.Ext(f.Name()) == ".log" || path.Ext(f.Name()) == ".tmp" {
+			continue
+		}
+
 		fpath := path.Join(networksPath, f.Name())
 
 		body, err := os.ReadFile(fpath)
@@ -182,19 +207,46 @@ func networkListCommandHandler(cmd *cobra.Command, args []string) {
 		networks = append(networks, n)
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "Network"})
-	table.SetRowLine(true)
+	return networks
+}
 
-	for _, i := range networks {
-		var rows []string
-		rows = append(rows, i.Name)
-		rows = append(rows, i.Network)
+// allocateSubnet returns the address of the bridge of a new network, in a subnet which is not used
+// by other networks
+func allocateSubnet(networks []Network) string {
+	var cidrs []string
+	for _, n := range networks {
+		if _, _, err := net.ParseCIDR(n.Network); err == nil {
+			cidrs = append(cidrs, n.Network)
+		}
+	}
+	if len(cidrs) == 0 {
+		return "192.168.33.1/24"
+	}
 
-		table.Append(rows)
+	_, subnet, err := net.ParseCIDR(network.AllocateNewCidrBlock(cidrs))
+	if err != nil {
+		return "192.168.33.1/24"
 	}
+	gateway := subnet.IP.To4()
+	gateway[3]++
+	ones, _ := subnet.Mask.Size()
+	return fmt.Sprintf("%s/%d", gateway, ones)
+}
 
-	table.Render()
+// activeLeases returns the number of addresses leased on a network
+func activeLeases(name string) int {
+	leases, err := dhcp.ReadLeases(lepton.NetworkLeasesPath(name))
+	if err != nil {
+		fmt.Println(err)
+	}
+	n := 0
+	for _, l := range leases {
+		// declined addresses are leased to no hardware address
+		if l.MAC != "" && l.Expires.After(time.Now()) {
+			n++
+		}
+	}
+	return n
 }
 
 func networkDeleteCommand() *cobra.Command {
@@ -221,6 +273,130 @@ func removeBridge(brName string) {
 	if err != nil {
 		fmt.Println(err)
 	}
+	os.Remove(lepton.NetworkLeasesPath(brName))
+	os.Remove(dhcpLogPath(brName))
+}
+
+func networkDHCPCommand() *cobra.Command {
+	var cmdNetworkDHCP = &cobra.Command{
+		Use:    "dhcp <network_name>",
+		Short:  "run the dhcp server of a network",
+		Run:    networkDHCPCommandHandler,
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+	}
+
+	cmdNetworkDHCP.PersistentFlags().StringP("subnet", "", "", "address of the bridge in its subnet")
+	cmdNetworkDHCP.PersistentFlags().StringP("leases", "", "", "file where leases are saved")
+	cmdNetworkDHCP.PersistentFlags().StringP("pid-file", "", "", "file where the pid of the server is written once it is listening")
+
+	return cmdNetworkDHCP
+}
+
+func networkDHCPCommandHandler(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	subnet, _ := flags.GetString("subnet")
+	leases, _ := flags.GetString("leases")
+	pidFile, _ := flags.GetString("pid-file")
+
+	pool, err := dhcp.NewPool(subnet, leases)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	conn, err := dhcp.Listen(args[0])
+	if err != nil {
+		exitWithError(fmt.Sprintf("cannot listen on %s: %v", args[0], err))
+	}
+
+	if pidFile != "" {
+		err = os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+	}
+
+	fmt.Printf("leasing addresses of %s on %s\n", subnet, args[0])
+	err = dhcp.NewServer(pool).Serve(conn)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+}
+
+func dhcpPidPath(bridgeName string) string {
+	return path.Join(lepton.GetOpsHome(), "networks", bridgeName+".pid")
+}
+
+// dhcpLogPath returns the file where the output of the DHCP server of a network is written, which
+// is kept in ops home rather than in a shared directory where it could be replaced by a symlink
+func dhcpLogPath(bridgeName string) string {
+	return path.Join(lepton.GetOpsHome(), "networks", bridgeName+".log")
+}
+
+func readDHCPServerPid(bridgeName string) (int, error) {
+	body, err := os.ReadFile(dhcpPidPath(bridgeName))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(body)))
+}
+
+// startDHCPServer starts the DHCP server of a network in the background and returns its pid; the
+// server runs as root as it listens on a privileged port
+func startDHCPServer(bridgeName string, subnet string) (int, error) {
+	ops, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	logFile, err := os.Create(dhcpLogPath(bridgeName))
+	if err != nil {
+		return 0, err
+	}
+	defer logFile.Close()
+
+	os.Remove(dhcpPidPath(bridgeName))
+	args := []string{ops, "network", "dhcp", bridgeName,
+		"--subnet", subnet,
+		"--leases", lepton.NetworkLeasesPath(bridgeName),
+		"--pid-file", dhcpPidPath(bridgeName),
+	}
+
+	var server *exec.Cmd
+	if os.Geteuid() == 0 {
+		server = exec.Command(args[0], args[1:]...)
+		server.SysProcAttr = &syscall.SysProcAttr{
+			Setsid: true,
+		}
+	} else {
+		// sudo runs the server in the background once it is authenticated
+		server = exec.Command("sudo", append([]string{"-b"}, args...)...)
+		server.Stdin = os.Stdin
+	}
+	server.Stdout = logFile
+	server.Stderr = logFile
+	if err = server.Start(); err != nil {
+		return 0, fmt.Errorf("cannot start dhcp server of network %s: %w", bridgeName, err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- server.Wait()
+	}()
+
+	for retry := 0; retry < 100; retry++ {
+		if pid, err := readDHCPServerPid(bridgeName); err == nil {
+			return pid, nil
+		}
+		select {
+		case err = <-exited:
+			if err != nil {
+				return 0, fmt.Errorf("dhcp server of network %s exited: %w, see %s", bridgeName, err, dhcpLogPath(bridgeName))
+			}
+			// sudo exits once the server is in the background
+			exited = nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return 0, errors.New("timed out waiting for dhcp server of network " + bridgeName + ", see " + dhcpLogPath(bridgeName))
 }
 
 // mv elsewhere and get rid of shelling
@@ -243,14 +419,28 @@ func killBridge(bridgeName string) {
 		fmt.Println(string(out))
 	}
 
-	pid := getPidOfBridge(bridgeName)
+	pid, err := readDHCPServerPid(bridgeName)
+	if err == nil {
+		if log {
+			fmt.Printf("killing dhcp server - has a pid of #%d#\n", pid)
+		}
 
-	if log {
-		fmt.Printf("killing dnsmasq - has a pid of #%s#\n", pid)
-	}
+		ecmd = exec.Command("sudo", "kill", strconv.Itoa(pid))
+		out, err = ecmd.CombinedOutput()
+		os.Remove(dhcpPidPath(bridgeName))
+	} else {
+		// networks created by previous versions of ops are served by dnsmasq
+		dnsmasqPid := getPidOfBridge(bridgeName)
 
-	ecmd = exec.Command("sudo", "kill", "-9", pid)
-	out, err = ecmd.CombinedOutput()
+		if log {
+			fmt.Printf("killing dnsmasq - has a pid of #%s#\n", dnsmasqPid)
+		}
+
+		if dnsmasqPid != "" {
+			ecmd = exec.Command("sudo", "kill", "-9", dnsmasqPid)
+			out, err = ecmd.CombinedOutput()
+		}
+	}
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -310,6 +500,9 @@ func getBridgeLines(body string, bridgeName string) string {
 
 func getPidFromBridge(body string) string {
 	fields := strings.Fields(body)
+	if len(fields) < 2 {
+		return ""
+	}
 
 	return strings.TrimSpace(fields[1])
 }
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocateSubnet(t *testing.T) {
+	assert.Equal(t, "192.168.33.1/24", allocateSubnet(nil))
+	assert.Equal(t, "192.168.34.1/24", allocateSubnet([]Network{{Name: "br0", Network: "192.168.33.1/24"}}))
+	assert.Equal(t, "10.10.6.1/24", allocateSubnet([]Network{
+		{Name: "br0", Network: "10.10.5.1/24"},
+		{Name: "br1", Network: "10.10.2.1/24"},
+		{Name: "br2", Network: "not a subnet"},
+	}))
+}
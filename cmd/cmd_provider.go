@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	api "github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/provider"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// ProviderCommands provides the commands describing the operations supported by providers
+func ProviderCommands() *cobra.Command {
+	var cmdProvider = &cobra.Command{
+		Use:       "provider",
+		Short:     "describe the operations supported by providers",
+		ValidArgs: []string{"list", "describe"},
+		Args:      cobra.OnlyValidArgs,
+	}
+
+	cmdProvider.AddCommand(providerListCommand())
+	cmdProvider.AddCommand(providerDescribeCommand())
+
+	return cmdProvider
+}
+
+func providerListCommand() *cobra.Command {
+	var cmdProviderList = &cobra.Command{
+		Use:   "list",
+		Short: "list providers and the optional operations they support",
+		Run:   providerListCommandHandler,
+	}
+	return cmdProviderList
+}
+
+func providerListCommandHandler(cmd *cobra.Command, args []string) {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	capabilities := make(map[string]api.Capabilities)
+	for _, name := range provider.Names {
+		caps, err := provider.Capabilities(name)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+		if caps == nil {
+			caps = api.Capabilities{}
+		}
+		capabilities[name] = caps
+	}
+
+	if jsonOutput {
+		json.NewEncoder(os.Stdout).Encode(capabilities)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	header := []string{"Provider"}
+	for _, c := range api.AllCapabilities {
+		header = append(header, string(c))
+	}
+	table.SetHeader(header)
+	table.SetRowLine(true)
+
+	for _, name := range provider.Names {
+		rows := []string{name}
+		for _, c := range api.AllCapabilities {
+			rows = append(rows, supportedMark(capabilities[name].Has(c)))
+		}
+		table.Append(rows)
+	}
+
+	table.Render()
+}
+
+func providerDescribeCommand() *cobra.Command {
+	var cmdProviderDescribe = &cobra.Command{
+		Use:   "describe <provider>",
+		Short: "describe the optional operations supported by a provider",
+		Run:   providerDescribeCommandHandler,
+		Args:  cobra.ExactArgs(1),
+	}
+	return cmdProviderDescribe
+}
+
+func providerDescribeCommandHandler(cmd *cobra.Command, args []string) {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	caps, err := provider.Capabilities(args[0])
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	if jsonOutput {
+		if caps == nil {
+			caps = api.Capabilities{}
+		}
+		json.NewEncoder(os.Stdout).Encode(caps)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Capability", "Supported"})
+	table.SetRowLine(true)
+
+	for _, c := range api.AllCapabilities {
+		table.Append([]string{string(c), supportedMark(caps.Has(c))})
+	}
+
+	table.Render()
+}
+
+func supportedMark(supported bool) string {
+	if supported {
+		return "yes"
+	}
+	return "no"
+}
+
+// requireCapabilities exits with an error if a provider does not support capabilities, which is
+// checked before the provider is initialized; unknown providers are reported on initialization
+func requireCapabilities(providerName string, caps ...api.Capability) {
+	supported, err := provider.Capabilities(providerName)
+	if err != nil {
+		return
+	}
+	if err = supported.Require(providerName, caps...); err != nil {
+		exitWithError(err.Error())
+	}
+}
@@ -40,6 +40,9 @@ func GetRootCommand() *cobra.Command {
 			log.InitDefault(os.Stdout, config)
 			return nil
 		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			cleanupCancelled()
+		},
 	}
 
 	// persist flags transversal to every command
@@ -52,6 +55,7 @@ func GetRootCommand() *cobra.Command {
 	rootCmd.AddCommand(InstanceCommands())
 	rootCmd.AddCommand(NetworkCommands())
 	rootCmd.AddCommand(ProfileCommand())
+	rootCmd.AddCommand(ProviderCommands())
 	rootCmd.AddCommand(PackageCommands())
 	rootCmd.AddCommand(RunCommand())
 	rootCmd.AddCommand(ComposeCommands())
@@ -60,6 +64,8 @@ func GetRootCommand() *cobra.Command {
 	rootCmd.AddCommand(VersionCommand())
 	rootCmd.AddCommand(VolumeCommands())
 	rootCmd.AddCommand(DeployCommand())
+	rootCmd.AddCommand(PlanCommand())
+	rootCmd.AddCommand(ApplyCommand())
 
 	return rootCmd
 }
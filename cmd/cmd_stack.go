@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	api "github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/provider"
+	"github.com/nanovms/ops/stack"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// PlanCommand prints the changes applying a stack file would make
+func PlanCommand() *cobra.Command {
+	var cmdPlan = &cobra.Command{
+		Use:   "plan",
+		Short: "show the changes converging the resources of providers to a stack file",
+		Args:  cobra.NoArgs,
+		Run:   planCommandHandler,
+	}
+
+	persistStackCommandFlags(cmdPlan.PersistentFlags())
+
+	return cmdPlan
+}
+
+// ApplyCommand converges the resources of providers to a stack file
+func ApplyCommand() *cobra.Command {
+	var cmdApply = &cobra.Command{
+		Use:   "apply",
+		Short: "create, replace and delete the resources of providers to match a stack file",
+		Args:  cobra.NoArgs,
+		Run:   applyCommandHandler,
+	}
+
+	persistentFlags := cmdApply.PersistentFlags()
+	persistStackCommandFlags(persistentFlags)
+	persistentFlags.BoolP("assume-yes", "", false, "apply the changes without waiting for confirmation")
+
+	return cmdApply
+}
+
+func persistStackCommandFlags(cmdFlags *pflag.FlagSet) {
+	PersistConfigCommandFlags(cmdFlags)
+	PersistNightlyCommandFlags(cmdFlags)
+	PersistNanosVersionCommandFlags(cmdFlags)
+	cmdFlags.StringP("file", "f", "stack.yaml", "stack file")
+}
+
+func planCommandHandler(cmd *cobra.Command, args []string) {
+	planner, ctx := newStackPlanner(cmd)
+
+	plan, err := planner.Plan(ctx)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	plan.Print(os.Stdout)
+}
+
+func applyCommandHandler(cmd *cobra.Command, args []string) {
+	assumeYes, _ := cmd.Flags().GetBool("assume-yes")
+
+	planner, ctx := newStackPlanner(cmd)
+
+	plan, err := planner.Plan(ctx)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	plan.Print(os.Stdout)
+	if plan.Empty() {
+		return
+	}
+
+	if !assumeYes {
+		fmt.Println("Are you sure? (yes/no)")
+		if !askForConfirmation() {
+			return
+		}
+	}
+
+	c := ctx.Config()
+	if c.Kernel == "" {
+		version, err := getCurrentVersion()
+		if err != nil {
+			fmt.Println(err)
+		}
+		version = setKernelVersion(version)
+
+		c.Kernel = getKernelVersion(version)
+	}
+
+	stop := cancelOnInterrupt()
+	err = planner.Apply(ctx, plan)
+	stop()
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	fmt.Printf("stack '%s' applied...\n", planner.Stack.Name)
+}
+
+// newStackPlanner loads the stack file and its state, and initializes the providers of the stack
+func newStackPlanner(cmd *cobra.Command) (*stack.Planner, *api.Context) {
+	flags := cmd.Flags()
+
+	configFlags := NewConfigCommandFlags(flags)
+	globalFlags := NewGlobalCommandFlags(flags)
+	nightlyFlags := NewNightlyCommandFlags(flags)
+	nanosVersionFlags := NewNanosVersionCommandFlags(flags)
+
+	c := api.NewConfig()
+
+	mergeContainer := NewMergeConfigContainer(configFlags, globalFlags, nightlyFlags, nanosVersionFlags)
+	err := mergeContainer.Merge(c)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	file, _ := flags.GetString("file")
+	s, err := stack.Load(file)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	state, err := stack.LoadState(s.Name)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	providers := make(map[string]api.Provider)
+	for _, name := range stack.ProviderNames(s, state) {
+		pc, err := s.ProviderConfig(c, name)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+		providers[name], err = provider.CloudProvider(name, &pc.CloudConfig)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+	}
+
+	planner := &stack.Planner{
+		Stack:      s,
+		State:      state,
+		Providers:  providers,
+		BuildImage: buildStackImage,
+	}
+
+	return planner, cancellableContext(api.NewContext(c))
+}
+
+// buildStackImage builds an image of a stack and creates it on the provider like image create
+func buildStackImage(p api.Provider, ctx *api.Context, image *stack.Image) error {
+	buildImageFlags := &BuildImageCommandFlags{ImageName: image.Name}
+	pkgFlags := &PkgCommandFlags{Package: image.Package}
+
+	mergeContainer := NewMergeConfigContainer(buildImageFlags, pkgFlags)
+	err := mergeContainer.Merge(ctx.Config())
+	if err != nil {
+		return err
+	}
+
+	return buildDeployImage(p, ctx, pkgFlags)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"strconv"
@@ -21,7 +22,7 @@ func VolumeCommands() *cobra.Command {
 	cmdVolume := &cobra.Command{
 		Use:       "volume",
 		Short:     "manage nanos volumes",
-		ValidArgs: []string{"create, list, delete, attach, tree, ls, cp"},
+		ValidArgs: []string{"create, list, delete, attach, tree, ls, cp, put, rm, fsck, compact, export"},
 		Args:      cobra.OnlyValidArgs,
 	}
 
@@ -40,12 +41,17 @@ func VolumeCommands() *cobra.Command {
 	cmdVolume.AddCommand(volumeTreeCommand())
 	cmdVolume.AddCommand(volumeLsCommand())
 	cmdVolume.AddCommand(volumeCopyCommand())
+	cmdVolume.AddCommand(volumePutCommand())
+	cmdVolume.AddCommand(volumeRmCommand())
+	cmdVolume.AddCommand(volumeFsckCommand())
+	cmdVolume.AddCommand(volumeCompactCommand())
+	cmdVolume.AddCommand(volumeExportCommand())
 	cmdVolume.AddCommand(volumeInfoCommand())
 	return cmdVolume
 }
 
 func volumeCreateCommand() *cobra.Command {
-	var data, size, typeof, iops, throughput string
+	var data, size, typeof, iops, throughput, fromTar string
 	cmdVolumeCreate := &cobra.Command{
 		Use:   "create <volume_name>",
 		Short: "create volume",
@@ -57,6 +63,7 @@ func volumeCreateCommand() *cobra.Command {
 	cmdVolumeCreate.PersistentFlags().StringVarP(&typeof, "typeof", "", "", "volume type")
 	cmdVolumeCreate.PersistentFlags().StringVarP(&iops, "iops", "", "", "volume iops")
 	cmdVolumeCreate.PersistentFlags().StringVarP(&throughput, "throughput", "", "", "volume throughput")
+	cmdVolumeCreate.PersistentFlags().StringVarP(&fromTar, "from-tar", "", "", "create local volume from tar archive (- for standard input)")
 
 	return cmdVolumeCreate
 }
@@ -68,6 +75,7 @@ func volumeCreateCommandHandler(cmd *cobra.Command, args []string) {
 	typeof, _ := cmd.Flags().GetString("typeof")
 	iops, _ := cmd.Flags().GetString("iops")
 	throughput, _ := cmd.Flags().GetString("throughput")
+	fromTar, _ := cmd.Flags().GetString("from-tar")
 
 	c, err := getVolumeCommandDefaultConfig(cmd)
 	if err != nil {
@@ -77,10 +85,21 @@ func volumeCreateCommandHandler(cmd *cobra.Command, args []string) {
 		c.BaseVolumeSz = size
 	}
 
+	if fromTar != "" {
+		if data != "" {
+			exitWithError("Volume data source and tar archive cannot be used together")
+		}
+		volumeCreateFromTar(c, name, fromTar)
+		return
+	}
+
+	requireCapabilities(c.CloudConfig.Platform, api.CapabilityVolumes)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer cancelOnInterrupt()()
 
 	cv := types.CloudVolume{
 		Name:   name,
@@ -110,6 +129,39 @@ func volumeCreateCommandHandler(cmd *cobra.Command, args []string) {
 
 // TODO might be nice to be able to filter by name/label
 // api.GetVolumes can be implemented to achieve this
+func volumeCreateFromTar(c *types.Config, name, tarPath string) {
+	if c.CloudConfig.Platform != onprem.ProviderName {
+		exitWithError("Volume creation from tar archive not implemented yet for cloud volumes")
+	}
+	var tarStream io.Reader
+	if tarPath == "-" {
+		if c.BaseVolumeSz == "" {
+			exitWithError("Volume size is required when reading tar archive from standard input")
+		}
+		tarStream = os.Stdin
+	} else {
+		f, err := os.Open(tarPath)
+		if err != nil {
+			exitWithError(fmt.Sprintf("Cannot open tar archive: %v", err))
+		}
+		defer f.Close()
+		if c.BaseVolumeSz == "" {
+			info, err := f.Stat()
+			if err != nil {
+				exitWithError(fmt.Sprintf("Cannot read tar archive: %v", err))
+			}
+			// the archive size covers file contents and metadata; leave room for the filesystem log
+			c.BaseVolumeSz = strconv.FormatInt(info.Size()+4*onprem.MByte, 10)
+		}
+		tarStream = f
+	}
+	res, err := api.CreateLocalVolumeFromTar(c, name, tarStream, c.CloudConfig.Platform)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	log.Infof("volume: %s created with UUID %s and label %s\n", res.Name, res.ID, res.Label)
+}
+
 func volumeListCommand() *cobra.Command {
 	cmdVolumeList := &cobra.Command{
 		Use:   "list",
@@ -125,6 +177,8 @@ func volumeListCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, api.CapabilityVolumes)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		log.Fatal(err)
@@ -156,6 +210,8 @@ func volumeDeleteCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, api.CapabilityVolumes)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		log.Fatal(err)
@@ -197,6 +253,8 @@ func volumeAttachCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, api.CapabilityVolumes)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		log.Fatal(err)
@@ -227,6 +285,8 @@ func volumeDetachCommandHandler(cmd *cobra.Command, args []string) {
 		exitWithError(err.Error())
 	}
 
+	requireCapabilities(c.CloudConfig.Platform, api.CapabilityVolumes)
+
 	p, ctx, err := getProviderAndContext(c, c.CloudConfig.Platform)
 	if err != nil {
 		log.Fatal(err)
@@ -291,6 +351,131 @@ func volumeCopyCommandHandler(cmd *cobra.Command, args []string) {
 	imageCopy(cmd, args, reader)
 }
 
+func volumePutCommand() *cobra.Command {
+	var cmdPut = &cobra.Command{
+		Use:   "put <volume_name:volume_uuid> <src>... <dest>",
+		Short: "copy files from local filesystem to volume",
+		Run:   volumePutCommandHandler,
+		Args:  cobra.MinimumNArgs(3),
+	}
+	flags := cmdPut.PersistentFlags()
+	flags.StringP("mode", "m", "", "set permission bits (octal) of copied files")
+	return cmdPut
+}
+
+func volumePutCommandHandler(cmd *cobra.Command, args []string) {
+	writer := getLocalVolumeWriter(cmd, args)
+	defer writer.Close()
+	imagePut(cmd, args, writer)
+}
+
+func volumeRmCommand() *cobra.Command {
+	var cmdRm = &cobra.Command{
+		Use:   "rm <volume_name:volume_uuid> <path>...",
+		Short: "remove files and directories from volume",
+		Run:   volumeRmCommandHandler,
+		Args:  cobra.MinimumNArgs(2),
+	}
+	flags := cmdRm.PersistentFlags()
+	flags.BoolP("recursive", "r", false, "remove directories and their contents recursively")
+	return cmdRm
+}
+
+func volumeRmCommandHandler(cmd *cobra.Command, args []string) {
+	writer := getLocalVolumeWriter(cmd, args)
+	defer writer.Close()
+	imageRm(cmd, args, writer)
+}
+
+func volumeFsckCommand() *cobra.Command {
+	var cmdFsck = &cobra.Command{
+		Use:   "fsck <volume_name:volume_uuid>",
+		Short: "check consistency of volume filesystem",
+		Run:   volumeFsckCommandHandler,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmdFsck.PersistentFlags()
+	flags.BoolP("repair", "", false, "drop invalid entries and rewrite the filesystem log")
+	return cmdFsck
+}
+
+func volumeFsckCommandHandler(cmd *cobra.Command, args []string) {
+	volumePath := getLocalVolumePath(cmd, args[0])
+	if repair, _ := cmd.Flags().GetBool("repair"); repair {
+		exitIfVolumeAttached(cmd, args[0], volumePath)
+	}
+	imageFsck(cmd, volumePath)
+}
+
+func volumeCompactCommand() *cobra.Command {
+	var cmdCompact = &cobra.Command{
+		Use:   "compact <volume_name:volume_uuid>",
+		Short: "rewrite the filesystem log of a detached volume",
+		Run:   volumeCompactCommandHandler,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	return cmdCompact
+}
+
+func volumeCompactCommandHandler(cmd *cobra.Command, args []string) {
+	volumeNameID := args[0]
+	volumePath := getLocalVolumePath(cmd, volumeNameID)
+	exitIfVolumeAttached(cmd, volumeNameID, volumePath)
+
+	writer, err := fs.NewWriter(volumePath)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot load volume %s: %v", volumeNameID, err))
+	}
+	defer writer.Close()
+	oldLogExts := writer.LogExtensions()
+	err = writer.Compact()
+	if err == nil {
+		err = writer.Commit()
+	}
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot compact volume %s: %v", volumeNameID, err))
+	}
+	fmt.Printf("Log extensions: %d -> %d\n", oldLogExts, writer.LogExtensions())
+}
+
+// exitIfVolumeAttached exits with an error if a volume is attached to an instance, as its
+// filesystem cannot be rewritten while the instance mounts it
+func exitIfVolumeAttached(cmd *cobra.Command, volumeNameID, volumePath string) {
+	c, err := getVolumeCommandDefaultConfig(cmd)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	p, ctx, err := getProviderAndContext(c, onprem.ProviderName)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	instance, err := api.UnwrapProvider(p).(*onprem.OnPrem).VolumeAttachedTo(ctx, volumePath)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot determine whether volume %s is attached: %v", volumeNameID, err))
+	}
+	if instance != "" {
+		exitWithError(fmt.Sprintf("Volume %s is attached to instance %s, detach it first", volumeNameID, instance))
+	}
+}
+
+func volumeExportCommand() *cobra.Command {
+	var cmdExport = &cobra.Command{
+		Use:   "export <volume_name:volume_uuid>",
+		Short: "export volume filesystem to tar archive",
+		Run:   volumeExportCommandHandler,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	flags := cmdExport.PersistentFlags()
+	flags.StringP("output", "o", "", "output file (default <volume_name>.tar, - for standard output)")
+	return cmdExport
+}
+
+func volumeExportCommandHandler(cmd *cobra.Command, args []string) {
+	reader := getLocalVolumeReader(cmd, args)
+	defer reader.Close()
+	imageExport(cmd, args, reader)
+}
+
 func volumeInfoCommand() *cobra.Command {
 	var cmdInfo = &cobra.Command{
 		Use:   "info <volume_file_path>",
@@ -340,6 +525,28 @@ func getLocalReaderFromFile(cmd *cobra.Command, filePath string) *fs.Reader {
 }
 
 func getLocalVolumeReader(cmd *cobra.Command, args []string) *fs.Reader {
+	volumeNameID := args[0]
+	reader, err := fs.NewReader(getLocalVolumePath(cmd, volumeNameID))
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot load volume %s: %v", volumeNameID, err))
+	}
+	return reader
+}
+
+// getLocalVolumeWriter returns a writer of a local volume, exiting with an error if the volume is
+// attached to an instance
+func getLocalVolumeWriter(cmd *cobra.Command, args []string) *fs.Writer {
+	volumeNameID := args[0]
+	volumePath := getLocalVolumePath(cmd, volumeNameID)
+	exitIfVolumeAttached(cmd, volumeNameID, volumePath)
+	writer, err := fs.NewWriter(volumePath)
+	if err != nil {
+		exitWithError(fmt.Sprintf("Cannot load volume %s: %v", volumeNameID, err))
+	}
+	return writer
+}
+
+func getLocalVolumePath(cmd *cobra.Command, volumeNameID string) string {
 	c, err := getVolumeCommandDefaultConfig(cmd)
 	if err != nil {
 		exitWithError(err.Error())
@@ -351,15 +558,19 @@ func getLocalVolumeReader(cmd *cobra.Command, args []string) *fs.Reader {
 	if err != nil {
 		exitWithError(err.Error())
 	}
-	volumeNameID := args[0]
 	query := map[string]string{
 		"label": volumeNameID,
 		"id":    volumeNameID,
 	}
 	localVolumeDir := ctx.Config().VolumesDir
 	volumes, err := onprem.GetVolumes(localVolumeDir, query)
+	if err != nil {
+		exitWithError(err.Error())
+	}
 
-	if len(volumes) > 1 {
+	if len(volumes) == 0 {
+		exitWithError(fmt.Sprintf("Local volume %s not found", volumeNameID))
+	} else if len(volumes) > 1 {
 		exitWithError(fmt.Sprintf("Found %d volumes with the same label %s, please select by uuid", len(volumes), volumeNameID))
 	}
 	volumePath := path.Join(volumes[0].Path)
@@ -372,11 +583,7 @@ func getLocalVolumeReader(cmd *cobra.Command, args []string) *fs.Reader {
 			}
 		}
 	}
-	reader, err := fs.NewReader(volumePath)
-	if err != nil {
-		exitWithError(fmt.Sprintf("Cannot load volume %s: %v", volumeNameID, err))
-	}
-	return reader
+	return volumePath
 }
 
 func getVolumeCommandDefaultConfig(cmd *cobra.Command) (c *types.Config, err error) {
@@ -299,7 +299,11 @@ func (com Compose) spawnProgram(comp ComposePackage, dnsIP string, c *types.Conf
 	c.CloudConfig.ImageName = pname
 	c.RunConfig.QMP = true
 
-	z := p.(*onprem.OnPrem)
+	err = api.VerifyImagePolicy(ctx, path.Join(api.LocalImageDir, c.CloudConfig.ImageName))
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	z := api.UnwrapProvider(p).(*onprem.OnPrem)
 	pid, err := z.CreateInstancePID(ctx)
 	if err != nil {
 		exitWithError(err.Error())
@@ -397,7 +401,11 @@ func (com Compose) spawnDNS(non string, brName string) string {
 	}
 
 	fmt.Println("spawning instance..")
-	z := p.(*onprem.OnPrem)
+	err = api.VerifyImagePolicy(ctx, path.Join(api.LocalImageDir, c.CloudConfig.ImageName))
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	z := api.UnwrapProvider(p).(*onprem.OnPrem)
 	pid, err := z.CreateInstancePID(ctx)
 	if err != nil {
 		exitWithError(err.Error())
@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/log"
+)
+
+// unixTimeDigits is the number of digits of the unix times of generations, deployed between 2001
+// and 2286
+const unixTimeDigits = 10
+
+// Deploy strategies
+const (
+	// deployRolling boots the new generation in batches, each batch being healthy before as many
+	// instances of the previous generations are deleted and the next batch is booted
+	deployRolling = "rolling"
+
+	// deployBlueGreen boots the whole new generation at once
+	deployBlueGreen = "bluegreen"
+)
+
+// deployment replaces the instances of the previous generations of an image with a new
+// generation of instances. With the bluegreen strategy, the previous generation keeps running until
+// every instance of the new generation is healthy and the DNS record of the domain points to the
+// new generation; if anything fails, the new generation is deleted instead. The rolling strategy
+// points the domain to the new generation once its first batch is healthy, and deletes a batch of
+// the previous generation after each healthy batch; if anything fails once instances of the
+// previous generation are deleted, only the instances of the new generation which are not healthy
+// are deleted.
+type deployment struct {
+	p     lepton.Provider
+	ctx   *lepton.Context
+	flags *DeployCommandFlags
+
+	// image is the name of the deployed image, and generation the name of the image of the new
+	// generation, eg: myapp-1700000000, deployed at the unix time started; instances are named
+	// after the image of their generation
+	image      string
+	generation string
+	started    int64
+
+	// created are the instances of the new generation, and healthy the ones which passed the
+	// health probe
+	created []string
+	healthy []string
+
+	// previous are the instances of the previous generations still running, and retired the
+	// number of instances of the previous generations deleted by the rolling strategy
+	previous []string
+	retired  int
+
+	// domain is the domain pointed to the new generation, once dnsMoved is set
+	domain   string
+	dnsMoved bool
+
+	interval time.Duration
+	probe    func(url string) error
+}
+
+func newDeployment(p lepton.Provider, ctx *lepton.Context, flags *DeployCommandFlags, now time.Time) *deployment {
+	image := ctx.Config().CloudConfig.ImageName
+	return &deployment{
+		p:          p,
+		ctx:        ctx,
+		flags:      flags,
+		image:      image,
+		generation: fmt.Sprintf("%s-%d", image, now.Unix()),
+		started:    now.Unix(),
+		interval:   2 * time.Second,
+		probe:      probeHTTP,
+	}
+}
+
+// run builds the image of the new generation with build, then replaces the previous generation
+func (d *deployment) run(build func() error) error {
+	config := d.ctx.Config()
+
+	var err error
+	d.previous, err = d.previousInstances()
+	if err != nil {
+		return err
+	}
+
+	// providers update the DNS record of the domain when instances are created or deleted, it is
+	// only moved once the new generation is healthy
+	d.domain = config.CloudConfig.DomainName
+	config.CloudConfig.DomainName = ""
+	defer func() { config.CloudConfig.DomainName = d.domain }()
+
+	// the image is built at the path of the run configuration, and onprem boots instances from
+	// the image named after the cloud configuration
+	config.CloudConfig.ImageName = d.generation
+	if config.RunConfig.ImageName != "" {
+		config.RunConfig.ImageName = path.Join(path.Dir(config.RunConfig.ImageName), d.generation)
+	}
+	if err = build(); err != nil {
+		return err
+	}
+
+	if err = d.boot(); err == nil {
+		err = d.moveDNS()
+	}
+	if err != nil && d.retired > 0 {
+		d.stop()
+		return fmt.Errorf("deploy of %s stopped after replacing %d instances: %w", d.generation, d.retired, err)
+	} else if err != nil {
+		d.rollback()
+		return fmt.Errorf("deploy of %s rolled back: %w", d.generation, err)
+	}
+
+	d.deletePrevious()
+	return nil
+}
+
+// boot creates the instances of the new generation and waits for them to be healthy
+func (d *deployment) boot() error {
+	batchSize := d.flags.Replicas
+	if d.flags.Strategy == deployRolling {
+		batchSize = d.flags.BatchSize
+	}
+
+	for start := 0; start < d.flags.Replicas; start += batchSize {
+		var batch []string
+		for i := start; i < start+batchSize && i < d.flags.Replicas; i++ {
+			name := fmt.Sprintf("%s-%d", filepath.Base(d.generation), i+1)
+			// recorded first so that an instance partially created is deleted on rollback
+			d.created = append(d.created, name)
+			batch = append(batch, name)
+
+			d.ctx.Config().RunConfig.InstanceName = name
+			if err := d.p.CreateInstance(d.ctx); err != nil {
+				return fmt.Errorf("cannot create instance %s: %w", name, err)
+			}
+		}
+
+		for _, name := range batch {
+			if err := d.waitHealthy(name); err != nil {
+				return err
+			}
+			d.healthy = append(d.healthy, name)
+			fmt.Printf("instance %s is healthy\n", name)
+		}
+
+		if d.flags.Strategy == deployRolling {
+			if err := d.retire(len(batch)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// retire deletes n instances of the previous generations, once the domain points to the new
+// generation
+func (d *deployment) retire(n int) error {
+	if len(d.previous) == 0 {
+		return nil
+	}
+	if err := d.moveDNS(); err != nil {
+		return err
+	}
+	for ; n > 0 && len(d.previous) > 0; n-- {
+		name := d.previous[0]
+		d.ctx.Logger().Debugf("deleting instance %s", name)
+		if err := d.p.DeleteInstance(d.ctx, name); err != nil && !lepton.IsInstanceNotFoundError(err) {
+			log.Errorf("cannot delete instance %s of a previous generation: %v", name, err)
+		}
+		d.previous = d.previous[1:]
+		d.retired++
+	}
+	return nil
+}
+
+// waitHealthy waits for an instance to pass the health probe
+func (d *deployment) waitHealthy(name string) error {
+	deadline := time.Now().Add(d.flags.HealthTimeout)
+	for {
+		err := d.checkHealth(name)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("instance %s is not healthy after %s: %w", name, d.flags.HealthTimeout, err)
+		}
+		d.ctx.Logger().Debugf("instance %s is not healthy yet: %v", name, err)
+		select {
+		case <-d.ctx.Context().Done():
+			return fmt.Errorf("instance %s is not healthy: %w", name, d.ctx.Context().Err())
+		case <-time.After(d.interval):
+		}
+	}
+}
+
+// checkHealth probes an instance, or checks that it is running if there is no health probe
+func (d *deployment) checkHealth(name string) error {
+	instance, err := d.p.GetInstanceByName(d.ctx, name)
+	if err != nil {
+		return err
+	}
+	if d.flags.Health == "" {
+		if !instanceRunning(instance) {
+			return fmt.Errorf("instance %s is %s", name, instance.Status)
+		}
+		return nil
+	}
+	address := instanceAddress(instance)
+	if address == "" {
+		return fmt.Errorf("instance %s has no address yet", name)
+	}
+	return d.probe(healthURL(d.flags.Health, address))
+}
+
+// moveDNS points the DNS record of the domain to the first instance of the new generation, unless
+// it was already moved
+func (d *deployment) moveDNS() error {
+	domain := d.domain
+	if domain == "" || d.dnsMoved {
+		return nil
+	}
+	dns, ok := lepton.UnwrapProvider(d.p).(lepton.DNSService)
+	if !ok {
+		log.Warnf("the provider does not manage DNS records, %s is not updated", domain)
+		d.dnsMoved = true
+		return nil
+	}
+
+	instance, err := d.p.GetInstanceByName(d.ctx, d.created[0])
+	if err != nil {
+		return err
+	}
+	address := instanceAddress(instance)
+	if address == "" {
+		return fmt.Errorf("instance %s has no address to assign to %s", instance.Name, domain)
+	}
+
+	config := d.ctx.Config()
+	config.CloudConfig.DomainName = domain
+	defer func() { config.CloudConfig.DomainName = "" }()
+	if err = lepton.CreateDNSRecord(d.ctx, address, dns); err != nil {
+		return fmt.Errorf("cannot assign %s to %s: %w", address, domain, err)
+	}
+	fmt.Printf("%s points to %s\n", domain, instance.Name)
+	d.dnsMoved = true
+	return nil
+}
+
+// rollback deletes the new generation, the previous generation being left untouched
+func (d *deployment) rollback() {
+	fmt.Printf("rolling back deploy of %s\n", d.generation)
+	for _, name := range d.created {
+		if err := d.p.DeleteInstance(d.ctx, name); err != nil && !lepton.IsInstanceNotFoundError(err) {
+			log.Errorf("cannot delete instance %s: %v", name, err)
+		}
+	}
+	if err := d.p.DeleteImage(d.ctx, d.generation); err != nil {
+		log.Errorf("cannot delete image %s: %v", d.generation, err)
+	}
+}
+
+// stop deletes the instances of the new generation which are not healthy, the healthy ones
+// replacing the deleted instances of the previous generations
+func (d *deployment) stop() {
+	fmt.Printf("stopping deploy of %s\n", d.generation)
+	healthy := make(map[string]bool)
+	for _, name := range d.healthy {
+		healthy[name] = true
+	}
+	for _, name := range d.created {
+		if healthy[name] {
+			continue
+		}
+		if err := d.p.DeleteInstance(d.ctx, name); err != nil && !lepton.IsInstanceNotFoundError(err) {
+			log.Errorf("cannot delete instance %s: %v", name, err)
+		}
+	}
+}
+
+// deletePrevious deletes the instances and images of the previous generations; failures are only
+// reported as the new generation is already serving
+func (d *deployment) deletePrevious() {
+	for _, name := range d.previous {
+		d.ctx.Logger().Debugf("deleting instance %s", name)
+		if err := d.p.DeleteInstance(d.ctx, name); err != nil {
+			log.Errorf("cannot delete instance %s of a previous generation: %v", name, err)
+		}
+	}
+
+	images, err := d.p.GetImages(d.ctx, "")
+	if err != nil {
+		log.Errorf("cannot list images of previous generations: %v", err)
+		return
+	}
+	for _, image := range images {
+		if image.Name == d.generation || !d.isGeneration(image.Name) {
+			continue
+		}
+		if err := d.p.DeleteImage(d.ctx, image.Name); err != nil {
+			log.Errorf("cannot delete image %s of a previous generation: %v", image.Name, err)
+		}
+	}
+}
+
+// previousInstances returns the instances deployed from previous generations of the image,
+// including the instances deployed without a strategy: instances tagged with the image or with the
+// image of their generation, and named after a generation
+func (d *deployment) previousInstances() ([]string, error) {
+	instances, err := d.p.GetInstances(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+	var previous []string
+	for _, i := range instances {
+		if filepath.Base(i.Image) != filepath.Base(d.image) && !d.isGeneration(i.Image) {
+			continue
+		}
+		if d.isGenerationInstance(i.Name) {
+			previous = append(previous, i.Name)
+		}
+	}
+	return previous, nil
+}
+
+// isGeneration returns whether image is the image of a generation of the deployed image, named
+// after the image and the unix time of its deploy, eg: myapp-1700000000 but not myapp-2
+func (d *deployment) isGeneration(image string) bool {
+	suffix, ok := strings.CutPrefix(filepath.Base(image), filepath.Base(d.image)+"-")
+	if !ok || len(suffix) != unixTimeDigits {
+		return false
+	}
+	deployed, err := strconv.ParseInt(suffix, 10, 64)
+	return err == nil && strconv.FormatInt(deployed, 10) == suffix && deployed <= d.started
+}
+
+// isGenerationInstance returns whether name is the name of an instance of a generation: the name of
+// the generation, followed by the number of the instance if it was deployed with a strategy
+func (d *deployment) isGenerationInstance(name string) bool {
+	if d.isGeneration(name) {
+		return true
+	}
+	n := strings.LastIndex(name, "-")
+	if n < 0 {
+		return false
+	}
+	_, err := strconv.ParseUint(name[n+1:], 10, 32)
+	return err == nil && d.isGeneration(name[:n])
+}
+
+// instanceRunning returns whether the status of an instance is a running status of any provider
+func instanceRunning(instance *lepton.CloudInstance) bool {
+	switch strings.ToLower(instance.Status) {
+	case "running", "active", "started":
+		return true
+	}
+	return false
+}
+
+// instanceAddress returns the public address of an instance, or its private address
+func instanceAddress(instance *lepton.CloudInstance) string {
+	if len(instance.PublicIps) > 0 && instance.PublicIps[0] != "" {
+		return instance.PublicIps[0]
+	}
+	if len(instance.PrivateIps) > 0 {
+		return instance.PrivateIps[0]
+	}
+	return ""
+}
+
+// healthURL returns the url of the health probe of an instance, the host of the probe defaulting
+// to the address of the instance
+func healthURL(probe string, address string) string {
+	u, err := url.Parse(probe)
+	if err != nil || u.Hostname() != "" {
+		return probe
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(address, port)
+	} else {
+		u.Host = address
+	}
+	return u.String()
+}
+
+// probeHTTP returns an error unless a GET of url succeeds
+func probeHTTP(url string) error {
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/provider/onprem"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// deployProvider is a provider recording the images and instances of deployments
+type deployProvider struct {
+	lepton.Provider
+
+	images    []string
+	instances []lepton.CloudInstance
+	dns       map[string]string
+
+	// events records the creation and deletion of instances, and created counts the created
+	// instances
+	events  []string
+	created int
+
+	// failCreate fails the creation of an instance, and status is the status of the created
+	// instances, running by default
+	failCreate string
+	status     string
+}
+
+func (p *deployProvider) CreateImage(ctx *lepton.Context, imagePath string) error {
+	p.images = append(p.images, ctx.Config().CloudConfig.ImageName)
+	return nil
+}
+
+func (p *deployProvider) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
+	var images []lepton.CloudImage
+	for _, name := range p.images {
+		images = append(images, lepton.CloudImage{Name: name})
+	}
+	return images, nil
+}
+
+func (p *deployProvider) DeleteImage(ctx *lepton.Context, imagename string) error {
+	for n, name := range p.images {
+		if name == imagename {
+			p.images = append(p.images[:n], p.images[n+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("image %s not found", imagename)
+}
+
+func (p *deployProvider) CreateInstance(ctx *lepton.Context) error {
+	c := ctx.Config()
+	if c.CloudConfig.DomainName != "" {
+		return errors.New("instances must be created without domain")
+	}
+	if c.RunConfig.InstanceName == p.failCreate {
+		return errors.New("no capacity")
+	}
+	status := p.status
+	if status == "" {
+		status = "running"
+	}
+	p.created++
+	p.instances = append(p.instances, lepton.CloudInstance{
+		Name:      c.RunConfig.InstanceName,
+		Image:     c.CloudConfig.ImageName,
+		Status:    status,
+		PublicIps: []string{fmt.Sprintf("10.0.1.%d", p.created)},
+	})
+	p.events = append(p.events, "create "+c.RunConfig.InstanceName)
+	return nil
+}
+
+func (p *deployProvider) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
+	return append([]lepton.CloudInstance{}, p.instances...), nil
+}
+
+func (p *deployProvider) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.CloudInstance, error) {
+	for _, i := range p.instances {
+		if i.Name == name {
+			return &i, nil
+		}
+	}
+	return nil, lepton.ErrInstanceNotFound(name)
+}
+
+func (p *deployProvider) DeleteInstance(ctx *lepton.Context, name string) error {
+	for n, i := range p.instances {
+		if i.Name == name {
+			p.instances = append(p.instances[:n], p.instances[n+1:]...)
+			p.events = append(p.events, "delete "+name)
+			return nil
+		}
+	}
+	return lepton.ErrInstanceNotFound(name)
+}
+
+func (p *deployProvider) FindOrCreateZoneIDByName(ctx *lepton.Context, name string) (string, error) {
+	return name, nil
+}
+
+func (p *deployProvider) DeleteZoneRecordIfExists(ctx *lepton.Context, zoneID string, recordName string) error {
+	delete(p.dns, recordName)
+	return nil
+}
+
+func (p *deployProvider) CreateZoneRecord(ctx *lepton.Context, zoneID string, record *lepton.DNSRecord) error {
+	p.dns[record.Name] = record.IP
+	return nil
+}
+
+func (p *deployProvider) instanceNames() []string {
+	var names []string
+	for _, i := range p.instances {
+		names = append(names, i.Name)
+	}
+	return names
+}
+
+// onpremDeployProvider is a deployProvider keeping its images in the images directory of onprem
+type onpremDeployProvider struct {
+	*deployProvider
+	op *onprem.OnPrem
+}
+
+func (p *onpremDeployProvider) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
+	return p.op.GetImages(ctx, filter)
+}
+
+func (p *onpremDeployProvider) DeleteImage(ctx *lepton.Context, imagename string) error {
+	return p.op.DeleteImage(ctx, imagename)
+}
+
+// CreateInstance creates an instance if its image is where onprem boots instances from
+func (p *onpremDeployProvider) CreateInstance(ctx *lepton.Context) error {
+	imagePath := path.Join(lepton.GetOpsHome(), "images", ctx.Config().CloudConfig.ImageName)
+	if _, err := os.Stat(imagePath); err != nil {
+		return err
+	}
+	return p.deployProvider.CreateInstance(ctx)
+}
+
+// imageNames returns the names of the images of the provider
+func (p *onpremDeployProvider) imageNames(t *testing.T) []string {
+	images, err := p.GetImages(nil, "")
+	assert.Nil(t, err)
+	var names []string
+	for _, image := range images {
+		names = append(names, image.Name)
+	}
+	return names
+}
+
+func newTestDeployment(p *deployProvider, flags *DeployCommandFlags, healthy func(url string) error) *deployment {
+	c := &types.Config{}
+	c.CloudConfig.ImageName = "app"
+	c.CloudConfig.DomainName = "www.example.com"
+	d := newDeployment(p, lepton.NewContext(c), flags, time.Unix(1700002000, 0))
+	d.interval = time.Millisecond
+	d.probe = healthy
+	return d
+}
+
+func TestDeployment(t *testing.T) {
+	t.Run("should replace the previous generation batch by batch once healthy", func(t *testing.T) {
+		p := &deployProvider{
+			images: []string{"app-1700001000", "other"},
+			instances: []lepton.CloudInstance{
+				{Name: "app-1700001000-1", Image: "app-1700001000"}, {Name: "app-1700001000-2", Image: "app-1700001000"},
+				{Name: "app-1700000900", Image: "app"}, {Name: "app-worker", Image: "worker"}, {Name: "app-1234", Image: "other"},
+			},
+			dns: map[string]string{},
+		}
+		d := newTestDeployment(p, &DeployCommandFlags{Strategy: deployRolling, Replicas: 3, BatchSize: 2, Health: "http://:8080/healthz", HealthTimeout: time.Second}, func(url string) error {
+			p.events = append(p.events, "probe "+url)
+			return nil
+		})
+		assert.Nil(t, d.run(func() error { return p.CreateImage(d.ctx, "") }))
+
+		assert.Equal(t, []string{
+			"create app-1700002000-1", "create app-1700002000-2",
+			"probe http://10.0.1.1:8080/healthz", "probe http://10.0.1.2:8080/healthz",
+			"delete app-1700001000-1", "delete app-1700001000-2",
+			"create app-1700002000-3",
+			"probe http://10.0.1.3:8080/healthz",
+			"delete app-1700000900",
+		}, p.events)
+		assert.Equal(t, []string{"app-worker", "app-1234", "app-1700002000-1", "app-1700002000-2", "app-1700002000-3"}, p.instanceNames())
+		assert.Equal(t, []string{"other", "app-1700002000"}, p.images)
+		assert.Equal(t, map[string]string{"www.example.com.": "10.0.1.1"}, p.dns)
+		assert.Equal(t, "www.example.com", d.ctx.Config().CloudConfig.DomainName)
+	})
+
+	t.Run("should leave the images and instances of other apps named after the image", func(t *testing.T) {
+		p := &deployProvider{
+			images: []string{"app-1700001000", "app-2", "app-2-1700001000"},
+			instances: []lepton.CloudInstance{
+				{Name: "app-1700001000-1", Image: "app-1700001000"},
+				{Name: "app-2-1700001000", Image: "app-2"}, {Name: "app-2-1700001000-1", Image: "app-2-1700001000"},
+				{Name: "web", Image: "app"}, {Name: "app-18", Image: "app-18"},
+			},
+			dns: map[string]string{},
+		}
+		d := newTestDeployment(p, &DeployCommandFlags{Strategy: deployBlueGreen, Replicas: 1, HealthTimeout: time.Second}, nil)
+		assert.Nil(t, d.run(func() error { return p.CreateImage(d.ctx, "") }))
+
+		assert.Equal(t, []string{"app-2-1700001000", "app-2-1700001000-1", "web", "app-18", "app-1700002000-1"}, p.instanceNames())
+		assert.Equal(t, []string{"app-2", "app-2-1700001000", "app-1700002000"}, p.images)
+	})
+
+	t.Run("should stop a rolling deploy if an instance is not healthy after a batch was replaced", func(t *testing.T) {
+		p := &deployProvider{
+			images:    []string{"app-1700001000"},
+			instances: []lepton.CloudInstance{{Name: "app-1700001000-1", Image: "app-1700001000"}, {Name: "app-1700001000-2", Image: "app-1700001000"}},
+			dns:       map[string]string{"www.example.com.": "10.0.0.1"},
+		}
+		d := newTestDeployment(p, &DeployCommandFlags{Strategy: deployRolling, Replicas: 2, BatchSize: 1, Health: "http://:8080/healthz", HealthTimeout: 10 * time.Millisecond}, func(url string) error {
+			if url == "http://10.0.1.2:8080/healthz" {
+				return errors.New("503")
+			}
+			return nil
+		})
+
+		err := d.run(func() error { return p.CreateImage(d.ctx, "") })
+
+		assert.EqualError(t, err, "deploy of app-1700002000 stopped after replacing 1 instances: instance app-1700002000-2 is not healthy after 10ms: 503")
+		assert.Equal(t, []string{"app-1700001000-2", "app-1700002000-1"}, p.instanceNames())
+		assert.Equal(t, []string{"app-1700001000", "app-1700002000"}, p.images)
+		assert.Equal(t, map[string]string{"www.example.com.": "10.0.1.1"}, p.dns)
+	})
+
+	t.Run("should roll back if an instance is not healthy", func(t *testing.T) {
+		p := &deployProvider{
+			images:    []string{"app-1700001000"},
+			instances: []lepton.CloudInstance{{Name: "app-1700001000-1", Image: "app-1700001000"}},
+			dns:       map[string]string{"www.example.com.": "10.0.0.1"},
+		}
+		d := newTestDeployment(p, &DeployCommandFlags{Strategy: deployBlueGreen, Replicas: 2, Health: "http://:8080/healthz", HealthTimeout: 10 * time.Millisecond}, func(url string) error {
+			if url == "http://10.0.1.2:8080/healthz" {
+				return errors.New("503")
+			}
+			return nil
+		})
+
+		err := d.run(func() error { return p.CreateImage(d.ctx, "") })
+
+		assert.EqualError(t, err, "deploy of app-1700002000 rolled back: instance app-1700002000-2 is not healthy after 10ms: 503")
+		assert.Equal(t, []string{"app-1700001000-1"}, p.instanceNames())
+		assert.Equal(t, []string{"app-1700001000"}, p.images)
+		assert.Equal(t, map[string]string{"www.example.com.": "10.0.0.1"}, p.dns)
+	})
+
+	t.Run("should roll back if an instance is not running without health probe", func(t *testing.T) {
+		p := &deployProvider{
+			instances: []lepton.CloudInstance{{Name: "app-1700001000", Image: "app"}},
+			dns:       map[string]string{},
+			status:    "pending",
+		}
+		d := newTestDeployment(p, &DeployCommandFlags{Strategy: deployBlueGreen, Replicas: 1, HealthTimeout: 10 * time.Millisecond}, nil)
+
+		err := d.run(func() error { return p.CreateImage(d.ctx, "") })
+
+		assert.EqualError(t, err, "deploy of app-1700002000 rolled back: instance app-1700002000-1 is not healthy after 10ms: instance app-1700002000-1 is pending")
+		assert.Equal(t, []string{"app-1700001000"}, p.instanceNames())
+		assert.Empty(t, p.images)
+	})
+
+	t.Run("should roll back if an instance cannot be created", func(t *testing.T) {
+		p := &deployProvider{
+			instances:  []lepton.CloudInstance{{Name: "app-1700001000", Image: "app"}},
+			dns:        map[string]string{},
+			failCreate: "app-1700002000-1",
+		}
+		d := newTestDeployment(p, &DeployCommandFlags{Strategy: deployRolling, Replicas: 2, BatchSize: 1, HealthTimeout: time.Second}, nil)
+
+		err := d.run(func() error { return p.CreateImage(d.ctx, "") })
+
+		assert.EqualError(t, err, "deploy of app-1700002000 rolled back: cannot create instance app-1700002000-1: no capacity")
+		assert.Equal(t, []string{"app-1700001000"}, p.instanceNames())
+		assert.Empty(t, p.images)
+	})
+}
+
+func TestOnpremDeployment(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	images := path.Join(lepton.GetOpsHome(), "images")
+	assert.Nil(t, os.MkdirAll(images, 0755))
+	assert.Nil(t, os.WriteFile(path.Join(images, "app-1700001000"), nil, 0644))
+
+	newOnpremDeployment := func(p *onpremDeployProvider, now time.Time, healthy func(url string) error) *deployment {
+		c := &types.Config{}
+		c.CloudConfig.ImageName = "app"
+		c.RunConfig.ImageName = path.Join(images, "app")
+		d := newDeployment(p, lepton.NewContext(c), &DeployCommandFlags{Strategy: deployBlueGreen, Replicas: 1, Health: "http://:8080/", HealthTimeout: 10 * time.Millisecond}, now)
+		d.interval = time.Millisecond
+		d.probe = healthy
+		return d
+	}
+	// the image is built where the run configuration points to, as onprem builds images
+	build := func(d *deployment) func() error {
+		return func() error {
+			return os.WriteFile(d.ctx.Config().RunConfig.ImageName, nil, 0644)
+		}
+	}
+
+	t.Run("should boot the new generation from its onprem image", func(t *testing.T) {
+		p := &onpremDeployProvider{
+			deployProvider: &deployProvider{instances: []lepton.CloudInstance{{Name: "app-1700001000-1", Image: "app-1700001000"}}},
+			op:             &onprem.OnPrem{},
+		}
+		d := newOnpremDeployment(p, time.Unix(1700002000, 0), func(url string) error { return nil })
+		assert.Nil(t, d.run(build(d)))
+
+		assert.Equal(t, []string{"app-1700002000-1"}, p.instanceNames())
+		assert.Equal(t, []string{"app-1700002000"}, p.imageNames(t))
+	})
+
+	// the image of the generation deployed by the previous test is kept
+	t.Run("should delete the onprem image of the new generation on rollback", func(t *testing.T) {
+		p := &onpremDeployProvider{
+			deployProvider: &deployProvider{instances: []lepton.CloudInstance{{Name: "app-1700002000-1", Image: "app-1700002000"}}},
+			op:             &onprem.OnPrem{},
+		}
+		d := newOnpremDeployment(p, time.Unix(1700003000, 0), func(url string) error { return errors.New("503") })
+
+		assert.NotNil(t, d.run(build(d)))
+
+		assert.Equal(t, []string{"app-1700002000-1"}, p.instanceNames())
+		assert.Equal(t, []string{"app-1700002000"}, p.imageNames(t))
+	})
+}
+
+func TestHealthURL(t *testing.T) {
+	assert.Equal(t, "http://10.0.0.1:8080/healthz", healthURL("http://:8080/healthz", "10.0.0.1"))
+	assert.Equal(t, "https://10.0.0.1/healthz", healthURL("https:///healthz", "10.0.0.1"))
+	assert.Equal(t, "http://[fd00::1]:80/", healthURL("http://:80/", "fd00::1"))
+	assert.Equal(t, "http://lb.example.com/healthz", healthURL("http://lb.example.com/healthz", "10.0.0.1"))
+}
+
+func TestDeployFlagsValidate(t *testing.T) {
+	assert.Nil(t, (&DeployCommandFlags{Replicas: 1, BatchSize: 1}).Validate())
+	assert.Nil(t, (&DeployCommandFlags{Strategy: deployRolling, Replicas: 3, BatchSize: 1, Health: "http://:8080/"}).Validate())
+	assert.NotNil(t, (&DeployCommandFlags{Replicas: 3, BatchSize: 1}).Validate())
+	assert.NotNil(t, (&DeployCommandFlags{Strategy: "canary", Replicas: 1, BatchSize: 1}).Validate())
+	assert.NotNil(t, (&DeployCommandFlags{Strategy: deployBlueGreen, Replicas: 0, BatchSize: 1}).Validate())
+	assert.NotNil(t, (&DeployCommandFlags{Strategy: deployBlueGreen, Replicas: 1, BatchSize: 1, Health: "tcp://:8080"}).Validate())
+}
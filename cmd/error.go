@@ -11,6 +11,7 @@ import (
 )
 
 func exitWithError(errs string) {
+	cleanupCancelled()
 	log.Fatalf(fmt.Sprintf(constants.ErrorColor, errs))
 }
 
@@ -20,6 +20,9 @@ type BuildImageCommandFlags struct {
 	CmdEnvs         []string
 	ImageName       string
 	TFSv4           bool
+	Incremental     bool
+	Reproducible    bool
+	SBOM            string
 	Mounts          []string
 	TargetRoot      string
 	IPAddress       string
@@ -60,6 +63,21 @@ func (flags *BuildImageCommandFlags) MergeToConfig(c *types.Config) (err error)
 		c.TFSv4 = true
 	}
 
+	if flags.Incremental {
+		c.Incremental = true
+	}
+
+	if flags.Reproducible {
+		c.Reproducible = true
+	}
+
+	if flags.SBOM != "" {
+		c.SBOM = flags.SBOM
+	}
+	if c.SBOM != "" && c.SBOM != lepton.SBOMFormatSPDX && c.SBOM != lepton.SBOMFormatCycloneDX {
+		return fmt.Errorf("unknown SBOM format %q, expected %s or %s", c.SBOM, lepton.SBOMFormatSPDX, lepton.SBOMFormatCycloneDX)
+	}
+
 	setNanosBaseImage(c)
 
 	if c.RunConfig.ImageName == "" && c.Program != "" {
@@ -156,6 +174,21 @@ func NewBuildImageCommandFlags(cmdFlags *pflag.FlagSet) (flags *BuildImageComman
 		exitWithError(err.Error())
 	}
 
+	flags.Incremental, err = cmdFlags.GetBool("incremental")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.Reproducible, err = cmdFlags.GetBool("reproducible")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.SBOM, err = cmdFlags.GetString("sbom")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
 	flags.TargetRoot, err = cmdFlags.GetString("target-root")
 	if err != nil {
 		exitWithError(err.Error())
@@ -220,6 +253,9 @@ func PersistBuildImageCommandFlags(cmdFlags *pflag.FlagSet) {
 	cmdFlags.StringP("target-root", "r", "", "target root")
 	cmdFlags.StringP("imagename", "i", "", "image name")
 	cmdFlags.BoolP("tfsv4", "4", false, "use TFSv4")
+	cmdFlags.Bool("incremental", false, "only rewrite files that changed since the previous build of the image")
+	cmdFlags.Bool("reproducible", false, "build a bit-for-bit reproducible image, with file times from SOURCE_DATE_EPOCH")
+	cmdFlags.String("sbom", "", "write a software bill of materials next to the image (spdx or cyclonedx)")
 	cmdFlags.StringArray("mounts", nil, "mount <volume_id:mount_path>")
 	cmdFlags.StringArrayP("args", "a", nil, "command line arguments")
 	cmdFlags.BoolP("disable-args-copy", "", false, "disable copying of files passed as arguments")
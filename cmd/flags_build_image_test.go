@@ -24,6 +24,8 @@ func TestCreateBuildImageFlags(t *testing.T) {
 		flagSet.Set("ipv6-address", "FE80::46F:65FF:FE9C:4861")
 		flagSet.Set("gateway", "192.168.1.254")
 		flagSet.Set("netmask", "255.255.0.0")
+		flagSet.Set("sbom", "cyclonedx")
+		flagSet.Set("reproducible", "true")
 
 		buildImageFlags := NewBuildImageCommandFlags(flagSet)
 
@@ -36,6 +38,8 @@ func TestCreateBuildImageFlags(t *testing.T) {
 		assert.Equal(t, buildImageFlags.IPv6Address, "FE80::46F:65FF:FE9C:4861")
 		assert.Equal(t, buildImageFlags.Gateway, "192.168.1.254")
 		assert.Equal(t, buildImageFlags.Netmask, "255.255.0.0")
+		assert.Equal(t, buildImageFlags.SBOM, "cyclonedx")
+		assert.Equal(t, buildImageFlags.Reproducible, true)
 	})
 }
 
@@ -127,6 +131,15 @@ func TestBuildImageFlagsMergeToConfig(t *testing.T) {
 		assert.Equal(t, expected, c)
 	})
 
+	t.Run("should reject unknown SBOM formats", func(t *testing.T) {
+		flagSet := newBuildImageFlagSet()
+		flagSet.Set("sbom", "xml")
+
+		err := NewBuildImageCommandFlags(flagSet).MergeToConfig(&types.Config{})
+
+		assert.NotNil(t, err)
+	})
+
 }
 
 func newBuildImageFlagSet() (flagSet *pflag.FlagSet) {
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// DeployCommandFlags consolidates the flags selecting how deploy replaces running instances
+type DeployCommandFlags struct {
+	Strategy      string
+	Replicas      int
+	BatchSize     int
+	Health        string
+	HealthTimeout time.Duration
+}
+
+// Validate checks the flags are consistent
+func (flags *DeployCommandFlags) Validate() error {
+	switch flags.Strategy {
+	case "":
+		if flags.Replicas != 1 || flags.Health != "" {
+			return fmt.Errorf("--replicas and --health require a deploy strategy: %s or %s", deployRolling, deployBlueGreen)
+		}
+		return nil
+	case deployRolling, deployBlueGreen:
+	default:
+		return fmt.Errorf("unknown deploy strategy %q, expected %s or %s", flags.Strategy, deployRolling, deployBlueGreen)
+	}
+
+	if flags.Replicas < 1 {
+		return fmt.Errorf("invalid number of replicas %d", flags.Replicas)
+	}
+	if flags.BatchSize < 1 {
+		return fmt.Errorf("invalid batch size %d", flags.BatchSize)
+	}
+	if flags.Health != "" {
+		u, err := url.Parse(flags.Health)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid health probe %q, expected an http or https url", flags.Health)
+		}
+	}
+	return nil
+}
+
+// NewDeployCommandFlags returns an instance of DeployCommandFlags initialized with command flags values
+func NewDeployCommandFlags(cmdFlags *pflag.FlagSet) (flags *DeployCommandFlags) {
+	var err error
+	flags = &DeployCommandFlags{}
+
+	flags.Strategy, err = cmdFlags.GetString("strategy")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.Replicas, err = cmdFlags.GetInt("replicas")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.BatchSize, err = cmdFlags.GetInt("batch-size")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.Health, err = cmdFlags.GetString("health")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.HealthTimeout, err = cmdFlags.GetDuration("health-timeout")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	return
+}
+
+// PersistDeployCommandFlags append the deploy strategy flags to a command
+func PersistDeployCommandFlags(cmdFlags *pflag.FlagSet) {
+	cmdFlags.String("strategy", "", "replace running instances with a new generation of instances: rolling, bluegreen")
+	cmdFlags.Int("replicas", 1, "number of instances of the new generation")
+	cmdFlags.Int("batch-size", 1, "number of instances replaced at a time by the rolling strategy")
+	cmdFlags.String("health", "", "url probed until new instances are healthy, the instance address is used if the host is empty, eg: http://:8080/healthz; without it new instances only need to be running")
+	cmdFlags.Duration("health-timeout", 5*time.Minute, "time for new instances to become healthy")
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/nanovms/ops/types"
 
 	"github.com/spf13/pflag"
@@ -12,6 +14,7 @@ type GlobalCommandFlags struct {
 	ShowErrors   bool
 	ShowDebug    bool
 	JSON         bool
+	Timeout      time.Duration
 }
 
 // MergeToConfig append command flags that are used transversally for all commands to configuration
@@ -20,6 +23,7 @@ func (flags *GlobalCommandFlags) MergeToConfig(config *types.Config) (err error)
 	config.RunConfig.ShowErrors = flags.ShowErrors
 	config.RunConfig.ShowDebug = flags.ShowDebug
 	config.RunConfig.JSON = flags.JSON
+	config.RunConfig.Timeout = flags.Timeout
 
 	return
 }
@@ -32,6 +36,7 @@ func NewGlobalCommandFlags(cmdFlags *pflag.FlagSet) (flags *GlobalCommandFlags)
 	flags.ShowErrors, _ = cmdFlags.GetBool("show-errors")
 	flags.ShowDebug, _ = cmdFlags.GetBool("show-debug")
 	flags.JSON, _ = cmdFlags.GetBool("json")
+	flags.Timeout, _ = cmdFlags.GetDuration("timeout")
 
 	return flags
 }
@@ -42,4 +47,5 @@ func PersistGlobalCommandFlags(cmdFlags *pflag.FlagSet) {
 	cmdFlags.Bool("show-errors", false, "display error messages")
 	cmdFlags.Bool("show-debug", false, "display debug messages")
 	cmdFlags.BoolP("json", "j", false, "display json messages")
+	cmdFlags.Duration("timeout", 0, "cancel provider operations taking longer, and remove the resources they partially created, eg: 30m")
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"testing"
+	"time"
 
 	"github.com/nanovms/ops/types"
 
@@ -34,6 +35,7 @@ func TestGlobalFlagsMergeToConfig(t *testing.T) {
 	flagSet.Set("show-debug", "true")
 	flagSet.Set("show-errors", "false")
 	flagSet.Set("show-warnings", "true")
+	flagSet.Set("timeout", "10m")
 
 	globalFlags := NewGlobalCommandFlags(flagSet)
 
@@ -48,6 +50,7 @@ func TestGlobalFlagsMergeToConfig(t *testing.T) {
 			ShowDebug:    true,
 			ShowErrors:   false,
 			ShowWarnings: true,
+			Timeout:      10 * time.Minute,
 		},
 	})
 }
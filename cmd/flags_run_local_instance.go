@@ -27,10 +27,13 @@ type RunLocalInstanceCommandFlags struct {
 	Debug           bool
 	Force           bool
 	GDBPort         int
+	Hypervisor      string
 	MissingFiles    bool
 	NoTrace         []string
 	Ports           []string
 	UDPPorts        []string
+	AllowedSources  []string
+	UnfilteredPorts bool
 	SkipBuild       bool
 	Memory          string
 	Smp             int
@@ -103,6 +106,10 @@ func (flags *RunLocalInstanceCommandFlags) MergeToConfig(c *types.Config) error
 		c.RunConfig.TapName = flags.TapName
 	}
 
+	if flags.Hypervisor != "" {
+		c.RunConfig.Hypervisor = flags.Hypervisor
+	}
+
 	if flags.BridgeName != "" {
 		c.RunConfig.BridgeName = flags.BridgeName
 	}
@@ -150,6 +157,14 @@ func (flags *RunLocalInstanceCommandFlags) MergeToConfig(c *types.Config) error
 		c.RunConfig.UDPPorts = append(c.RunConfig.UDPPorts, flags.UDPPorts...)
 	}
 
+	if len(flags.AllowedSources) != 0 {
+		c.RunConfig.AllowedSources = append(c.RunConfig.AllowedSources, flags.AllowedSources...)
+	}
+
+	if flags.UnfilteredPorts {
+		c.RunConfig.UnfilteredPorts = true
+	}
+
 	for _, port := range flags.Ports {
 		conn, err := net.DialTimeout("tcp", ":"+port, time.Second)
 		if err != nil {
@@ -206,6 +221,11 @@ func NewRunLocalInstanceCommandFlags(cmdFlags *pflag.FlagSet) (flags *RunLocalIn
 		exitWithError(err.Error())
 	}
 
+	flags.Hypervisor, err = cmdFlags.GetString("hypervisor")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
 	flags.MissingFiles, err = cmdFlags.GetBool("missing-files")
 	if err != nil {
 		exitWithError(err.Error())
@@ -226,6 +246,16 @@ func NewRunLocalInstanceCommandFlags(cmdFlags *pflag.FlagSet) (flags *RunLocalIn
 		exitWithError(err.Error())
 	}
 
+	flags.AllowedSources, err = cmdFlags.GetStringArray("allow-source")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	flags.UnfilteredPorts, err = cmdFlags.GetBool("unfiltered-ports")
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
 	flags.SkipBuild, err = cmdFlags.GetBool("skipbuild")
 	if err != nil {
 		exitWithError(err.Error())
@@ -278,6 +308,8 @@ func NewRunLocalInstanceCommandFlags(cmdFlags *pflag.FlagSet) (flags *RunLocalIn
 func PersistRunLocalInstanceCommandFlags(cmdFlags *pflag.FlagSet) {
 	cmdFlags.StringArrayP("port", "p", nil, "port to forward")
 	cmdFlags.StringArrayP("udp", "", nil, "udp ports to forward")
+	cmdFlags.StringArray("allow-source", nil, "address or CIDR allowed to reach the ports of instances on a tap device")
+	cmdFlags.Bool("unfiltered-ports", false, "run instances on a tap device with all their ports reachable if their firewall cannot be set up")
 	cmdFlags.BoolP("force", "f", false, "update images")
 	cmdFlags.BoolP("debug", "d", false, "enable interactive debugger")
 	cmdFlags.BoolP("trace", "", false, "enable required flags to trace")
@@ -290,6 +322,7 @@ func PersistRunLocalInstanceCommandFlags(cmdFlags *pflag.FlagSet) {
 	cmdFlags.StringP("tapname", "t", "", "tap device name")
 	cmdFlags.BoolP("skipbuild", "s", false, "skip building image")
 	cmdFlags.Bool("accel", true, "use cpu virtualization extension")
+	cmdFlags.String("hypervisor", "", "hypervisor running the instance: qemu, firecracker, cloud-hypervisor")
 	cmdFlags.StringP("memory", "m", "", "RAM size")
 	cmdFlags.IntP("smp", "", 1, "number of threads to use")
 	cmdFlags.Bool("syscall-summary", false, "print syscall summary on exit")
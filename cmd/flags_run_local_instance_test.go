@@ -17,6 +17,7 @@ func TestCreateRunLocalInstanceFlags(t *testing.T) {
 	assert.Equal(t, runLocalInstanceFlags.Debug, true)
 	assert.Equal(t, runLocalInstanceFlags.Trace, true)
 	assert.Equal(t, runLocalInstanceFlags.GDBPort, 1234)
+	assert.Equal(t, runLocalInstanceFlags.Hypervisor, "firecracker")
 	assert.Equal(t, runLocalInstanceFlags.NoTrace, []string{"a"})
 	assert.Equal(t, runLocalInstanceFlags.Verbose, true)
 	assert.Equal(t, runLocalInstanceFlags.Bridged, true)
@@ -57,6 +58,7 @@ func TestRunLocalInstanceFlagsMergeToConfig(t *testing.T) {
 				CPUs:       2,
 				Debug:      false,
 				GdbPort:    1234,
+				Hypervisor: "firecracker",
 				Mounts:     []string(nil),
 				Ports:      []string{"80", "81", "82-85"},
 				TapName:    "tap1",
@@ -97,6 +99,23 @@ func TestRunLocalInstanceFlagsMergeToConfig(t *testing.T) {
 		assert.Equal(t, expected, c)
 	})
 
+	t.Run("should allow instances with unfiltered ports", func(t *testing.T) {
+		flagSet := pflag.NewFlagSet("test", 0)
+
+		PersistRunLocalInstanceCommandFlags(flagSet)
+
+		flagSet.Set("unfiltered-ports", "true")
+
+		runLocalInstanceFlags := NewRunLocalInstanceCommandFlags(flagSet)
+
+		c := &types.Config{}
+
+		err := runLocalInstanceFlags.MergeToConfig(c)
+
+		assert.Nil(t, err, nil)
+		assert.True(t, c.RunConfig.UnfilteredPorts)
+	})
+
 }
 
 func newRunLocalInstanceFlagSet(debug string) *RunLocalInstanceCommandFlags {
@@ -109,6 +128,7 @@ func newRunLocalInstanceFlagSet(debug string) *RunLocalInstanceCommandFlags {
 	flagSet.Set("debug", debug)
 	flagSet.Set("trace", "true")
 	flagSet.Set("gdbport", "1234")
+	flagSet.Set("hypervisor", "firecracker")
 	flagSet.Set("no-trace", "a")
 	flagSet.Set("verbose", "true")
 	flagSet.Set("bridged", "true")
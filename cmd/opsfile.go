@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	api "github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/types"
+)
+
+// Opsfile is a multi-stage image build file. Each stage but the last one produces files that can be
+// copied into the last stage, which produces the image.
+//
+// A stage is either:
+//   - a crossbuild stage, running Commands in the crossbuild environment
+//   - a package stage, using the files of Package (a local package if Local is set)
+//   - a docker stage, extracting Executable and its libraries from the Docker image Docker
+//
+// The last stage may also have no source and use a previous package or docker stage as base image
+// content (From). Its Copy steps copy files from previous stages (or from the directory of the
+// Opsfile if the step has no From stage) into the image, and its Config is merged into the image
+// configuration, on top of the configuration of the base package.
+type Opsfile struct {
+	Stages []*OpsfileStage
+}
+
+// OpsfileStage is a build stage of an Opsfile
+type OpsfileStage struct {
+	Name string
+
+	// crossbuild stage
+	Commands []string `json:",omitempty"`
+
+	// package stage
+	Package string `json:",omitempty"`
+	Local   bool   `json:",omitempty"`
+
+	// docker stage
+	Docker      string `json:",omitempty"`
+	Executable  string `json:",omitempty"`
+	CopyWholeFS bool   `json:",omitempty"`
+
+	// last stage
+	From   string            `json:",omitempty"`
+	Copy   []OpsfileCopyStep `json:",omitempty"`
+	Config json.RawMessage   `json:",omitempty"`
+
+	root string
+	pkg  *PkgCommandFlags
+}
+
+// OpsfileCopyStep copies a file or directory from a stage into the image. A destination ending with
+// a slash is a directory into which the source is copied.
+type OpsfileCopyStep struct {
+	From string `json:",omitempty"`
+	Src  string
+	Dest string
+}
+
+// ReadOpsfile reads and validates an Opsfile
+func ReadOpsfile(file string) (*Opsfile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read Opsfile: %w", err)
+	}
+	return ParseOpsfile(data)
+}
+
+// ParseOpsfile parses and validates the content of an Opsfile
+func ParseOpsfile(data []byte) (*Opsfile, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	f := &Opsfile{}
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("cannot parse Opsfile: %w", err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Opsfile) validate() error {
+	if len(f.Stages) == 0 {
+		return errors.New("Opsfile has no stages")
+	}
+	last := len(f.Stages) - 1
+	names := make(map[string]*OpsfileStage)
+	for i, s := range f.Stages {
+		if s.Name == "" && i != last {
+			return fmt.Errorf("stage %d has no name", i+1)
+		}
+		if names[s.Name] != nil {
+			return fmt.Errorf("duplicate stage %q", s.Name)
+		}
+		sources := 0
+		for _, set := range []bool{len(s.Commands) > 0, s.Package != "", s.Docker != "", s.From != ""} {
+			if set {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return fmt.Errorf("stage %q must have only one of Commands, Package, Docker or From", s.Name)
+		}
+		if i != last {
+			if sources == 0 {
+				return fmt.Errorf("stage %q must have Commands, Package or Docker", s.Name)
+			}
+			if s.From != "" || len(s.Copy) > 0 || s.Config != nil {
+				return fmt.Errorf("stage %q: only the last stage can have From, Copy or Config", s.Name)
+			}
+		} else {
+			if len(s.Commands) > 0 {
+				return errors.New("the last stage cannot be a crossbuild stage")
+			}
+			if s.From != "" {
+				base := names[s.From]
+				if base == nil {
+					return fmt.Errorf("unknown stage %q", s.From)
+				}
+				if base.Package == "" && base.Docker == "" {
+					return fmt.Errorf("stage %q is not a package or docker stage", s.From)
+				}
+			}
+			for _, cp := range s.Copy {
+				if cp.Src == "" || cp.Dest == "" {
+					return errors.New("copy step must have Src and Dest")
+				}
+				if cp.From != "" && names[cp.From] == nil {
+					return fmt.Errorf("unknown stage %q", cp.From)
+				}
+			}
+		}
+		if s.Docker != "" && s.Executable == "" {
+			return fmt.Errorf("stage %q must have the Executable to extract from the docker image", s.Name)
+		}
+		names[s.Name] = s
+	}
+	return nil
+}
+
+// stage returns the stage with the given name
+func (f *Opsfile) stage(name string) *OpsfileStage {
+	for _, s := range f.Stages {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// copiedPaths returns the paths copied from a stage by the copy steps of the last stage
+func (f *Opsfile) copiedPaths(s *OpsfileStage) []string {
+	var paths []string
+	for _, cp := range f.Stages[len(f.Stages)-1].Copy {
+		if cp.From == s.Name {
+			paths = append(paths, cp.Src)
+		}
+	}
+	return paths
+}
+
+// Build runs the stages of an Opsfile, merges the image configuration into c and returns the path
+// of the package the image is built from; baseDir is the directory of the Opsfile and tmpDir a
+// temporary directory holding stage files until the image is built.
+func (f *Opsfile) Build(baseDir, tmpDir string, c *types.Config, quiet, verbose bool) (string, error) {
+	for i, s := range f.Stages[:len(f.Stages)-1] {
+		fmt.Printf("Stage %d/%d: %s\n", i+1, len(f.Stages), s.Name)
+		if err := f.runStage(s, tmpDir, quiet, verbose); err != nil {
+			return "", fmt.Errorf("stage %q: %w", s.Name, err)
+		}
+	}
+
+	last := f.Stages[len(f.Stages)-1]
+	if last.Name != "" {
+		fmt.Printf("Stage %d/%d: %s\n", len(f.Stages), len(f.Stages), last.Name)
+	}
+	if last.From != "" {
+		last.pkg = f.stage(last.From).pkg
+	} else if err := f.runStage(last, tmpDir, quiet, verbose); err != nil {
+		return "", fmt.Errorf("stage %q: %w", last.Name, err)
+	}
+
+	sysroot := path.Join(tmpDir, "image", api.PackageSysRootFolderName)
+	if err := os.MkdirAll(sysroot, 0755); err != nil {
+		return "", err
+	}
+	for _, cp := range last.Copy {
+		src := filepath.Join(baseDir, cp.Src)
+		if cp.From != "" {
+			src = f.stage(cp.From).path(cp.Src)
+		}
+		dest := path.Join(sysroot, cp.Dest)
+		if strings.HasSuffix(cp.Dest, "/") {
+			dest = path.Join(dest, path.Base(cp.Src))
+		}
+		if err := copyPath(src, dest); err != nil {
+			return "", fmt.Errorf("cannot copy %s: %w", cp.Src, err)
+		}
+	}
+
+	pkgPath := path.Join(tmpDir, "image")
+	if last.pkg != nil {
+		if err := last.pkg.MergeToConfig(c); err != nil {
+			return "", err
+		}
+		pkgPath = last.pkg.PackagePath()
+		if len(last.Copy) > 0 {
+			if c.MapDirs == nil {
+				c.MapDirs = make(map[string]string)
+			}
+			c.MapDirs[sysroot+"/*"] = "/"
+		}
+	}
+	if last.Config != nil {
+		if err := ConvertJSONToConfig(last.Config, c); err != nil {
+			return "", err
+		}
+	}
+	if c.Program == "" {
+		return "", errors.New("the last stage must have a base package or a Program in its Config")
+	}
+	return pkgPath, nil
+}
+
+func (f *Opsfile) runStage(s *OpsfileStage, tmpDir string, quiet, verbose bool) error {
+	switch {
+	case len(s.Commands) > 0:
+		env := loadEnvironment(true)
+		if err := env.Boot(); err != nil {
+			return fmt.Errorf("cannot start environment: %w", err)
+		}
+		defer env.Shutdown()
+		if err := env.Run(s.Commands); err != nil {
+			return err
+		}
+		s.root = path.Join(tmpDir, "stages", s.Name)
+		if err := os.MkdirAll(s.root, 0755); err != nil {
+			return err
+		}
+		return env.GetPaths(f.copiedPaths(s), s.root)
+	case s.Package != "":
+		s.pkg = &PkgCommandFlags{Package: s.Package, LocalPackage: s.Local}
+	case s.Docker != "":
+		s.pkg = &PkgCommandFlags{LocalPackage: true}
+		s.pkg.Package, _ = ExtractFromDockerImage(s.Docker, "", s.pkg.Parch(), s.Executable, quiet, verbose, s.CopyWholeFS, false, nil)
+	default:
+		return nil
+	}
+	// make sure the package is available locally
+	if err := s.pkg.MergeToConfig(&types.Config{}); err != nil {
+		return err
+	}
+	s.root = s.pkg.PackagePath()
+	return nil
+}
+
+// path returns the host path of a file of the stage; files of packages are looked up in the package
+// sysroot first
+func (s *OpsfileStage) path(p string) string {
+	if s.pkg != nil {
+		sysrootPath := filepath.Join(s.root, api.PackageSysRootFolderName, p)
+		if _, err := os.Lstat(sysrootPath); err == nil {
+			return sysrootPath
+		}
+	}
+	return filepath.Join(s.root, p)
+}
+
+// copyPath copies a file or directory, creating the parent directories of the destination
+func copyPath(src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return copyDirectory(src, dest)
+	}
+	if err = os.MkdirAll(path.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return copyFile(src, dest)
+}
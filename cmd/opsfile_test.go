@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOpsfile(t *testing.T) {
+	f, err := ParseOpsfile([]byte(`{
+		"Stages": [
+			{"Name": "build", "Commands": ["gcc -o /home/user/hello hello.c"]},
+			{"Name": "base", "Package": "eyberg/node:20.5.0"},
+			{
+				"From": "base",
+				"Copy": [{"From": "build", "Src": "/home/user/hello", "Dest": "/bin/"}],
+				"Config": {"Args": ["/bin/hello"]}
+			}
+		]
+	}`))
+	assert.Nil(t, err)
+	assert.Len(t, f.Stages, 3)
+	assert.Equal(t, []string{"/home/user/hello"}, f.copiedPaths(f.Stages[0]))
+	assert.Nil(t, f.copiedPaths(f.Stages[1]))
+
+	invalid := map[string]string{
+		"no stages":         `{"Stages": []}`,
+		"unknown field":     `{"Stages": [{"Name": "a", "Pkg": "x"}]}`,
+		"two sources":       `{"Stages": [{"Name": "a", "Package": "x", "Docker": "y", "Executable": "z"}, {}]}`,
+		"no source":         `{"Stages": [{"Name": "a"}, {}]}`,
+		"unnamed stage":     `{"Stages": [{"Package": "x"}, {}]}`,
+		"duplicate stage":   `{"Stages": [{"Name": "a", "Package": "x"}, {"Name": "a", "Package": "y"}, {}]}`,
+		"copy in stage":     `{"Stages": [{"Name": "a", "Package": "x", "Copy": [{"Src": "a", "Dest": "b"}]}, {}]}`,
+		"crossbuild last":   `{"Stages": [{"Commands": ["true"]}]}`,
+		"unknown base":      `{"Stages": [{"From": "a"}]}`,
+		"crossbuild base":   `{"Stages": [{"Name": "a", "Commands": ["true"]}, {"From": "a"}]}`,
+		"unknown copy from": `{"Stages": [{"Copy": [{"From": "a", "Src": "a", "Dest": "b"}]}]}`,
+		"no executable":     `{"Stages": [{"Docker": "redis"}]}`,
+	}
+	for name, data := range invalid {
+		_, err = ParseOpsfile([]byte(data))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestOpsfileBuild(t *testing.T) {
+	baseDir := t.TempDir()
+	tmpDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(baseDir, "hello"), []byte("hello"), 0755)
+	assert.Nil(t, err)
+	err = os.MkdirAll(filepath.Join(baseDir, "static", "css"), 0755)
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(baseDir, "static", "css", "main.css"), []byte("body {}"), 0644)
+	assert.Nil(t, err)
+
+	f, err := ParseOpsfile([]byte(`{
+		"Stages": [{
+			"Copy": [
+				{"Src": "hello", "Dest": "/bin/"},
+				{"Src": "static", "Dest": "/www"}
+			],
+			"Config": {"Program": "/bin/hello", "Args": ["hello", "-v"]}
+		}]
+	}`))
+	assert.Nil(t, err)
+
+	c := &types.Config{}
+	pkgPath, err := f.Build(baseDir, tmpDir, c, true, false)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "image"), pkgPath)
+	assert.Equal(t, "/bin/hello", c.Program)
+	assert.Equal(t, []string{"hello", "-v"}, c.Args)
+
+	data, err := os.ReadFile(filepath.Join(pkgPath, "sysroot", "bin", "hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(data))
+	data, err = os.ReadFile(filepath.Join(pkgPath, "sysroot", "www", "css", "main.css"))
+	assert.Nil(t, err)
+	assert.Equal(t, "body {}", string(data))
+
+	f, err = ParseOpsfile([]byte(`{"Stages": [{"Copy": [{"Src": "hello", "Dest": "/hello"}]}]}`))
+	assert.Nil(t, err)
+	_, err = f.Build(baseDir, t.TempDir(), &types.Config{}, true, false)
+	assert.NotNil(t, err)
+}
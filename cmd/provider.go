@@ -1,18 +1,96 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
 	api "github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/provider"
 	"github.com/nanovms/ops/types"
 )
 
+// runningContext is the context of the provider operations of the command, whose cleanup
+// functions are called if the command exits on an error after the operations were cancelled
+var runningContext *api.Context
+
+// cancelRunning cancels the operations of runningContext
+var cancelRunning context.CancelFunc = func() {}
+
 func getProviderAndContext(c *types.Config, providerName string) (api.Provider, *api.Context, error) {
 	p, err := provider.CloudProvider(providerName, &c.CloudConfig)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	ctx := api.NewContext(c)
+	ctx := cancellableContext(api.NewContext(c))
 
 	return p, ctx, nil
 }
+
+// cancellableContext returns a copy of ctx whose operations are cancelled by cancelOnInterrupt,
+// or once the timeout of the configuration elapses
+func cancellableContext(ctx *api.Context) *api.Context {
+	parent, cancel := context.WithCancel(context.Background())
+	c := parent
+	stopTimeout := context.CancelFunc(func() {})
+	timeout := ctx.Config().RunConfig.Timeout
+	if timeout > 0 {
+		c, stopTimeout = context.WithTimeout(parent, timeout)
+	}
+
+	go func() {
+		<-c.Done()
+		stopTimeout()
+		if errors.Is(c.Err(), context.DeadlineExceeded) {
+			fmt.Fprintf(os.Stderr, "timed out after %s, cancelling\n", timeout)
+		}
+	}()
+
+	cancelRunning = cancel
+	runningContext = ctx.WithContext(c)
+	return runningContext
+}
+
+// cancelOnInterrupt cancels the operations of the command on interrupt instead of killing it,
+// until the returned function restores the default handling of interrupts. It is only installed
+// around operations which check their context, others would ignore the interrupt.
+func cancelOnInterrupt() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			// a second interrupt kills the process without waiting for the cleanup
+			signal.Stop(signals)
+			fmt.Fprintln(os.Stderr, "interrupted, cancelling")
+			cancelRunning()
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// cleanupCancelled removes the resources partially created by the cancelled operations of the
+// command
+func cleanupCancelled() {
+	if runningContext == nil || runningContext.Context().Err() == nil {
+		return
+	}
+	if err := runningContext.Cleanup(); err != nil {
+		log.Errorf("%v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/network"
 	"github.com/nanovms/ops/provider/onprem"
 	"github.com/nanovms/ops/qemu"
@@ -20,11 +21,10 @@ func RunLocalInstance(c *types.Config) (err error) {
 			return
 		}
 	}
-	hypervisor := qemu.HypervisorInstance()
-	if hypervisor == nil {
-		ErrNoHypervisor := "No hypervisor found on $PATH"
+	hypervisor, err := qemu.HypervisorByName(c.RunConfig.Hypervisor)
+	if err != nil {
 		InfoInstallOps := "Please install OPS using curl https://ops.city/get.sh -sSfL | sh"
-		return fmt.Errorf("%s\n%s", ErrNoHypervisor, InfoInstallOps)
+		return fmt.Errorf("%s\n%s", err, InfoInstallOps)
 	}
 
 	tapDeviceName := c.RunConfig.TapName
@@ -45,6 +45,22 @@ func RunLocalInstance(c *types.Config) (err error) {
 		if err != nil {
 			return
 		}
+
+		rules := network.FirewallRules{
+			TCPPorts: c.RunConfig.Ports,
+			UDPPorts: c.RunConfig.UDPPorts,
+			Sources:  c.RunConfig.AllowedSources,
+		}
+		if err = network.SetupFirewall(networkService, tapDeviceName, rules); err != nil {
+			if !c.RunConfig.UnfilteredPorts {
+				if offErr := network.TurnOffNetworkInterfaces(networkService, tapDeviceName, bridgeName); offErr != nil {
+					log.Warnf("cannot turn off tap %s: %v", tapDeviceName, offErr)
+				}
+				return
+			}
+			log.Warnf("%v, all the ports of the instance are reachable", err)
+			err = nil
+		}
 	}
 
 	fmt.Println("running local instance")
@@ -56,7 +72,10 @@ func RunLocalInstance(c *types.Config) (err error) {
 	}
 
 	fmt.Printf("booting %s ...\n", c.RunConfig.ImageName)
-	hypervisor.Start(&c.RunConfig)
+	err = hypervisor.Start(&c.RunConfig)
+	if err != nil {
+		return
+	}
 
 	if tapDeviceName != "" {
 		err = network.TurnOffNetworkInterfaces(networkService, tapDeviceName, bridgeName)
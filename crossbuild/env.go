@@ -199,8 +199,13 @@ func (env *Environment) RunCommands(cmdFile string) error {
 	if err != nil {
 		return fmt.Errorf("cannot read command file: %v", err)
 	}
+	return env.Run(strings.Split(string(contents), "\n"))
+}
+
+// Run runs a sequence of commands in the VM.
+func (env *Environment) Run(commands []string) error {
 	vmCmd := env.NewEmptyCommand()
-	for _, cmd := range strings.Split(string(contents), "\n") {
+	for _, cmd := range commands {
 		vmCmd = vmCmd.Then(cmd)
 	}
 	if err := vmCmd.AsAdmin().Execute(); err != nil {
@@ -285,6 +290,29 @@ func (env *Environment) GetFiles(c *types.Config, hostPath string) error {
 	return nil
 }
 
+// GetPaths copies files and directories from VM to local directory, keeping their VM path relative
+// to the local directory.
+func (env *Environment) GetPaths(paths []string, hostPath string) error {
+	sshClient, err := newSSHClient(env.SSHPort, "root", EnvironmentRootPassword)
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+	for _, p := range paths {
+		vmCmd := env.NewCommand("test -d " + p).AsAdmin()
+		vmCmd.SuppressOutput = true
+		if vmCmd.Execute() == nil {
+			err = env.vmDownloadDir(sshClient, p, hostPath)
+		} else {
+			err = env.vmDownloadFile(sshClient, p, filepath.Join(hostPath, p))
+		}
+		if err != nil {
+			return fmt.Errorf("cannot copy %s: %v", p, err)
+		}
+	}
+	return nil
+}
+
 // Shutdown stops the environment VM, if running.
 func (env *Environment) Shutdown() error {
 	vmCmd := env.NewCommand("shutdown", "-hP", "now").AsAdmin()
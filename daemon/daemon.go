@@ -11,6 +11,7 @@ import (
 
 	api "github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/provider"
+	"github.com/nanovms/ops/provider/onprem"
 	"github.com/nanovms/ops/types"
 
 	"github.com/nanovms/ops/protos/imageservice"
@@ -35,8 +36,8 @@ func (*server) GetInstances(_ context.Context, in *instanceservice.InstanceListR
 		fmt.Println(err)
 	}
 
-	// for now we read from old 'ops run' output stored in
-	// ~/.ops/instances/{pid} but can def. re-factor to be in-mem for
+	// for now we read from the instances state stored in
+	// ~/.ops/instances.json but can def. re-factor to be in-mem for
 	// future since this is coming from daemon
 	ctx := api.NewContext(c)
 	instances, err := p.GetInstances(ctx)
@@ -44,6 +45,19 @@ func (*server) GetInstances(_ context.Context, in *instanceservice.InstanceListR
 		return nil, err
 	}
 
+	// instance ids are stable across restarts, the pids come from
+	// the metadata of the running instances
+	pids := map[string]string{}
+	if op, ok := api.UnwrapProvider(p).(*onprem.OnPrem); ok {
+		running, err := op.GetMetaInstances(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range running {
+			pids[r.ID] = r.Pid
+		}
+	}
+
 	pb := &instanceservice.InstancesResponse{
 		Count: int32(len(instances)),
 	}
@@ -52,7 +66,7 @@ func (*server) GetInstances(_ context.Context, in *instanceservice.InstanceListR
 		instance := &instanceservice.Instance{
 			Name:      instances[i].Name,
 			Image:     instances[i].Image,
-			Pid:       instances[i].ID,
+			Pid:       pids[instances[i].ID],
 			Status:    instances[i].Status,
 			PrivateIp: instances[i].PrivateIps[0],
 			Created:   instances[i].Created,
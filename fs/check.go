@@ -0,0 +1,293 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// CheckReport contains the results of a filesystem consistency check
+type CheckReport struct {
+	LogExtensions int
+	Directories   int
+	Files         int
+	Symlinks      int
+	// DataSize is the amount of file data referenced by file extents, in bytes
+	DataSize uint64
+	// OrphanedExtents are the unreferenced regions between extents used by the log or by files
+	OrphanedExtents []Extent
+	Errors          []string
+	// Repaired is set when the filesystem log has been rewritten to fix the errors found
+	Repaired bool
+}
+
+// Extent is a region of a filesystem, with offset and length expressed in bytes
+type Extent struct {
+	Offset uint64
+	Length uint64
+}
+
+// OrphanedSize returns the total size of orphaned extents, in bytes
+func (r *CheckReport) OrphanedSize() uint64 {
+	var size uint64
+	for _, extent := range r.OrphanedExtents {
+		size += extent.Length
+	}
+	return size
+}
+
+// Check verifies the consistency of the root filesystem of an image or volume
+func Check(imagePath string) (*CheckReport, error) {
+	return checkImage(imagePath, false)
+}
+
+// Repair verifies the consistency of the root filesystem of an image or volume and, if errors are
+// found, drops the invalid entries and rewrites the filesystem log
+func Repair(imagePath string) (*CheckReport, error) {
+	return checkImage(imagePath, true)
+}
+
+func checkImage(imagePath string, repair bool) (*CheckReport, error) {
+	flag := os.O_RDONLY
+	if repair {
+		flag = os.O_RDWR
+	}
+	imageFile, err := os.OpenFile(imagePath, flag, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open image file: %w", err)
+	}
+	defer imageFile.Close()
+	fsStart, fsSize, partitioned, err := findRootFS(imageFile)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{
+		t:      newTfs(imageFile, fsStart, fsSize),
+		report: &CheckReport{},
+		repair: repair,
+		files:  make(map[*map[string]interface{}]bool),
+	}
+	c.checkLog()
+	if c.t.root != nil {
+		c.checkDir("/", c.t.root, map[*map[string]interface{}]bool{c.t.root: true})
+	}
+	c.checkExtents()
+	if !repair || (len(c.report.Errors) == 0) {
+		return c.report, nil
+	}
+	if (c.t.root == nil) || (c.t.currentExt == nil) {
+		return c.report, errors.New("root directory not found, cannot repair filesystem")
+	}
+	if partitioned {
+		// the root filesystem is the last partition of an image, so it can grow with the image file
+		c.t.size = 0
+	}
+	c.t.updateAllocated(c.t.root)
+	err = c.t.rewriteLog()
+	if err != nil {
+		return c.report, fmt.Errorf("cannot rewrite filesystem log: %w", err)
+	}
+	if partitioned {
+		err = resizeRootPartition(imageFile)
+		if err != nil {
+			return c.report, err
+		}
+	}
+	os.Remove(IndexPath(imagePath))
+	c.report.Repaired = true
+	return c.report, nil
+}
+
+type checker struct {
+	t       *tfs
+	report  *CheckReport
+	repair  bool
+	files   map[*map[string]interface{}]bool
+	extents []checkExtent
+}
+
+// checkExtent is a region of the filesystem used by the log (if dir is nil) or by a file
+type checkExtent struct {
+	start, end uint64
+	owner      string
+	dir        *map[string]interface{}
+	name       string
+}
+
+func (c *checker) errorf(format string, a ...interface{}) {
+	c.report.Errors = append(c.report.Errors, fmt.Sprintf(format, a...))
+}
+
+// checkLog decodes the log as far as possible; with a corrupted log, the directory tree is what had
+// been decoded up to the first invalid record
+func (c *checker) checkLog() {
+	t := c.t
+	err := t.readLog()
+	if err != nil {
+		c.errorf("%v", err)
+	} else if t.decoder.tupleRemain > 0 {
+		c.errorf("log ends with an incomplete record (%d bytes missing)", t.decoder.tupleRemain)
+	}
+	c.report.LogExtensions = len(t.logExts)
+	for i, offset := range t.logExts {
+		size := uint64(logExtensionSize)
+		if i == 0 {
+			size = sectorSize
+		}
+		c.extents = append(c.extents, checkExtent{
+			start: offset,
+			end:   offset + size,
+			owner: "filesystem log",
+		})
+	}
+	if len(t.decoder.dict) == 0 {
+		return
+	}
+	t.root, err = t.getDictTuple(1)
+	if err != nil {
+		c.errorf("root directory not found: %v", err)
+		return
+	}
+	if getTuple(t.root, "children") == nil {
+		c.errorf("root directory has no children tuple")
+		t.root = nil
+	}
+}
+
+func (c *checker) checkDir(dirPath string, dir *map[string]interface{}, ancestors map[*map[string]interface{}]bool) {
+	children := getTuple(dir, "children")
+	var names []string
+	for name := range *children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entryPath := path.Join(dirPath, name)
+		child, ok := (*children)[name].(*map[string]interface{})
+		if !ok {
+			c.errorf("%s: directory entry is not a tuple", entryPath)
+			c.remove(dir, name)
+			continue
+		}
+		if _, ok := (*child)["children"]; ok {
+			if getTuple(child, "children") == nil {
+				c.errorf("%s: invalid children tuple", entryPath)
+				c.remove(dir, name)
+			} else if ancestors[child] {
+				c.errorf("%s: directory contains itself", entryPath)
+				c.remove(dir, name)
+			} else {
+				c.report.Directories++
+				ancestors[child] = true
+				c.checkDir(entryPath, child, ancestors)
+				delete(ancestors, child)
+			}
+		} else if _, ok := (*child)["linktarget"]; ok {
+			c.report.Symlinks++
+		} else if _, ok := (*child)["extents"]; ok {
+			c.report.Files++
+			if !c.checkFile(entryPath, dir, name, child) {
+				c.remove(dir, name)
+			}
+		}
+	}
+}
+
+// checkFile validates the extents of a file, and returns false if the file is invalid
+func (c *checker) checkFile(filePath string, dir *map[string]interface{}, name string, file *map[string]interface{}) bool {
+	if c.files[file] {
+		return true // already checked via another directory entry
+	}
+	c.files[file] = true
+	if length := getString(file, "filelength"); length != "" {
+		if _, err := strconv.ParseUint(length, 10, 64); err != nil {
+			c.errorf("%s: invalid file length %q", filePath, length)
+			return false
+		}
+	}
+	extents := getTuple(file, "extents")
+	if extents == nil {
+		c.errorf("%s: invalid extents tuple", filePath)
+		return false
+	}
+	for fileOffset, v := range *extents {
+		if _, err := strconv.ParseUint(fileOffset, 10, 64); err != nil {
+			c.errorf("%s: invalid extent file offset %q", filePath, fileOffset)
+			return false
+		}
+		extent, ok := v.(*map[string]interface{})
+		if !ok {
+			c.errorf("%s: extent at file offset %s is not a tuple", filePath, fileOffset)
+			return false
+		}
+		offset, err := strconv.ParseUint(getString(extent, "offset"), 10, 64)
+		if err != nil {
+			c.errorf("%s: invalid extent offset %q", filePath, getString(extent, "offset"))
+			return false
+		}
+		length, err := strconv.ParseUint(getString(extent, "length"), 10, 64)
+		if err != nil {
+			c.errorf("%s: invalid extent length %q", filePath, getString(extent, "length"))
+			return false
+		}
+		allocated := length
+		if s := getString(extent, "allocated"); s != "" {
+			allocated, err = strconv.ParseUint(s, 10, 64)
+			if (err != nil) || (allocated < length) {
+				c.errorf("%s: invalid extent allocation %q", filePath, s)
+				return false
+			}
+		}
+		start := offset * sectorSize
+		end := (offset + allocated) * sectorSize
+		if (c.t.size != 0) && (end > c.t.size) {
+			c.errorf("%s: extent at offset %d extends beyond the end of the filesystem", filePath, start)
+			return false
+		}
+		c.report.DataSize += length * sectorSize
+		c.extents = append(c.extents, checkExtent{
+			start: start,
+			end:   end,
+			owner: filePath,
+			dir:   dir,
+			name:  name,
+		})
+	}
+	return true
+}
+
+// checkExtents looks for overlapping extents and for allocated space that is not used
+func (c *checker) checkExtents() {
+	sort.Slice(c.extents, func(i, j int) bool {
+		return c.extents[i].start < c.extents[j].start
+	})
+	var last checkExtent
+	for _, extent := range c.extents {
+		if extent.start < last.end {
+			c.errorf("%s: extent at offset %d overlaps %s", extent.owner, extent.start, last.owner)
+			if extent.dir != nil {
+				c.remove(extent.dir, extent.name)
+			} else {
+				c.remove(last.dir, last.name)
+			}
+		} else if extent.start > last.end {
+			c.report.OrphanedExtents = append(c.report.OrphanedExtents, Extent{
+				Offset: last.end,
+				Length: extent.start - last.end,
+			})
+		}
+		if extent.end > last.end {
+			last = extent
+		}
+	}
+}
+
+// remove drops an invalid directory entry from the tree that will be written by a repair
+func (c *checker) remove(dir *map[string]interface{}, name string) {
+	if c.repair && (dir != nil) {
+		delete(*getTuple(dir, "children"), name)
+	}
+}
@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildCheckImage(t *testing.T) string {
+	hostDir := t.TempDir()
+	imagePath := filepath.Join(t.TempDir(), "volume")
+	m := NewManifest("")
+	for _, name := range []string{"a", "b"} {
+		if err := m.AddFile("/data/"+name, writeHostFile(t, hostDir, name, "file "+name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buildImage(t, imagePath, m, false, withSize("1M"))
+	return imagePath
+}
+
+func TestCheckRepairExtent(t *testing.T) {
+	imagePath := buildCheckImage(t)
+	report, err := Check(imagePath)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 2, report.LogExtensions)
+	assert.Equal(t, 2, report.Files)
+	assert.Equal(t, 1, report.Directories)
+
+	// point the extent of a file beyond the end of the filesystem
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, _, err := w.rootFS.lookup(w.rootFS.root, "/data/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extent := getTuple(getTuple(file, "extents"), "0")
+	assert.Nil(t, w.rootFS.updateTuple(extent, map[string]interface{}{"offset": "100000"}))
+	assert.Nil(t, w.Commit())
+	w.Close()
+
+	report, err = Check(imagePath)
+	assert.Nil(t, err)
+	assert.Len(t, report.Errors, 1)
+	assert.False(t, report.Repaired)
+
+	report, err = Repair(imagePath)
+	assert.Nil(t, err)
+	assert.Len(t, report.Errors, 1)
+	assert.True(t, report.Repaired)
+
+	report, err = Check(imagePath)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.Files)
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assert.Equal(t, "file a", readImageFile(t, r, "/data/a"))
+	_, err = r.Stat("/data/b")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCheckRepairLog(t *testing.T) {
+	imagePath := buildCheckImage(t)
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordOffset := w.rootFS.imgOffset + w.rootFS.currentExt.offset + uint64(len(w.rootFS.currentExt.buffer))
+	assert.Nil(t, w.Mkdir("/new"))
+	assert.Nil(t, w.Commit())
+	_, err = w.imageFile.WriteAt([]byte{0xff}, int64(recordOffset))
+	assert.Nil(t, err)
+	w.Close()
+
+	_, err = NewReader(imagePath)
+	assert.NotNil(t, err)
+	report, err := Check(imagePath)
+	assert.Nil(t, err)
+	assert.Len(t, report.Errors, 1)
+
+	report, err = Repair(imagePath)
+	assert.Nil(t, err)
+	assert.True(t, report.Repaired)
+	report, err = Check(imagePath)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assert.Equal(t, "file a", readImageFile(t, r, "/data/a"))
+	assert.Equal(t, "file b", readImageFile(t, r, "/data/b"))
+}
@@ -0,0 +1,157 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+)
+
+// FSDiff contains the differences between two filesystems
+type FSDiff struct {
+	Root  []AttributeDiff `json:"root,omitempty"`
+	Files []FileDiff      `json:"files,omitempty"`
+}
+
+// AttributeDiff is a root tuple attribute that differs between two filesystems; Old is empty for an
+// added attribute, and New is empty for a removed attribute
+type AttributeDiff struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// FileDiff is a path that differs between two filesystems; Old is nil for an added file, and New is
+// nil for a removed file
+type FileDiff struct {
+	Path string     `json:"path"`
+	Old  *DiffEntry `json:"old,omitempty"`
+	New  *DiffEntry `json:"new,omitempty"`
+}
+
+// DiffEntry describes a file in one of the compared filesystems; the content hash is only computed
+// for regular files whose size is the same in both filesystems
+type DiffEntry struct {
+	Type   string `json:"type"`
+	Size   int64  `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// Diff compares the root tuple attributes and the directory trees of two filesystems
+func Diff(a, b *Reader) (*FSDiff, error) {
+	diff := &FSDiff{}
+	oldAttrs := a.RootAttributes()
+	newAttrs := b.RootAttributes()
+	names := make(map[string]bool)
+	for name := range oldAttrs {
+		names[name] = true
+	}
+	for name := range newAttrs {
+		names[name] = true
+	}
+	for _, name := range sortedNames(names) {
+		if oldAttrs[name] != newAttrs[name] {
+			diff.Root = append(diff.Root, AttributeDiff{
+				Name: name,
+				Old:  oldAttrs[name],
+				New:  newAttrs[name],
+			})
+		}
+	}
+
+	oldFiles := make(map[string]*DiffEntry)
+	err := a.diffEntries("/", oldFiles)
+	if err != nil {
+		return nil, err
+	}
+	newFiles := make(map[string]*DiffEntry)
+	err = b.diffEntries("/", newFiles)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool)
+	for filePath := range oldFiles {
+		paths[filePath] = true
+	}
+	for filePath := range newFiles {
+		paths[filePath] = true
+	}
+	for _, filePath := range sortedNames(paths) {
+		oldFile, newFile := oldFiles[filePath], newFiles[filePath]
+		if (oldFile != nil) && (newFile != nil) && (oldFile.Type == "file") && (*oldFile == *newFile) {
+			oldFile.SHA256, err = a.fileHash(filePath)
+			if err != nil {
+				return nil, err
+			}
+			newFile.SHA256, err = b.fileHash(filePath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if (oldFile == nil) || (newFile == nil) || (*oldFile != *newFile) {
+			diff.Files = append(diff.Files, FileDiff{
+				Path: filePath,
+				Old:  oldFile,
+				New:  newFile,
+			})
+		}
+	}
+	return diff, nil
+}
+
+func (r *Reader) diffEntries(dirPath string, entries map[string]*DiffEntry) error {
+	files, err := r.ReadDir(dirPath)
+	if err != nil {
+		return fmt.Errorf("cannot read directory %q: %w", dirPath, err)
+	}
+	for _, file := range files {
+		filePath := path.Join(dirPath, file.Name())
+		entry := &DiffEntry{}
+		switch file.Mode() {
+		case os.ModeDir:
+			entry.Type = "dir"
+			err = r.diffEntries(filePath, entries)
+			if err != nil {
+				return err
+			}
+		case os.ModeSymlink:
+			entry.Type = "symlink"
+			entry.Target, err = r.ReadLink(filePath)
+			if err != nil {
+				return err
+			}
+		case 0:
+			entry.Type = "file"
+			entry.Size = file.Size()
+		default:
+			entry.Type = "other"
+		}
+		entries[filePath] = entry
+	}
+	return nil
+}
+
+func (r *Reader) fileHash(filePath string) (string, error) {
+	fileReader, err := r.rootFS.fileReader(filePath)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err = io.Copy(h, fileReader); err != nil {
+		return "", fmt.Errorf("cannot read file %q: %w", filePath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	hostDir := t.TempDir()
+	build := func(files map[string]string, env string) *Reader {
+		imagePath := filepath.Join(t.TempDir(), "image")
+		m := NewManifest("")
+		m.AddEnvironmentVariable("VAR", env)
+		m.AddArgument("app")
+		for vmPath, hostPath := range files {
+			if err := m.AddFile(vmPath, hostPath); err != nil {
+				t.Fatal(err)
+			}
+		}
+		m.AddLink("/lib64", "/lib")
+		buildImage(t, imagePath, m, false)
+		r, err := NewReader(imagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	a := build(map[string]string{
+		"/app":         writeHostFile(t, hostDir, "app1", "aaaa"),
+		"/lib/libc.so": writeHostFile(t, hostDir, "libc", "libc"),
+		"/lib/old.so":  writeHostFile(t, hostDir, "old", "old"),
+	}, "one")
+	defer a.Close()
+	b := build(map[string]string{
+		"/app":         writeHostFile(t, hostDir, "app2", "bbbb"),
+		"/lib/libc.so": writeHostFile(t, hostDir, "libc", "libc"),
+		"/lib/new.so":  writeHostFile(t, hostDir, "new", "new"),
+	}, "two")
+	defer b.Close()
+
+	diff, err := Diff(a, b)
+	assert.Nil(t, err)
+	assert.Equal(t, []AttributeDiff{{Name: "environment.VAR", Old: "one", New: "two"}}, diff.Root)
+	var paths []string
+	for _, f := range diff.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"/app", "/lib/new.so", "/lib/old.so"}, paths)
+	assert.NotEqual(t, diff.Files[0].Old.SHA256, diff.Files[0].New.SHA256)
+	assert.Nil(t, diff.Files[1].Old)
+	assert.Nil(t, diff.Files[2].New)
+
+	diff, err = Diff(a, a)
+	assert.Nil(t, err)
+	assert.Empty(t, diff.Root)
+	assert.Empty(t, diff.Files)
+}
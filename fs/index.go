@@ -0,0 +1,216 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+)
+
+// ImageIndexExt is the file name extension of image content indexes, which are stored next to the
+// image they describe
+const ImageIndexExt = ".index"
+
+// imageIndex records which root filesystem it describes, the root attributes and a content hash of
+// every file, so that a later build of the same image can rewrite only what changed
+type imageIndex struct {
+	UUID     string               `json:"uuid"`
+	FSOffset uint64               `json:"fs_offset"`
+	Garbage  uint64               `json:"garbage"`
+	Root     map[string]string    `json:"root"`
+	Files    map[string]indexFile `json:"files"`
+}
+
+type indexFile struct {
+	HostPath string `json:"host_path"`
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mtime"`
+	SHA256   string `json:"sha256"`
+}
+
+// IndexPath returns the path of the content index of an image
+func IndexPath(imagePath string) string {
+	return imagePath + ImageIndexExt
+}
+
+func loadImageIndex(indexPath string) (*imageIndex, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	idx := &imageIndex{}
+	err = json.Unmarshal(data, idx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse image index %q: %w", indexPath, err)
+	}
+	return idx, nil
+}
+
+func (idx *imageIndex) save(indexPath string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexPath, data, 0644)
+}
+
+// newImageIndex indexes the root filesystem of a manifest; file hashes are reused from the previous
+// index when the host file has not been modified since
+func newImageIndex(root map[string]interface{}, prev *imageIndex) (*imageIndex, error) {
+	if prev == nil {
+		prev = &imageIndex{}
+	}
+	idx := &imageIndex{
+		Root:  make(map[string]string),
+		Files: make(map[string]indexFile),
+	}
+	for k, v := range root {
+		if k == "children" {
+			continue
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot index root attribute %q: %w", k, err)
+		}
+		idx.Root[k] = string(value)
+	}
+	err := idx.addDir("/", getRootDir(root), prev)
+	return idx, err
+}
+
+func (idx *imageIndex) addDir(vmDir string, dir map[string]interface{}, prev *imageIndex) error {
+	for name, v := range dir {
+		vmPath := path.Join(vmDir, name)
+		switch value := v.(type) {
+		case string:
+			info, err := os.Stat(value)
+			if err != nil {
+				return fmt.Errorf("cannot index file %q: %w", value, err)
+			}
+			f := indexFile{
+				HostPath: value,
+				Size:     info.Size(),
+				ModTime:  info.ModTime().UnixNano(),
+			}
+			if old, ok := prev.Files[vmPath]; ok && (old.HostPath == f.HostPath) &&
+				(old.Size == f.Size) && (old.ModTime == f.ModTime) {
+				f.SHA256 = old.SHA256
+			} else {
+				f.SHA256, err = fileHash(value)
+				if err != nil {
+					return err
+				}
+			}
+			idx.Files[vmPath] = f
+		case map[string]interface{}:
+			err := idx.addDir(vmPath, value, prev)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// garbage returns the amount of file data in the image that would no longer be referenced after
+// updating the image from the previous index to this one
+func (idx *imageIndex) garbage(prev *imageIndex) uint64 {
+	var garbage uint64
+	for vmPath, old := range prev.Files {
+		if f, ok := idx.Files[vmPath]; !ok || (f.SHA256 != old.SHA256) {
+			garbage += uint64(old.Size)
+		}
+	}
+	return garbage
+}
+
+func fileHash(hostPath string) (string, error) {
+	f, err := os.Open(hostPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file %q: %w", hostPath, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("cannot read file %q: %w", hostPath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// applyManifest brings the root filesystem in line with a manifest, writing new log entries only for
+// root attributes and directory entries that differ between the previous and the current index
+func (t *tfs) applyManifest(root map[string]interface{}, prev, idx *imageIndex) error {
+	attrs := make(map[string]interface{})
+	for k, v := range idx.Root {
+		if prev.Root[k] != v {
+			attrs[k] = root[k]
+		}
+	}
+	for k := range prev.Root {
+		if _, ok := idx.Root[k]; !ok {
+			attrs[k] = nil
+		}
+	}
+	if len(attrs) > 0 {
+		err := t.updateTuple(t.root, attrs)
+		if err != nil {
+			return fmt.Errorf("cannot update root attributes: %w", err)
+		}
+	}
+	return t.applyDir(t.root, "/", getRootDir(root), prev, idx)
+}
+
+func (t *tfs) applyDir(tfsDir *map[string]interface{}, vmDir string, dir map[string]interface{}, prev, idx *imageIndex) error {
+	var names []string
+	for name := range dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vmPath := path.Join(vmDir, name)
+		child := getChild(tfsDir, name)
+		if child != nil {
+			switch value := dir[name].(type) {
+			case map[string]interface{}:
+				if getTuple(child, "children") != nil {
+					err := t.applyDir(child, vmPath, value, prev, idx)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			case link:
+				if getString(child, "linktarget") == value.path {
+					continue
+				}
+			case string:
+				old, ok := prev.Files[vmPath]
+				if ok && (getTuple(child, "extents") != nil) && (old.SHA256 == idx.Files[vmPath].SHA256) {
+					continue
+				}
+			}
+		}
+		err := t.setDirEntry(tfsDir, name, dir[name])
+		if err != nil {
+			return fmt.Errorf("cannot update %q: %w", vmPath, err)
+		}
+	}
+	var removed []string
+	for name := range *getTuple(tfsDir, "children") {
+		if _, ok := dir[name]; !ok && (name != ".") && (name != "..") {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		err := t.setDirEntry(tfsDir, name, nil)
+		if err != nil {
+			return fmt.Errorf("cannot remove %q: %w", path.Join(vmDir, name), err)
+		}
+	}
+	return nil
+}
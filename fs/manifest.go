@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +17,9 @@ type link struct {
 
 // ManifestNetworkConfig has network configuration to set static IP
 type ManifestNetworkConfig struct {
+	// Interface is the network interface configured, eg: en2; the first interface if empty
+	Interface string
+
 	IP      string
 	IPv6    string
 	Gateway string
@@ -41,12 +45,27 @@ func NewManifest(targetRoot string) *Manifest {
 	return m
 }
 
-// AddNetworkConfig adds network configuration
+// AddNetworkConfig adds network configuration; the first interface is configured at the root of
+// the manifest, the other ones in a tuple named after the interface
 func (m *Manifest) AddNetworkConfig(networkConfig *ManifestNetworkConfig) {
-	m.root["ipaddr"] = networkConfig.IP
-	m.root["netmask"] = networkConfig.NetMask
-	m.root["gateway"] = networkConfig.Gateway
-	m.root["ip6addr"] = networkConfig.IPv6
+	if networkConfig.Interface == "" || networkConfig.Interface == "en1" {
+		m.root["ipaddr"] = networkConfig.IP
+		m.root["netmask"] = networkConfig.NetMask
+		m.root["gateway"] = networkConfig.Gateway
+		m.root["ip6addr"] = networkConfig.IPv6
+		return
+	}
+
+	// the interface gets its address by dhcp if no address is set
+	iface := map[string]interface{}{
+		"ipaddr":  networkConfig.IP,
+		"netmask": networkConfig.NetMask,
+		"gateway": networkConfig.Gateway,
+	}
+	if networkConfig.IPv6 != "" {
+		iface["ip6addr"] = networkConfig.IPv6
+	}
+	m.root[networkConfig.Interface] = iface
 }
 
 // b7 = 183 = arm; 3e = 62 = x86
@@ -413,6 +432,64 @@ func (m *Manifest) AddFileTo(dir map[string]interface{}, filepath string, hostpa
 	return nil
 }
 
+// ManifestFile is a regular file added to a manifest
+type ManifestFile struct {
+	// Path is the path of the file in the image
+	Path string
+	// HostPath is the path of the file in the local filesystem, resolved against the target root
+	HostPath string
+	// Boot is set for files in the boot filesystem (kernel and klibs)
+	Boot bool
+}
+
+// Files returns the regular files added to the root and boot filesystems, sorted by path
+func (m *Manifest) Files() ([]ManifestFile, error) {
+	var files []ManifestFile
+	var err error
+	if m.boot != nil {
+		files, err = m.appendFiles(files, "/", m.bootDir(), true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	files, err = m.appendFiles(files, "/", m.rootDir(), false)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Boot != files[j].Boot {
+			return files[i].Boot
+		}
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+func (m *Manifest) appendFiles(files []ManifestFile, dirPath string, dir map[string]interface{}, boot bool) ([]ManifestFile, error) {
+	for name, v := range dir {
+		filePath := path.Join(dirPath, name)
+		switch value := v.(type) {
+		case string:
+			hostPath, err := LookupFile(m.targetRoot, value)
+			if err != nil {
+				return nil, fmt.Errorf("file %q is missing: %w", value, err)
+			}
+			files = append(files, ManifestFile{
+				Path:     filePath,
+				HostPath: hostPath,
+				Boot:     boot,
+			})
+		case map[string]interface{}:
+			var err error
+			files, err = m.appendFiles(files, filePath, value, boot)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
 // AddPassthrough to add key, value directly to manifest
 func (m *Manifest) AddPassthrough(key string, value interface{}) {
 	m.root[key] = value
@@ -28,3 +28,21 @@ func TestManifestWithEnv(t *testing.T) {
 	env := m.root["environment"].(map[string]interface{})
 	assert.Equal(t, "value1", env["var1"])
 }
+
+func TestManifestWithNetworkConfig(t *testing.T) {
+	m := NewManifest("")
+	m.AddNetworkConfig(&ManifestNetworkConfig{IP: "10.0.0.2", NetMask: "255.255.255.0", Gateway: "10.0.0.1"})
+	m.AddNetworkConfig(&ManifestNetworkConfig{Interface: "en2", IP: "10.1.0.2", NetMask: "255.255.0.0", Gateway: "10.1.0.1", IPv6: "fd00::2"})
+	m.AddNetworkConfig(&ManifestNetworkConfig{Interface: "en3"})
+
+	assert.Equal(t, "10.0.0.2", m.root["ipaddr"])
+	assert.Equal(t, "255.255.255.0", m.root["netmask"])
+	assert.Equal(t, "10.0.0.1", m.root["gateway"])
+	assert.Equal(t, map[string]interface{}{
+		"ipaddr":  "10.1.0.2",
+		"netmask": "255.255.0.0",
+		"gateway": "10.1.0.1",
+		"ip6addr": "fd00::2",
+	}, m.root["en2"])
+	assert.Equal(t, map[string]interface{}{"ipaddr": "", "netmask": "", "gateway": ""}, m.root["en3"])
+}
@@ -2,13 +2,18 @@ package fs
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -137,6 +142,10 @@ type MkfsCommand struct {
 	outPath     string
 	rootTfs     *tfs
 	oldEncoding bool
+	indexPath   string
+	// reproducible builds derive filesystem UUIDs from the manifest and use a fixed mtime
+	reproducible bool
+	mtime        time.Time
 }
 
 // NewMkfsCommand returns an instance of MkfsCommand
@@ -214,24 +223,131 @@ func (m *MkfsCommand) SetOldEncoding() {
 	m.oldEncoding = true
 }
 
+// SetIncremental enables incremental builds: a content index of the image is kept at indexPath, and
+// if the output image already exists only the files that changed since the last build are written
+func (m *MkfsCommand) SetIncremental(indexPath string) {
+	m.indexPath = indexPath
+}
+
+// SetReproducible enables reproducible builds, where building the same manifest always produces the
+// same image: filesystem UUIDs are derived from the manifest contents, and if mtime is not zero it is
+// set as modification time of all files and directories
+func (m *MkfsCommand) SetReproducible(mtime time.Time) {
+	m.reproducible = true
+	m.mtime = mtime
+}
+
 // Execute runs mkfs command
 func (m *MkfsCommand) Execute() error {
 	if m.outPath == "" {
 		return fmt.Errorf("output image file path not set")
 	}
-	var outFile *os.File
+	var idx *imageIndex
 	var err error
+	if m.indexPath != "" && m.manifest != nil {
+		m.manifest.finalize()
+		prev, _ := loadImageIndex(m.indexPath)
+		idx, err = newImageIndex(m.manifest.root, prev)
+		if err != nil {
+			return fmt.Errorf("cannot index image contents: %w", err)
+		}
+		// updating an image in place depends on its build history, so it is not reproducible
+		if prev != nil && !m.reproducible {
+			var updated bool
+			updated, err = m.update(prev, idx)
+			if err != nil {
+				return err
+			}
+			if updated {
+				return idx.save(m.indexPath)
+			}
+		}
+	}
+	var outFile *os.File
 	outFile, err = os.Create(m.outPath)
 	if err != nil {
 		return fmt.Errorf("cannot create output file %q: %w", m.outPath, err)
 	}
 	defer outFile.Close()
+	outOffset, err := m.writeBoot(outFile)
+	if err != nil {
+		return err
+	}
+	var root map[string]interface{}
+	if m.manifest != nil {
+		root = m.manifest.root
+	} else {
+		root = mkFS()
+	}
+	m.rootTfs, err = m.writeTfs(outFile, outOffset, 0, m.label, root)
+	if err != nil {
+		return fmt.Errorf("cannot write root filesystem: %w", err)
+	}
+	err = m.finish(outFile)
+	if err != nil {
+		return err
+	}
+	if idx != nil {
+		idx.UUID = m.rootTfs.getUUID()
+		idx.FSOffset = outOffset
+		idx.Garbage = 0
+		return idx.save(m.indexPath)
+	}
+	return nil
+}
+
+// update writes to an existing image the changes between its index and the current manifest; it
+// returns false if the image cannot be updated in place and has to be rebuilt from scratch
+func (m *MkfsCommand) update(prev, idx *imageIndex) (bool, error) {
+	outFile, err := os.OpenFile(m.outPath, os.O_RDWR, 0)
+	if err != nil {
+		return false, nil
+	}
+	defer outFile.Close()
+	rootTfs, err := tfsRead(outFile, prev.FSOffset, 0)
+	if err != nil {
+		return false, nil
+	}
+	if (rootTfs.getUUID() != prev.UUID) || (rootTfs.label != m.label) ||
+		(rootTfs.currentExt.oldEncoding != m.oldEncoding) {
+		return false, nil
+	}
+
+	// rebuild from scratch when most of the image would be taken by stale file data
+	idx.Garbage = prev.Garbage + idx.garbage(prev)
+	if 2*idx.Garbage > rootTfs.allocated {
+		return false, nil
+	}
+	outOffset, err := m.writeBoot(outFile)
+	if err != nil {
+		return false, err
+	}
+	if outOffset != prev.FSOffset {
+		return false, nil
+	}
+	idx.UUID = prev.UUID
+	idx.FSOffset = outOffset
+	err = rootTfs.applyManifest(m.manifest.root, prev, idx)
+	if err != nil {
+		return false, fmt.Errorf("cannot update root filesystem: %w", err)
+	}
+	err = rootTfs.flush()
+	if err != nil {
+		return false, fmt.Errorf("cannot update root filesystem: %w", err)
+	}
+	m.rootTfs = rootTfs
+	return true, m.finish(outFile)
+}
+
+// writeBoot writes everything that precedes the root filesystem, and returns the root filesystem offset
+func (m *MkfsCommand) writeBoot(outFile *os.File) (uint64, error) {
 	var outOffset uint64
+	var err error
 	var bootFile *os.File
 	if m.bootPath != "" {
 		bootFile, err = os.Open(m.bootPath)
 		if err != nil {
-			return fmt.Errorf("cannot open boot image %q: %w", m.bootPath, err)
+			return 0, fmt.Errorf("cannot open boot image %q: %w", m.bootPath, err)
 		}
 		defer bootFile.Close()
 		b := make([]byte, 8192)
@@ -240,11 +356,11 @@ func (m *MkfsCommand) Execute() error {
 			if err == io.EOF {
 				break
 			} else if err != nil {
-				return fmt.Errorf("cannot read boot image %q: %w", m.bootPath, err)
+				return 0, fmt.Errorf("cannot read boot image %q: %w", m.bootPath, err)
 			}
 			n, err = outFile.Write(b[:n])
 			if err != nil {
-				return fmt.Errorf("cannot write output file %q: %w", m.outPath, err)
+				return 0, fmt.Errorf("cannot write output file %q: %w", m.outPath, err)
 			}
 			outOffset += uint64(n)
 		}
@@ -255,7 +371,7 @@ func (m *MkfsCommand) Execute() error {
 		mbr[sectorSize-1] = 0xAA
 		_, err = outFile.Write(mbr)
 		if err != nil {
-			return fmt.Errorf("cannot write partition table: %w", err)
+			return 0, fmt.Errorf("cannot write partition table: %w", err)
 		}
 		outOffset = sectorSize
 	}
@@ -265,28 +381,96 @@ func (m *MkfsCommand) Execute() error {
 	if m.uefiPath != "" {
 		outOffset, err = writeUefiPart(outFile, outOffset, m.uefiPath)
 		if err != nil {
-			return fmt.Errorf("cannot write UEFI partition: %w", err)
+			return 0, fmt.Errorf("cannot write UEFI partition: %w", err)
 		}
 	}
 	manifest := m.manifest
-	var root map[string]interface{}
 	if manifest != nil {
 		manifest.finalize()
 		if manifest.boot != nil {
-			_, err = tfsWrite(outFile, outOffset, bootFSSize, "", manifest.boot, m.oldEncoding)
+			_, err = m.writeTfs(outFile, outOffset, bootFSSize, "", manifest.boot)
 			if err != nil {
-				return fmt.Errorf("cannot write boot filesystem: %w", err)
+				return 0, fmt.Errorf("cannot write boot filesystem: %w", err)
 			}
 			outOffset += bootFSSize
 		}
-		root = manifest.root
-	} else {
-		root = mkFS()
 	}
-	m.rootTfs, err = tfsWrite(outFile, outOffset, 0, m.label, root, m.oldEncoding)
+	return outOffset, nil
+}
+
+// writeTfs writes a filesystem with a random UUID, or with a UUID derived from its contents for
+// reproducible builds
+func (m *MkfsCommand) writeTfs(outFile *os.File, offset, size uint64, label string, root map[string]interface{}) (*tfs, error) {
+	if !m.reproducible {
+		return tfsWrite(outFile, offset, size, label, root, m.oldEncoding)
+	}
+	t := newTfs(outFile, offset, size)
+	var err error
+	t.uuid, err = manifestUUID(label, root)
 	if err != nil {
-		return fmt.Errorf("cannot write root filesystem: %w", err)
+		return nil, err
+	}
+	if !m.mtime.IsZero() {
+		t.mtime = tfsTimestamp(m.mtime)
 	}
+	return t, t.write(label, root, m.oldEncoding)
+}
+
+// manifestUUID derives a filesystem UUID from the label, the root attributes and the directory tree
+// of a manifest, using file contents rather than host paths
+func manifestUUID(label string, root map[string]interface{}) ([16]byte, error) {
+	var uuid [16]byte
+	h := sha256.New()
+	fmt.Fprintf(h, "label %q\n", label)
+	attrs := make(map[string]interface{})
+	for k, v := range root {
+		if k != "children" {
+			attrs[k] = v
+		}
+	}
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return uuid, fmt.Errorf("cannot encode root attributes: %w", err)
+	}
+	h.Write(data)
+	err = hashManifestDir(h, "/", getRootDir(root))
+	if err != nil {
+		return uuid, err
+	}
+	copy(uuid[:], h.Sum(nil))
+
+	// name-based UUID (RFC 4122 version 5 layout)
+	uuid[6] = (uuid[6] & 0x0f) | 0x50
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return uuid, nil
+}
+
+func hashManifestDir(h hash.Hash, dirPath string, dir map[string]interface{}) error {
+	for _, name := range sortedKeys(dir) {
+		entryPath := path.Join(dirPath, name)
+		switch value := dir[name].(type) {
+		case string:
+			sum, err := fileHash(value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "file %q %s\n", entryPath, sum)
+		case link:
+			fmt.Fprintf(h, "link %q %q\n", entryPath, value.path)
+		case map[string]interface{}:
+			fmt.Fprintf(h, "dir %q\n", entryPath)
+			err := hashManifestDir(h, entryPath, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// finish sets the final size of the image and writes its partition table
+func (m *MkfsCommand) finish(outFile *os.File) error {
+	var err error
 	if m.size != 0 {
 		var info os.FileInfo
 		info, err = outFile.Stat()
@@ -1,9 +1,21 @@
 package fs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
+var updateGolden = flag.Bool("update", false, "update golden files")
+
 func CheckMKFSSize(t *testing.T, mkfs *MkfsCommand, s string, size int64) {
 	err := mkfs.SetFileSystemSize(s)
 	if err != nil {
@@ -40,3 +52,176 @@ func TestMKFSCommand(t *testing.T) {
 		}
 	})
 }
+
+// writeHostFile writes a file of the local filesystem under dir, creating its parent directories,
+// and returns its path
+func writeHostFile(t *testing.T, dir, name, content string) string {
+	hostPath := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(hostPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hostPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return hostPath
+}
+
+// buildImage creates at imagePath the filesystem of a manifest, with the mkfs command configured by
+// options
+func buildImage(t *testing.T, imagePath string, m *Manifest, partitions bool, options ...func(*MkfsCommand) error) {
+	mkfs := NewMkfsCommand(m, partitions)
+	mkfs.SetFileSystemPath(imagePath)
+	for _, option := range options {
+		if err := option(mkfs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mkfs.Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withSize is a buildImage option setting the size of the filesystem
+func withSize(size string) func(*MkfsCommand) error {
+	return func(mkfs *MkfsCommand) error {
+		return mkfs.SetFileSystemSize(size)
+	}
+}
+
+func readImageFile(t *testing.T, r *Reader, path string) string {
+	fr, err := r.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read %s: %v", path, err)
+	}
+	b, err := io.ReadAll(fr)
+	if err != nil {
+		t.Fatalf("cannot read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func fileExtentOffset(t *testing.T, r *Reader, path string) string {
+	info, err := r.Stat(path)
+	if err != nil {
+		t.Fatalf("cannot stat %s: %v", path, err)
+	}
+	tuple := info.Sys().(*map[string]interface{})
+	return getString(getTuple(getTuple(tuple, "extents"), "0"), "offset")
+}
+
+func TestMKFSIncremental(t *testing.T) {
+	hostDir := t.TempDir()
+	imagePath := filepath.Join(t.TempDir(), "image")
+	build := func(files map[string]string, env string) {
+		m := NewManifest("")
+		m.AddEnvironmentVariable("VAR", env)
+		for vmPath, hostPath := range files {
+			if err := m.AddFile(vmPath, hostPath); err != nil {
+				t.Fatal(err)
+			}
+		}
+		buildImage(t, imagePath, m, false, func(mkfs *MkfsCommand) error {
+			mkfs.SetIncremental(IndexPath(imagePath))
+			return nil
+		})
+	}
+
+	files := map[string]string{
+		"/app":          writeHostFile(t, hostDir, "app", "version 1"),
+		"/lib/libc.so":  writeHostFile(t, hostDir, "libc.so", "libc"),
+		"/etc/hostname": writeHostFile(t, hostDir, "hostname", "nanos"),
+	}
+	build(files, "one")
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuid := r.GetUUID()
+	libOffset := fileExtentOffset(t, r, "/lib/libc.so")
+	r.Close()
+
+	files["/app"] = writeHostFile(t, hostDir, "app2", "version 2")
+	files["/data/new"] = writeHostFile(t, hostDir, "new", "new file")
+	delete(files, "/etc/hostname")
+	build(files, "two")
+
+	r, err = NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assert.Equal(t, uuid, r.GetUUID())
+	assert.Equal(t, libOffset, fileExtentOffset(t, r, "/lib/libc.so"))
+	assert.Equal(t, "version 2", readImageFile(t, r, "/app"))
+	assert.Equal(t, "libc", readImageFile(t, r, "/lib/libc.so"))
+	assert.Equal(t, "new file", readImageFile(t, r, "/data/new"))
+	assert.Equal(t, "two", r.ListEnv()["VAR"])
+	_, err = r.Stat("/etc/hostname")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMKFSReproducible(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	build := func() string {
+		hostDir := t.TempDir()
+		m := NewManifest("")
+		m.AddKernel(writeHostFile(t, hostDir, "kernel", "kernel"))
+		m.SetKlibDir(hostDir)
+		writeHostFile(t, hostDir, "tls", "tls klib")
+		m.AddKlibs([]string{"tls"})
+		m.SetProgram("/app")
+		m.AddArgument("/app")
+		m.AddArgument("-v")
+		m.AddEnvironmentVariable("A", "1")
+		m.AddEnvironmentVariable("B", "2")
+		for i, name := range []string{"/app", "/lib/libc.so", "/etc/hosts", "/etc/passwd", "/data/a/b"} {
+			if err := m.AddFile(name, writeHostFile(t, hostDir, strings.ReplaceAll(name, "/", "_"), strings.Repeat(name, 100*i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Symlink("_lib_libc.so", filepath.Join(hostDir, "libc.so.6")); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.AddLink("/lib/libc.so.6", filepath.Join(hostDir, "libc.so.6")); err != nil {
+			t.Fatal(err)
+		}
+		imagePath := filepath.Join(t.TempDir(), "image")
+		buildImage(t, imagePath, m, true, func(mkfs *MkfsCommand) error {
+			mkfs.SetReproducible(mtime)
+			return nil
+		})
+		data, err := os.ReadFile(imagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := NewReader(imagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		for _, name := range []string{"/app", "/data", "/lib/libc.so.6"} {
+			info, err := r.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, mtime.Equal(info.ModTime()), name)
+		}
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+
+	digest := build()
+	assert.Equal(t, digest, build())
+
+	goldenPath := filepath.Join("testdata", "reproducible.sha256")
+	if *updateGolden {
+		if err := os.WriteFile(goldenPath, []byte(digest+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.TrimSpace(string(golden)), digest)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 )
 
 // Reader allows reading filesystem contents from an image
@@ -82,6 +83,33 @@ func (r *Reader) ListEnv() map[string]string {
 	return envVars
 }
 
+// RootAttributes returns the attributes of the root tuple other than the directory tree; nested
+// tuples and vectors are flattened into dotted names, e.g. "environment.PATH" or "arguments.0"
+func (r *Reader) RootAttributes() map[string]string {
+	attrs := make(map[string]string)
+	for name, value := range *r.rootFS.root {
+		if name != "children" {
+			flattenValue(name, value, attrs)
+		}
+	}
+	return attrs
+}
+
+func flattenValue(name string, value interface{}, attrs map[string]string) {
+	switch v := value.(type) {
+	case string:
+		attrs[name] = v
+	case *map[string]interface{}:
+		for k, elem := range *v {
+			flattenValue(name+"."+k, elem, attrs)
+		}
+	case *[]interface{}:
+		for i, elem := range *v {
+			flattenValue(name+"."+strconv.Itoa(i), elem, attrs)
+		}
+	}
+}
+
 // GetUUID of image file system
 func (r *Reader) GetUUID() string {
 	return r.rootFS.getUUID()
@@ -103,46 +131,54 @@ func NewReader(imagePath string) (*Reader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot open image file: %w", err)
 	}
+	fsStart, fsSize, _, err := findRootFS(imageFile)
+	if err != nil {
+		imageFile.Close()
+		return nil, err
+	}
+	rootFS, err := tfsRead(imageFile, fsStart, fsSize)
+	if err != nil {
+		imageFile.Close()
+		return nil, err
+	}
+	return &Reader{
+		imageFile: imageFile,
+		rootFS:    rootFS,
+	}, nil
+}
+
+// findRootFS returns the location of the root filesystem in an image file, and whether the image
+// has a partition table
+func findRootFS(imageFile *os.File) (fsStart, fsSize uint64, partitioned bool, err error) {
 	var info os.FileInfo
 	info, err = imageFile.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("cannot read image file: %w", err)
+		return 0, 0, false, fmt.Errorf("cannot read image file: %w", err)
 	}
 	mbr := make([]byte, sectorSize)
-	_, err = imageFile.Read(mbr)
+	_, err = imageFile.ReadAt(mbr, 0)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read MBR: %w", err)
+		return 0, 0, false, fmt.Errorf("cannot read MBR: %w", err)
 	}
-	var fsStart, fsSize uint64
 	if (mbr[sectorSize-2] != 0x55) || (mbr[sectorSize-1] != 0xAA) { // assume raw filesystem
-		fsStart = 0
-		fsSize = uint64(info.Size())
+		return 0, uint64(info.Size()), false, nil
+	}
+	part := getPartition(mbr, 0)
+	partType := part[4]
+	var rootFSPart int
+	if partType == 0xEF { // EFI System Partition
+		rootFSPart = 2 // 0 - uefi, 1 - bootfs, 2 - rootfs
 	} else {
-		part := getPartition(mbr, 0)
-		partType := part[4]
-		var rootFSPart int
-		if partType == 0xEF { // EFI System Partition
-			rootFSPart = 2 // 0 - uefi, 1 - bootfs, 2 - rootfs
-		} else {
-			rootFSPart = 1 // 0 - bootfs, 1 - rootfs
-		}
-		part = getPartition(mbr, rootFSPart)
-		var lbaStart, sectors uint32
-		binary.Read(bytes.NewReader(part[8:12]), binary.LittleEndian, &lbaStart)
-		binary.Read(bytes.NewReader(part[12:16]), binary.LittleEndian, &sectors)
-		if lbaStart == 0 || sectors == 0 { // assume raw filesystem
-			fsStart = 0
-			fsSize = uint64(info.Size())
-		} else {
-			fsStart = uint64(lbaStart) * sectorSize
-			fsSize = uint64(sectors) * sectorSize
-		}
+		rootFSPart = 1 // 0 - bootfs, 1 - rootfs
 	}
-	reader := &Reader{
-		imageFile: imageFile,
+	part = getPartition(mbr, rootFSPart)
+	var lbaStart, sectors uint32
+	binary.Read(bytes.NewReader(part[8:12]), binary.LittleEndian, &lbaStart)
+	binary.Read(bytes.NewReader(part[12:16]), binary.LittleEndian, &sectors)
+	if lbaStart == 0 || sectors == 0 { // assume raw filesystem
+		return 0, uint64(info.Size()), false, nil
 	}
-	reader.rootFS, err = tfsRead(imageFile, fsStart, fsSize)
-	return reader, err
+	return uint64(lbaStart) * sectorSize, uint64(sectors) * sectorSize, true, nil
 }
 
 // NewReaderBootFS returns an instance of Reader for bootFS
@@ -153,9 +189,11 @@ func NewReaderBootFS(imagePath string) (*Reader, error) {
 	}
 	mbr := make([]byte, sectorSize)
 	if _, err = imageFile.Read(mbr); err != nil {
+		imageFile.Close()
 		return nil, fmt.Errorf("cannot read MBR: %w", err)
 	}
 	if (mbr[sectorSize-2] != 0x55) || (mbr[sectorSize-1] != 0xAA) { // assume raw filesystem
+		imageFile.Close()
 		return nil, fmt.Errorf("%s", "bootfs not found")
 	}
 	part := getPartition(mbr, 0)
@@ -167,14 +205,19 @@ func NewReaderBootFS(imagePath string) (*Reader, error) {
 	binary.Read(bytes.NewReader(part[8:12]), binary.LittleEndian, &lbaStart)
 	binary.Read(bytes.NewReader(part[12:16]), binary.LittleEndian, &sectors)
 	if lbaStart == 0 || sectors == 0 { // assume raw filesystem
+		imageFile.Close()
 		return nil, fmt.Errorf("%s", "bootfs not found")
 	}
 	bootFSStart := uint64(lbaStart) * sectorSize
-	reader := &Reader{
-		imageFile: imageFile,
+	rootFS, err := tfsRead(imageFile, bootFSStart, bootFSSize)
+	if err != nil {
+		imageFile.Close()
+		return nil, err
 	}
-	reader.rootFS, err = tfsRead(imageFile, bootFSStart, bootFSSize)
-	return reader, err
+	return &Reader{
+		imageFile: imageFile,
+		rootFS:    rootFS,
+	}, nil
 }
 
 func getPartition(mbr []byte, index int) []byte {
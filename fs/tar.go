@@ -0,0 +1,145 @@
+package fs
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportTar writes the contents of the filesystem to a tar stream
+func (r *Reader) ExportTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := r.exportDir(tw, "/")
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func (r *Reader) exportDir(tw *tar.Writer, dirPath string) error {
+	entries, err := r.ReadDir(dirPath)
+	if err != nil {
+		return fmt.Errorf("cannot read directory %q: %w", dirPath, err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		entryPath := path.Join(dirPath, entry.Name())
+		hdr := &tar.Header{
+			Name:    strings.TrimPrefix(entryPath, "/"),
+			Mode:    filePerm(entry),
+			ModTime: entry.ModTime(),
+			Format:  tar.FormatPAX,
+		}
+		switch entry.Mode() {
+		case os.ModeDir:
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+		case os.ModeSymlink:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname, err = r.ReadLink(entryPath)
+			if err != nil {
+				return err
+			}
+		case 0:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = entry.Size()
+		default:
+			continue
+		}
+		err = tw.WriteHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("cannot write tar header for %q: %w", entryPath, err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = r.exportDir(tw, entryPath)
+			if err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			fileReader, err := r.rootFS.fileReader(entryPath)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tw, fileReader)
+			if err != nil {
+				return fmt.Errorf("cannot export file %q: %w", entryPath, err)
+			}
+		}
+	}
+	return nil
+}
+
+// filePerm returns the permission bits of a file: the mode recorded by ImportTar or set with
+// Writer.Chmod if any, otherwise a default for the file type
+func filePerm(info os.FileInfo) int64 {
+	if tuple, ok := info.Sys().(*map[string]interface{}); ok {
+		if mode, err := strconv.ParseUint(getString(tuple, "mode"), 10, 32); err == nil {
+			return int64(mode & uint64(os.ModePerm))
+		}
+	}
+	switch info.Mode() {
+	case os.ModeDir:
+		return 0755
+	case os.ModeSymlink:
+		return 0777
+	default:
+		return 0644
+	}
+}
+
+// ImportTar adds the contents of a tar stream to the filesystem, keeping file modes and modification
+// times; entries other than regular files, directories, symbolic links and hard links (such as device
+// nodes) are skipped
+func (w *Writer) ImportTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("cannot read tar stream: %w", err)
+		}
+		name := path.Clean("/" + hdr.Name)
+		if name == "/" {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if info, statErr := w.Stat(name); (statErr != nil) || !info.IsDir() {
+				err = w.setEntry(name, make(map[string]interface{}))
+			}
+		case tar.TypeReg:
+			err = w.WriteData(name, tr, hdr.Size)
+		case tar.TypeSymlink:
+			err = w.setEntry(name, link{path: hdr.Linkname})
+		case tar.TypeLink:
+			err = w.Link(path.Clean("/"+hdr.Linkname), name)
+		default:
+			continue
+		}
+		if (err == nil) && (hdr.Typeflag != tar.TypeLink) {
+			attrs := map[string]interface{}{
+				"mtime": strconv.FormatUint(tfsTimestamp(hdr.ModTime), 10),
+			}
+			if hdr.Typeflag != tar.TypeSymlink {
+				attrs["mode"] = strconv.FormatInt(hdr.Mode&int64(os.ModePerm), 10)
+			}
+			var tuple *map[string]interface{}
+			tuple, _, err = w.rootFS.lookup(w.rootFS.root, name)
+			if err == nil {
+				err = w.rootFS.updateTuple(tuple, attrs)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("cannot import %q: %w", hdr.Name, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package fs
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTarVolume(t *testing.T) string {
+	imagePath := filepath.Join(t.TempDir(), "volume")
+	buildImage(t, imagePath, nil, false, withSize("4M"))
+	return imagePath
+}
+
+func importTar(t *testing.T, imagePath string, r io.Reader) {
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	assert.Nil(t, w.ImportTar(r))
+	assert.Nil(t, w.Compact())
+	assert.Nil(t, w.Commit())
+}
+
+func exportTar(t *testing.T, imagePath string) []byte {
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var buf bytes.Buffer
+	assert.Nil(t, r.ExportTar(&buf))
+	return buf.Bytes()
+}
+
+func TestTarImportExport(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []struct {
+		hdr  tar.Header
+		data string
+	}{
+		{tar.Header{Name: "./etc/", Typeflag: tar.TypeDir, Mode: 0700, ModTime: mtime}, ""},
+		{tar.Header{Name: "./etc/passwd", Typeflag: tar.TypeReg, Mode: 0600, ModTime: mtime}, "root:x:0:0"},
+		{tar.Header{Name: "./bin/app", Typeflag: tar.TypeReg, Mode: 0755, ModTime: mtime}, "binary"},
+		{tar.Header{Name: "./bin/app2", Typeflag: tar.TypeLink, Linkname: "./bin/app"}, ""},
+		{tar.Header{Name: "./usr/bin", Typeflag: tar.TypeSymlink, Linkname: "../bin", ModTime: mtime}, ""},
+		{tar.Header{Name: "./dev/null", Typeflag: tar.TypeChar, Mode: 0666}, ""},
+	}
+	for _, e := range entries {
+		e.hdr.Size = int64(len(e.data))
+		assert.Nil(t, tw.WriteHeader(&e.hdr))
+		_, err := tw.Write([]byte(e.data))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+
+	imagePath := newTarVolume(t)
+	importTar(t, imagePath, &buf)
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "root:x:0:0", readImageFile(t, r, "/etc/passwd"))
+	assert.Equal(t, "binary", readImageFile(t, r, "/bin/app2"))
+	info, err := r.Stat("/etc/passwd")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0600), filePerm(info))
+	assert.True(t, info.ModTime().Equal(mtime))
+	info, err = r.Stat("/etc")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0700), filePerm(info))
+	target, err := r.ReadLink("/usr/bin")
+	assert.Nil(t, err)
+	assert.Equal(t, "../bin", target)
+	_, err = r.Stat("/dev/null")
+	assert.True(t, os.IsNotExist(err))
+	r.Close()
+
+	// a volume imported from an export is exported identically
+	exported := exportTar(t, imagePath)
+	copyPath := newTarVolume(t)
+	importTar(t, copyPath, bytes.NewReader(exported))
+	assert.Equal(t, exported, exportTar(t, copyPath))
+}
+
+func TestTarExportLinks(t *testing.T) {
+	imagePath := newTarVolume(t)
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, w.WriteData("/a", bytes.NewReader([]byte("linked")), 6))
+	assert.Nil(t, w.Link("/a", "/b"))
+	assert.Nil(t, w.Commit())
+	w.Close()
+
+	// hard-linked files are exported with the name of each of their entries
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := r.Stat("/b")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", info.Name())
+	r.Close()
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(exportTar(t, imagePath)))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []string{"a", "b"}, names)
+}
@@ -55,12 +55,19 @@ type tfs struct {
 	allocated   uint64
 	uuid        [16]byte
 	label       string
+	mtime       uint64 // if not zero, modification time of the files and directories written
 	currentExt  *tlogExt
+	logExts     []uint64
+	logSpace    []Extent
 	symDict     map[string]int
 	nonSymCount int
 	staging     []byte
 	decoder     tfsDecoder
 	root        *map[string]interface{}
+
+	// encoded are the dictionary indexes of the tuples encoded by rewriteLog, so that a tuple
+	// shared by several directory entries is encoded once
+	encoded map[*map[string]interface{}]int
 }
 
 func (t *tfs) logInit(oldEncoding bool) error {
@@ -68,6 +75,7 @@ func (t *tfs) logInit(oldEncoding bool) error {
 		return fmt.Errorf("available space (%d bytes) too small, required %d", t.size-t.allocated, sectorSize)
 	}
 	t.currentExt = t.newLogExt(true, oldEncoding)
+	t.logExts = append(t.logExts, t.currentExt.offset)
 	t.allocated += sectorSize
 	return t.logExtend()
 }
@@ -100,13 +108,15 @@ func (t *tfs) newLogExt(initial bool, oldEncoding bool) *tlogExt {
 }
 
 func (t *tfs) logExtend() error {
-	if (t.size != 0) && (t.allocated+logExtensionSize > t.size) {
-		return fmt.Errorf("available space (%d bytes) too small, required %d", t.size-t.allocated, logExtensionSize)
+	offset, err := t.allocLogSpace(logExtensionSize)
+	if err != nil {
+		return err
 	}
 	logExt := t.newLogExt(false, t.currentExt.oldEncoding)
+	logExt.offset = offset
 	t.currentExt.linkTo(logExt.offset)
-	t.allocated += logExtensionSize
-	err := t.currentExt.flush(t.imgFile, t.imgOffset)
+	t.logExts = append(t.logExts, logExt.offset)
+	err = t.currentExt.flush(t.imgFile, t.imgOffset)
 	if err != nil {
 		return err
 	}
@@ -114,11 +124,33 @@ func (t *tfs) logExtend() error {
 	return nil
 }
 
+// allocLogSpace returns the offset of a free region for the log: a region left unused by a log rewrite
+// if available, otherwise the end of the allocated space
+func (t *tfs) allocLogSpace(size uint64) (uint64, error) {
+	for i, free := range t.logSpace {
+		if free.Length >= size {
+			t.logSpace[i].Offset += size
+			t.logSpace[i].Length -= size
+			return free.Offset, nil
+		}
+	}
+	if (t.size != 0) && (t.allocated+size > t.size) {
+		return 0, fmt.Errorf("available space (%d bytes) too small, required %d", t.size-t.allocated, size)
+	}
+	offset := t.allocated
+	t.allocated += size
+	return offset, nil
+}
+
 func (t *tfs) readLogExt(offset, size uint64) (uint64, error) {
 	buffer := make([]byte, size)
 	if _, err := t.imgFile.ReadAt(buffer, int64(t.imgOffset+offset)); err != nil {
 		return 0, fmt.Errorf("cannot read image file: %w", err)
 	}
+	extOffset := offset
+	if extOffset+size > t.allocated {
+		t.allocated = extOffset + size
+	}
 	if bytes.Compare(buffer[0:len(tfsMagic)], []byte(tfsMagic)) != 0 {
 		return 0, errors.New("TFS magic number not found")
 	}
@@ -135,6 +167,11 @@ func (t *tfs) readLogExt(offset, size uint64) (uint64, error) {
 	} else {
 		return 0, fmt.Errorf("TFS version mismatch: expected %d, found %d", tfsVersion, version)
 	}
+	// until the end of log is found, this only records the encoding
+	t.currentExt = &tlogExt{
+		offset:      extOffset,
+		oldEncoding: oldEncoding,
+	}
 	_size, err := getVarint(buffer, &offset)
 	if err != nil {
 		return 0, err
@@ -155,10 +192,19 @@ func (t *tfs) readLogExt(offset, size uint64) (uint64, error) {
 		offset += uint64(len(t.label) + 1)
 	}
 	for {
+		if offset >= uint64(len(buffer)) {
+			return 0, errors.New("log extension has no end of log or link record")
+		}
 		record := buffer[offset]
 		offset++
 		switch record {
 		case endOfLog:
+			// keep the log tail around so that new records can be appended to it
+			t.currentExt = &tlogExt{
+				offset:      extOffset,
+				oldEncoding: oldEncoding,
+				buffer:      buffer[:offset-1],
+			}
 			return 0, nil
 		case tupleAvailable:
 			if t.decoder.tupleRemain > 0 {
@@ -179,6 +225,10 @@ func (t *tfs) readLogExt(offset, size uint64) (uint64, error) {
 					"offset: %d)", length, tupleTotalLen, offset)
 				return 0, err
 			}
+			if offset+uint64(length) > size {
+				return 0, fmt.Errorf("tupleAvailable record exceeds log extension (length: %d, offset: %d)",
+					length, offset)
+			}
 			if length == tupleTotalLen {
 				var decoded uint64
 				_, err := t.decodeValue(buffer[offset:offset+uint64(length)], &decoded, oldEncoding)
@@ -201,6 +251,10 @@ func (t *tfs) readLogExt(offset, size uint64) (uint64, error) {
 					length, t.decoder.tupleRemain)
 				return 0, err
 			}
+			if offset+uint64(length) > size {
+				return 0, fmt.Errorf("tupleExtended record exceeds log extension (length: %d, offset: %d)",
+					length, offset)
+			}
 			t.staging = append(t.staging, buffer[offset:offset+uint64(length)]...)
 			t.decoder.tupleRemain -= length
 			if t.decoder.tupleRemain == 0 {
@@ -236,7 +290,8 @@ func (t *tfs) writeDirEntries(dir map[string]interface{}) error {
 	var err error
 	t.encodeSymbol("children")
 	t.encodeTupleHeader(len(dir))
-	for k, v := range dir {
+	for _, k := range sortedKeys(dir) {
+		v := dir[k]
 		nvalue, nok := v.(link)
 		if nok {
 			err = t.writeLink(k, nvalue.path)
@@ -250,8 +305,15 @@ func (t *tfs) writeDirEntries(dir map[string]interface{}) error {
 			err = t.writeFile(k, value)
 		} else {
 			t.encodeSymbol(k)
-			t.encodeTupleHeader(1) // for "children" attribute
-			err = t.writeDirEntries(v.(map[string]interface{}))
+			if t.mtime != 0 {
+				t.encodeTupleHeader(2) // for "mtime" and "children" attributes
+				err = t.encodeMetadata("mtime", strconv.FormatUint(t.mtime, 10))
+			} else {
+				t.encodeTupleHeader(1) // for "children" attribute
+			}
+			if err == nil {
+				err = t.writeDirEntries(v.(map[string]interface{}))
+			}
 		}
 		if err != nil {
 			break
@@ -300,9 +362,32 @@ func (t *tfs) encodeValue(value interface{}) error {
 	if isTuple {
 		return t.encodeTuple(tuple)
 	}
+	sharedTuple, isSharedTuple := value.(*map[string]interface{})
+	if isSharedTuple {
+		return t.encodeSharedTuple(sharedTuple)
+	}
 	return fmt.Errorf("unknown type of value %p", value)
 }
 
+// encodeSharedTuple encodes a tuple the first time it is encountered, and a reference to it after
+// that, so that hard-linked files keep a single tuple
+func (t *tfs) encodeSharedTuple(tuple *map[string]interface{}) error {
+	if ref, ok := t.encoded[tuple]; ok {
+		t.pushHeader(entryReference, typeTuple, 0)
+		t.staging = appendVarint(t.staging, uint(ref))
+		return nil
+	}
+	t.encodeTupleHeader(len(*tuple))
+	t.encoded[tuple] = len(t.symDict) + t.nonSymCount
+	for _, k := range sortedKeys(*tuple) {
+		err := t.encodeMetadata(k, (*tuple)[k])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t *tfs) encodeMetadata(name string, value interface{}) error {
 	t.encodeSymbol(name)
 	err := t.encodeValue(value)
@@ -326,8 +411,8 @@ func (t *tfs) encodeTupleHeader(tupleEntries int) {
 
 func (t *tfs) encodeTuple(tuple map[string]interface{}) error {
 	t.encodeTupleHeader(len(tuple))
-	for k, v := range tuple {
-		err := t.encodeMetadata(k, v)
+	for _, k := range sortedKeys(tuple) {
+		err := t.encodeMetadata(k, tuple[k])
 		if err != nil {
 			return err
 		}
@@ -389,6 +474,9 @@ func (t *tfs) decodeVector(buffer []byte, offset *uint64, entry byte, length uin
 		if err != nil {
 			return vector, err
 		}
+		if int(length) > len(*vector) {
+			return vector, fmt.Errorf("vector %d has %d elements, cannot set %d", ref, len(*vector), length)
+		}
 	}
 	for i := 0; i < int(length); i++ {
 		value, err := t.decodeValue(buffer, offset, oldEncoding)
@@ -406,6 +494,7 @@ func (t *tfs) decodeTuple(buffer []byte, offset *uint64, entry byte, length uint
 		newTuple := make(map[string]interface{})
 		tuple = &newTuple
 		t.decoder.dict[len(t.decoder.dict)+1] = tuple
+		t.decoder.tupleRefs[tuple] = len(t.decoder.dict)
 	} else {
 		ref, err := getVarint(buffer, offset)
 		if err != nil {
@@ -447,6 +536,9 @@ func (t *tfs) decodeTuple(buffer []byte, offset *uint64, entry byte, length uint
 
 func (t *tfs) decodeSymbol(buffer []byte, offset *uint64, entry byte, length uint) (string, error) {
 	if entry == entryImmediate {
+		if *offset+uint64(length) > uint64(len(buffer)) {
+			return "", fmt.Errorf("decodeSymbol(): buffer length %d exhausted", len(buffer))
+		}
 		sym := string(buffer[*offset : *offset+uint64(length)])
 		*offset += uint64(length)
 		t.decoder.dict[len(t.decoder.dict)+1] = sym
@@ -460,6 +552,9 @@ func (t *tfs) decodeBuf(buffer []byte, offset *uint64, entry byte, length uint)
 		return "", nil
 	}
 	if entry == entryImmediate {
+		if *offset+uint64(length) > uint64(len(buffer)) {
+			return "", fmt.Errorf("decodeBuf(): buffer length %d exhausted", len(buffer))
+		}
 		buf := string(buffer[*offset : *offset+uint64(length)])
 		*offset += uint64(length)
 		return buf, nil
@@ -516,6 +611,9 @@ func (t *tfs) getDictString(ref uint) (string, error) {
 func (t *tfs) writeLink(name string, target string) error {
 	tuple := make(map[string]interface{})
 	tuple["linktarget"] = target
+	if t.mtime != 0 {
+		tuple["mtime"] = strconv.FormatUint(t.mtime, 10)
+	}
 	return t.encodeMetadata(name, tuple)
 }
 
@@ -530,11 +628,21 @@ func (t *tfs) writeFile(name string, hostPath string) error {
 	if err != nil {
 		return fmt.Errorf("cannot get size of file %q: %w", hostPath, err)
 	}
+	err = t.writeFileData(name, file, info.Size())
+	if err != nil {
+		return fmt.Errorf("cannot write file %q: %w", hostPath, err)
+	}
+	return nil
+}
+
+// writeFileData writes the given amount of data from a reader as the contents of a new file
+func (t *tfs) writeFileData(name string, file io.Reader, size int64) error {
+	var err error
 	tuple := make(map[string]interface{})
-	tuple["filelength"] = strconv.FormatInt(info.Size(), 10)
+	tuple["filelength"] = strconv.FormatInt(size, 10)
 	extents := make(map[string]interface{})
-	if info.Size() > 0 {
-		sectors := uint64((info.Size() + sectorSize - 1) / sectorSize)
+	if size > 0 {
+		sectors := uint64((size + sectorSize - 1) / sectorSize)
 		paddedLen := sectors * sectorSize
 		if (t.size != 0) && (t.allocated+paddedLen > t.size) {
 			return fmt.Errorf("available space (%d bytes) too small, required %d", t.size-t.allocated, paddedLen)
@@ -544,19 +652,26 @@ func (t *tfs) writeFile(name string, hostPath string) error {
 			return fmt.Errorf("cannot seek image file: %w", err)
 		}
 		b := make([]byte, 8192)
-		for {
+		var written int64
+		for written < size {
 			var n int
-			n, err = file.Read(b)
+			n, err = file.Read(b[:min(int64(len(b)), size-written)])
+			if n > 0 {
+				_, werr := t.imgFile.Write(b[:n])
+				if werr != nil {
+					return fmt.Errorf("cannot write image file: %w", werr)
+				}
+				written += int64(n)
+			}
 			if err == io.EOF {
 				break
 			} else if err != nil {
-				return fmt.Errorf("cannot read file %q: %w", hostPath, err)
-			}
-			n, err = t.imgFile.Write(b[:n])
-			if err != nil {
-				return fmt.Errorf("cannot write image file: %w", err)
+				return fmt.Errorf("cannot read file: %w", err)
 			}
 		}
+		if written < size {
+			return fmt.Errorf("unexpected end of file after %d bytes, expected %d", written, size)
+		}
 		extent := make(map[string]interface{})
 		extent["length"] = strconv.FormatUint(sectors, 10)
 		extent["offset"] = strconv.FormatUint(t.allocated/sectorSize, 10)
@@ -565,6 +680,9 @@ func (t *tfs) writeFile(name string, hostPath string) error {
 		extents["0"] = extent
 	}
 	tuple["extents"] = extents
+	if t.mtime != 0 {
+		tuple["mtime"] = strconv.FormatUint(t.mtime, 10)
+	}
 	return t.encodeMetadata(name, tuple)
 }
 
@@ -596,7 +714,8 @@ func (t *tfs) pushHeader(entry byte, dataType byte, length int) {
 	}
 }
 
-func (t *tfs) flush() error {
+// logRecord moves the staged value into the log as a new record
+func (t *tfs) logRecord() error {
 	ext := t.currentExt
 	written := 0
 	for len(t.staging) > 0 {
@@ -625,11 +744,22 @@ func (t *tfs) flush() error {
 		t.staging = t.staging[length:]
 		written += length
 	}
+	return nil
+}
+
+func (t *tfs) flush() error {
+	err := t.logRecord()
+	if err != nil {
+		return err
+	}
+	ext := t.currentExt
 	ext.buffer = append(ext.buffer, endOfLog)
-	err := ext.flush(t.imgFile, t.imgOffset)
+	err = ext.flush(t.imgFile, t.imgOffset)
 	if err != nil {
 		return err
 	}
+	// further records overwrite the end of log marker
+	ext.buffer = ext.buffer[:len(ext.buffer)-1]
 	var info os.FileInfo
 	info, err = t.imgFile.Stat()
 	if err != nil {
@@ -674,10 +804,15 @@ func (t *tfs) stat(path string) (os.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &tfsFileInfo{
+	info := &tfsFileInfo{
 		tuple:       tuple,
 		parentTuple: parent,
-	}, nil
+	}
+	trimmed := strings.TrimRight(path, "/")
+	if name := trimmed[strings.LastIndex(trimmed, "/")+1:]; (name != ".") && (name != "..") {
+		info.name = name
+	}
+	return info, nil
 }
 
 func (t *tfs) readLink(path string) (string, error) {
@@ -756,6 +891,7 @@ func (t *tfs) readDir(path string) ([]os.FileInfo, error) {
 			entries = append(entries, &tfsFileInfo{
 				tuple:       v.(*map[string]interface{}),
 				parentTuple: dir,
+				name:        k,
 			})
 		}
 	}
@@ -780,11 +916,15 @@ func (t *tfs) getUUID() string {
 type tfsDecoder struct {
 	tupleRemain uint
 	dict        map[int]interface{}
+	tupleRefs   map[*map[string]interface{}]int
 }
 
 type tfsFileInfo struct {
 	tuple       *map[string]interface{}
 	parentTuple *map[string]interface{}
+
+	// name is the name of the entry the file was found with, as hard-linked files share a tuple
+	name string
 }
 
 func (i *tfsFileInfo) IsDir() bool {
@@ -797,11 +937,21 @@ func (i *tfsFileInfo) IsDir() bool {
 
 func (i *tfsFileInfo) ModTime() time.Time {
 	c := getString(i.tuple, "mtime")
-	var timestamp int64
+	var timestamp uint64
 	if c != "" {
-		timestamp, _ = strconv.ParseInt(c, 10, 64)
+		timestamp, _ = strconv.ParseUint(c, 10, 64)
 	}
-	return time.Unix(timestamp>>32, (timestamp&0xfffffffff)*0x10000000/1000000000)
+	return tfsTime(timestamp)
+}
+
+// tfsTime converts a Nanos timestamp (seconds in 32.32 fixed point format) to a time value
+func tfsTime(timestamp uint64) time.Time {
+	return time.Unix(int64(timestamp>>32), int64(((timestamp&0xffffffff)*1000000000)>>32))
+}
+
+// tfsTimestamp converts a time value to a Nanos timestamp
+func tfsTimestamp(t time.Time) uint64 {
+	return (uint64(t.Unix()) << 32) | ((uint64(t.Nanosecond()) << 32) / 1000000000)
 }
 
 func (i *tfsFileInfo) Mode() os.FileMode {
@@ -821,6 +971,9 @@ func (i *tfsFileInfo) Name() string {
 	if i.parentTuple == i.tuple {
 		return "/"
 	}
+	if i.name != "" {
+		return i.name
+	}
 	children := getTuple(i.parentTuple, "children")
 	for k, v := range *children {
 		if v == i.tuple {
@@ -859,9 +1012,13 @@ func (r *tfsFileReader) Read(p []byte) (n int, err error) {
 	}
 	if len(r.extentOffsets) == 0 {
 		n = len(p)
+		if uint64(n) > r.length-r.offset {
+			n = int(r.length - r.offset)
+		}
 		for i := 0; i < n; i++ {
 			p[i] = 0
 		}
+		r.offset += uint64(n)
 		return n, nil
 	}
 	n = 0
@@ -1086,66 +1243,398 @@ func getChild(parent *map[string]interface{}, child string) *map[string]interfac
 	return getTuple(children, child)
 }
 
+// sortedKeys returns the keys of a tuple in lexical order, so that encoding a tuple always produces
+// the same bytes
+func sortedKeys(tuple map[string]interface{}) []string {
+	keys := make([]string, 0, len(tuple))
+	for k := range tuple {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func newTfs(imgFile *os.File, imgOffset uint64, fsSize uint64) *tfs {
 	return &tfs{
 		imgFile:   imgFile,
 		imgOffset: imgOffset,
 		size:      fsSize,
 		symDict:   make(map[string]int),
+		decoder: tfsDecoder{
+			dict:      make(map[int]interface{}),
+			tupleRefs: make(map[*map[string]interface{}]int),
+		},
 	}
 }
 
 // tfsWrite writes filesystem metadata and contents to image file
 func tfsWrite(imgFile *os.File, imgOffset uint64, fsSize uint64, label string, root map[string]interface{}, oldEncoding bool) (*tfs, error) {
 	tfs := newTfs(imgFile, imgOffset, fsSize)
-	tfs.label = label
 	rand.Seed(time.Now().UnixNano())
 	_, err := rand.Read(tfs.uuid[:])
 	if err != nil {
 		return nil, fmt.Errorf("error generating random uuid: %w", err)
 	}
-	err = tfs.logInit(oldEncoding)
+	return tfs, tfs.write(label, root, oldEncoding)
+}
+
+// write creates a new filesystem log with the given label and contents; the UUID must have been set
+func (t *tfs) write(label string, root map[string]interface{}, oldEncoding bool) error {
+	t.label = label
+	err := t.logInit(oldEncoding)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create filesystem log: %w", err)
+		return fmt.Errorf("cannot create filesystem log: %w", err)
 	}
-	tfs.encodeTupleHeader(len(root))
-	for k, v := range root {
+	t.encodeTupleHeader(len(root))
+	for _, k := range sortedKeys(root) {
 		if k == "children" {
-			err = tfs.writeDirEntries(v.(map[string]interface{}))
-			if err != nil {
-				return nil, err
-			}
+			err = t.writeDirEntries(root[k].(map[string]interface{}))
 		} else {
-			err = tfs.encodeMetadata(k, v)
-			if err != nil {
-				return nil, err
-			}
+			err = t.encodeMetadata(k, root[k])
+		}
+		if err != nil {
+			return err
 		}
 	}
-	return tfs, tfs.flush()
+	return t.flush()
 }
 
 func tfsRead(imgFile *os.File, fsOffset, fsSize uint64) (*tfs, error) {
 	tfs := newTfs(imgFile, fsOffset, fsSize)
-	tfs.decoder.dict = make(map[int]interface{})
-	nextExt, err := tfs.readLogExt(0, sectorSize)
+	err := tfs.readLog()
 	if err != nil {
-		return nil, fmt.Errorf("cannot read filesystem at first log extension: %w", err)
-	}
-	for nextExt != 0 {
-		nextExt, err = tfs.readLogExt(nextExt, logExtensionSize)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read filesystem log extension: %w", err)
-		}
+		return nil, err
 	}
 	tfs.root, err = tfs.getDictTuple(1)
 	if err != nil {
 		return nil, err
 	}
+	tfs.updateAllocated(tfs.root)
+	tfs.loadDict()
 	fixupDirectory(tfs.root, tfs.root)
 	return tfs, nil
 }
 
+// readLog decodes the chain of log extensions, starting from the first one
+func (t *tfs) readLog() error {
+	t.logExts = []uint64{0}
+	nextExt, err := t.readLogExt(0, sectorSize)
+	if err != nil {
+		return fmt.Errorf("cannot read filesystem at first log extension: %w", err)
+	}
+	for nextExt != 0 {
+		for _, ext := range t.logExts {
+			if ext == nextExt {
+				return fmt.Errorf("log extension at offset %d is linked more than once", nextExt)
+			}
+		}
+		t.logExts = append(t.logExts, nextExt)
+		nextExt, err = t.readLogExt(nextExt, logExtensionSize)
+		if err != nil {
+			return fmt.Errorf("cannot read filesystem log extension at offset %d: %w",
+				t.logExts[len(t.logExts)-1], err)
+		}
+	}
+	return nil
+}
+
+// loadDict fills the encoder dictionary from the decoded log, so that new records can refer to
+// existing symbols
+func (t *tfs) loadDict() {
+	for index, value := range t.decoder.dict {
+		if sym, isString := value.(string); isString {
+			t.symDict[sym] = index
+		}
+	}
+	t.nonSymCount = len(t.decoder.dict) - len(t.symDict)
+}
+
+// updateAllocated extends the allocated space so that it covers the extents of all files in a directory tree
+func (t *tfs) updateAllocated(dir *map[string]interface{}) {
+	walkExtents(dir, func(offset, length uint64) {
+		if offset+length > t.allocated {
+			t.allocated = offset + length
+		}
+	})
+}
+
+// walkExtents calls fn with the offset and allocated length, in bytes, of each file extent in a directory tree
+func walkExtents(dir *map[string]interface{}, fn func(offset, length uint64)) {
+	if extents := getTuple(dir, "extents"); extents != nil {
+		for _, v := range *extents {
+			extent, ok := v.(*map[string]interface{})
+			if !ok {
+				continue
+			}
+			offset, _ := strconv.ParseUint(getString(extent, "offset"), 10, 64)
+			length, _ := strconv.ParseUint(getString(extent, "allocated"), 10, 64)
+			if length == 0 {
+				length, _ = strconv.ParseUint(getString(extent, "length"), 10, 64)
+			}
+			fn(offset*sectorSize, length*sectorSize)
+		}
+	}
+	children := getTuple(dir, "children")
+	if children == nil {
+		return
+	}
+	for k, v := range *children {
+		if (k == ".") || (k == "..") {
+			continue
+		}
+		if child, ok := v.(*map[string]interface{}); ok {
+			walkExtents(child, fn)
+		}
+	}
+}
+
+// freeExtents returns the unused regions between the given extents
+func freeExtents(used []Extent) []Extent {
+	sort.Slice(used, func(i, j int) bool {
+		return used[i].Offset < used[j].Offset
+	})
+	var free []Extent
+	var end uint64
+	for _, extent := range used {
+		if extent.Offset > end {
+			free = append(free, Extent{Offset: end, Length: extent.Offset - end})
+		}
+		if extent.Offset+extent.Length > end {
+			end = extent.Offset + extent.Length
+		}
+	}
+	return free
+}
+
+// encodeTupleRef encodes the header of a tuple that amends an existing tuple
+func (t *tfs) encodeTupleRef(tuple *map[string]interface{}, tupleEntries int) error {
+	ref, ok := t.decoder.tupleRefs[tuple]
+	if !ok {
+		return errors.New("tuple not found in filesystem dictionary")
+	}
+	t.pushHeader(entryReference, typeTuple, tupleEntries)
+	t.staging = appendVarint(t.staging, uint(ref))
+	return nil
+}
+
+// logUpdate stages a value with the given encoding function and commits it to the log; if encoding
+// fails, the staged data and any symbols it added to the dictionary are discarded
+func (t *tfs) logUpdate(encode func() error) error {
+	nonSymCount := t.nonSymCount
+	dictSize := len(t.symDict) + t.nonSymCount
+	err := encode()
+	if err != nil {
+		t.staging = t.staging[:0]
+		for sym, index := range t.symDict {
+			if index > dictSize {
+				delete(t.symDict, sym)
+			}
+		}
+		t.nonSymCount = nonSymCount
+		return err
+	}
+	return t.logCommit()
+}
+
+// logCommit writes the staged value to the log and applies it to the in-memory filesystem
+func (t *tfs) logCommit() error {
+	record := append([]byte(nil), t.staging...)
+	err := t.logRecord()
+	if err != nil {
+		return err
+	}
+	var offset uint64
+	_, err = t.decodeValue(record, &offset, t.currentExt.oldEncoding)
+	return err
+}
+
+// updateTuple sets the given attributes of an existing tuple; attributes with a nil value are removed
+func (t *tfs) updateTuple(tuple *map[string]interface{}, attrs map[string]interface{}) error {
+	return t.logUpdate(func() error {
+		err := t.encodeTupleRef(tuple, len(attrs))
+		if err != nil {
+			return err
+		}
+		for _, k := range sortedKeys(attrs) {
+			v := attrs[k]
+			if v == nil {
+				t.encodeSymbol(k)
+				t.encodeString("", typeBuffer)
+				continue
+			}
+			err = t.encodeMetadata(k, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// setDirEntry adds or replaces an entry in an existing directory; the entry value has the same format as
+// in a manifest (a host file path, a link or a directory map), and a nil value removes the entry
+func (t *tfs) setDirEntry(dir *map[string]interface{}, name string, value interface{}) error {
+	children := getTuple(dir, "children")
+	if children == nil {
+		return errors.New("not a directory")
+	}
+	err := t.logUpdate(func() error {
+		err := t.encodeTupleRef(children, 1)
+		if err != nil {
+			return err
+		}
+		switch v := value.(type) {
+		case nil:
+			t.encodeSymbol(name)
+			t.encodeString("", typeBuffer)
+		case link:
+			return t.writeLink(name, v.path)
+		case string:
+			return t.writeFile(name, v)
+		case fileData:
+			return t.writeFileData(name, v.r, v.size)
+		case map[string]interface{}:
+			t.encodeSymbol(name)
+			t.encodeTupleHeader(1) // for "children" attribute
+			return t.writeDirEntries(v)
+		default:
+			return fmt.Errorf("unknown type of directory entry %q", name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if child := getChild(dir, name); child != nil {
+		fixupDirectory(dir, child)
+	}
+	return nil
+}
+
+// moveDirEntry moves an existing directory entry to a (possibly different) directory, so that the
+// new entry refers to the same tuple and file contents are not rewritten
+func (t *tfs) moveDirEntry(srcDir *map[string]interface{}, srcName string, destDir *map[string]interface{}, destName string) error {
+	child := getChild(srcDir, srcName)
+	if child == nil {
+		return os.ErrNotExist
+	}
+	err := t.linkDirEntry(destDir, destName, child)
+	if err != nil {
+		return err
+	}
+	if childEntries := getTuple(child, "children"); childEntries != nil {
+		(*childEntries)[".."] = destDir
+	}
+	if (srcDir == destDir) && (srcName == destName) {
+		return nil
+	}
+	return t.setDirEntry(srcDir, srcName, nil)
+}
+
+// linkDirEntry adds or replaces a directory entry so that it refers to an existing tuple
+func (t *tfs) linkDirEntry(dir *map[string]interface{}, name string, tuple *map[string]interface{}) error {
+	children := getTuple(dir, "children")
+	if children == nil {
+		return errors.New("not a directory")
+	}
+	return t.logUpdate(func() error {
+		err := t.encodeTupleRef(children, 1)
+		if err != nil {
+			return err
+		}
+		t.encodeSymbol(name)
+		return t.encodeTupleRef(tuple, 0)
+	})
+}
+
+// rewriteLog replaces the filesystem log with a new log that encodes the current directory tree in a
+// single record, with a new dictionary; file contents are not moved. The first log extension is
+// written last, so that the old log remains valid until the new one is complete.
+func (t *tfs) rewriteLog() error {
+	root := plainValue(t.root, make(map[*map[string]interface{}]*map[string]interface{}))
+	t.encoded = make(map[*map[string]interface{}]int)
+	defer func() { t.encoded = nil }()
+	oldEncoding := t.currentExt.oldEncoding
+	t.symDict = make(map[string]int)
+	t.nonSymCount = 0
+	t.staging = nil
+	t.decoder = tfsDecoder{
+		dict:      make(map[int]interface{}),
+		tupleRefs: make(map[*map[string]interface{}]int),
+	}
+
+	// the new log must not overwrite file contents or the old log
+	var used []Extent
+	walkExtents(t.root, func(offset, length uint64) {
+		used = append(used, Extent{Offset: offset, Length: length})
+	})
+	for i, offset := range t.logExts {
+		length := uint64(logExtensionSize)
+		if i == 0 {
+			length = sectorSize
+		}
+		used = append(used, Extent{Offset: offset, Length: length})
+	}
+	t.logSpace = freeExtents(used)
+
+	// until the new log is complete, its first extension is written to a spare sector
+	spare, err := t.allocLogSpace(sectorSize)
+	if err != nil {
+		return err
+	}
+	initial := t.newLogExt(true, oldEncoding)
+	initial.offset = spare
+	t.currentExt = initial
+	t.logExts = []uint64{0}
+	err = t.logUpdate(func() error {
+		return t.encodeValue(root)
+	})
+	if err != nil {
+		return err
+	}
+	err = t.flush()
+	if err != nil {
+		return err
+	}
+	t.root, err = t.getDictTuple(1)
+	if err != nil {
+		return err
+	}
+	fixupDirectory(t.root, t.root)
+	initial.offset = 0
+	// a log which fits in its first extension ends there
+	if t.currentExt == initial {
+		return t.flush()
+	}
+	return initial.flush(t.imgFile, t.imgOffset)
+}
+
+// plainValue converts a decoded value to the representation used by the encoder; tuples are
+// converted once, so that tuples shared by several directory entries stay shared
+func plainValue(value interface{}, tuples map[*map[string]interface{}]*map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case *map[string]interface{}:
+		if tuple, ok := tuples[v]; ok {
+			return tuple
+		}
+		tuple := make(map[string]interface{})
+		tuples[v] = &tuple
+		for k, child := range *v {
+			if (k != ".") && (k != "..") {
+				tuple[k] = plainValue(child, tuples)
+			}
+		}
+		return &tuple
+	case *[]interface{}:
+		vector := make([]interface{}, len(*v))
+		for i, elem := range *v {
+			vector[i] = plainValue(elem, tuples)
+		}
+		return vector
+	}
+	return value
+}
+
 func fixupDirectory(parent, dir *map[string]interface{}) {
 	children := getTuple(dir, "children")
 	if children == nil {
@@ -0,0 +1,317 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writer allows modifying the filesystem contents of an existing image or volume; changes are
+// appended to the filesystem log and become visible to Nanos after Commit
+type Writer struct {
+	imagePath   string
+	imageFile   *os.File
+	rootFS      *tfs
+	partitioned bool
+}
+
+// NewWriter returns an instance of Writer for the root filesystem of an image or volume
+func NewWriter(imagePath string) (*Writer, error) {
+	imageFile, err := os.OpenFile(imagePath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open image file: %w", err)
+	}
+	fsStart, fsSize, partitioned, err := findRootFS(imageFile)
+	if err != nil {
+		imageFile.Close()
+		return nil, err
+	}
+	if partitioned {
+		// the root filesystem is the last partition of an image, so it can grow with the image file
+		fsSize = 0
+	}
+	rootFS, err := tfsRead(imageFile, fsStart, fsSize)
+	if err != nil {
+		imageFile.Close()
+		return nil, err
+	}
+	return &Writer{
+		imagePath:   imagePath,
+		imageFile:   imageFile,
+		rootFS:      rootFS,
+		partitioned: partitioned,
+	}, nil
+}
+
+// Stat retrieves information for a file in the image
+func (w *Writer) Stat(path string) (os.FileInfo, error) {
+	return w.rootFS.stat(path)
+}
+
+// WriteFile copies a file, symbolic link or directory tree from the local filesystem to the image,
+// replacing whatever is at the destination path; missing parent directories are created
+func (w *Writer) WriteFile(src, dest string) error {
+	entry, err := hostDirEntry(src)
+	if err != nil {
+		return err
+	}
+	return w.setEntry(dest, entry)
+}
+
+// WriteData creates a file in the image with size bytes read from r, replacing whatever is at the
+// destination path; missing parent directories are created
+func (w *Writer) WriteData(dest string, r io.Reader, size int64) error {
+	return w.setEntry(dest, fileData{r: r, size: size})
+}
+
+// Mkdir creates a directory in the image
+func (w *Writer) Mkdir(path string) error {
+	dir, name, err := w.lookupParent(path, false)
+	if err != nil {
+		return err
+	}
+	if getChild(dir, name) != nil {
+		return os.ErrExist
+	}
+	return w.rootFS.setDirEntry(dir, name, make(map[string]interface{}))
+}
+
+// Symlink creates a symbolic link in the image
+func (w *Writer) Symlink(target, path string) error {
+	dir, name, err := w.lookupParent(path, false)
+	if err != nil {
+		return err
+	}
+	if getChild(dir, name) != nil {
+		return os.ErrExist
+	}
+	return w.rootFS.setDirEntry(dir, name, link{path: target})
+}
+
+// Link creates a new directory entry for an existing file (a hard link)
+func (w *Writer) Link(oldPath, newPath string) error {
+	tuple, _, err := w.rootFS.lookup(w.rootFS.root, oldPath)
+	if err != nil {
+		return err
+	}
+	if getTuple(tuple, "children") != nil {
+		return fmt.Errorf("%q is a directory", oldPath)
+	}
+	dir, name, err := w.lookupParent(newPath, false)
+	if err != nil {
+		return err
+	}
+	return w.rootFS.linkDirEntry(dir, name, tuple)
+}
+
+// Rename moves a file or directory to a new path in the image, without rewriting its contents
+func (w *Writer) Rename(oldPath, newPath string) error {
+	oldDir, oldName, err := w.lookupParent(oldPath, false)
+	if err != nil {
+		return err
+	}
+	tuple := getChild(oldDir, oldName)
+	if tuple == nil {
+		return os.ErrNotExist
+	}
+	newDir, newName, err := w.lookupParent(newPath, false)
+	if err != nil {
+		return err
+	}
+	for dir := newDir; ; dir = getChild(dir, "..") {
+		if dir == tuple {
+			return fmt.Errorf("cannot move %q to a subdirectory of itself", oldPath)
+		}
+		if dir == w.rootFS.root {
+			break
+		}
+	}
+	return w.rootFS.moveDirEntry(oldDir, oldName, newDir, newName)
+}
+
+// Chmod sets the permission bits of a file or directory in the image, in the mode attribute that
+// filePerm reads when ExportTar exports the file
+func (w *Writer) Chmod(path string, mode os.FileMode) error {
+	tuple, _, err := w.rootFS.lookup(w.rootFS.root, path)
+	if err != nil {
+		return err
+	}
+	return w.rootFS.updateTuple(tuple, map[string]interface{}{
+		"mode": strconv.FormatUint(uint64(mode.Perm()), 10),
+	})
+}
+
+// Chtimes sets the modification time of a file or directory in the image
+func (w *Writer) Chtimes(path string, mtime time.Time) error {
+	tuple, _, err := w.rootFS.lookup(w.rootFS.root, path)
+	if err != nil {
+		return err
+	}
+	return w.rootFS.updateTuple(tuple, map[string]interface{}{
+		"mtime": strconv.FormatUint(tfsTimestamp(mtime), 10),
+	})
+}
+
+// Remove removes a file or an empty directory from the image
+func (w *Writer) Remove(path string) error {
+	dir, name, err := w.lookupParent(path, false)
+	if err != nil {
+		return err
+	}
+	tuple := getChild(dir, name)
+	if tuple == nil {
+		return os.ErrNotExist
+	}
+	if children := getTuple(tuple, "children"); (children != nil) && (len(*children) > 2) {
+		return errors.New("directory not empty")
+	}
+	return w.rootFS.setDirEntry(dir, name, nil)
+}
+
+// RemoveAll removes a file or a directory and all its contents from the image
+func (w *Writer) RemoveAll(path string) error {
+	dir, name, err := w.lookupParent(path, false)
+	if err != nil {
+		return err
+	}
+	if getChild(dir, name) == nil {
+		return os.ErrNotExist
+	}
+	return w.rootFS.setDirEntry(dir, name, nil)
+}
+
+// Compact replaces the filesystem log with a new log describing the current directory tree in a single
+// record, with a new dictionary; file contents, UUID and label are preserved
+func (w *Writer) Compact() error {
+	err := w.rootFS.rewriteLog()
+	if err != nil {
+		return fmt.Errorf("cannot rewrite filesystem log: %w", err)
+	}
+	return nil
+}
+
+// LogExtensions returns the number of extensions in the filesystem log
+func (w *Writer) LogExtensions() int {
+	return len(w.rootFS.logExts)
+}
+
+// Commit writes the end of the filesystem log, making all changes visible
+func (w *Writer) Commit() error {
+	err := w.rootFS.flush()
+	if err != nil {
+		return fmt.Errorf("cannot write filesystem log: %w", err)
+	}
+	if w.partitioned {
+		err = resizeRootPartition(w.imageFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the image no longer matches the index of its last incremental build
+	os.Remove(IndexPath(w.imagePath))
+	return nil
+}
+
+// Close closes the image file; changes that have not been committed are lost
+func (w *Writer) Close() error {
+	return w.imageFile.Close()
+}
+
+// resizeRootPartition updates the partition table of an image so that the root filesystem partition
+// extends to the end of the image file
+func resizeRootPartition(imageFile *os.File) error {
+	mbr := make([]byte, sectorSize)
+	_, err := imageFile.ReadAt(mbr, 0)
+	if err != nil {
+		return fmt.Errorf("cannot read MBR: %w", err)
+	}
+	err = writeMBR(imageFile, getPartition(mbr, 0)[4] == 0xEF)
+	if err != nil {
+		return fmt.Errorf("cannot write MBR: %w", err)
+	}
+	return nil
+}
+
+// setEntry adds or replaces a directory entry, creating missing parent directories
+func (w *Writer) setEntry(p string, value interface{}) error {
+	dir, name, err := w.lookupParent(p, true)
+	if err != nil {
+		return err
+	}
+	return w.rootFS.setDirEntry(dir, name, value)
+}
+
+// lookupParent returns the directory tuple containing a path and the final path element
+func (w *Writer) lookupParent(p string, create bool) (*map[string]interface{}, string, error) {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return nil, "", errors.New("invalid path")
+	}
+	dir := w.rootFS.root
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for _, part := range parts[:len(parts)-1] {
+		child := getChild(dir, part)
+		if (child == nil) && create {
+			err := w.rootFS.setDirEntry(dir, part, make(map[string]interface{}))
+			if err != nil {
+				return nil, "", err
+			}
+			child = getChild(dir, part)
+		}
+		if child == nil {
+			return nil, "", os.ErrNotExist
+		}
+		if getTuple(child, "children") == nil {
+			return nil, "", fmt.Errorf("%q is not a directory", part)
+		}
+		dir = child
+	}
+	return dir, parts[len(parts)-1], nil
+}
+
+// fileData is a directory entry value for a file whose contents are read from a stream
+type fileData struct {
+	r    io.Reader
+	size int64
+}
+
+// hostDirEntry returns the manifest representation of a file, symbolic link or directory tree in the
+// local filesystem
+func hostDirEntry(hostPath string) (interface{}, error) {
+	info, err := os.Lstat(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(hostPath)
+		if err != nil {
+			return nil, fmt.Errorf("bad link: %w", err)
+		}
+		return link{path: target}, nil
+	case info.IsDir():
+		entries, err := os.ReadDir(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		dir := make(map[string]interface{})
+		for _, entry := range entries {
+			dir[entry.Name()], err = hostDirEntry(filepath.Join(hostPath, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dir, nil
+	case info.Mode().IsRegular():
+		return hostPath, nil
+	default:
+		return nil, fmt.Errorf("%q is not a regular file", hostPath)
+	}
+}
@@ -0,0 +1,160 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	hostDir := t.TempDir()
+	imagePath := filepath.Join(t.TempDir(), "volume")
+
+	m := NewManifest("")
+	if err := m.AddFile("/etc/config", writeHostFile(t, hostDir, "config", "old config")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddFile("/data/old", writeHostFile(t, hostDir, "old", "old data")); err != nil {
+		t.Fatal(err)
+	}
+	buildImage(t, imagePath, m, false, withSize("1M"))
+
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, w.WriteFile(writeHostFile(t, hostDir, "config2", "new config"), "/etc/config"))
+	writeHostFile(t, hostDir, "tree/a", "file a")
+	writeHostFile(t, hostDir, "tree/sub/b", "file b")
+	assert.Nil(t, w.WriteFile(filepath.Join(hostDir, "tree"), "/srv/www"))
+	assert.Nil(t, w.Mkdir("/empty"))
+	assert.True(t, os.IsExist(w.Mkdir("/empty")))
+	assert.Nil(t, w.Symlink("/srv/www/a", "/link"))
+	assert.True(t, os.IsExist(w.Symlink("/srv/www/b", "/link")))
+	assert.True(t, os.IsExist(w.Symlink("/srv/www/a", "/srv/www")))
+	assert.Nil(t, w.Rename("/data/old", "/srv/moved"))
+	assert.NotNil(t, w.Rename("/srv", "/srv/www/srv"))
+	assert.Nil(t, w.Chmod("/srv/moved", 0600))
+	assert.True(t, os.IsNotExist(w.Chmod("/missing", 0600)))
+	assert.NotNil(t, w.Remove("/srv/www"))
+	assert.Nil(t, w.RemoveAll("/srv/www/sub"))
+	assert.True(t, os.IsNotExist(w.Remove("/missing")))
+	assert.NotNil(t, w.WriteFile(filepath.Join(hostDir, "missing"), "/missing"))
+	assert.Nil(t, w.Commit())
+	w.Close()
+
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assert.Equal(t, "new config", readImageFile(t, r, "/etc/config"))
+	assert.Equal(t, "file a", readImageFile(t, r, "/srv/www/a"))
+	assert.Equal(t, "old data", readImageFile(t, r, "/srv/moved"))
+	info, err := r.Stat("/srv/moved")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0600), filePerm(info))
+	target, err := r.ReadLink("/link")
+	assert.Nil(t, err)
+	assert.Equal(t, "/srv/www/a", target)
+	info, err = r.Stat("/empty")
+	assert.Nil(t, err)
+	assert.True(t, info.IsDir())
+	for _, path := range []string{"/data/old", "/srv/www/sub", "/missing"} {
+		_, err = r.Stat(path)
+		assert.True(t, os.IsNotExist(err), path)
+	}
+}
+
+func TestWriterCompact(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "volume")
+	m := NewManifest("")
+	if err := m.AddFile("/data", writeHostFile(t, t.TempDir(), "data", "data")); err != nil {
+		t.Fatal(err)
+	}
+	buildImage(t, imagePath, m, false, withSize("4M"), func(mkfs *MkfsCommand) error {
+		mkfs.SetLabel("vol")
+		return nil
+	})
+
+	// fill more than one log extension with short-lived entries
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 60000; i++ {
+		assert.Nil(t, w.Mkdir("/tmp"))
+		assert.Nil(t, w.Remove("/tmp"))
+	}
+	assert.Nil(t, w.Commit())
+	uuid := w.rootFS.getUUID()
+	assert.Greater(t, w.LogExtensions(), 2)
+	w.Close()
+
+	// compacting repeatedly must reuse the space of the previous log, which fits in its first
+	// extension once compacted
+	for i := 0; i < 10; i++ {
+		w, err = NewWriter(imagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, w.Compact())
+		assert.Nil(t, w.Commit())
+		assert.Equal(t, 1, w.LogExtensions())
+		w.Close()
+	}
+
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assert.Equal(t, uuid, r.GetUUID())
+	assert.Equal(t, "vol", r.GetLabel())
+	assert.Equal(t, "data", readImageFile(t, r, "/data"))
+	_, err = r.Stat("/tmp")
+	assert.True(t, os.IsNotExist(err))
+	report, err := Check(imagePath)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+}
+
+func TestWriterCompactLinks(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "volume")
+	m := NewManifest("")
+	if err := m.AddFile("/a", writeHostFile(t, t.TempDir(), "a", "linked data")); err != nil {
+		t.Fatal(err)
+	}
+	buildImage(t, imagePath, m, false, withSize("8M"))
+
+	w, err := NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, w.Link("/a", "/b"))
+	assert.Nil(t, w.Commit())
+	w.Close()
+
+	// the linked files keep sharing their tuple, and so their extents
+	w, err = NewWriter(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, w.Compact())
+	assert.Nil(t, w.Commit())
+	w.Close()
+
+	report, err := Check(imagePath)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+
+	r, err := NewReader(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assert.Equal(t, "linked data", readImageFile(t, r, "/a"))
+	assert.Equal(t, "linked data", readImageFile(t, r, "/b"))
+}
@@ -0,0 +1,119 @@
+package lepton
+
+import (
+	"fmt"
+
+	"github.com/nanovms/ops/types"
+)
+
+// Capability is an operation, or an option of an operation, which only some providers support
+type Capability string
+
+// Capabilities of providers
+const (
+	// CapabilityImageResize is support for ResizeImage
+	CapabilityImageResize Capability = "image-resize"
+
+	// CapabilityImageSync is support for SyncImage, the provider being the source of the image
+	CapabilityImageSync Capability = "image-sync"
+
+	// CapabilityInstanceReboot is support for RebootInstance
+	CapabilityInstanceReboot Capability = "instance-reboot"
+
+	// CapabilityInstanceStats is support for InstanceStats
+	CapabilityInstanceStats Capability = "instance-stats"
+
+	// CapabilityInstanceLogs is support for GetInstanceLogs and PrintInstanceLogs
+	CapabilityInstanceLogs Capability = "instance-logs"
+
+	// CapabilityLogsWatch is support for the watch option of PrintInstanceLogs
+	CapabilityLogsWatch Capability = "logs-watch"
+
+	// CapabilityVolumes is support for the operations of VolumeService
+	CapabilityVolumes Capability = "volumes"
+
+	// CapabilityDNS is support for CloudConfig.DomainName, a record of the domain being created
+	// for instances
+	CapabilityDNS Capability = "dns"
+
+	// CapabilityMirror is support for Mirrorer
+	CapabilityMirror Capability = "mirror"
+
+	// CapabilitySpot is support for CloudConfig.Spot
+	CapabilitySpot Capability = "spot"
+
+	// CapabilityGPU is support for RunConfig.GPUs
+	CapabilityGPU Capability = "gpu"
+
+	// CapabilityInstanceGroups is support for RunConfig.InstanceGroup
+	CapabilityInstanceGroups Capability = "instance-groups"
+)
+
+// AllCapabilities are the capabilities a provider may report, in the order they are displayed
+var AllCapabilities = []Capability{
+	CapabilityImageResize,
+	CapabilityImageSync,
+	CapabilityInstanceReboot,
+	CapabilityInstanceStats,
+	CapabilityInstanceLogs,
+	CapabilityLogsWatch,
+	CapabilityVolumes,
+	CapabilityDNS,
+	CapabilityMirror,
+	CapabilitySpot,
+	CapabilityGPU,
+	CapabilityInstanceGroups,
+}
+
+// Capabilities are the capabilities supported by a provider
+type Capabilities []Capability
+
+// Has returns whether a capability is supported
+func (caps Capabilities) Has(c Capability) bool {
+	for _, cc := range caps {
+		if cc == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrUnsupported is returned when a provider does not support an operation
+type ErrUnsupported struct {
+	Provider   string
+	Capability Capability
+}
+
+func (e *ErrUnsupported) Error() string {
+	return fmt.Sprintf("%s is not supported by the %s provider, see ops provider describe %s", e.Capability, e.Provider, e.Provider)
+}
+
+// Require returns an ErrUnsupported error for the first of the required capabilities which is not
+// supported by a provider
+func (caps Capabilities) Require(providerName string, required ...Capability) error {
+	for _, c := range required {
+		if !caps.Has(c) {
+			return &ErrUnsupported{Provider: providerName, Capability: c}
+		}
+	}
+	return nil
+}
+
+// ConfigCapabilities returns the capabilities required by the options of a configuration to create
+// instances
+func ConfigCapabilities(c *types.Config) []Capability {
+	var caps []Capability
+	if c.CloudConfig.DomainName != "" {
+		caps = append(caps, CapabilityDNS)
+	}
+	if c.CloudConfig.Spot {
+		caps = append(caps, CapabilitySpot)
+	}
+	if c.RunConfig.GPUs > 0 {
+		caps = append(caps, CapabilityGPU)
+	}
+	if c.RunConfig.InstanceGroup != "" {
+		caps = append(caps, CapabilityInstanceGroups)
+	}
+	return caps
+}
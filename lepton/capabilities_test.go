@@ -0,0 +1,30 @@
+package lepton
+
+import (
+	"testing"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitiesRequire(t *testing.T) {
+	caps := Capabilities{CapabilityVolumes, CapabilityDNS}
+
+	assert.Nil(t, caps.Require("gcp"))
+	assert.Nil(t, caps.Require("gcp", CapabilityDNS, CapabilityVolumes))
+
+	err := caps.Require("gcp", CapabilityDNS, CapabilitySpot, CapabilityGPU)
+	assert.Equal(t, &ErrUnsupported{Provider: "gcp", Capability: CapabilitySpot}, err)
+	assert.EqualError(t, err, "spot is not supported by the gcp provider, see ops provider describe gcp")
+}
+
+func TestConfigCapabilities(t *testing.T) {
+	c := &types.Config{}
+	assert.Empty(t, ConfigCapabilities(c))
+
+	c.CloudConfig.DomainName = "test.example.com"
+	c.CloudConfig.Spot = true
+	c.RunConfig.GPUs = 1
+	c.RunConfig.InstanceGroup = "group"
+	assert.Equal(t, []Capability{CapabilityDNS, CapabilitySpot, CapabilityGPU, CapabilityInstanceGroups}, ConfigCapabilities(c))
+}
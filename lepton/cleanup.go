@@ -0,0 +1,83 @@
+package lepton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CleanupTimeout is the time given to cleanup functions to remove the resources of cancelled
+// operations
+var CleanupTimeout = 2 * time.Minute
+
+// cleanupFunc removes a resource partially created by an operation
+type cleanupFunc struct {
+	id          int
+	description string
+	f           func(ctx *Context) error
+}
+
+// cleanupRegistry is the registry of the cleanup functions of the running operations
+type cleanupRegistry struct {
+	mu     sync.Mutex
+	nextID int
+	list   []cleanupFunc
+}
+
+// AddCleanup registers f to remove a resource, described by description, which an operation
+// creates in several steps, eg: an image whose disk is uploaded before the image is created. The
+// operation calls the returned function once the resource is complete, or removed, to unregister
+// f; if the operation is cancelled before, f is called by Cleanup.
+func (c *Context) AddCleanup(description string, f func(ctx *Context) error) (done func()) {
+	r := c.cleanups
+	if r == nil {
+		return func() {}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := r.nextID
+	r.list = append(r.list, cleanupFunc{id: id, description: description, f: f})
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for n, cl := range r.list {
+			if cl.id == id {
+				r.list = append(r.list[:n], r.list[n+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Cleanup calls the registered cleanup functions, the most recent first, and unregisters them.
+// The functions are given a copy of the context which is not cancelled, as the context of the
+// operations usually is.
+func (c *Context) Cleanup() error {
+	r := c.cleanups
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	list := r.list
+	r.list = nil
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), CleanupTimeout)
+	defer cancel()
+	cc := c.WithContext(ctx)
+
+	var errs []error
+	for n := len(list) - 1; n >= 0; n-- {
+		if c.logger != nil {
+			c.logger.Infof("cleaning up %s", list[n].description)
+		}
+		if err := list[n].f(cc); err != nil {
+			errs = append(errs, fmt.Errorf("cannot clean up %s: %w", list[n].description, err))
+		}
+	}
+	return errors.Join(errs...)
+}
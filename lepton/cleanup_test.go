@@ -0,0 +1,51 @@
+package lepton
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanup(t *testing.T) {
+	ctx := NewContext(&types.Config{})
+
+	var order []string
+	add := func(name string, err error) func() {
+		return ctx.AddCleanup(name, func(ctx *Context) error {
+			assert.Nil(t, ctx.Context().Err())
+			order = append(order, name)
+			return err
+		})
+	}
+	add("image", nil)
+	done := add("snapshot", nil)
+	add("instance", errors.New("not found"))
+	done()
+
+	// the cleanup functions are shared with the copies of the context
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ctx.WithContext(cancelled).Cleanup()
+
+	assert.EqualError(t, err, "cannot clean up instance: not found")
+	assert.Equal(t, []string{"instance", "image"}, order)
+
+	assert.Nil(t, ctx.Cleanup())
+	assert.Len(t, order, 2)
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	ctx := NewContext(&types.Config{})
+
+	timed, cancel := ctx.WithTimeout(time.Millisecond)
+	defer cancel()
+	<-timed.Context().Done()
+
+	assert.ErrorIs(t, timed.Context().Err(), context.DeadlineExceeded)
+	assert.Nil(t, ctx.Context().Err())
+	assert.Equal(t, ctx.Config(), timed.Config())
+}
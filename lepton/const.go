@@ -49,6 +49,12 @@ func GenerateImageName(program string) string {
 	return fmt.Sprintf("%s/%s", images, program)
 }
 
+// NetworkLeasesPath returns the file where the DHCP server of the onprem network named name saves
+// its leases
+func NetworkLeasesPath(name string) string {
+	return path.Join(GetOpsHome(), "networks", name+".leases")
+}
+
 // GetOpsHome get ops directory path
 // We store all ops related info, packages, images in this directory
 //
@@ -427,28 +427,16 @@ func BuildManifest(c *types.Config) (*fs.Manifest, error) {
 		})
 	}
 
-	// new many nics/instance
-	// only for proxmox atm
+	// many nics/instance, supported by proxmox and onprem
 	// this overrides anything in legacy RunConfig ip address setting
-	nics := c.RunConfig.Nics
-	for i := 0; i < len(nics); i++ {
-		if i == 0 {
-			m.AddNetworkConfig(&fs.ManifestNetworkConfig{
-				IP:      nics[i].IPAddress,
-				Gateway: nics[i].Gateway,
-				NetMask: nics[i].NetMask,
-			})
-		} else {
-			ifaces := make(map[string]interface{})
-
-			// only set if ip given otherwise assume dhcp
-			ifaces["ipaddr"] = nics[i].IPAddress
-			ifaces["netmask"] = nics[i].NetMask
-			ifaces["gateway"] = nics[i].Gateway
-
-			s := strconv.Itoa(i + 1)
-			m.AddPassthrough("en"+s, ifaces)
-		}
+	for i, nic := range c.RunConfig.Nics {
+		m.AddNetworkConfig(&fs.ManifestNetworkConfig{
+			Interface: "en" + strconv.Itoa(i+1),
+			IP:        nic.IPAddress,
+			IPv6:      nic.IPv6Address,
+			Gateway:   nic.Gateway,
+			NetMask:   nic.NetMask,
+		})
 	}
 
 	for k, v := range c.ManifestPassthrough {
@@ -491,12 +479,18 @@ func addMappedFiles(src string, dest string, m *fs.Manifest) error {
 
 func createImageFile(c *types.Config, m *fs.Manifest) error {
 	// produce final image, boot + kernel + elf
-	fd, err := createFile(c.RunConfig.ImageName)
-	defer func() {
+	if c.Incremental {
+		// the existing image is updated in place by mkfs
+		if err := os.MkdirAll(path.Dir(c.RunConfig.ImageName), os.ModePerm); err != nil {
+			return err
+		}
+	} else {
+		fd, err := createFile(c.RunConfig.ImageName)
+		if err != nil {
+			return err
+		}
 		fd.Close()
-	}()
-	if err != nil {
-		return err
+		os.Remove(fs.IndexPath(c.RunConfig.ImageName))
 	}
 
 	defer cleanup(c)
@@ -530,14 +524,44 @@ func createImageFile(c *types.Config, m *fs.Manifest) error {
 		mkfsCommand.SetOldEncoding()
 	}
 
-	err = mkfsCommand.Execute()
+	if c.Incremental {
+		mkfsCommand.SetIncremental(fs.IndexPath(c.RunConfig.ImageName))
+	}
+
+	if c.Reproducible {
+		mtime, err := SourceDateEpoch()
+		if err != nil {
+			return err
+		}
+		mkfsCommand.SetReproducible(mtime)
+	}
+
+	err := mkfsCommand.Execute()
 	if err != nil {
 		return err
 	}
 
+	if c.SBOM != "" {
+		return writeSBOM(c, m)
+	}
+
 	return nil
 }
 
+// SourceDateEpoch returns the time set in the SOURCE_DATE_EPOCH environment variable, used as
+// timestamp by reproducible builds; the zero time is returned if the variable is not set
+func SourceDateEpoch() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 func cleanup(c *types.Config) {
 	os.RemoveAll(c.BuildDir)
 }
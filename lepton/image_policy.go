@@ -0,0 +1,271 @@
+package lepton
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// signedImageProvider applies the image signing policy of the provider configuration: images are
+// signed after being built if a signing key is configured, and if signed images are required, cloud
+// images are only created from local images with a valid signature, and instances only from the
+// cloud images created that way, identified by the provider
+type signedImageProvider struct {
+	Provider
+}
+
+// NewSignedImageProvider wraps a provider so that it applies the image signing policy of the
+// provider configuration
+func NewSignedImageProvider(p Provider) Provider {
+	return &signedImageProvider{p}
+}
+
+// Unwrap returns the wrapped provider
+func (p *signedImageProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// UnwrapProvider returns the provider implementation behind any provider wrappers, so that
+// provider-specific functionality can be accessed
+func UnwrapProvider(p Provider) Provider {
+	for {
+		w, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			return p
+		}
+		p = w.Unwrap()
+	}
+}
+
+// BuildImage builds an image and signs it if a signing key is configured
+func (p *signedImageProvider) BuildImage(ctx *Context) (string, error) {
+	imagePath, err := p.Provider.BuildImage(ctx)
+	if err != nil {
+		return imagePath, err
+	}
+	return imagePath, signBuiltImage(ctx, imagePath)
+}
+
+// BuildImageWithPackage builds an image from a package and signs it if a signing key is configured
+func (p *signedImageProvider) BuildImageWithPackage(ctx *Context, pkgpath string) (string, error) {
+	imagePath, err := p.Provider.BuildImageWithPackage(ctx, pkgpath)
+	if err != nil {
+		return imagePath, err
+	}
+	return imagePath, signBuiltImage(ctx, imagePath)
+}
+
+// CreateImage creates a cloud image, after verifying the signature of the local image it is made
+// from, and records that the cloud image was verified
+func (p *signedImageProvider) CreateImage(ctx *Context, imagePath string) error {
+	c := ctx.Config()
+	if imagePath == "" {
+		imagePath = c.RunConfig.ImageName
+	}
+	err := VerifyImagePolicy(ctx, imagePath)
+	if err != nil {
+		return err
+	}
+	err = p.Provider.CreateImage(ctx, imagePath)
+	if err != nil {
+		return err
+	}
+	if !c.CloudConfig.RequireSignedImages {
+		// the image replaces any verified image of the same name
+		return forgetVerifiedImage(c.CloudConfig.Platform, c.CloudConfig.ImageName)
+	}
+	return recordVerifiedImage(ctx, p.Provider, c.CloudConfig.ImageName, imagePath)
+}
+
+// DeleteImage deletes a cloud image and forgets whether it was verified
+func (p *signedImageProvider) DeleteImage(ctx *Context, imageName string) error {
+	err := p.Provider.DeleteImage(ctx, imageName)
+	if err != nil {
+		return err
+	}
+	return forgetVerifiedImage(ctx.Config().CloudConfig.Platform, imageName)
+}
+
+// CreateInstance creates an instance, after checking that its image is the one created from a local
+// image with a valid signature; onprem instances run the local image, whose signature is verified
+func (p *signedImageProvider) CreateInstance(ctx *Context) error {
+	c := ctx.Config()
+	if c.CloudConfig.Platform == "onprem" {
+		err := VerifyImagePolicy(ctx, path.Join(LocalImageDir, c.CloudConfig.ImageName))
+		if err != nil {
+			return err
+		}
+	} else if c.CloudConfig.RequireSignedImages {
+		verified, err := isVerifiedImage(ctx, p.Provider, c.CloudConfig.ImageName)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return fmt.Errorf("image signature policy: image %q is not the image created from a verified image by ops, create it again with signed images required", c.CloudConfig.ImageName)
+		}
+	}
+	return p.Provider.CreateInstance(ctx)
+}
+
+// signBuiltImage signs the built image, and the image customized for the provider if it is another
+// file, if a signing key is configured
+func signBuiltImage(ctx *Context, imagePath string) error {
+	c := ctx.Config()
+	if c.CloudConfig.ImageSigningKey == "" {
+		return nil
+	}
+	key, err := os.ReadFile(c.CloudConfig.ImageSigningKey)
+	if err != nil {
+		return fmt.Errorf("cannot read image signing key: %w", err)
+	}
+	_, err = SignImage(c.RunConfig.ImageName, key)
+	if err != nil || imagePath == "" || imagePath == c.RunConfig.ImageName {
+		return err
+	}
+	_, err = SignImage(imagePath, key)
+	return err
+}
+
+// verifiedImage records the cloud image created from a local image whose signature was verified
+type verifiedImage struct {
+	// ID identifies the cloud image on the provider, so that another image given the same name is
+	// not trusted
+	ID string `json:"id"`
+	// Digest is the digest of the local image
+	Digest string `json:"digest"`
+}
+
+// verifiedImagesFile is the file recording the cloud images created from local images, after their
+// signature was verified
+func verifiedImagesFile() string {
+	return path.Join(GetOpsHome(), "verified-images.json")
+}
+
+func readVerifiedImages() (map[string]verifiedImage, error) {
+	records := map[string]json.RawMessage{}
+	body, err := os.ReadFile(verifiedImagesFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]verifiedImage{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &records); err != nil {
+		return nil, fmt.Errorf("cannot read verified images %s: %w", verifiedImagesFile(), err)
+	}
+	images := map[string]verifiedImage{}
+	for key, record := range records {
+		var image verifiedImage
+		// records of older versions only hold the digest, and are not trusted as they do not
+		// identify the cloud image
+		if json.Unmarshal(record, &image) == nil && image.ID != "" {
+			images[key] = image
+		}
+	}
+	return images, nil
+}
+
+// cloudImageID returns what identifies the cloud image named imageName on the provider, which
+// changes when the image is replaced by another of the same name
+func cloudImageID(ctx *Context, p Provider, imageName string) (string, error) {
+	images, err := p.GetImages(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	for _, image := range images {
+		if image.Name != imageName {
+			continue
+		}
+		id := image.ID
+		if !image.Created.IsZero() {
+			id += "@" + image.Created.UTC().Format(time.RFC3339Nano)
+		}
+		if id == "" {
+			return "", fmt.Errorf("image signature policy: the provider does not identify image %q", imageName)
+		}
+		return id, nil
+	}
+	return "", fmt.Errorf("image %q not found", imageName)
+}
+
+// recordVerifiedImage records the cloud image of the provider created from the verified local image,
+// along with the digest of the local image
+func recordVerifiedImage(ctx *Context, p Provider, imageName, imagePath string) error {
+	digests, err := imageDigests(imagePath)
+	if err != nil {
+		return err
+	}
+	id, err := cloudImageID(ctx, p, imageName)
+	if err != nil {
+		return err
+	}
+	images, err := readVerifiedImages()
+	if err != nil {
+		return err
+	}
+	images[ctx.Config().CloudConfig.Platform+"/"+imageName] = verifiedImage{ID: id, Digest: digests.ImageDigest}
+	return writeVerifiedImages(images)
+}
+
+// forgetVerifiedImage removes the record of a cloud image of platform
+func forgetVerifiedImage(platform, imageName string) error {
+	images, err := readVerifiedImages()
+	if err != nil {
+		return err
+	}
+	if _, ok := images[platform+"/"+imageName]; !ok {
+		return nil
+	}
+	delete(images, platform+"/"+imageName)
+	return writeVerifiedImages(images)
+}
+
+func writeVerifiedImages(images map[string]verifiedImage) error {
+	body, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(verifiedImagesFile(), body, 0644)
+}
+
+// isVerifiedImage returns whether the cloud image of the provider named imageName is the image which
+// was created from a verified image
+func isVerifiedImage(ctx *Context, p Provider, imageName string) (bool, error) {
+	images, err := readVerifiedImages()
+	if err != nil {
+		return false, err
+	}
+	image, ok := images[ctx.Config().CloudConfig.Platform+"/"+imageName]
+	if !ok {
+		return false, nil
+	}
+	id, err := cloudImageID(ctx, p, imageName)
+	if err != nil {
+		return false, err
+	}
+	return id == image.ID, nil
+}
+
+// VerifyImagePolicy checks the signature of a local image if the provider configuration requires
+// signed images
+func VerifyImagePolicy(ctx *Context, imagePath string) error {
+	c := ctx.Config()
+	if !c.CloudConfig.RequireSignedImages {
+		return nil
+	}
+	if c.CloudConfig.ImageVerifyKey == "" {
+		return errors.New("signed images are required, but no image verification key is configured")
+	}
+	key, err := os.ReadFile(c.CloudConfig.ImageVerifyKey)
+	if err != nil {
+		return fmt.Errorf("cannot read image verification key: %w", err)
+	}
+	_, err = VerifyImage(imagePath, key)
+	if err != nil {
+		return fmt.Errorf("image signature policy: %w", err)
+	}
+	ctx.Logger().Infof("Verified signature of image %s", imagePath)
+	return nil
+}
@@ -1,9 +1,11 @@
 package lepton
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
@@ -44,6 +46,10 @@ type Provider interface {
 	PrintInstanceLogs(ctx *Context, instancename string, watch bool) error
 
 	VolumeService
+
+	// Capabilities returns the optional operations supported by the provider, it does not
+	// require the provider to be initialized
+	Capabilities() Capabilities
 }
 
 // Mirrorer is an interface that all provider which provider feature to
@@ -64,7 +70,7 @@ func IsInstanceNotFoundError(err error) bool {
 
 // Storage is an interface that provider's storage must implement
 type Storage interface {
-	CopyToBucket(config *types.Config, source string) error
+	CopyToBucket(ctx *Context, source string) error
 }
 
 // VolumeService is an interface for volume related operations
@@ -86,32 +92,40 @@ type DNSRecord struct {
 
 // DNSService is an interface for DNS related operations
 type DNSService interface {
-	FindOrCreateZoneIDByName(config *types.Config, name string) (string, error)
-	DeleteZoneRecordIfExists(config *types.Config, zoneID string, recordName string) error
-	CreateZoneRecord(config *types.Config, zoneID string, record *DNSRecord) error
+	FindOrCreateZoneIDByName(ctx *Context, name string) (string, error)
+	DeleteZoneRecordIfExists(ctx *Context, zoneID string, recordName string) error
+	CreateZoneRecord(ctx *Context, zoneID string, record *DNSRecord) error
 }
 
-// CreateDNSRecord does the necessary operations to create a DNS record without issues in an cloud provider
-func CreateDNSRecord(config *types.Config, aRecordIP string, dnsService DNSService) error {
-	domainName := config.CloudConfig.DomainName
-	if err := isDomainValid(domainName); err != nil {
-		return err
+// dnsRecordNames returns the name of the zone and of the A record of a domain
+func dnsRecordNames(domainName string) (dnsName string, aRecordName string, err error) {
+	if err = isDomainValid(domainName); err != nil {
+		return
 	}
 
 	domainParts := strings.Split(domainName, ".")
 
 	// example:
 	// domainParts := []string{"test","example","com"}
-	zoneName := domainParts[len(domainParts)-2]                 // example
-	dnsName := zoneName + "." + domainParts[len(domainParts)-1] // example.com
-	aRecordName := domainName + "."                             // test.example.com
+	zoneName := domainParts[len(domainParts)-2]                // example
+	dnsName = zoneName + "." + domainParts[len(domainParts)-1] // example.com
+	aRecordName = domainName + "."                             // test.example.com
+	return
+}
 
-	zoneID, err := dnsService.FindOrCreateZoneIDByName(config, dnsName)
+// CreateDNSRecord does the necessary operations to create a DNS record without issues in an cloud provider
+func CreateDNSRecord(ctx *Context, aRecordIP string, dnsService DNSService) error {
+	dnsName, aRecordName, err := dnsRecordNames(ctx.Config().CloudConfig.DomainName)
 	if err != nil {
 		return err
 	}
 
-	err = dnsService.DeleteZoneRecordIfExists(config, zoneID, aRecordName)
+	zoneID, err := dnsService.FindOrCreateZoneIDByName(ctx, dnsName)
+	if err != nil {
+		return err
+	}
+
+	err = dnsService.DeleteZoneRecordIfExists(ctx, zoneID, aRecordName)
 	if err != nil {
 		return err
 	}
@@ -122,7 +136,7 @@ func CreateDNSRecord(config *types.Config, aRecordIP string, dnsService DNSServi
 		Type: "A",
 		TTL:  TTLDefault,
 	}
-	err = dnsService.CreateZoneRecord(config, zoneID, record)
+	err = dnsService.CreateZoneRecord(ctx, zoneID, record)
 	if err != nil {
 		return err
 	}
@@ -130,10 +144,31 @@ func CreateDNSRecord(config *types.Config, aRecordIP string, dnsService DNSServi
 	return nil
 }
 
+// DeleteDNSRecord deletes the DNS record of the domain of a configuration, if it exists
+func DeleteDNSRecord(ctx *Context, dnsService DNSService) error {
+	dnsName, aRecordName, err := dnsRecordNames(ctx.Config().CloudConfig.DomainName)
+	if err != nil {
+		return err
+	}
+
+	zoneID, err := dnsService.FindOrCreateZoneIDByName(ctx, dnsName)
+	if err != nil {
+		return err
+	}
+
+	return dnsService.DeleteZoneRecordIfExists(ctx, zoneID, aRecordName)
+}
+
 // Context captures required info for provider operation
 type Context struct {
 	config *types.Config
 	logger *log.Logger
+
+	// ctx cancels the calls made by provider operations to cloud APIs
+	ctx context.Context
+
+	// cleanups are shared by the copies of the context made by WithContext and WithTimeout
+	cleanups *cleanupRegistry
 }
 
 // Config returns context configuration
@@ -172,7 +207,41 @@ func NewContext(c *types.Config) *Context {
 	}
 
 	return &Context{
-		config: c,
-		logger: logger,
+		config:   c,
+		logger:   logger,
+		ctx:      context.Background(),
+		cleanups: &cleanupRegistry{},
 	}
 }
+
+// Context returns the context of the calls made by provider operations, which is done once the
+// operation is cancelled or times out
+func (c *Context) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// WithContext returns a copy of the context whose operations are cancelled with ctx
+func (c *Context) WithContext(ctx context.Context) *Context {
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
+// WithConfig returns a copy of the context using another configuration, whose operations are
+// cancelled and cleaned up with the context
+func (c *Context) WithConfig(config *types.Config) *Context {
+	cc := *c
+	cc.config = config
+	return &cc
+}
+
+// WithTimeout returns a copy of the context whose operations are cancelled after timeout, and the
+// function releasing the resources of the timeout, which must be called once the operations
+// complete
+func (c *Context) WithTimeout(timeout time.Duration) (*Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.Context(), timeout)
+	return c.WithContext(ctx), cancel
+}
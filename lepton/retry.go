@@ -0,0 +1,300 @@
+package lepton
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nanovms/ops/log"
+)
+
+// RetryPolicy configures how the calls made to the API of a provider are retried when they are
+// throttled or fail transiently, and how many of them run at once
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a call is attempted
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, which doubles with each retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts; a call asked by the API to retry later than
+	// MaxDelay fails
+	MaxDelay time.Duration
+
+	// MaxConcurrency is the number of calls to the API of a provider which run at once
+	MaxConcurrency int
+}
+
+// DefaultRetryPolicy is the policy of the calls made by providers
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    6,
+	BaseDelay:      500 * time.Millisecond,
+	MaxDelay:       30 * time.Second,
+	MaxConcurrency: 16,
+}
+
+// maxReplayedBody is the size of the largest request body which is buffered to be sent again,
+// requests with a larger body are not retried unless they can provide their body again
+const maxReplayedBody = 8 << 20
+
+// throttlingCodes are the error codes APIs answer throttled calls with, when they do not answer
+// with 429 or 503, eg: AWS answers some throttled calls with 400 and Throttling
+var throttlingCodes = [][]byte{
+	[]byte("RequestLimitExceeded"),
+	[]byte("Throttling"),
+	[]byte("TooManyRequests"),
+	[]byte("RequestThrottled"),
+	[]byte("SlowDown"),
+	[]byte("rateLimitExceeded"),
+	[]byte("userRateLimitExceeded"),
+}
+
+// idempotencyHeaders are the headers of requests an API processes at most once, which are safe
+// to retry whatever their method
+var idempotencyHeaders = []string{
+	"Idempotency-Key",
+	"X-Idempotency-Key",
+	"Opc-Retry-Token",
+}
+
+// RetryTransport is an http.RoundTripper retrying the calls made to the API of a provider. Calls
+// which were throttled, and so not processed, are retried with any method, while calls which failed
+// with a network error or a 500, 502 or 504 are only retried if they are idempotent. The delay
+// between attempts grows exponentially with jitter, unless the API gives it with Retry-After.
+type RetryTransport struct {
+	Provider string
+	Base     http.RoundTripper
+	Policy   RetryPolicy
+
+	// Idempotent returns whether a call is safe to send again, for APIs whose idempotent calls
+	// cannot be told by their method or headers; calls are idempotent according to their method
+	// and idempotency headers if nil
+	Idempotent func(req *http.Request) bool
+}
+
+// AllIdempotent is the Idempotent function of the APIs whose calls are all safe to send again
+// whatever their method, such as the EC2 query API which sends every call as a POST, with the
+// idempotency token of the calls creating resources in the body
+func AllIdempotent(req *http.Request) bool {
+	return true
+}
+
+// NewRetryTransport returns a transport retrying the calls of a provider made with base, or with
+// http.DefaultTransport if base is nil, with DefaultRetryPolicy
+func NewRetryTransport(provider string, base http.RoundTripper) *RetryTransport {
+	return &RetryTransport{Provider: provider, Base: base, Policy: DefaultRetryPolicy}
+}
+
+// NewHTTPClient returns an http client retrying the calls of a provider with DefaultRetryPolicy
+func NewHTTPClient(provider string) *http.Client {
+	return &http.Client{Transport: NewRetryTransport(provider, nil)}
+}
+
+// RoundTrip sends a request, retrying it according to the policy of the transport
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	getBody, buffered, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := providerLimiter(t.Provider, t.Policy.MaxConcurrency)
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 || buffered {
+			r = req.Clone(ctx)
+			if r.Body, err = getBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case limiter <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		resp, err := base.RoundTrip(r)
+		<-limiter
+
+		retry, after := t.shouldRetry(r, resp, err)
+		if !retry || getBody == nil || attempt >= t.Policy.MaxAttempts {
+			return resp, err
+		}
+
+		delay := t.Policy.backoff(attempt)
+		if after > 0 {
+			if after > t.Policy.MaxDelay {
+				return resp, err
+			}
+			delay = after
+		}
+
+		if resp != nil {
+			log.Debugf("%s %s %s: %s, retrying in %s", t.Provider, req.Method, req.URL.Path, resp.Status, delay)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		} else {
+			log.Debugf("%s %s %s: %v, retrying in %s", t.Provider, req.Method, req.URL.Path, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// backoff returns the delay before the retry following an attempt, half of it being random so that
+// the calls throttled together are not retried together
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if attempt < 32 && p.BaseDelay<<(attempt-1) < p.MaxDelay {
+		delay = p.BaseDelay << (attempt - 1)
+	}
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+// replayableBody returns a function returning the body of a request for the retries, or nil if
+// the body cannot be read again and the request cannot be retried; buffered is true if the body
+// was read to be replayed, and must be taken from the function for the first attempt too
+func replayableBody(req *http.Request) (getBody func() (io.ReadCloser, error), buffered bool, err error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, false, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, false, nil
+	}
+	if req.ContentLength <= 0 || req.ContentLength > maxReplayedBody {
+		return nil, false, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, false, err
+	}
+	return func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }, true, nil
+}
+
+// shouldRetry returns whether a call should be retried, and the delay asked by the API if any
+func (t *RetryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) (bool, time.Duration) {
+	isIdempotent := t.Idempotent
+	if isIdempotent == nil {
+		isIdempotent = idempotentRequest
+	}
+
+	if err != nil {
+		return req.Context().Err() == nil && isIdempotent(req), 0
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true, retryAfter(resp)
+	case http.StatusBadRequest, http.StatusForbidden:
+		return isThrottled(resp), retryAfter(resp)
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req), retryAfter(resp)
+	}
+	return false, 0
+}
+
+// idempotentRequest returns whether sending a request several times has the effect of sending it
+// once, according to its method and idempotency headers
+func idempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	for _, h := range idempotencyHeaders {
+		if req.Header.Get(h) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isThrottled returns whether the error answered by an API is a throttling error, the body of the
+// response being left readable
+func isThrottled(resp *http.Response) bool {
+	head, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+
+	for _, code := range throttlingCodes {
+		if bytes.Contains(head, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter returns the delay asked by an API before retrying a call, 0 if none
+func retryAfter(resp *http.Response) time.Duration {
+	if ms, err := strconv.Atoi(resp.Header.Get("Retry-After-Ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	if ms, err := strconv.Atoi(resp.Header.Get("X-Ms-Retry-After-Ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+var limiters = struct {
+	sync.Mutex
+	m map[string]chan struct{}
+}{m: map[string]chan struct{}{}}
+
+// providerLimiter returns the semaphore limiting the calls to the API of a provider which run at
+// once, which is shared by the clients of the provider
+func providerLimiter(provider string, size int) chan struct{} {
+	if size <= 0 {
+		size = 1
+	}
+
+	limiters.Lock()
+	defer limiters.Unlock()
+
+	l, ok := limiters.m[provider]
+	if !ok {
+		l = make(chan struct{}, size)
+		limiters.m[provider] = l
+	}
+	return l
+}
@@ -0,0 +1,220 @@
+package lepton
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPI is an API answering calls with the responses of handle, the number of the call being
+// given, and recording the bodies of the calls
+type fakeAPI struct {
+	*httptest.Server
+	calls  int32
+	bodies []string
+	mu     sync.Mutex
+}
+
+func newFakeAPI(t *testing.T, handle func(call int, w http.ResponseWriter, r *http.Request)) *fakeAPI {
+	api := &fakeAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		api.mu.Lock()
+		api.bodies = append(api.bodies, string(body))
+		api.mu.Unlock()
+		handle(int(atomic.AddInt32(&api.calls, 1)), w, r)
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func newTestClient(t *testing.T) *http.Client {
+	transport := NewRetryTransport(t.Name(), nil)
+	transport.Policy = RetryPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      time.Millisecond,
+		MaxDelay:       time.Second,
+		MaxConcurrency: 2,
+	}
+	return &http.Client{Transport: transport}
+}
+
+func TestRetryTransportThrottled(t *testing.T) {
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		if call < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("created"))
+	})
+
+	// a throttled call is retried whatever its method, with its body
+	resp, err := newTestClient(t).Post(api.URL, "text/plain", strings.NewReader("image"))
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "created", string(body))
+	assert.Equal(t, []string{"image", "image", "image"}, api.bodies)
+}
+
+func TestRetryTransportThrottlingCode(t *testing.T) {
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		if r.URL.Path == "/throttled" && call == 1 {
+			w.Write([]byte("<Response><Errors><Error><Code>RequestLimitExceeded</Code></Error></Errors></Response>"))
+			return
+		}
+		w.Write([]byte("<Response><Errors><Error><Code>InvalidAMIID.Malformed</Code></Error></Errors></Response>"))
+	})
+	client := newTestClient(t)
+
+	resp, err := client.Post(api.URL+"/throttled", "application/x-www-form-urlencoded", strings.NewReader("Action=RunInstances"))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 2, api.calls)
+
+	// other errors are not retried, and their body is left readable
+	resp, err = client.Post(api.URL+"/invalid", "application/x-www-form-urlencoded", strings.NewReader("Action=RunInstances"))
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "InvalidAMIID.Malformed")
+	assert.EqualValues(t, 3, api.calls)
+}
+
+func TestRetryTransportIdempotency(t *testing.T) {
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t)
+
+	// a call which may have been processed is not retried, unless it is idempotent
+	resp, err := client.Post(api.URL, "text/plain", strings.NewReader("instance"))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 1, api.calls)
+
+	req, _ := http.NewRequest(http.MethodPost, api.URL, strings.NewReader("instance"))
+	req.Header.Set("Opc-Retry-Token", "f5a1c8")
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 4, api.calls)
+
+	// the last response is returned once the attempts are exhausted
+	resp, err = client.Get(api.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.EqualValues(t, 7, api.calls)
+}
+
+func TestRetryTransportIdempotentAPI(t *testing.T) {
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		if call == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("<DescribeInstancesResponse/>"))
+	})
+	client := newTestClient(t)
+	client.Transport.(*RetryTransport).Idempotent = AllIdempotent
+
+	// the calls of APIs whose calls are all idempotent are retried whatever their method
+	resp, err := client.Post(api.URL, "application/x-www-form-urlencoded", strings.NewReader("Action=DescribeInstances"))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Action=DescribeInstances", "Action=DescribeInstances"}, api.bodies)
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	// a call which the API asks to retry later than the maximum delay fails
+	resp, err := newTestClient(t).Get(api.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, api.calls)
+
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+	assert.Equal(t, 2*time.Second, retryAfter(&http.Response{Header: header}))
+	header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.InDelta(t, time.Minute, retryAfter(&http.Response{Header: header}), float64(2*time.Second))
+	header.Set("X-Ms-Retry-After-Ms", "1500")
+	assert.Equal(t, 1500*time.Millisecond, retryAfter(&http.Response{Header: header}))
+}
+
+func TestRetryTransportCancelled(t *testing.T) {
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.URL, nil)
+
+	start := time.Now()
+	_, err := newTestClient(t).Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.EqualValues(t, 1, api.calls)
+}
+
+func TestRetryTransportConcurrency(t *testing.T) {
+	var running, maxRunning int32
+	api := newFakeAPI(t, func(call int, w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	// the clients of a provider share its limit
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := newTestClient(t).Get(api.URL)
+			if assert.Nil(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 8, api.calls)
+	assert.EqualValues(t, 2, maxRunning)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		delay := p.backoff(attempt + 1)
+		assert.GreaterOrEqual(t, delay, max/2)
+		assert.Less(t, delay, max)
+	}
+}
@@ -0,0 +1,402 @@
+package lepton
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nanovms/ops/fs"
+	"github.com/nanovms/ops/types"
+)
+
+// Software bill of materials formats supported by image builds
+const (
+	SBOMFormatSPDX      = "spdx"
+	SBOMFormatCycloneDX = "cyclonedx"
+)
+
+// File name extensions of software bills of materials, which are stored next to the image they describe
+const (
+	SBOMExtSPDX      = ".spdx.json"
+	SBOMExtCycloneDX = ".cdx.json"
+)
+
+// SBOMPath returns the path of the software bill of materials of an image in a given format
+func SBOMPath(imagePath string, format string) string {
+	if format == SBOMFormatCycloneDX {
+		return imagePath + SBOMExtCycloneDX
+	}
+	return imagePath + SBOMExtSPDX
+}
+
+// sbom is the format-independent content of a software bill of materials
+type sbom struct {
+	imageName    string
+	id           uuid.UUID
+	created      time.Time
+	nanosVersion string
+	files        []sbomFile
+	packages     []PackageIdentifier
+}
+
+type sbomFile struct {
+	fs.ManifestFile
+	// source is the host path reported for the file; files generated in the build directory are
+	// reported without their temporary path
+	source string
+	sha256 string
+	// pkg is the index in sbom.packages of the ops package the file comes from, or -1
+	pkg int
+}
+
+// klib returns the name of the kernel library in a boot filesystem file, or an empty string
+func (f *sbomFile) klib() string {
+	if f.Boot && strings.HasPrefix(f.Path, "/klib/") {
+		return strings.TrimPrefix(f.Path, "/klib/")
+	}
+	return ""
+}
+
+// writeSBOM writes the software bill of materials of an image built from a manifest
+func writeSBOM(c *types.Config, m *fs.Manifest) error {
+	files, err := m.Files()
+	if err != nil {
+		return err
+	}
+	s := &sbom{
+		imageName:    c.CloudConfig.ImageName,
+		id:           uuid.New(),
+		created:      time.Now().UTC(),
+		nanosVersion: nanosVersion(c),
+	}
+	pkgIndexes := make(map[PackageIdentifier]int)
+	for _, f := range files {
+		file := sbomFile{
+			ManifestFile: f,
+			source:       f.HostPath,
+			sha256:       sha256Of(f.HostPath),
+			pkg:          -1,
+		}
+		if c.BuildDir != "" && strings.HasPrefix(f.HostPath, c.BuildDir+string(filepath.Separator)) {
+			file.source = "generated"
+		}
+		if pkg := filePackage(f.HostPath); pkg != nil {
+			index, ok := pkgIndexes[*pkg]
+			if !ok {
+				index = len(s.packages)
+				pkgIndexes[*pkg] = index
+				s.packages = append(s.packages, *pkg)
+			}
+			file.pkg = index
+		}
+		s.files = append(s.files, file)
+	}
+	if c.Reproducible {
+		// the document must be the same for the same image contents
+		s.created, err = SourceDateEpoch()
+		if err != nil {
+			return err
+		}
+		if s.created.IsZero() {
+			s.created = time.Unix(0, 0).UTC()
+		}
+		var contents strings.Builder
+		fmt.Fprintf(&contents, "%s %s\n", s.imageName, s.nanosVersion)
+		for _, f := range s.files {
+			fmt.Fprintf(&contents, "%t %s %s\n", f.Boot, f.Path, f.sha256)
+		}
+		s.id = uuid.NewSHA1(uuid.NameSpaceOID, []byte(contents.String()))
+	}
+
+	var doc interface{}
+	switch c.SBOM {
+	case SBOMFormatSPDX:
+		doc = s.spdx()
+	case SBOMFormatCycloneDX:
+		doc = s.cycloneDX()
+	default:
+		return fmt.Errorf("unknown SBOM format %q", c.SBOM)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(SBOMPath(c.RunConfig.ImageName, c.SBOM), data, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write SBOM: %w", err)
+	}
+	return nil
+}
+
+// nanosVersion returns the version of the kernel used to build an image; when no version is
+// configured, it is the name of the release directory containing the kernel
+func nanosVersion(c *types.Config) string {
+	if c.NightlyBuild {
+		return "nightly"
+	}
+	if c.NanosVersion != "" && c.NanosVersion != "0.0" {
+		return c.NanosVersion
+	}
+	if c.Kernel == "" {
+		return ""
+	}
+	return strings.TrimSuffix(filepath.Base(filepath.Dir(c.Kernel)), "-arm")
+}
+
+// filePackage returns the identifier of the ops package a file has been extracted from, i.e. the
+// nearest parent directory below the packages root which contains a package manifest
+func filePackage(hostPath string) *PackageIdentifier {
+	for _, root := range []string{PackagesRoot, LocalPackagesRoot} {
+		rel, err := filepath.Rel(root, hostPath)
+		if (err != nil) || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		// <arch>/[<namespace>/]<name>_<version>/...
+		parts := strings.Split(rel, string(filepath.Separator))
+		for i := 2; i < len(parts); i++ {
+			pkgDir := filepath.Join(root, filepath.Join(parts[:i]...))
+			if _, err := os.Stat(filepath.Join(pkgDir, "package.manifest")); err != nil {
+				continue
+			}
+			namespace, name, version := GetNSPkgnameAndVersion(strings.Join(parts[1:i], "/"))
+			return &PackageIdentifier{
+				Name:      name,
+				Namespace: namespace,
+				Version:   version,
+			}
+		}
+	}
+	return nil
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Files             []spdxFile         `json:"files"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	Supplier         string `json:"supplier,omitempty"`
+	DownloadLocation string `json:"downloadLocation"`
+	FilesAnalyzed    bool   `json:"filesAnalyzed"`
+	PrimaryPurpose   string `json:"primaryPackagePurpose,omitempty"`
+}
+
+type spdxFile struct {
+	SPDXID    string         `json:"SPDXID"`
+	FileName  string         `json:"fileName"`
+	Checksums []spdxChecksum `json:"checksums"`
+	Comment   string         `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	Element        string `json:"spdxElementId"`
+	Type           string `json:"relationshipType"`
+	RelatedElement string `json:"relatedSpdxElement"`
+}
+
+func (s *sbom) spdx() *spdxDocument {
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.imageName,
+		DocumentNamespace: "https://nanovms.com/spdx/" + s.imageName + "-" + s.id.String(),
+		CreationInfo: spdxCreationInfo{
+			Created:  s.created.Format(time.RFC3339),
+			Creators: []string{"Tool: ops-" + Version},
+		},
+		Files:         []spdxFile{},
+		Relationships: []spdxRelationship{},
+	}
+	image := "SPDXRef-Image"
+	doc.Packages = append(doc.Packages, spdxPackage{
+		SPDXID:           image,
+		Name:             s.imageName,
+		DownloadLocation: "NOASSERTION",
+		PrimaryPurpose:   "OPERATING-SYSTEM",
+	})
+	doc.Relationships = append(doc.Relationships, spdxRelationship{
+		Element:        doc.SPDXID,
+		Type:           "DESCRIBES",
+		RelatedElement: image,
+	})
+	addPackage := func(id, name, version, supplier string) {
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:           id,
+			Name:             name,
+			VersionInfo:      version,
+			Supplier:         supplier,
+			DownloadLocation: "NOASSERTION",
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			Element:        image,
+			Type:           "CONTAINS",
+			RelatedElement: id,
+		})
+	}
+	addPackage("SPDXRef-Nanos", "nanos", s.nanosVersion, "Organization: NanoVMs")
+	for i, pkg := range s.packages {
+		name := pkg.Name
+		if pkg.Namespace != "" {
+			name = pkg.Namespace + "/" + pkg.Name
+		}
+		addPackage(fmt.Sprintf("SPDXRef-Package-%d", i), name, pkg.Version, "")
+	}
+	for i, f := range s.files {
+		id := fmt.Sprintf("SPDXRef-File-%d", i)
+		fileName := f.Path
+		if f.Boot {
+			fileName = "bootfs:" + f.Path
+		}
+		doc.Files = append(doc.Files, spdxFile{
+			SPDXID:    id,
+			FileName:  fileName,
+			Checksums: []spdxChecksum{{Algorithm: "SHA256", Value: f.sha256}},
+			Comment:   "host path: " + f.source,
+		})
+		owner := image
+		if klib := f.klib(); klib != "" {
+			owner = fmt.Sprintf("SPDXRef-Klib-%d", i)
+			addPackage(owner, "klib/"+klib, s.nanosVersion, "Organization: NanoVMs")
+		} else if f.Boot {
+			owner = "SPDXRef-Nanos"
+		} else if f.pkg >= 0 {
+			owner = fmt.Sprintf("SPDXRef-Package-%d", f.pkg)
+		}
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			Element:        owner,
+			Type:           "CONTAINS",
+			RelatedElement: id,
+		})
+	}
+	return doc
+}
+
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Group      string         `json:"group,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Hashes     []cdxHash      `json:"hashes,omitempty"`
+	Properties []cdxProperty  `json:"properties,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (s *sbom) cycloneDX() *cdxDocument {
+	doc := &cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + s.id.String(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: s.created.Format(time.RFC3339),
+			Tools:     []cdxTool{{Vendor: "NanoVMs", Name: "ops", Version: Version}},
+			Component: cdxComponent{Type: "application", Name: s.imageName},
+		},
+	}
+	nanos := cdxComponent{
+		Type:    "operating-system",
+		BOMRef:  "nanos",
+		Name:    "nanos",
+		Version: s.nanosVersion,
+	}
+	pkgs := make([]cdxComponent, len(s.packages))
+	for i, pkg := range s.packages {
+		pkgs[i] = cdxComponent{
+			Type:    "library",
+			BOMRef:  fmt.Sprintf("package-%d", i),
+			Group:   pkg.Namespace,
+			Name:    pkg.Name,
+			Version: pkg.Version,
+		}
+	}
+	var files []cdxComponent
+	for _, f := range s.files {
+		file := cdxComponent{
+			Type:   "file",
+			Name:   f.Path,
+			Hashes: []cdxHash{{Algorithm: "SHA-256", Content: f.sha256}},
+			Properties: []cdxProperty{
+				{Name: "ops:host-path", Value: f.source},
+			},
+		}
+		switch {
+		case f.klib() != "":
+			file.Type = "library"
+			file.Name = f.klib()
+			file.Version = s.nanosVersion
+			file.Properties = append(file.Properties, cdxProperty{Name: "ops:klib", Value: "true"})
+			fallthrough
+		case f.Boot:
+			nanos.Components = append(nanos.Components, file)
+		case f.pkg >= 0:
+			pkgs[f.pkg].Components = append(pkgs[f.pkg].Components, file)
+		default:
+			files = append(files, file)
+		}
+	}
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		return pkgs[i].Group+"/"+pkgs[i].Name < pkgs[j].Group+"/"+pkgs[j].Name
+	})
+	doc.Components = append(doc.Components, nanos)
+	doc.Components = append(doc.Components, pkgs...)
+	doc.Components = append(doc.Components, files...)
+	return doc
+}
@@ -0,0 +1,110 @@
+package lepton
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanovms/ops/fs"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSBOM(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	oldPackagesRoot := PackagesRoot
+	PackagesRoot = filepath.Join(dir, "packages")
+	defer func() { PackagesRoot = oldPackagesRoot }()
+	writeFile("packages/amd64/eyberg/node_v18.0.0/package.manifest", "{}")
+	node := writeFile("packages/amd64/eyberg/node_v18.0.0/sysroot/usr/bin/node", "node")
+	app := writeFile("app", "app")
+	kernel := writeFile("0.1.50/kernel.img", "kernel")
+	writeFile("0.1.50/klibs/tls", "tls")
+
+	m := fs.NewManifest("")
+	m.AddKernel(kernel)
+	m.SetKlibDir(filepath.Join(dir, "0.1.50", "klibs"))
+	m.AddKlibs([]string{"tls"})
+	assert.Nil(t, m.AddFile("/app", app))
+	assert.Nil(t, m.AddFile("/usr/bin/node", node))
+
+	c := &types.Config{
+		Kernel: kernel,
+		RunConfig: types.RunConfig{
+			ImageName: filepath.Join(dir, "image"),
+		},
+	}
+	c.CloudConfig.ImageName = "image"
+
+	t.Run("spdx", func(t *testing.T) {
+		c.SBOM = SBOMFormatSPDX
+		assert.Nil(t, writeSBOM(c, m))
+		data, err := os.ReadFile(filepath.Join(dir, "image.spdx.json"))
+		assert.Nil(t, err)
+		doc := &spdxDocument{}
+		assert.Nil(t, json.Unmarshal(data, doc))
+		versions := make(map[string]string)
+		for _, pkg := range doc.Packages {
+			versions[pkg.Name] = pkg.VersionInfo
+		}
+		assert.Equal(t, map[string]string{
+			"image":       "",
+			"nanos":       "0.1.50",
+			"klib/tls":    "0.1.50",
+			"eyberg/node": "v18.0.0",
+		}, versions)
+		var names []string
+		for _, f := range doc.Files {
+			names = append(names, f.FileName)
+		}
+		assert.Equal(t, []string{"bootfs:/kernel", "bootfs:/klib/tls", "/app", "/usr/bin/node"}, names)
+		assert.Equal(t, sha256Of(app), doc.Files[2].Checksums[0].Value)
+		assert.Equal(t, "host path: "+app, doc.Files[2].Comment)
+	})
+
+	t.Run("cyclonedx", func(t *testing.T) {
+		c.SBOM = SBOMFormatCycloneDX
+		assert.Nil(t, writeSBOM(c, m))
+		data, err := os.ReadFile(filepath.Join(dir, "image.cdx.json"))
+		assert.Nil(t, err)
+		doc := &cdxDocument{}
+		assert.Nil(t, json.Unmarshal(data, doc))
+		assert.Equal(t, 3, len(doc.Components))
+		assert.Equal(t, "nanos", doc.Components[0].Name)
+		assert.Equal(t, "0.1.50", doc.Components[0].Version)
+		assert.Equal(t, "tls", doc.Components[0].Components[1].Name)
+		assert.Equal(t, "eyberg", doc.Components[1].Group)
+		assert.Equal(t, "node", doc.Components[1].Name)
+		assert.Equal(t, "/usr/bin/node", doc.Components[1].Components[0].Name)
+		assert.Equal(t, "/app", doc.Components[2].Name)
+		assert.Equal(t, sha256Of(app), doc.Components[2].Hashes[0].Content)
+	})
+
+	t.Run("reproducible", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		c.SBOM = SBOMFormatCycloneDX
+		c.Reproducible = true
+		defer func() { c.Reproducible = false }()
+		assert.Nil(t, writeSBOM(c, m))
+		first, err := os.ReadFile(filepath.Join(dir, "image.cdx.json"))
+		assert.Nil(t, err)
+		assert.Nil(t, writeSBOM(c, m))
+		second, err := os.ReadFile(filepath.Join(dir, "image.cdx.json"))
+		assert.Nil(t, err)
+		assert.Equal(t, string(first), string(second))
+		doc := &cdxDocument{}
+		assert.Nil(t, json.Unmarshal(first, doc))
+		assert.Equal(t, "2023-11-14T22:13:20Z", doc.Metadata.Timestamp)
+	})
+}
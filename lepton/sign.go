@@ -0,0 +1,201 @@
+package lepton
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nanovms/ops/fs"
+)
+
+// ImageSignatureExt is the file name extension of detached image signatures, which are stored next to
+// the image they sign
+const ImageSignatureExt = ".sig"
+
+// ImageSignature is a detached signature over the digest of an image file and of its manifest (the root
+// tuple attributes of the image filesystem)
+type ImageSignature struct {
+	ImageDigest    string `json:"image_digest"`
+	ManifestDigest string `json:"manifest_digest,omitempty"`
+	Algorithm      string `json:"algorithm"`
+	Signature      []byte `json:"signature"`
+}
+
+// ImageSignaturePath returns the path of the detached signature of an image
+func ImageSignaturePath(imagePath string) string {
+	return imagePath + ImageSignatureExt
+}
+
+// payload returns the signed data
+func (s *ImageSignature) payload() []byte {
+	return []byte(fmt.Sprintf("ops image signature v1\nimage %s\nmanifest %s\n", s.ImageDigest, s.ManifestDigest))
+}
+
+// SignImage signs an image with a PEM private key (Ed25519, ECDSA or RSA) and writes the signature
+// next to the image
+func SignImage(imagePath string, keyPEM []byte) (*ImageSignature, error) {
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse signing key: %w", err)
+	}
+	sig, err := imageDigests(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	switch key.Public().(type) {
+	case ed25519.PublicKey:
+		sig.Algorithm = "ed25519"
+		sig.Signature, err = key.Sign(rand.Reader, sig.payload(), crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		sig.Algorithm = "ecdsa-sha256"
+		digest := sha256.Sum256(sig.payload())
+		sig.Signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case *rsa.PublicKey:
+		sig.Algorithm = "rsa-sha256"
+		digest := sha256.Sum256(sig.payload())
+		sig.Signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign image: %w", err)
+	}
+	data, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(ImageSignaturePath(imagePath), data, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot write image signature: %w", err)
+	}
+	return sig, nil
+}
+
+// VerifyImage checks that an image has a valid signature from the owner of a PEM public key, and that
+// neither the image nor its manifest have been modified since the image was signed
+func VerifyImage(imagePath string, pubPEM []byte) (*ImageSignature, error) {
+	pub, err := ParsePublicKeyPEM(pubPEM)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse verification key: %w", err)
+	}
+	data, err := os.ReadFile(ImageSignaturePath(imagePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("image %q is not signed", imagePath)
+		}
+		return nil, fmt.Errorf("cannot read image signature: %w", err)
+	}
+	sig := &ImageSignature{}
+	err = json.Unmarshal(data, sig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse image signature: %w", err)
+	}
+	digest := sha256.Sum256(sig.payload())
+	var valid bool
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		valid = (sig.Algorithm == "ed25519") && ed25519.Verify(key, sig.payload(), sig.Signature)
+	case *ecdsa.PublicKey:
+		valid = (sig.Algorithm == "ecdsa-sha256") && ecdsa.VerifyASN1(key, digest[:], sig.Signature)
+	case *rsa.PublicKey:
+		valid = (sig.Algorithm == "rsa-sha256") && (rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig.Signature) == nil)
+	default:
+		return nil, errors.New("unsupported verification key type")
+	}
+	if !valid {
+		return sig, fmt.Errorf("invalid signature for image %q", imagePath)
+	}
+	current, err := imageDigests(imagePath)
+	if err != nil {
+		return sig, err
+	}
+	if current.ManifestDigest != sig.ManifestDigest {
+		return sig, fmt.Errorf("manifest of image %q has been modified since it was signed", imagePath)
+	}
+	if current.ImageDigest != sig.ImageDigest {
+		return sig, fmt.Errorf("image %q has been modified since it was signed", imagePath)
+	}
+	return sig, nil
+}
+
+// imageDigests returns an unsigned signature with the digests of an image; the manifest digest is
+// empty if the image is not in raw format (e.g. a cloud-specific archive)
+func imageDigests(imagePath string) (*ImageSignature, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open image: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("cannot read image: %w", err)
+	}
+	sig := &ImageSignature{
+		ImageDigest: "sha256:" + hex.EncodeToString(h.Sum(nil)),
+	}
+	reader, err := fs.NewReader(imagePath)
+	if err != nil {
+		return sig, nil
+	}
+	defer reader.Close()
+	attrs := reader.RootAttributes()
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h = sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%q\n", name, attrs[name])
+	}
+	sig.ManifestDigest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	return sig, nil
+}
+
+// ParsePublicKeyPEM parses a PEM-encoded PKIX public key
+func ParsePublicKeyPEM(pembytes []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pembytes)
+	if block == nil {
+		return nil, errors.New("couldn't parse PEM data")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// parsePrivateKeyPEM parses a PEM-encoded PKCS #8, SEC 1 (EC) or PKCS #1 (RSA) private key
+func parsePrivateKeyPEM(pembytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pembytes)
+	if block == nil {
+		return nil, errors.New("couldn't parse PEM data")
+	}
+	var key interface{}
+	var err error
+	switch {
+	case strings.HasPrefix(block.Type, "EC "):
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case strings.HasPrefix(block.Type, "RSA "):
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	return signer, nil
+}
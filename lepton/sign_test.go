@@ -0,0 +1,169 @@
+package lepton
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanovms/ops/fs"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeyPair(t *testing.T, key crypto.Signer) ([]byte, []byte) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	der, err = x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return priv, pub
+}
+
+func testImage(t *testing.T, env string) string {
+	imagePath := filepath.Join(t.TempDir(), "image")
+	m := fs.NewManifest("")
+	m.AddEnvironmentVariable("VAR", env)
+	mkfs := fs.NewMkfsCommand(m, false)
+	mkfs.SetFileSystemPath(imagePath)
+	if err := mkfs.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return imagePath
+}
+
+func TestSignImage(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	for name, key := range map[string]crypto.Signer{"ed25519": edKey, "ecdsa": ecKey, "rsa": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			priv, pub := testKeyPair(t, key)
+			imagePath := testImage(t, "one")
+
+			_, err := VerifyImage(imagePath, pub)
+			assert.ErrorContains(t, err, "not signed")
+
+			sig, err := SignImage(imagePath, priv)
+			assert.Nil(t, err)
+			assert.NotEmpty(t, sig.ManifestDigest)
+			_, err = VerifyImage(imagePath, pub)
+			assert.Nil(t, err)
+
+			_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+			_, otherPub := testKeyPair(t, otherKey)
+			_, err = VerifyImage(imagePath, otherPub)
+			assert.ErrorContains(t, err, "invalid signature")
+
+			f, err := os.OpenFile(imagePath, os.O_WRONLY|os.O_APPEND, 0)
+			assert.Nil(t, err)
+			f.Write([]byte("tampered"))
+			f.Close()
+			_, err = VerifyImage(imagePath, pub)
+			assert.ErrorContains(t, err, "has been modified")
+		})
+	}
+}
+
+func TestVerifyImagePolicy(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	priv, pub := testKeyPair(t, key)
+	keyDir := t.TempDir()
+	privPath := filepath.Join(keyDir, "key.pem")
+	pubPath := filepath.Join(keyDir, "key.pub")
+	os.WriteFile(privPath, priv, 0600)
+	os.WriteFile(pubPath, pub, 0644)
+
+	imagePath := testImage(t, "one")
+	c := &types.Config{}
+	c.RunConfig.ImageName = imagePath
+	ctx := NewContext(c)
+	assert.Nil(t, VerifyImagePolicy(ctx, imagePath))
+
+	c.CloudConfig.RequireSignedImages = true
+	assert.ErrorContains(t, VerifyImagePolicy(ctx, imagePath), "no image verification key")
+	c.CloudConfig.ImageVerifyKey = pubPath
+	assert.ErrorContains(t, VerifyImagePolicy(ctx, imagePath), "not signed")
+
+	c.CloudConfig.ImageSigningKey = privPath
+	assert.Nil(t, signBuiltImage(ctx, imagePath))
+	assert.Nil(t, VerifyImagePolicy(ctx, imagePath))
+
+	// a rebuilt image needs a new signature
+	os.Rename(testImage(t, "two"), imagePath)
+	assert.ErrorContains(t, VerifyImagePolicy(ctx, imagePath), "has been modified")
+}
+
+// imagesProvider is a provider holding cloud images
+type imagesProvider struct {
+	Provider
+	images []CloudImage
+}
+
+func (p *imagesProvider) GetImages(ctx *Context, filter string) ([]CloudImage, error) {
+	return p.images, nil
+}
+
+func TestVerifiedImages(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	imagePath := testImage(t, "one")
+	c := &types.Config{}
+	c.CloudConfig.Platform = "gcp"
+	ctx := NewContext(c)
+	p := &imagesProvider{images: []CloudImage{{ID: "1", Name: "web"}}}
+
+	verified, err := isVerifiedImage(ctx, p, "web")
+	assert.Nil(t, err)
+	assert.False(t, verified)
+
+	assert.Nil(t, recordVerifiedImage(ctx, p, "web", imagePath))
+	verified, err = isVerifiedImage(ctx, p, "web")
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	// another image given the same name is not trusted
+	p.images[0].ID = "2"
+	verified, err = isVerifiedImage(ctx, p, "web")
+	assert.Nil(t, err)
+	assert.False(t, verified)
+	p.images[0].ID = "1"
+
+	// the record is per platform
+	c.CloudConfig.Platform = "aws"
+	verified, err = isVerifiedImage(ctx, p, "web")
+	assert.Nil(t, err)
+	assert.False(t, verified)
+	c.CloudConfig.Platform = "gcp"
+
+	assert.Nil(t, forgetVerifiedImage("gcp", "web"))
+	verified, err = isVerifiedImage(ctx, p, "web")
+	assert.Nil(t, err)
+	assert.False(t, verified)
+}
+
+func TestVerifiedImagesUnidentified(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	c := &types.Config{}
+	c.CloudConfig.Platform = "vsphere"
+	p := &imagesProvider{images: []CloudImage{{Name: "web"}}}
+
+	assert.ErrorContains(t, recordVerifiedImage(NewContext(c), p, "web", testImage(t, "one")), "does not identify")
+
+	// records without the identity of the cloud image are not trusted
+	assert.Nil(t, os.WriteFile(verifiedImagesFile(), []byte(`{"vsphere/web": "sha256:0"}`), 0644))
+	verified, err := isVerifiedImage(NewContext(c), p, "web")
+	assert.Nil(t, err)
+	assert.False(t, verified)
+}
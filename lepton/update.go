@@ -3,8 +3,6 @@ package lepton
 import (
 	"bytes"
 	"crypto"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
@@ -204,12 +202,7 @@ func (o *Options) CheckPermissions() error {
 // used for checking a completed update's signature by parsing a
 // Public Key formatted as PEM data.
 func (o *Options) SetPublicKeyPEM(pembytes []byte) error {
-	block, _ := pem.Decode(pembytes)
-	if block == nil {
-		return errors.New("couldn't parse PEM data")
-	}
-
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	pub, err := ParsePublicKeyPEM(pembytes)
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package lepton
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"strconv"
@@ -129,6 +130,38 @@ func CreateLocalVolume(config *types.Config, name, data, provider string) (Nanos
 	return vol, nil
 }
 
+// CreateLocalVolumeFromTar creates a local volume with the contents of a tar stream
+func CreateLocalVolumeFromTar(config *types.Config, name string, r io.Reader, provider string) (NanosVolume, error) {
+	vol, err := CreateLocalVolume(config, name, "", provider)
+	if err != nil {
+		return vol, err
+	}
+	err = importVolumeTar(vol.Path, r)
+	if err != nil {
+		os.Remove(vol.Path)
+		return vol, err
+	}
+	return vol, nil
+}
+
+func importVolumeTar(volumePath string, r io.Reader) error {
+	w, err := fs.NewWriter(volumePath)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	err = w.ImportTar(r)
+	if err != nil {
+		return err
+	}
+	// the import writes a log record for each entry
+	err = w.Compact()
+	if err != nil {
+		return err
+	}
+	return w.Commit()
+}
+
 // buildVolumeManifest builds manifests for non-empty volume
 func buildVolumeManifest(conf *types.Config) (*fs.Manifest, error) {
 	m := fs.NewManifest("")
@@ -0,0 +1,44 @@
+package lepton
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/nanovms/ops/fs"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLocalVolumeFromTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "data/a", Typeflag: tar.TypeReg, Mode: 0644, Size: 6}))
+	_, err := tw.Write([]byte("linked"))
+	assert.Nil(t, err)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "data/b", Typeflag: tar.TypeLink, Linkname: "data/a"}))
+	assert.Nil(t, tw.Close())
+
+	c := &types.Config{VolumesDir: t.TempDir(), BaseVolumeSz: "8M"}
+	vol, err := CreateLocalVolumeFromTar(c, "data", &buf, "onprem")
+	assert.Nil(t, err)
+
+	// the hard-linked files still share their extents once the log is compacted
+	report, err := fs.Check(vol.Path)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+
+	r, err := fs.NewReader(vol.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, name := range []string{"/data/a", "/data/b"} {
+		file, err := r.ReadFile(name)
+		assert.Nil(t, err)
+		body, err := io.ReadAll(file)
+		assert.Nil(t, err)
+		assert.Equal(t, "linked", string(body), name)
+	}
+}
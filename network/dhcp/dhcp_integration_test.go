@@ -0,0 +1,128 @@
+//go:build integration && linux
+// +build integration,linux
+
+package dhcp_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/network/dhcp"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// TestServerOnBridge leases an address over a bridge to a client in a network namespace, which
+// is attached to the bridge by a veth pair
+func TestServerOnBridge(t *testing.T) {
+	const (
+		bridge = "br-dhcp-test"
+		netns  = "ops-dhcp-test"
+	)
+	ip := func(args ...string) {
+		out, err := exec.Command("ip", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("ip %v: %v: %s", args, err, out)
+		}
+	}
+	ip("link", "add", "name", bridge, "type", "bridge")
+	defer exec.Command("ip", "link", "delete", bridge).Run()
+	ip("addr", "add", "10.213.0.1/24", "dev", bridge)
+	ip("link", "set", bridge, "up")
+	ip("netns", "add", netns)
+	defer exec.Command("ip", "netns", "delete", netns).Run()
+	ip("link", "add", "veth-dhcp-test", "type", "veth", "peer", "name", "eth0", "netns", netns)
+	ip("link", "set", "veth-dhcp-test", "master", bridge, "up")
+	ip("netns", "exec", netns, "ip", "link", "set", "eth0", "address", "52:54:00:12:34:56", "up")
+
+	leases := path.Join(t.TempDir(), "br.leases")
+	pool, err := dhcp.NewPool("10.213.0.1/24", leases)
+	assert.Nil(t, err)
+	conn, err := dhcp.Listen(bridge)
+	assert.Nil(t, err)
+	defer conn.Close()
+	go dhcp.NewServer(pool).Serve(conn)
+
+	client, err := listenInNetns(netns, "eth0")
+	assert.Nil(t, err)
+	defer client.Close()
+
+	mac, _ := net.ParseMAC("52:54:00:12:34:56")
+	exchange := func(msgType dhcp.MessageType, options map[dhcp.OptionCode][]byte) *dhcp.Packet {
+		options[dhcp.OptionMessageType] = []byte{byte(msgType)}
+		req := &dhcp.Packet{
+			Op:        dhcp.BootRequest,
+			XID:       0xcafe,
+			Flags:     dhcp.Broadcast,
+			ClientMAC: mac,
+			Options:   options,
+		}
+		_, err := client.WriteTo(req.Marshal(), &net.UDPAddr{IP: net.IPv4bcast, Port: 67})
+		assert.Nil(t, err)
+		buf := make([]byte, 1500)
+		client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := client.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply, err := dhcp.Parse(buf[:n])
+		assert.Nil(t, err)
+		return reply
+	}
+
+	offer := exchange(dhcp.Discover, map[dhcp.OptionCode][]byte{})
+	assert.Equal(t, dhcp.Offer, offer.MessageType())
+	assert.Equal(t, "10.213.0.2", offer.YourIP.String())
+
+	ack := exchange(dhcp.Request, map[dhcp.OptionCode][]byte{
+		dhcp.OptionRequestedIP: offer.YourIP.To4(),
+		dhcp.OptionServerID:    offer.IPOption(dhcp.OptionServerID),
+	})
+	assert.Equal(t, dhcp.Ack, ack.MessageType())
+
+	recorded, err := dhcp.ReadLeases(leases)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.213.0.2", dhcp.FindLease(recorded, mac.String()).IP)
+}
+
+// listenInNetns returns a DHCP client connection on an interface of a network namespace
+func listenInNetns(netns string, ifname string) (net.PacketConn, error) {
+	type result struct {
+		conn net.PacketConn
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		// the thread is left in the namespace and terminated with the goroutine
+		runtime.LockOSThread()
+		f, err := os.Open(path.Join("/var/run/netns", netns))
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer f.Close()
+		if err = unix.Setns(int(f.Fd()), unix.CLONE_NEWNET); err != nil {
+			done <- result{err: err}
+			return
+		}
+		lc := net.ListenConfig{
+			Control: func(network, address string, c syscall.RawConn) error {
+				return c.Control(func(fd uintptr) {
+					unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, ifname)
+					unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_BROADCAST, 1)
+				})
+			},
+		}
+		conn, err := lc.ListenPacket(context.Background(), "udp4", "0.0.0.0:68")
+		done <- result{conn, err}
+	}()
+	r := <-done
+	return r.conn, r.err
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package dhcp
+
+import (
+	"errors"
+	"net"
+)
+
+// Listen returns a connection receiving the DHCP requests of a network interface; only supported
+// on linux, where networks are bridges
+func Listen(ifname string) (net.PacketConn, error) {
+	return nil, errors.New("the DHCP server of ops networks is only supported on linux")
+}
@@ -0,0 +1,223 @@
+// Package dhcp implements the DHCP server and address allocator of ops networks, which lease the
+// addresses of a bridge subnet to the instances attached to the bridge.
+package dhcp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// BOOTP operations
+const (
+	BootRequest = 1
+	BootReply   = 2
+)
+
+// Broadcast is the flag of requests whose replies must be broadcast
+const Broadcast = 0x8000
+
+// MessageType is the type of a DHCP message, carried by the OptionMessageType option
+type MessageType byte
+
+// DHCP message types
+const (
+	Discover MessageType = 1
+	Offer    MessageType = 2
+	Request  MessageType = 3
+	Decline  MessageType = 4
+	Ack      MessageType = 5
+	Nak      MessageType = 6
+	Release  MessageType = 7
+	Inform   MessageType = 8
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case Discover:
+		return "DHCPDISCOVER"
+	case Offer:
+		return "DHCPOFFER"
+	case Request:
+		return "DHCPREQUEST"
+	case Decline:
+		return "DHCPDECLINE"
+	case Ack:
+		return "DHCPACK"
+	case Nak:
+		return "DHCPNAK"
+	case Release:
+		return "DHCPRELEASE"
+	case Inform:
+		return "DHCPINFORM"
+	}
+	return fmt.Sprintf("DHCP message type %d", byte(t))
+}
+
+// OptionCode is the code of a DHCP option
+type OptionCode byte
+
+// DHCP options used by the server
+const (
+	OptionPad           OptionCode = 0
+	OptionSubnetMask    OptionCode = 1
+	OptionRouter        OptionCode = 3
+	OptionDNS           OptionCode = 6
+	OptionHostname      OptionCode = 12
+	OptionRequestedIP   OptionCode = 50
+	OptionLeaseTime     OptionCode = 51
+	OptionMessageType   OptionCode = 53
+	OptionServerID      OptionCode = 54
+	OptionParameterList OptionCode = 55
+	OptionEnd           OptionCode = 255
+)
+
+// magicCookie starts the options of DHCP packets
+var magicCookie = []byte{99, 130, 83, 99}
+
+// headerLen is the length of the fixed part of DHCP packets, up to the magic cookie
+const headerLen = 236
+
+// Packet is a DHCP packet
+type Packet struct {
+	Op        byte
+	XID       uint32
+	Secs      uint16
+	Flags     uint16
+	ClientIP  net.IP
+	YourIP    net.IP
+	ServerIP  net.IP
+	GatewayIP net.IP
+	ClientMAC net.HardwareAddr
+	Options   map[OptionCode][]byte
+}
+
+// Parse decodes a DHCP packet
+func Parse(b []byte) (*Packet, error) {
+	if len(b) < headerLen+len(magicCookie) {
+		return nil, errors.New("packet too short")
+	}
+	if string(b[headerLen:headerLen+4]) != string(magicCookie) {
+		return nil, errors.New("not a DHCP packet")
+	}
+	hlen := int(b[2])
+	if hlen > 16 {
+		return nil, fmt.Errorf("invalid hardware address length %d", hlen)
+	}
+
+	p := &Packet{
+		Op:        b[0],
+		XID:       binary.BigEndian.Uint32(b[4:8]),
+		Secs:      binary.BigEndian.Uint16(b[8:10]),
+		Flags:     binary.BigEndian.Uint16(b[10:12]),
+		ClientIP:  net.IP(append([]byte{}, b[12:16]...)),
+		YourIP:    net.IP(append([]byte{}, b[16:20]...)),
+		ServerIP:  net.IP(append([]byte{}, b[20:24]...)),
+		GatewayIP: net.IP(append([]byte{}, b[24:28]...)),
+		ClientMAC: net.HardwareAddr(append([]byte{}, b[28:28+hlen]...)),
+		Options:   make(map[OptionCode][]byte),
+	}
+
+	options := b[headerLen+4:]
+	for len(options) > 0 {
+		code := OptionCode(options[0])
+		if code == OptionEnd {
+			break
+		}
+		if code == OptionPad {
+			options = options[1:]
+			continue
+		}
+		if len(options) < 2 || len(options) < 2+int(options[1]) {
+			return nil, fmt.Errorf("option %d truncated", code)
+		}
+		n := int(options[1])
+		// options which are too long are split, and concatenated by receivers
+		p.Options[code] = append(p.Options[code], options[2:2+n]...)
+		options = options[2+n:]
+	}
+	return p, nil
+}
+
+// Marshal encodes the packet
+func (p *Packet) Marshal() []byte {
+	b := make([]byte, headerLen, headerLen+len(magicCookie)+64)
+	b[0] = p.Op
+	b[1] = 1 // ethernet
+	b[2] = byte(len(p.ClientMAC))
+	binary.BigEndian.PutUint32(b[4:8], p.XID)
+	binary.BigEndian.PutUint16(b[8:10], p.Secs)
+	binary.BigEndian.PutUint16(b[10:12], p.Flags)
+	copy(b[12:16], p.ClientIP.To4())
+	copy(b[16:20], p.YourIP.To4())
+	copy(b[20:24], p.ServerIP.To4())
+	copy(b[24:28], p.GatewayIP.To4())
+	copy(b[28:44], p.ClientMAC)
+	b = append(b, magicCookie...)
+
+	// the message type comes first, as some clients expect
+	codes := make([]int, 0, len(p.Options))
+	for code := range p.Options {
+		if code != OptionMessageType {
+			codes = append(codes, int(code))
+		}
+	}
+	sort.Ints(codes)
+	if _, ok := p.Options[OptionMessageType]; ok {
+		codes = append([]int{int(OptionMessageType)}, codes...)
+	}
+	for _, code := range codes {
+		value := p.Options[OptionCode(code)]
+		for {
+			n := len(value)
+			if n > 255 {
+				n = 255
+			}
+			b = append(b, byte(code), byte(n))
+			b = append(b, value[:n]...)
+			value = value[n:]
+			if len(value) == 0 {
+				break
+			}
+		}
+	}
+	b = append(b, byte(OptionEnd))
+
+	// BOOTP relays and old clients drop packets smaller than a BOOTP packet
+	for len(b) < 300 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// MessageType returns the DHCP message type of the packet, 0 for BOOTP packets
+func (p *Packet) MessageType() MessageType {
+	if v := p.Options[OptionMessageType]; len(v) == 1 {
+		return MessageType(v[0])
+	}
+	return 0
+}
+
+// IPOption returns the first address of an option, or nil
+func (p *Packet) IPOption(code OptionCode) net.IP {
+	if v := p.Options[code]; len(v) >= 4 {
+		return net.IP(v[:4])
+	}
+	return nil
+}
+
+func ipsOption(ips []net.IP) []byte {
+	var b []byte
+	for _, ip := range ips {
+		b = append(b, ip.To4()...)
+	}
+	return b
+}
+
+func uint32Option(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
@@ -0,0 +1,310 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// offerTime is how long an offered address is reserved for the client it is offered to
+const offerTime = time.Minute
+
+// declineTime is how long an address declined by a client is not leased
+const declineTime = 10 * time.Minute
+
+// Lease is an address leased to a hardware address; addresses declined by clients as they are used
+// by other hosts are leased to no hardware address
+type Lease struct {
+	MAC      string    `json:"mac"`
+	IP       string    `json:"ip"`
+	Hostname string    `json:"hostname,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Pool allocates the addresses of a subnet, except the address of the gateway, to hardware
+// addresses. Clients are given back the address they were leased last, even if the lease expired
+// in the meantime, as long as the address was not leased to another client.
+type Pool struct {
+	mu      sync.Mutex
+	subnet  *net.IPNet
+	gateway net.IP
+	file    string
+	leases  []Lease
+	now     func() time.Time
+}
+
+// NewPool returns the pool of the subnet of the address of a gateway in CIDR notation, eg:
+// 192.168.33.1/24. Leases are saved to file if it is not empty, and the leases already saved in
+// it are loaded.
+func NewPool(gateway string, file string) (*Pool, error) {
+	ip, subnet, err := net.ParseCIDR(gateway)
+	if err != nil {
+		return nil, err
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 address", gateway)
+	}
+	if ones, bits := subnet.Mask.Size(); bits-ones < 2 {
+		return nil, fmt.Errorf("subnet %s has no address to lease", subnet)
+	}
+
+	p := &Pool{subnet: subnet, gateway: ip.To4(), file: file, now: time.Now}
+	if file != "" {
+		if p.leases, err = ReadLeases(file); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Subnet returns the subnet of the pool
+func (p *Pool) Subnet() *net.IPNet {
+	return p.subnet
+}
+
+// Gateway returns the address of the gateway of the subnet
+func (p *Pool) Gateway() net.IP {
+	return p.gateway
+}
+
+// Offer returns an address for a client, preferably the address the client requested if it is
+// available, and reserves it for a short time
+func (p *Pool) Offer(mac net.HardwareAddr, requested net.IP) (net.IP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ip := p.leasedIP(mac)
+	if ip == nil && requested != nil && p.available(requested, mac) {
+		ip = requested.To4()
+	}
+	if ip == nil {
+		ip = p.free(mac)
+	}
+	if ip == nil {
+		return nil, fmt.Errorf("no address available in %s", p.subnet)
+	}
+
+	l := p.lease(mac)
+	if l == nil || l.IP != ip.String() || l.Expires.Before(p.now().Add(offerTime)) {
+		if err := p.set(Lease{MAC: mac.String(), IP: ip.String(), Expires: p.now().Add(offerTime)}); err != nil {
+			return nil, err
+		}
+	}
+	return ip, nil
+}
+
+// Lease leases an address to a client for duration
+func (p *Pool) Lease(mac net.HardwareAddr, ip net.IP, hostname string, duration time.Duration) (*Lease, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.available(ip, mac) {
+		return nil, fmt.Errorf("address %s is not available", ip)
+	}
+	l := Lease{MAC: mac.String(), IP: ip.To4().String(), Hostname: hostname, Expires: p.now().Add(duration)}
+	if err := p.set(l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Release ends the lease of a client; the address is kept for the client unless it is leased to
+// another client
+func (p *Pool) Release(mac net.HardwareAddr) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l := p.lease(mac)
+	if l == nil {
+		return nil
+	}
+	l.Expires = p.now()
+	return p.save()
+}
+
+// Decline marks the address a client declined, as it is used by another host, unavailable to every
+// client for a while; the address last leased to the client is declined if ip is nil
+func (p *Pool) Decline(mac net.HardwareAddr, ip net.IP) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ip == nil {
+		l := p.lease(mac)
+		if l == nil {
+			return nil
+		}
+		ip = net.ParseIP(l.IP)
+	}
+	ip = ip.To4()
+	if ip == nil || !p.subnet.Contains(ip) {
+		return nil
+	}
+
+	leases := []Lease{{IP: ip.String(), Expires: p.now().Add(declineTime)}}
+	for _, other := range p.leases {
+		if other.IP != ip.String() {
+			leases = append(leases, other)
+		}
+	}
+	p.leases = leases
+	return p.save()
+}
+
+// Leases returns the leases of the pool, including expired ones
+func (p *Pool) Leases() []Lease {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Lease{}, p.leases...)
+}
+
+func (p *Pool) lease(mac net.HardwareAddr) *Lease {
+	for i := range p.leases {
+		if p.leases[i].MAC == mac.String() {
+			return &p.leases[i]
+		}
+	}
+	return nil
+}
+
+// leasedIP returns the address last leased to a client if it is still available to it
+func (p *Pool) leasedIP(mac net.HardwareAddr) net.IP {
+	l := p.lease(mac)
+	if l == nil {
+		return nil
+	}
+	ip := net.ParseIP(l.IP)
+	if ip == nil || !p.available(ip, mac) {
+		return nil
+	}
+	return ip.To4()
+}
+
+// available returns whether an address can be leased to a client
+func (p *Pool) available(ip net.IP, mac net.HardwareAddr) bool {
+	ip = ip.To4()
+	if ip == nil || !p.subnet.Contains(ip) || ip.Equal(p.gateway) {
+		return false
+	}
+	n := binary.BigEndian.Uint32(ip)
+	first, last := p.bounds()
+	if n < first || n > last {
+		return false
+	}
+	for _, l := range p.leases {
+		if l.IP == ip.String() && l.MAC != mac.String() && l.Expires.After(p.now()) {
+			return false
+		}
+	}
+	return true
+}
+
+// free returns the first address which was never leased, or else the address whose lease expired
+// first
+func (p *Pool) free(mac net.HardwareAddr) net.IP {
+	first, last := p.bounds()
+	var expired net.IP
+	var expiredAt time.Time
+	for n := first; n <= last; n++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, n)
+		if !p.available(ip, mac) {
+			continue
+		}
+		l := p.leaseOf(ip)
+		if l == nil {
+			return ip
+		}
+		if expired == nil || l.Expires.Before(expiredAt) {
+			expired = ip
+			expiredAt = l.Expires
+		}
+	}
+	return expired
+}
+
+func (p *Pool) leaseOf(ip net.IP) *Lease {
+	for i := range p.leases {
+		if p.leases[i].IP == ip.String() {
+			return &p.leases[i]
+		}
+	}
+	return nil
+}
+
+// bounds returns the first and last addresses of the subnet which can be leased, excluding the
+// network and broadcast addresses
+func (p *Pool) bounds() (uint32, uint32) {
+	network := binary.BigEndian.Uint32(p.subnet.IP.To4())
+	mask := binary.BigEndian.Uint32(net.IP(p.subnet.Mask).To4())
+	return network + 1, (network | ^mask) - 1
+}
+
+// set records a lease, replacing the lease of the same client and forgetting the expired leases
+// of other clients for the same address
+func (p *Pool) set(l Lease) error {
+	leases := []Lease{l}
+	for _, other := range p.leases {
+		if other.MAC != l.MAC && other.IP != l.IP {
+			leases = append(leases, other)
+		}
+	}
+	p.leases = leases
+	return p.save()
+}
+
+func (p *Pool) save() error {
+	if p.file == "" {
+		return nil
+	}
+	return WriteLeases(p.file, p.leases)
+}
+
+// ReadLeases returns the leases saved in a file, or no leases if the file does not exist
+func ReadLeases(file string) ([]Lease, error) {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var leases []Lease
+	if err = json.Unmarshal(body, &leases); err != nil {
+		return nil, fmt.Errorf("cannot read leases %s: %w", file, err)
+	}
+	return leases, nil
+}
+
+// WriteLeases saves leases to a file; the file is replaced atomically as it is read by other
+// processes
+func WriteLeases(file string, leases []Lease) error {
+	body, err := json.MarshalIndent(leases, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err = os.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// FindLease returns the lease of a hardware address which has not expired, or nil
+func FindLease(leases []Lease, mac string) *Lease {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil
+	}
+	for i := range leases {
+		if strings.EqualFold(leases[i].MAC, hw.String()) && leases[i].Expires.After(time.Now()) {
+			return &leases[i]
+		}
+	}
+	return nil
+}
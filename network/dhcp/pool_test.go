@@ -0,0 +1,119 @@
+package dhcp_test
+
+import (
+	"net"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/network/dhcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustMAC(t *testing.T, s string) net.HardwareAddr {
+	mac, err := net.ParseMAC(s)
+	assert.Nil(t, err)
+	return mac
+}
+
+func TestPool(t *testing.T) {
+	file := path.Join(t.TempDir(), "br0.leases")
+	pool, err := dhcp.NewPool("192.168.33.1/29", file)
+	assert.Nil(t, err)
+	a := mustMAC(t, "52:54:00:00:00:0a")
+	b := mustMAC(t, "52:54:00:00:00:0b")
+	c := mustMAC(t, "52:54:00:00:00:0c")
+
+	// the gateway is skipped
+	ip, err := pool.Offer(a, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.2", ip.String())
+
+	// the offered address is reserved
+	ip, err = pool.Offer(b, net.ParseIP("192.168.33.2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.3", ip.String())
+	_, err = pool.Lease(b, net.ParseIP("192.168.33.2"), "", time.Hour)
+	assert.EqualError(t, err, "address 192.168.33.2 is not available")
+
+	lease, err := pool.Lease(a, net.ParseIP("192.168.33.2"), "web", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "web", lease.Hostname)
+
+	// the requested address is offered if it is available
+	ip, err = pool.Offer(c, net.ParseIP("192.168.33.6"))
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.6", ip.String())
+	_, err = pool.Lease(c, net.ParseIP("192.168.33.7"), "", time.Hour)
+	assert.EqualError(t, err, "address 192.168.33.7 is not available")
+	_, err = pool.Lease(c, net.ParseIP("10.0.0.2"), "", time.Hour)
+	assert.EqualError(t, err, "address 10.0.0.2 is not available")
+
+	// leases survive the server
+	pool, err = dhcp.NewPool("192.168.33.1/29", file)
+	assert.Nil(t, err)
+	leases, err := dhcp.ReadLeases(file)
+	assert.Nil(t, err)
+	assert.Len(t, leases, 3)
+	assert.Equal(t, "192.168.33.2", dhcp.FindLease(leases, "52:54:00:00:00:0A").IP)
+	assert.Nil(t, dhcp.FindLease(leases, "52:54:00:00:00:0d"))
+
+	// a client gets its address back after releasing it
+	assert.Nil(t, pool.Release(a))
+	leases, err = dhcp.ReadLeases(file)
+	assert.Nil(t, err)
+	assert.Nil(t, dhcp.FindLease(leases, a.String()))
+	ip, err = pool.Offer(a, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.2", ip.String())
+}
+
+func TestPoolDecline(t *testing.T) {
+	file := path.Join(t.TempDir(), "br0.leases")
+	pool, err := dhcp.NewPool("192.168.33.1/29", file)
+	assert.Nil(t, err)
+	a := mustMAC(t, "52:54:00:00:00:0a")
+	b := mustMAC(t, "52:54:00:00:00:0b")
+
+	ip, err := pool.Offer(a, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.2", ip.String())
+
+	// the declined address is leased to no client, even the one which requests it
+	assert.Nil(t, pool.Decline(a, nil))
+	ip, err = pool.Offer(a, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.3", ip.String())
+	ip, err = pool.Offer(b, net.ParseIP("192.168.33.2"))
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.33.4", ip.String())
+	_, err = pool.Lease(b, net.ParseIP("192.168.33.2"), "", time.Hour)
+	assert.EqualError(t, err, "address 192.168.33.2 is not available")
+
+	// declined addresses survive the server
+	pool, err = dhcp.NewPool("192.168.33.1/29", file)
+	assert.Nil(t, err)
+	_, err = pool.Lease(a, net.ParseIP("192.168.33.2"), "", time.Hour)
+	assert.EqualError(t, err, "address 192.168.33.2 is not available")
+}
+
+func TestPoolExhausted(t *testing.T) {
+	pool, err := dhcp.NewPool("10.0.0.1/30", "")
+	assert.Nil(t, err)
+
+	ip, err := pool.Offer(mustMAC(t, "52:54:00:00:00:0a"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.2", ip.String())
+	_, err = pool.Offer(mustMAC(t, "52:54:00:00:00:0b"), nil)
+	assert.EqualError(t, err, "no address available in 10.0.0.0/30")
+
+	// expired leases are reused
+	_, err = pool.Lease(mustMAC(t, "52:54:00:00:00:0a"), ip, "", 0)
+	assert.Nil(t, err)
+	ip, err = pool.Offer(mustMAC(t, "52:54:00:00:00:0b"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.2", ip.String())
+
+	_, err = dhcp.NewPool("10.0.0.1/31", "")
+	assert.EqualError(t, err, "subnet 10.0.0.0/31 has no address to lease")
+}
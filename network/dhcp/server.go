@@ -0,0 +1,171 @@
+package dhcp
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nanovms/ops/log"
+)
+
+// DefaultLeaseTime is the duration of leases
+const DefaultLeaseTime = 12 * time.Hour
+
+// Server is a DHCP server leasing the addresses of a pool
+type Server struct {
+	Pool      *Pool
+	DNS       []net.IP
+	LeaseTime time.Duration
+}
+
+// NewServer returns a server for a pool, which advertises the DNS servers of the host
+func NewServer(pool *Pool) *Server {
+	return &Server{
+		Pool:      pool,
+		DNS:       Nameservers("/etc/resolv.conf"),
+		LeaseTime: DefaultLeaseTime,
+	}
+}
+
+// Serve answers the requests received on conn until conn is closed; replies are broadcast, unless
+// the client already has an address
+func (s *Server) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		req, err := Parse(buf[:n])
+		if err != nil {
+			log.Debugf("ignoring packet: %v", err)
+			continue
+		}
+		reply := s.Handle(req)
+		if reply == nil {
+			continue
+		}
+
+		dst := &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+		if !req.ClientIP.IsUnspecified() && req.Flags&Broadcast == 0 {
+			dst.IP = req.ClientIP
+		}
+		if _, err = conn.WriteTo(reply.Marshal(), dst); err != nil {
+			log.Errorf("cannot reply to %s: %v", req.ClientMAC, err)
+		}
+	}
+}
+
+// Handle returns the reply to a request, or nil if the request is not answered
+func (s *Server) Handle(req *Packet) *Packet {
+	if req.Op != BootRequest || len(req.ClientMAC) != 6 {
+		return nil
+	}
+	mac := req.ClientMAC
+
+	switch req.MessageType() {
+	case Discover:
+		ip, err := s.Pool.Offer(mac, req.IPOption(OptionRequestedIP))
+		if err != nil {
+			log.Errorf("cannot offer an address to %s: %v", mac, err)
+			return nil
+		}
+		log.Infof("%s %s to %s", Offer, ip, mac)
+		return s.reply(req, Offer, ip)
+
+	case Request:
+		// the client accepted the offer of another server
+		if server := req.IPOption(OptionServerID); server != nil && !server.Equal(s.Pool.Gateway()) {
+			return nil
+		}
+		ip := req.IPOption(OptionRequestedIP)
+		if ip == nil {
+			// the client renews its lease
+			ip = req.ClientIP
+		}
+		lease, err := s.Pool.Lease(mac, ip, string(req.Options[OptionHostname]), s.LeaseTime)
+		if err != nil {
+			log.Infof("%s %s to %s: %v", Nak, ip, mac, err)
+			return s.reply(req, Nak, nil)
+		}
+		log.Infof("%s %s to %s", Ack, lease.IP, mac)
+		return s.reply(req, Ack, ip)
+
+	case Decline:
+		// the address is used by another host
+		ip := req.IPOption(OptionRequestedIP)
+		log.Warnf("%s declined %s", mac, ip)
+		if err := s.Pool.Decline(mac, ip); err != nil {
+			log.Errorf("cannot decline %s: %v", ip, err)
+		}
+
+	case Release:
+		log.Infof("%s released %s", mac, req.ClientIP)
+		s.Pool.Release(mac)
+
+	case Inform:
+		return s.reply(req, Ack, nil)
+	}
+	return nil
+}
+
+// reply returns a reply to a request, leasing ip if it is not nil
+func (s *Server) reply(req *Packet, t MessageType, ip net.IP) *Packet {
+	reply := &Packet{
+		Op:        BootReply,
+		XID:       req.XID,
+		Flags:     req.Flags,
+		ClientIP:  net.IPv4zero,
+		YourIP:    net.IPv4zero,
+		ServerIP:  net.IPv4zero,
+		GatewayIP: req.GatewayIP,
+		ClientMAC: req.ClientMAC,
+		Options: map[OptionCode][]byte{
+			OptionMessageType: {byte(t)},
+			OptionServerID:    s.Pool.Gateway().To4(),
+		},
+	}
+	if t == Nak {
+		return reply
+	}
+
+	if ip != nil {
+		reply.YourIP = ip
+		reply.Options[OptionLeaseTime] = uint32Option(uint32(s.LeaseTime / time.Second))
+	} else {
+		reply.ClientIP = req.ClientIP
+	}
+	reply.Options[OptionSubnetMask] = []byte(s.Pool.Subnet().Mask)
+	reply.Options[OptionRouter] = s.Pool.Gateway().To4()
+	if len(s.DNS) > 0 {
+		reply.Options[OptionDNS] = ipsOption(s.DNS)
+	}
+	return reply
+}
+
+// Nameservers returns the IPv4 nameservers of a resolv.conf file, except loopback ones which are
+// not reachable from instances
+func Nameservers(resolvConf string) []net.IP {
+	f, err := os.Open(resolvConf)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var ips []net.IP
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		ip := net.ParseIP(fields[1])
+		if ip == nil || ip.To4() == nil || ip.IsLoopback() {
+			continue
+		}
+		ips = append(ips, ip.To4())
+	}
+	return ips
+}
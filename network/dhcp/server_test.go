@@ -0,0 +1,103 @@
+package dhcp_test
+
+import (
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/network/dhcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func request(t *testing.T, mac string, msgType dhcp.MessageType, options map[dhcp.OptionCode][]byte) *dhcp.Packet {
+	if options == nil {
+		options = make(map[dhcp.OptionCode][]byte)
+	}
+	options[dhcp.OptionMessageType] = []byte{byte(msgType)}
+	p := &dhcp.Packet{
+		Op:        dhcp.BootRequest,
+		XID:       0x1234,
+		ClientIP:  net.IPv4zero,
+		YourIP:    net.IPv4zero,
+		ServerIP:  net.IPv4zero,
+		GatewayIP: net.IPv4zero,
+		ClientMAC: mustMAC(t, mac),
+		Options:   options,
+	}
+
+	// requests go through the wire format
+	parsed, err := dhcp.Parse(p.Marshal())
+	assert.Nil(t, err)
+	return parsed
+}
+
+func TestPacket(t *testing.T) {
+	p := request(t, "52:54:00:12:34:56", dhcp.Discover, map[dhcp.OptionCode][]byte{
+		dhcp.OptionHostname: []byte("web"),
+		dhcp.OptionDNS:      make([]byte, 300),
+	})
+	assert.Equal(t, dhcp.Discover, p.MessageType())
+	assert.Equal(t, uint32(0x1234), p.XID)
+	assert.Equal(t, "52:54:00:12:34:56", p.ClientMAC.String())
+	assert.Equal(t, []byte("web"), p.Options[dhcp.OptionHostname])
+	assert.Len(t, p.Options[dhcp.OptionDNS], 300)
+
+	_, err := dhcp.Parse(make([]byte, 100))
+	assert.EqualError(t, err, "packet too short")
+	_, err = dhcp.Parse(make([]byte, 300))
+	assert.EqualError(t, err, "not a DHCP packet")
+}
+
+func TestServer(t *testing.T) {
+	resolvConf := path.Join(t.TempDir(), "resolv.conf")
+	assert.Nil(t, os.WriteFile(resolvConf, []byte("nameserver 127.0.0.53\nnameserver 10.1.1.1\nsearch local\n"), 0644))
+
+	pool, err := dhcp.NewPool("192.168.33.1/24", "")
+	assert.Nil(t, err)
+	server := &dhcp.Server{Pool: pool, DNS: dhcp.Nameservers(resolvConf), LeaseTime: time.Hour}
+	mac := "52:54:00:12:34:56"
+
+	offer := server.Handle(request(t, mac, dhcp.Discover, nil))
+	assert.Equal(t, dhcp.Offer, offer.MessageType())
+	assert.Equal(t, uint8(dhcp.BootReply), offer.Op)
+	assert.Equal(t, uint32(0x1234), offer.XID)
+	assert.Equal(t, "192.168.33.2", offer.YourIP.String())
+	assert.Equal(t, "192.168.33.1", offer.IPOption(dhcp.OptionServerID).String())
+	assert.Equal(t, "192.168.33.1", offer.IPOption(dhcp.OptionRouter).String())
+	assert.Equal(t, "10.1.1.1", offer.IPOption(dhcp.OptionDNS).String())
+	assert.Equal(t, []byte{255, 255, 255, 0}, offer.Options[dhcp.OptionSubnetMask])
+	assert.Equal(t, []byte{0, 0, 14, 16}, offer.Options[dhcp.OptionLeaseTime])
+
+	ack := server.Handle(request(t, mac, dhcp.Request, map[dhcp.OptionCode][]byte{
+		dhcp.OptionRequestedIP: offer.YourIP.To4(),
+		dhcp.OptionServerID:    offer.IPOption(dhcp.OptionServerID),
+		dhcp.OptionHostname:    []byte("web"),
+	}))
+	assert.Equal(t, dhcp.Ack, ack.MessageType())
+	assert.Equal(t, "192.168.33.2", ack.YourIP.String())
+	assert.Equal(t, "web", pool.Leases()[0].Hostname)
+
+	// another client cannot take the address
+	nak := server.Handle(request(t, "52:54:00:12:34:57", dhcp.Request, map[dhcp.OptionCode][]byte{
+		dhcp.OptionRequestedIP: offer.YourIP.To4(),
+	}))
+	assert.Equal(t, dhcp.Nak, nak.MessageType())
+
+	// requests to other servers are ignored
+	assert.Nil(t, server.Handle(request(t, "52:54:00:12:34:57", dhcp.Request, map[dhcp.OptionCode][]byte{
+		dhcp.OptionRequestedIP: net.ParseIP("192.168.33.3").To4(),
+		dhcp.OptionServerID:    net.ParseIP("192.168.33.254").To4(),
+	})))
+
+	assert.Nil(t, server.Handle(request(t, mac, dhcp.Release, nil)))
+	assert.Nil(t, dhcp.FindLease(pool.Leases(), mac))
+
+	// a declined address is not offered again
+	assert.Nil(t, server.Handle(request(t, mac, dhcp.Decline, map[dhcp.OptionCode][]byte{
+		dhcp.OptionRequestedIP: offer.YourIP.To4(),
+	})))
+	offer = server.Handle(request(t, mac, dhcp.Discover, nil))
+	assert.Equal(t, "192.168.33.3", offer.YourIP.String())
+}
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// FirewallRules are the ports of an instance reachable from its bridge or host, all the other
+// ports being filtered
+type FirewallRules struct {
+	// TCPPorts and UDPPorts are ports as configured in RunConfig.Ports and RunConfig.UDPPorts, eg:
+	// "80", "8080-80" where 80 is the port of the instance, or "80,443"
+	TCPPorts []string
+	UDPPorts []string
+
+	// Sources are the addresses or CIDRs allowed to reach the ports, any address if empty
+	Sources []string
+}
+
+// firewallTable returns the name of the nftables table filtering the traffic of a tap device
+func firewallTable(tapDeviceName string) string {
+	return "ops-" + tapDeviceName
+}
+
+// FirewallRuleset returns the nftables script filtering the traffic sent to a tap device. The
+// rules are kept in their own table, which the script replaces atomically, and accept the replies
+// to the connections of the instance, ARP, DHCP and IPv6 neighbor discovery, and the declared
+// ports.
+func FirewallRuleset(tapDeviceName string, rules FirewallRules) (string, error) {
+	tcpPorts, err := instancePorts(rules.TCPPorts)
+	if err != nil {
+		return "", err
+	}
+	udpPorts, err := instancePorts(rules.UDPPorts)
+	if err != nil {
+		return "", err
+	}
+	var sources4, sources6 []string
+	for _, s := range rules.Sources {
+		cidr, err := parseSource(s)
+		if err != nil {
+			return "", err
+		}
+		if cidr.IP.To4() != nil {
+			sources4 = append(sources4, cidr.String())
+		} else {
+			sources6 = append(sources6, cidr.String())
+		}
+	}
+
+	// matches are the source matches of the accepted ports, one rule being added for each
+	matches := []string{""}
+	if len(rules.Sources) > 0 {
+		matches = nil
+		if len(sources4) > 0 {
+			matches = append(matches, fmt.Sprintf("ip saddr { %s } ", strings.Join(sources4, ", ")))
+		}
+		if len(sources6) > 0 {
+			matches = append(matches, fmt.Sprintf("ip6 saddr { %s } ", strings.Join(sources6, ", ")))
+		}
+	}
+
+	table := firewallTable(tapDeviceName)
+	var b strings.Builder
+	fmt.Fprintf(&b, "add table bridge %s\n", table)
+	fmt.Fprintf(&b, "delete table bridge %s\n", table)
+	fmt.Fprintf(&b, "table bridge %s {\n", table)
+	for _, hook := range []string{"forward", "output"} {
+		fmt.Fprintf(&b, "\tchain %s {\n", hook)
+		fmt.Fprintf(&b, "\t\ttype filter hook %s priority 0; policy accept;\n", hook)
+		fmt.Fprintf(&b, "\t\toifname %q jump instance\n", tapDeviceName)
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\tchain instance {\n")
+	b.WriteString("\t\tct state established,related accept\n")
+	b.WriteString("\t\tether type arp accept\n")
+	b.WriteString("\t\tudp dport 68 accept\n")
+	b.WriteString("\t\ticmpv6 type { nd-neighbor-solicit, nd-neighbor-advert, nd-router-advert } accept\n")
+	for _, match := range matches {
+		if len(tcpPorts) > 0 {
+			fmt.Fprintf(&b, "\t\t%stcp dport { %s } accept\n", match, strings.Join(tcpPorts, ", "))
+		}
+		if len(udpPorts) > 0 {
+			fmt.Fprintf(&b, "\t\t%sudp dport { %s } accept\n", match, strings.Join(udpPorts, ", "))
+		}
+	}
+	b.WriteString("\t\tdrop\n")
+	b.WriteString("\t}\n")
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// firewallRulesetDelete returns the nftables script removing the rules of a tap device, which
+// succeeds even if the device has no rules
+func firewallRulesetDelete(tapDeviceName string) string {
+	table := firewallTable(tapDeviceName)
+	return fmt.Sprintf("add table bridge %s\ndelete table bridge %s\n", table, table)
+}
+
+// instancePorts returns the ports of the instance of a list of forwarded ports; in a range such as
+// "8080-80" the second port is the port of the instance
+func instancePorts(ports []string) ([]string, error) {
+	var result []string
+	seen := make(map[int]bool)
+	for _, list := range ports {
+		for _, p := range strings.Split(list, ",") {
+			p = strings.TrimSpace(p)
+			if parts := strings.Split(p, "-"); len(parts) == 2 {
+				p = parts[1]
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("invalid port %q", list)
+			}
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			result = append(result, strconv.Itoa(n))
+		}
+	}
+	return result, nil
+}
+
+// parseSource parses an address or a CIDR
+func parseSource(s string) (*net.IPNet, error) {
+	if _, cidr, err := net.ParseCIDR(s); err == nil {
+		return cidr, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid source address %q", s)
+	}
+	if ip.To4() != nil {
+		return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
@@ -0,0 +1,53 @@
+package network_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nanovms/ops/network"
+	"gotest.tools/assert"
+)
+
+func TestFirewallRuleset(t *testing.T) {
+	t.Run("should only accept declared ports of the instance", func(t *testing.T) {
+		ruleset, err := network.FirewallRuleset("tap0", network.FirewallRules{
+			TCPPorts: []string{"80", "8080-8081", "443,80"},
+			UDPPorts: []string{"53"},
+		})
+
+		assert.NilError(t, err)
+		assert.Assert(t, strings.HasPrefix(ruleset, "add table bridge ops-tap0\ndelete table bridge ops-tap0\n"))
+		assert.Assert(t, strings.Contains(ruleset, "\t\toifname \"tap0\" jump instance\n"))
+		assert.Assert(t, strings.Contains(ruleset, "\t\ttcp dport { 80, 8081, 443 } accept\n"))
+		assert.Assert(t, strings.Contains(ruleset, "\t\tudp dport { 53 } accept\n"))
+		assert.Assert(t, strings.HasSuffix(ruleset, "\t\tdrop\n\t}\n}\n"))
+	})
+
+	t.Run("should restrict ports to allowed sources", func(t *testing.T) {
+		ruleset, err := network.FirewallRuleset("tap0", network.FirewallRules{
+			TCPPorts: []string{"80"},
+			Sources:  []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"},
+		})
+
+		assert.NilError(t, err)
+		assert.Assert(t, strings.Contains(ruleset, "\t\tip saddr { 10.0.0.0/8, 192.168.1.10/32 } tcp dport { 80 } accept\n"))
+		assert.Assert(t, strings.Contains(ruleset, "\t\tip6 saddr { fd00::/8 } tcp dport { 80 } accept\n"))
+		assert.Assert(t, !strings.Contains(ruleset, "\t\ttcp dport"))
+	})
+
+	t.Run("should drop everything but replies if no port is declared", func(t *testing.T) {
+		ruleset, err := network.FirewallRuleset("tap0", network.FirewallRules{})
+
+		assert.NilError(t, err)
+		assert.Assert(t, !strings.Contains(ruleset, "dport {"))
+		assert.Assert(t, strings.Contains(ruleset, "\t\tct state established,related accept\n"))
+	})
+
+	t.Run("should reject invalid ports and sources", func(t *testing.T) {
+		_, err := network.FirewallRuleset("tap0", network.FirewallRules{TCPPorts: []string{"http"}})
+		assert.ErrorContains(t, err, "invalid port")
+
+		_, err = network.FirewallRuleset("tap0", network.FirewallRules{TCPPorts: []string{"80"}, Sources: []string{"10.0.0.0/33"}})
+		assert.ErrorContains(t, err, "invalid source address")
+	})
+}
@@ -203,3 +203,26 @@ func (s *IprouteNetworkService) GetNetworkInterfaceIP(ifcName string) (string, e
 
 	return "", nil
 }
+
+// AddFirewallRules replaces the nftables rules filtering the traffic sent to a tap device
+func (s *IprouteNetworkService) AddFirewallRules(tapDeviceName string, rules FirewallRules) (string, error) {
+	ruleset, err := FirewallRuleset(tapDeviceName, rules)
+	if err != nil {
+		return "", err
+	}
+	return nft(ruleset)
+}
+
+// DeleteFirewallRules removes the nftables rules filtering the traffic sent to a tap device
+func (s *IprouteNetworkService) DeleteFirewallRules(tapDeviceName string) (string, error) {
+	return nft(firewallRulesetDelete(tapDeviceName))
+}
+
+// nft runs an nftables script
+func nft(script string) (string, error) {
+	cmd := exec.Command("sudo", "nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.CombinedOutput()
+	output := string(out)
+	return output, err
+}
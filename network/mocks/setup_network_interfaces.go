@@ -12,6 +12,7 @@ package mocks
 import (
 	reflect "reflect"
 
+	network "github.com/nanovms/ops/network"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -53,6 +54,21 @@ func (mr *MockServiceMockRecorder) AddBridge(br any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBridge", reflect.TypeOf((*MockService)(nil).AddBridge), br)
 }
 
+// AddFirewallRules mocks base method.
+func (m *MockService) AddFirewallRules(tapDeviceName string, rules network.FirewallRules) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFirewallRules", tapDeviceName, rules)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFirewallRules indicates an expected call of AddFirewallRules.
+func (mr *MockServiceMockRecorder) AddFirewallRules(tapDeviceName, rules any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFirewallRules", reflect.TypeOf((*MockService)(nil).AddFirewallRules), tapDeviceName, rules)
+}
+
 // AddTap mocks base method.
 func (m *MockService) AddTap(tap string) (string, error) {
 	m.ctrl.T.Helper()
@@ -113,6 +129,21 @@ func (mr *MockServiceMockRecorder) CheckNetworkInterfaceExists(name any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckNetworkInterfaceExists", reflect.TypeOf((*MockService)(nil).CheckNetworkInterfaceExists), name)
 }
 
+// DeleteFirewallRules mocks base method.
+func (m *MockService) DeleteFirewallRules(tapDeviceName string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFirewallRules", tapDeviceName)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFirewallRules indicates an expected call of DeleteFirewallRules.
+func (mr *MockServiceMockRecorder) DeleteFirewallRules(tapDeviceName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFirewallRules", reflect.TypeOf((*MockService)(nil).DeleteFirewallRules), tapDeviceName)
+}
+
 // DeleteNIC mocks base method.
 func (m *MockService) DeleteNIC(ifc string) (string, error) {
 	m.ctrl.T.Helper()
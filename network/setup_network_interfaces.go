@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
+
+	"github.com/nanovms/ops/log"
 )
 
 // Service represents a network service able to apply changes to network configuration
@@ -24,6 +27,8 @@ type Service interface {
 	DeleteNIC(ifc string) (string, error)
 	IsNIUp(ifcName string) (bool, error)
 	GetNetworkInterfaceIP(ifcName string) (string, error)
+	AddFirewallRules(tapDeviceName string, rules FirewallRules) (string, error)
+	DeleteFirewallRules(tapDeviceName string) (string, error)
 }
 
 // SetupNetworkInterfaces changes network configuration to support requirements
@@ -124,9 +129,50 @@ func SetupNetworkInterfaces(network Service, tapDeviceName string, bridgeName st
 	return nil
 }
 
-// TurnOffNetworkInterfaces turns network interfaces off if they aren't used
+// SetupFirewall filters the traffic sent to a tap device, so that only the ports of rules are
+// reachable
+func SetupFirewall(network Service, tapDeviceName string, rules FirewallRules) error {
+	out, err := network.AddFirewallRules(tapDeviceName, rules)
+	if err != nil {
+		if msg := strings.TrimSpace(out); msg != "" {
+			err = errors.New(msg)
+		}
+		return fmt.Errorf("Not able to set up firewall of tap %s: %w", tapDeviceName, err)
+	}
+	return nil
+}
+
+// RemoveTap removes a tap device and its firewall rules
+func RemoveTap(network Service, tapDeviceName string) error {
+	_, err := network.DeleteFirewallRules(tapDeviceName)
+	if err != nil {
+		log.Warnf("Not able to remove firewall rules of tap %s: %v", tapDeviceName, err)
+	}
+
+	tapExists, err := network.CheckNetworkInterfaceExists(tapDeviceName)
+	if err != nil {
+		return errors.New("Not able to check tap exists")
+	}
+
+	if tapExists {
+		_, err = network.DeleteNIC(tapDeviceName)
+		if err != nil {
+			return fmt.Errorf("Not able to delete tap %s", tapDeviceName)
+		}
+	}
+
+	return nil
+}
+
+// TurnOffNetworkInterfaces turns network interfaces off if they aren't used, removing the firewall
+// rules of the tap device when they can be removed
 func TurnOffNetworkInterfaces(network Service, tapDeviceName string, bridgeName string) error {
-	_, err := network.TurnNIDown(tapDeviceName)
+	_, err := network.DeleteFirewallRules(tapDeviceName)
+	if err != nil {
+		log.Warnf("Not able to remove firewall rules of tap %s: %v", tapDeviceName, err)
+	}
+
+	_, err = network.TurnNIDown(tapDeviceName)
 	if err != nil {
 		return errors.New("Not able to turn tap down")
 	}
@@ -1,11 +1,13 @@
 package network_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/nanovms/ops/network"
 	mock_network "github.com/nanovms/ops/network/mocks"
 	"go.uber.org/mock/gomock"
+	"gotest.tools/assert"
 )
 
 func TestSetupNetworkInterfaces(t *testing.T) {
@@ -212,6 +214,150 @@ func TestSetupNetworkInterfaces(t *testing.T) {
 
 }
 
+func TestSetupFirewall(t *testing.T) {
+
+	t.Run("should add the firewall rules of the tap", func(t *testing.T) {
+		networkService := NewNetworkService(t)
+		rules := network.FirewallRules{TCPPorts: []string{"80"}, Sources: []string{"10.0.0.0/8"}}
+
+		networkService.
+			EXPECT().
+			AddFirewallRules("tap0-test", rules).
+			Return("", nil)
+
+		err := network.SetupFirewall(networkService, "tap0-test", rules)
+
+		assert.NilError(t, err)
+	})
+
+	t.Run("should return the output of nft if the rules cannot be added", func(t *testing.T) {
+		networkService := NewNetworkService(t)
+
+		networkService.
+			EXPECT().
+			AddFirewallRules("tap0-test", gomock.Any()).
+			Return("Error: Could not process rule: No such file or directory\n", errors.New("exit status 1"))
+
+		err := network.SetupFirewall(networkService, "tap0-test", network.FirewallRules{})
+
+		assert.ErrorContains(t, err, "Could not process rule")
+	})
+
+}
+
+func TestTurnOffNetworkInterfaces(t *testing.T) {
+
+	t.Run("should remove firewall rules and turn the tap and the unused bridge down", func(t *testing.T) {
+		networkService := NewNetworkService(t)
+		tapDeviceName := "tap0-test"
+		bridgeName := "br0-test"
+
+		gomock.InOrder(
+			networkService.
+				EXPECT().
+				DeleteFirewallRules(tapDeviceName).
+				Return("", nil),
+			networkService.
+				EXPECT().
+				TurnNIDown(tapDeviceName).
+				Return("", nil),
+		)
+
+		networkService.
+			EXPECT().
+			GetBridgeInterfacesNames(bridgeName).
+			Return([]string{tapDeviceName}, nil)
+
+		networkService.
+			EXPECT().
+			IsNIUp(tapDeviceName).
+			Return(false, nil)
+
+		networkService.
+			EXPECT().
+			TurnNIDown(bridgeName).
+			Return("", nil)
+
+		networkService.
+			EXPECT().
+			FlushIPFromNI(bridgeName).
+			Return("", nil)
+
+		err := network.TurnOffNetworkInterfaces(networkService, tapDeviceName, bridgeName)
+
+		assert.NilError(t, err)
+	})
+
+	t.Run("should turn the tap down if the firewall rules cannot be removed", func(t *testing.T) {
+		networkService := NewNetworkService(t)
+		tapDeviceName := "tap0-test"
+
+		gomock.InOrder(
+			networkService.
+				EXPECT().
+				DeleteFirewallRules(tapDeviceName).
+				Return("", errors.New("nft: command not found")),
+			networkService.
+				EXPECT().
+				TurnNIDown(tapDeviceName).
+				Return("", nil),
+		)
+
+		err := network.TurnOffNetworkInterfaces(networkService, tapDeviceName, "")
+
+		assert.NilError(t, err)
+	})
+
+}
+
+func TestRemoveTap(t *testing.T) {
+
+	t.Run("should remove firewall rules and delete the tap", func(t *testing.T) {
+		networkService := NewNetworkService(t)
+		tapDeviceName := "tap0-test"
+
+		gomock.InOrder(
+			networkService.
+				EXPECT().
+				DeleteFirewallRules(tapDeviceName).
+				Return("", nil),
+			networkService.
+				EXPECT().
+				CheckNetworkInterfaceExists(tapDeviceName).
+				Return(true, nil),
+			networkService.
+				EXPECT().
+				DeleteNIC(tapDeviceName).
+				Return("", nil),
+		)
+
+		err := network.RemoveTap(networkService, tapDeviceName)
+
+		assert.NilError(t, err)
+	})
+
+	t.Run("should not delete a tap which does not exist", func(t *testing.T) {
+		networkService := NewNetworkService(t)
+		tapDeviceName := "tap0-test"
+
+		gomock.InOrder(
+			networkService.
+				EXPECT().
+				DeleteFirewallRules(tapDeviceName).
+				Return("", errors.New("nft: command not found")),
+			networkService.
+				EXPECT().
+				CheckNetworkInterfaceExists(tapDeviceName).
+				Return(false, nil),
+		)
+
+		err := network.RemoveTap(networkService, tapDeviceName)
+
+		assert.NilError(t, err)
+	})
+
+}
+
 func NewNetworkService(t *testing.T) *mock_network.MockService {
 	ctrl := gomock.NewController(t)
 
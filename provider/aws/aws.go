@@ -68,6 +68,17 @@ func (p *AWS) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by AWS
+func (p *AWS) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+		lepton.CapabilityVolumes,
+		lepton.CapabilityDNS,
+		lepton.CapabilityMirror,
+		lepton.CapabilityInstanceGroups,
+	}
+}
+
 // buildAwsTags converts configuration tags to AWS tags and returns the resource name. The defaultName is overridden if there is a tag with key name
 func buildAwsTags(configTags []types.Tag, defaultName string) ([]awsEc2Types.Tag, string) {
 	tags := []awsEc2Types.Tag{}
@@ -3,7 +3,6 @@
 package aws
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -32,13 +31,13 @@ type Cron struct {
 // a *lot* of this shares w/instance create and we should have it
 // share..
 func (p *AWS) CreateCron(ctx *lepton.Context, name string, schedule string) error {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := config.LoadDefaultConfig(ctx.Context(), retryLoadOptions()...)
 	if err != nil {
 		log.Fatalf("failed to load SDK config, %v", err)
 	}
 
 	ctx.Logger().Debug("getting aws images")
-	result, err := getAWSImages(p.execCtx, p.ec2)
+	result, err := getAWSImages(ctx.Context(), p.ec2)
 	if err != nil {
 		ctx.Logger().Errorf("failed getting images")
 		return err
@@ -76,14 +75,14 @@ func (p *AWS) CreateCron(ctx *lepton.Context, name string, schedule string) erro
 	}
 
 	ctx.Logger().Debug("getting vpc")
-	vpc, err := p.GetVPC(p.execCtx, ctx, svc)
+	vpc, err := p.GetVPC(ctx.Context(), ctx, svc)
 	if err != nil {
 		return err
 	}
 
 	if vpc == nil {
 		ctx.Logger().Debugf("creating vpc with name %s", cloudConfig.VPC)
-		vpc, err = p.CreateVPC(p.execCtx, ctx, svc)
+		vpc, err = p.CreateVPC(ctx.Context(), ctx, svc)
 		if err != nil {
 			return err
 		}
@@ -93,14 +92,14 @@ func (p *AWS) CreateCron(ctx *lepton.Context, name string, schedule string) erro
 
 	if cloudConfig.SecurityGroup != "" && cloudConfig.VPC != "" {
 		ctx.Logger().Debugf("getting security group with name %s", cloudConfig.SecurityGroup)
-		sg, err = p.GetSecurityGroup(p.execCtx, ctx, svc, vpc)
+		sg, err = p.GetSecurityGroup(ctx.Context(), ctx, svc, vpc)
 		if err != nil {
 			return err
 		}
 	} else {
 		iname := ctx.Config().RunConfig.InstanceName
 		ctx.Logger().Debugf("creating new security group in vpc %s", *vpc.VpcId)
-		sg, err = p.CreateSG(p.execCtx, ctx, svc, iname, *vpc.VpcId)
+		sg, err = p.CreateSG(ctx.Context(), ctx, svc, iname, *vpc.VpcId)
 		if err != nil {
 			return err
 		}
@@ -108,13 +107,13 @@ func (p *AWS) CreateCron(ctx *lepton.Context, name string, schedule string) erro
 
 	ctx.Logger().Debug("getting subnet")
 	var subnet *awsEc2Types.Subnet
-	subnet, err = p.GetSubnet(p.execCtx, ctx, svc, *vpc.VpcId)
+	subnet, err = p.GetSubnet(ctx.Context(), ctx, svc, *vpc.VpcId)
 	if err != nil {
 		return err
 	}
 
 	if subnet == nil {
-		subnet, err = p.CreateSubnet(p.execCtx, ctx, vpc)
+		subnet, err = p.CreateSubnet(ctx.Context(), ctx, vpc)
 		if err != nil {
 			return err
 		}
@@ -164,7 +163,7 @@ func (p *AWS) CreateCron(ctx *lepton.Context, name string, schedule string) erro
 		},
 	}
 
-	_, err = client.CreateSchedule(context.TODO(), input)
+	_, err = client.CreateSchedule(ctx.Context(), input)
 	if err != nil {
 		log.Fatalf("failed to create schedule, %v", err)
 	}
@@ -175,7 +174,7 @@ func (p *AWS) CreateCron(ctx *lepton.Context, name string, schedule string) erro
 
 // DeleteCron deletes an eventbridge schedule.
 func (p *AWS) DeleteCron(ctx *lepton.Context, schedule string) error {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := config.LoadDefaultConfig(ctx.Context(), retryLoadOptions()...)
 	if err != nil {
 		return err
 	}
@@ -186,7 +185,7 @@ func (p *AWS) DeleteCron(ctx *lepton.Context, schedule string) error {
 		Name: &schedule,
 	}
 
-	_, err = svc.DeleteSchedule(context.TODO(), input)
+	_, err = svc.DeleteSchedule(ctx.Context(), input)
 	if err != nil {
 		if _, ok := err.(*types.ResourceNotFoundException); ok {
 			fmt.Printf("Schedule '%s' not found.\n", schedule)
@@ -209,14 +208,14 @@ func (p *AWS) EnableCron(ctx *lepton.Context, schedule string) error {
 		return err
 	}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := config.LoadDefaultConfig(ctx.Context(), retryLoadOptions()...)
 	if err != nil {
 		return err
 	}
 
 	svc := scheduler.NewFromConfig(cfg)
 
-	_, err = svc.UpdateSchedule(context.TODO(), &scheduler.UpdateScheduleInput{
+	_, err = svc.UpdateSchedule(ctx.Context(), &scheduler.UpdateScheduleInput{
 		Name:               &schedule,
 		ScheduleExpression: cron.ScheduleExpression,
 		Target:             cron.Target,
@@ -237,14 +236,14 @@ func (p *AWS) DisableCron(ctx *lepton.Context, schedule string) error {
 		return err
 	}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := config.LoadDefaultConfig(ctx.Context(), retryLoadOptions()...)
 	if err != nil {
 		return err
 	}
 
 	svc := scheduler.NewFromConfig(cfg)
 
-	_, err = svc.UpdateSchedule(context.TODO(), &scheduler.UpdateScheduleInput{
+	_, err = svc.UpdateSchedule(ctx.Context(), &scheduler.UpdateScheduleInput{
 		Name:               &schedule,
 		ScheduleExpression: cron.ScheduleExpression,
 		Target:             cron.Target,
@@ -296,7 +295,7 @@ func (p *AWS) ListCrons(ctx *lepton.Context) error {
 }
 
 func (p *AWS) getCronByName(ctx *lepton.Context, name string) (*scheduler.GetScheduleOutput, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := config.LoadDefaultConfig(ctx.Context(), retryLoadOptions()...)
 	if err != nil {
 		return &scheduler.GetScheduleOutput{}, err
 	}
@@ -307,13 +306,13 @@ func (p *AWS) getCronByName(ctx *lepton.Context, name string) (*scheduler.GetSch
 		Name: aws.String(name),
 	}
 
-	return svc.GetSchedule(context.TODO(), input)
+	return svc.GetSchedule(ctx.Context(), input)
 }
 
 func (p *AWS) getCrons(ctx *lepton.Context) ([]Cron, error) {
 	var crons []Cron
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	cfg, err := config.LoadDefaultConfig(ctx.Context(), retryLoadOptions()...)
 	if err != nil {
 		return crons, err
 	}
@@ -322,7 +321,7 @@ func (p *AWS) getCrons(ctx *lepton.Context) ([]Cron, error) {
 
 	input := &scheduler.ListSchedulesInput{}
 
-	result, err := svc.ListSchedules(context.TODO(), input)
+	result, err := svc.ListSchedules(ctx.Context(), input)
 	if err != nil {
 		return crons, err
 	}
@@ -343,7 +342,7 @@ func (p *AWS) getCrons(ctx *lepton.Context) ([]Cron, error) {
 
 		for result.NextToken != nil {
 			input.NextToken = result.NextToken
-			result, err = svc.ListSchedules(context.TODO(), input)
+			result, err = svc.ListSchedules(ctx.Context(), input)
 			if err != nil {
 				return crons, err
 			}
@@ -11,13 +11,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	awsRoute53Types "github.com/aws/aws-sdk-go-v2/service/route53/types"
 	"github.com/nanovms/ops/lepton"
-	"github.com/nanovms/ops/types"
 )
 
 // FindOrCreateZoneIDByName searches for a DNS zone with the name passed by argument and if it doesn't exist it creates one
-func (p *AWS) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (string, error) {
+func (p *AWS) FindOrCreateZoneIDByName(ctx *lepton.Context, dnsName string) (string, error) {
 	var zoneID string
-	hostedZones, err := p.dnsService.ListHostedZonesByName(p.execCtx, &route53.ListHostedZonesByNameInput{DNSName: &dnsName})
+	hostedZones, err := p.dnsService.ListHostedZonesByName(ctx.Context(), &route53.ListHostedZonesByNameInput{DNSName: &dnsName})
 	if err == nil && hostedZones.HostedZones == nil {
 		reference := strconv.Itoa(int(time.Now().Unix()))
 
@@ -26,7 +25,7 @@ func (p *AWS) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (st
 			Name:            &dnsName,
 		}
 
-		hostedZone, err := p.dnsService.CreateHostedZone(p.execCtx, createHostedZoneInput)
+		hostedZone, err := p.dnsService.CreateHostedZone(ctx.Context(), createHostedZoneInput)
 		if err != nil {
 			return "", err
 		}
@@ -42,8 +41,8 @@ func (p *AWS) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (st
 }
 
 // DeleteZoneRecordIfExists deletes a record from a DNS zone if it exists
-func (p *AWS) DeleteZoneRecordIfExists(config *types.Config, zoneID string, recordName string) error {
-	records, err := p.dnsService.ListResourceRecordSets(p.execCtx, &route53.ListResourceRecordSetsInput{HostedZoneId: &zoneID})
+func (p *AWS) DeleteZoneRecordIfExists(ctx *lepton.Context, zoneID string, recordName string) error {
+	records, err := p.dnsService.ListResourceRecordSets(ctx.Context(), &route53.ListResourceRecordSetsInput{HostedZoneId: &zoneID})
 	if err != nil {
 		return err
 	}
@@ -62,7 +61,7 @@ func (p *AWS) DeleteZoneRecordIfExists(config *types.Config, zoneID string, reco
 				HostedZoneId: aws.String(zoneID),
 			}
 
-			_, err = p.dnsService.ChangeResourceRecordSets(p.execCtx, input)
+			_, err = p.dnsService.ChangeResourceRecordSets(ctx.Context(), input)
 			if err != nil {
 				return err
 			}
@@ -73,7 +72,7 @@ func (p *AWS) DeleteZoneRecordIfExists(config *types.Config, zoneID string, reco
 }
 
 // CreateZoneRecord creates a record in a DNS zone
-func (p *AWS) CreateZoneRecord(config *types.Config, zoneID string, record *lepton.DNSRecord) error {
+func (p *AWS) CreateZoneRecord(ctx *lepton.Context, zoneID string, record *lepton.DNSRecord) error {
 	input := &route53.ChangeResourceRecordSetsInput{
 		ChangeBatch: &awsRoute53Types.ChangeBatch{
 			Changes: []awsRoute53Types.Change{
@@ -95,7 +94,7 @@ func (p *AWS) CreateZoneRecord(config *types.Config, zoneID string, record *lept
 		HostedZoneId: aws.String(zoneID),
 	}
 
-	_, err := p.dnsService.ChangeResourceRecordSets(p.execCtx, input)
+	_, err := p.dnsService.ChangeResourceRecordSets(ctx.Context(), input)
 	if err != nil {
 		return err
 	}
@@ -106,7 +106,7 @@ func (p *AWS) BuildImageWithPackage(ctx *lepton.Context, pkgpath string) (string
 func (p *AWS) CreateImage(ctx *lepton.Context, imagePath string) error {
 	imageName := ctx.Config().CloudConfig.ImageName
 
-	i, _ := p.findImageByName(imageName)
+	i, _ := p.findImageByName(ctx.Context(), imageName)
 	if i != nil {
 		return fmt.Errorf("failed creating image: image with name %s already exists", imageName)
 	}
@@ -116,7 +116,18 @@ func (p *AWS) CreateImage(ctx *lepton.Context, imagePath string) error {
 	key := c.CloudConfig.ImageName
 
 	ctx.Logger().Info("Creating snapshot")
-	snapshotID, err := p.createSnapshot(&c.CloudConfig.Zone, imagePath, c.CloudConfig.KMS)
+	snapshotID, err := p.createSnapshot(ctx.Context(), &c.CloudConfig.Zone, imagePath, c.CloudConfig.KMS)
+	if snapshotID != "" {
+		done := ctx.AddCleanup("snapshot "+snapshotID, func(ctx *lepton.Context) error {
+			_, err := p.ec2.DeleteSnapshot(ctx.Context(), &ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshotID)})
+			return err
+		})
+		defer func() {
+			if err == nil {
+				done()
+			}
+		}()
+	}
 	if err != nil {
 		return err
 	}
@@ -125,7 +136,7 @@ func (p *AWS) CreateImage(ctx *lepton.Context, imagePath string) error {
 	tags, _ := buildAwsTags(c.CloudConfig.Tags, key)
 
 	ctx.Logger().Info("Tagging snapshot")
-	_, err = p.ec2.CreateTags(p.execCtx, &ec2.CreateTagsInput{
+	_, err = p.ec2.CreateTags(ctx.Context(), &ec2.CreateTagsInput{
 		Resources: []string{snapshotID},
 		Tags:      tags,
 	})
@@ -159,14 +170,23 @@ func (p *AWS) CreateImage(ctx *lepton.Context, imagePath string) error {
 	}
 
 	ctx.Logger().Info("Registering image")
-	resreg, err := p.ec2.RegisterImage(p.execCtx, rinput)
+	resreg, err := p.ec2.RegisterImage(ctx.Context(), rinput)
 	if err != nil {
 		return err
 	}
+	done := ctx.AddCleanup("image "+amiName, func(ctx *lepton.Context) error {
+		_, err := p.ec2.DeregisterImage(ctx.Context(), &ec2.DeregisterImageInput{ImageId: resreg.ImageId})
+		return err
+	})
+	defer func() {
+		if err == nil {
+			done()
+		}
+	}()
 
 	// Add name tag to the created ami
 	ctx.Logger().Info("Tagging image")
-	_, err = p.ec2.CreateTags(p.execCtx, &ec2.CreateTagsInput{
+	_, err = p.ec2.CreateTags(ctx.Context(), &ec2.CreateTagsInput{
 		Resources: []string{aws.ToString(resreg.ImageId)},
 		Tags:      tags,
 	})
@@ -179,7 +199,7 @@ func (p *AWS) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 // MirrorImage copies an image using its imageName from one region to another
 func (p *AWS) MirrorImage(ctx *lepton.Context, imageName, srcRegion, dstRegion string) (string, error) {
-	i, err := p.findImageByNameUsingSession(p.ec2, imageName)
+	i, err := p.findImageByNameUsingSession(ctx.Context(), p.ec2, imageName)
 	if i == nil {
 		return "", fmt.Errorf("no image with name %s found", imageName)
 	}
@@ -187,7 +207,7 @@ func (p *AWS) MirrorImage(ctx *lepton.Context, imageName, srcRegion, dstRegion s
 		return "", fmt.Errorf("error while search for image: %s", err.Error())
 	}
 
-	output, err := p.ec2.CopyImage(p.execCtx, &ec2.CopyImageInput{
+	output, err := p.ec2.CopyImage(ctx.Context(), &ec2.CopyImageInput{
 		Name:          aws.String(imageName),
 		SourceImageId: i.ImageId,
 		SourceRegion:  &srcRegion,
@@ -198,7 +218,7 @@ func (p *AWS) MirrorImage(ctx *lepton.Context, imageName, srcRegion, dstRegion s
 
 	tags, _ := buildAwsTags(ctx.Config().CloudConfig.Tags, imageName)
 
-	_, err = p.ec2.CreateTags(p.execCtx, &ec2.CreateTagsInput{
+	_, err = p.ec2.CreateTags(ctx.Context(), &ec2.CreateTagsInput{
 		Resources: []string{aws.ToString(output.ImageId)},
 		Tags:      tags,
 	})
@@ -211,7 +231,7 @@ func (p *AWS) MirrorImage(ctx *lepton.Context, imageName, srcRegion, dstRegion s
 
 // createSnapshot process create Snapshot to EBS
 // Returns snapshotID and err
-func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string, error) {
+func (p *AWS) createSnapshot(execCtx context.Context, zone *string, imagePath string, kms string) (string, error) {
 	// Open file first
 	f, err := os.Open(imagePath)
 	if err != nil {
@@ -247,7 +267,7 @@ func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string
 		}
 	}
 
-	snapshotOutput, err := p.volumeService.StartSnapshot(p.execCtx, esi)
+	snapshotOutput, err := p.volumeService.StartSnapshot(execCtx, esi)
 	if err != nil {
 		return "", err
 	}
@@ -298,7 +318,7 @@ func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string
 
 		wg.Add(1)
 		sem <- struct{}{}
-		go p.writeToBlock(input, &wg, chanBlockResult, sem)
+		go p.writeToBlock(execCtx, input, &wg, chanBlockResult, sem)
 
 		blockIndex++
 
@@ -323,7 +343,7 @@ func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string
 	<-done
 	close(done)
 
-	if err := p.retryPutSnapshotBlocks(bar, f, snapshotID, &blockResults); err != nil {
+	if err := p.retryPutSnapshotBlocks(execCtx, bar, f, snapshotID, &blockResults); err != nil {
 		fmt.Printf("err in retry %s", err.Error())
 		return snapshotID, err
 	}
@@ -332,7 +352,7 @@ func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string
 	h.Write(snapshotBlocksChecksums)
 	snapshotChecksum := b64.StdEncoding.EncodeToString(h.Sum(nil))
 
-	if _, err := p.volumeService.CompleteSnapshot(p.execCtx, &ebs.CompleteSnapshotInput{
+	if _, err := p.volumeService.CompleteSnapshot(execCtx, &ebs.CompleteSnapshotInput{
 		ChangedBlocksCount:        aws.Int32(int32(blockIndex)),
 		Checksum:                  aws.String(snapshotChecksum),
 		ChecksumAggregationMethod: awsEbsTypes.ChecksumAggregationMethodChecksumAggregationLinear,
@@ -345,7 +365,7 @@ func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string
 
 	bar.Add64(1)
 
-	if err := WaitUntilEc2SnapshotCompleted(p.execCtx, zone, &ec2.DescribeSnapshotsInput{
+	if err := WaitUntilEc2SnapshotCompleted(execCtx, zone, &ec2.DescribeSnapshotsInput{
 		SnapshotIds: []string{*snapshotOutput.SnapshotId},
 	}); err != nil {
 		fmt.Println("errr in wait")
@@ -357,7 +377,7 @@ func (p *AWS) createSnapshot(zone *string, imagePath string, kms string) (string
 
 // retryPutSnapshotBlocks if any error from BlockResults, we get data from the file again and try PutSnapshotBlock sequentially
 // Returns an error
-func (p *AWS) retryPutSnapshotBlocks(bar *progressbar.ProgressBar, f *os.File, snapshotID string, blockResults *PutSnapshotBlockResults) error {
+func (p *AWS) retryPutSnapshotBlocks(execCtx context.Context, bar *progressbar.ProgressBar, f *os.File, snapshotID string, blockResults *PutSnapshotBlockResults) error {
 	var errs []error
 	for _, data := range blockResults.Data {
 		if data.Error != nil {
@@ -369,7 +389,7 @@ func (p *AWS) retryPutSnapshotBlocks(bar *progressbar.ProgressBar, f *os.File, s
 			input, _ := buildSnapshotBlockInput(snapshotID, data.BlockIndex, block)
 
 			log.Debug("RetryPutSnapshotBlock", data.BlockIndex, "PreviousErr", data.Error)
-			if _, err := p.volumeService.PutSnapshotBlock(p.execCtx, input); err != nil {
+			if _, err := p.volumeService.PutSnapshotBlock(execCtx, input); err != nil {
 				errs = append(errs, err)
 			}
 
@@ -382,10 +402,10 @@ func (p *AWS) retryPutSnapshotBlocks(bar *progressbar.ProgressBar, f *os.File, s
 	return nil
 }
 
-func (p *AWS) writeToBlock(input *ebs.PutSnapshotBlockInput, wg *sync.WaitGroup, chanBlockResult chan PutSnapshotBlockResult, sem chan struct{}) {
+func (p *AWS) writeToBlock(execCtx context.Context, input *ebs.PutSnapshotBlockInput, wg *sync.WaitGroup, chanBlockResult chan PutSnapshotBlockResult, sem chan struct{}) {
 	defer func() { <-sem }()
 	defer wg.Done()
-	_, err := p.volumeService.PutSnapshotBlock(p.execCtx, input)
+	_, err := p.volumeService.PutSnapshotBlock(execCtx, input)
 	if err != nil {
 		fmt.Printf("err in putsnapshotblock %s", err.Error())
 	}
@@ -540,7 +560,7 @@ func getAWSImages(execCtx context.Context, ec2Service *ec2.Client) (*ec2.Describ
 func (p *AWS) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
 	var cimages []lepton.CloudImage
 
-	result, err := getAWSImages(p.execCtx, p.ec2)
+	result, err := getAWSImages(ctx.Context(), p.ec2)
 	if err != nil {
 		return nil, err
 	}
@@ -626,7 +646,7 @@ func (p *AWS) ResizeImage(ctx *lepton.Context, imagename string, hbytes string)
 // DeleteImage deletes image from AWS by ami name
 func (p *AWS) DeleteImage(ctx *lepton.Context, imagename string) error {
 	// delete ami by ami name
-	image, err := p.findImageByName(imagename)
+	image, err := p.findImageByName(ctx.Context(), imagename)
 	if err != nil {
 		return fmt.Errorf("error running deregister image operation: %s", err)
 	}
@@ -640,7 +660,7 @@ func (p *AWS) DeleteImage(ctx *lepton.Context, imagename string) error {
 		ImageId: aws.String(amiID),
 		DryRun:  aws.Bool(false),
 	}
-	_, err = p.ec2.DeregisterImage(p.execCtx, params)
+	_, err = p.ec2.DeregisterImage(ctx.Context(), params)
 	if err != nil {
 		return fmt.Errorf("error running deregister image operation: %s", err)
 	}
@@ -650,7 +670,7 @@ func (p *AWS) DeleteImage(ctx *lepton.Context, imagename string) error {
 		SnapshotId: aws.String(snapID),
 		DryRun:     aws.Bool(false),
 	}
-	_, err = p.ec2.DeleteSnapshot(p.execCtx, params2)
+	_, err = p.ec2.DeleteSnapshot(ctx.Context(), params2)
 	if err != nil {
 		return fmt.Errorf("error running snapshot delete: %s", err)
 	}
@@ -658,11 +678,11 @@ func (p *AWS) DeleteImage(ctx *lepton.Context, imagename string) error {
 	return nil
 }
 
-func (p *AWS) findImageByName(name string) (*awsEc2Types.Image, error) {
-	return p.findImageByNameUsingSession(p.ec2, name)
+func (p *AWS) findImageByName(execCtx context.Context, name string) (*awsEc2Types.Image, error) {
+	return p.findImageByNameUsingSession(execCtx, p.ec2, name)
 }
 
-func (p *AWS) findImageByNameUsingSession(ec2Session *ec2.Client, name string) (*awsEc2Types.Image, error) {
+func (p *AWS) findImageByNameUsingSession(execCtx context.Context, ec2Session *ec2.Client, name string) (*awsEc2Types.Image, error) {
 	ec2Filters := []awsEc2Types.Filter{
 		{Name: aws.String("tag:Name"), Values: []string{name}},
 		{Name: aws.String("tag:CreatedBy"), Values: []string{"ops"}},
@@ -672,7 +692,7 @@ func (p *AWS) findImageByNameUsingSession(ec2Session *ec2.Client, name string) (
 		Filters: ec2Filters,
 	}
 
-	result, err := ec2Session.DescribeImages(p.execCtx, input)
+	result, err := ec2Session.DescribeImages(execCtx, input)
 	if err != nil {
 		if aerr, ok := err.(smithy.APIError); ok {
 			switch aerr.ErrorCode() {
@@ -710,12 +730,12 @@ func (p *AWS) getArchiveName(ctx *lepton.Context) string {
 	return imagePath
 }
 
-func (p *AWS) waitSnapshotToBeReady(config *types.Config, importTaskID *string) (*string, error) {
+func (p *AWS) waitSnapshotToBeReady(execCtx context.Context, config *types.Config, importTaskID *string) (*string, error) {
 	taskFilter := &ec2.DescribeImportSnapshotTasksInput{
 		ImportTaskIds: []string{aws.ToString(importTaskID)},
 	}
 
-	_, err := p.ec2.DescribeImportSnapshotTasks(p.execCtx, taskFilter)
+	_, err := p.ec2.DescribeImportSnapshotTasks(execCtx, taskFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -726,7 +746,7 @@ func (p *AWS) waitSnapshotToBeReady(config *types.Config, importTaskID *string)
 	bar := progressbar.New(100)
 	bar.RenderBlank()
 
-	err = WaitUntilEc2SnapshotCompleted(p.execCtx, &config.CloudConfig.Zone, &ec2.DescribeSnapshotsInput{Filters: taskFilter.Filters})
+	err = WaitUntilEc2SnapshotCompleted(execCtx, &config.CloudConfig.Zone, &ec2.DescribeSnapshotsInput{Filters: taskFilter.Filters})
 
 	bar.Set(100)
 	bar.Finish()
@@ -739,7 +759,7 @@ func (p *AWS) waitSnapshotToBeReady(config *types.Config, importTaskID *string)
 
 	fmt.Printf("\nimport done - took %f minutes\n", time.Since(waitStartTime).Minutes())
 
-	describeOutput, err := p.ec2.DescribeImportSnapshotTasks(p.execCtx, taskFilter)
+	describeOutput, err := p.ec2.DescribeImportSnapshotTasks(execCtx, taskFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +90,7 @@ func (p *AWS) StartInstance(ctx *lepton.Context, instanceName string) error {
 		return errors.New("enter instance name")
 	}
 
-	instance, err := p.findInstanceByName(instanceName)
+	instance, err := p.findInstanceByName(ctx.Context(), instanceName)
 	if err != nil {
 		return err
 	}
@@ -101,7 +101,7 @@ func (p *AWS) StartInstance(ctx *lepton.Context, instanceName string) error {
 		},
 	}
 
-	result, err := p.ec2.StartInstances(p.execCtx, input)
+	result, err := p.ec2.StartInstances(ctx.Context(), input)
 
 	if err != nil {
 		if aerr, ok := err.(smithy.APIError); ok {
@@ -128,7 +128,7 @@ func (p *AWS) StopInstance(ctx *lepton.Context, instanceName string) error {
 		return errors.New("enter instance name")
 	}
 
-	instance, err := p.findInstanceByName(instanceName)
+	instance, err := p.findInstanceByName(ctx.Context(), instanceName)
 	if err != nil {
 		return err
 	}
@@ -137,7 +137,7 @@ func (p *AWS) StopInstance(ctx *lepton.Context, instanceName string) error {
 		InstanceIds: []string{aws.ToString(instance.InstanceId)},
 	}
 
-	result, err := p.ec2.StopInstances(p.execCtx, input)
+	result, err := p.ec2.StopInstances(ctx.Context(), input)
 
 	if err != nil {
 		if aerr, ok := err.(smithy.APIError); ok {
@@ -161,7 +161,7 @@ func (p *AWS) StopInstance(ctx *lepton.Context, instanceName string) error {
 // CreateInstance - Creates instance on AWS Platform
 func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 	ctx.Logger().Debug("getting aws images")
-	result, err := getAWSImages(p.execCtx, p.ec2)
+	result, err := getAWSImages(ctx.Context(), p.ec2)
 	if err != nil {
 		ctx.Logger().Errorf("failed getting images")
 		return err
@@ -213,45 +213,51 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 	// create security group - could take a potential 'RemotePort' from
 	// config.json in future
 	ctx.Logger().Debug("getting vpc")
-	vpc, err := p.GetVPC(p.execCtx, ctx, svc)
+	vpc, err := p.GetVPC(ctx.Context(), ctx, svc)
 	if err != nil {
 		return err
 	}
 
 	if vpc == nil {
 		ctx.Logger().Debugf("creating vpc with name %s", cloudConfig.VPC)
-		vpc, err = p.CreateVPC(p.execCtx, ctx, svc)
+		vpc, err = p.CreateVPC(ctx.Context(), ctx, svc)
 		if err != nil {
 			return err
 		}
 	}
 
 	var sg *awsEc2Types.SecurityGroup
+	sgDone := func() {}
 
 	if cloudConfig.SecurityGroup != "" && cloudConfig.VPC != "" {
 		ctx.Logger().Debugf("getting security group with name %s", cloudConfig.SecurityGroup)
-		sg, err = p.GetSecurityGroup(p.execCtx, ctx, svc, vpc)
+		sg, err = p.GetSecurityGroup(ctx.Context(), ctx, svc, vpc)
 		if err != nil {
 			return err
 		}
 	} else {
 		iname := ctx.Config().RunConfig.InstanceName
 		ctx.Logger().Debugf("creating new security group in vpc %s", *vpc.VpcId)
-		sg, err = p.CreateSG(p.execCtx, ctx, svc, iname, *vpc.VpcId)
+		sg, err = p.CreateSG(ctx.Context(), ctx, svc, iname, *vpc.VpcId)
 		if err != nil {
 			return err
 		}
+		// once the instance is created, the security group is deleted with it by DeleteInstance
+		sgDone = ctx.AddCleanup("security group "+aws.ToString(sg.GroupId), func(ctx *lepton.Context) error {
+			p.DeleteSG(ctx.Context(), sg.GroupId)
+			return nil
+		})
 	}
 
 	ctx.Logger().Debug("getting subnet")
 	var subnet *awsEc2Types.Subnet
-	subnet, err = p.GetSubnet(p.execCtx, ctx, svc, *vpc.VpcId)
+	subnet, err = p.GetSubnet(ctx.Context(), ctx, svc, *vpc.VpcId)
 	if err != nil {
 		return err
 	}
 
 	if subnet == nil {
-		subnet, err = p.CreateSubnet(p.execCtx, ctx, vpc)
+		subnet, err = p.CreateSubnet(ctx.Context(), ctx, vpc)
 		if err != nil {
 			return err
 		}
@@ -363,7 +369,7 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 			InstanceType:        cloudConfig.Flavor,
 			Tags:                tags,
 		}
-		if err := p.autoScalingLaunchTemplate(ltInput); err != nil {
+		if err := p.autoScalingLaunchTemplate(ctx.Context(), ltInput); err != nil {
 			log.Errorf("Could not create launch template for auto scaling group %v", err)
 			return err
 		}
@@ -382,11 +388,16 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 
 	// Specify the details of the instance that you want to create.
 	ctx.Logger().Debugf("running instance with input %v", instanceInput)
-	_, err = svc.RunInstances(p.execCtx, instanceInput)
+	_, err = svc.RunInstances(ctx.Context(), instanceInput)
 	if err != nil {
 		log.Errorf("Could not create instance %v", err)
 		return err
 	}
+	sgDone()
+	// the instance is deleted without its DNS record, which may point to another instance
+	done := ctx.AddCleanup("instance "+tagInstanceName, func(ctx *lepton.Context) error {
+		return p.DeleteInstance(ctx, tagInstanceName)
+	})
 
 	log.Info("Created instance", tagInstanceName)
 
@@ -411,7 +422,7 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 				InstanceId: aws.String(instance.ID),
 				PublicIp:   aws.String(cloudConfig.StaticIP),
 			}
-			result, err := svc.AssociateAddress(p.execCtx, input)
+			result, err := svc.AssociateAddress(ctx.Context(), input)
 			if err != nil {
 				log.Errorf("Could not associate elastic IP: %v", err.(smithy.APIError).Error())
 			} else {
@@ -421,6 +432,7 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 			log.Errorf("Could not retrieve instance: %v", err.Error())
 		}
 	}
+	done()
 
 	// create dns zones/records to associate DNS record to instance IP
 	if cloudConfig.DomainName != "" {
@@ -439,7 +451,7 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 
 			if len(instance.PublicIps) != 0 {
 				ctx.Logger().Debugf("creating dns record %s with ip %s", cloudConfig.DomainName, instance.PublicIps[0])
-				err := lepton.CreateDNSRecord(ctx.Config(), instance.PublicIps[0], p)
+				err := lepton.CreateDNSRecord(ctx, instance.PublicIps[0], p)
 				if err != nil {
 					return err
 				}
@@ -454,7 +466,7 @@ func (p *AWS) CreateInstance(ctx *lepton.Context) error {
 
 // GetInstances return all instances on AWS
 func (p *AWS) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
-	cinstances, err := getAWSInstances(p.execCtx, p.ec2, ctx.Config().CloudConfig.Zone, nil)
+	cinstances, err := getAWSInstances(ctx.Context(), p.ec2, ctx.Config().CloudConfig.Zone, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -465,7 +477,7 @@ func (p *AWS) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error)
 func (p *AWS) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.CloudInstance, error) {
 	var filters []awsEc2Types.Filter
 	filters = append(filters, awsEc2Types.Filter{Name: aws.String("tag:Name"), Values: []string{name}})
-	instances, err := getAWSInstances(p.execCtx, p.ec2, ctx.Config().CloudConfig.Zone, filters)
+	instances, err := getAWSInstances(ctx.Context(), p.ec2, ctx.Config().CloudConfig.Zone, filters)
 	if err != nil {
 		return nil, err
 	} else if len(instances) == 0 {
@@ -528,7 +540,7 @@ func (p *AWS) DeleteInstance(ctx *lepton.Context, instanceName string) error {
 		return errors.New("enter instance name")
 	}
 
-	instance, err := p.findInstanceByName(instanceName)
+	instance, err := p.findInstanceByName(ctx.Context(), instanceName)
 	if err != nil {
 		return err
 	}
@@ -539,12 +551,12 @@ func (p *AWS) DeleteInstance(ctx *lepton.Context, instanceName string) error {
 		},
 	}
 
-	sg, err := p.findSGByName(instanceName)
+	sg, err := p.findSGByName(ctx.Context(), instanceName)
 	if err != nil {
 		return err
 	}
 
-	_, err = p.ec2.TerminateInstances(p.execCtx, input)
+	_, err = p.ec2.TerminateInstances(ctx.Context(), input)
 	if err != nil {
 		if aerr, ok := err.(smithy.APIError); ok {
 			switch aerr.ErrorCode() {
@@ -566,12 +578,12 @@ func (p *AWS) DeleteInstance(ctx *lepton.Context, instanceName string) error {
 			},
 		}
 
-		_, err = WaitUntilEc2InstanceTerminated(p.execCtx, p.ec2, i2)
+		_, err = WaitUntilEc2InstanceTerminated(ctx.Context(), p.ec2, i2)
 		if err != nil {
 			fmt.Println(err)
 		}
 
-		p.DeleteSG(p.execCtx, sg.GroupId)
+		p.DeleteSG(ctx.Context(), sg.GroupId)
 	}
 
 	return nil
@@ -598,7 +610,7 @@ func (p *AWS) GetInstanceLogs(ctx *lepton.Context, instanceName string) (string,
 		return "", errors.New("enter instance name")
 	}
 
-	instance, err := p.findInstanceByName(instanceName)
+	instance, err := p.findInstanceByName(ctx.Context(), instanceName)
 	if err != nil {
 		return "", err
 	}
@@ -609,7 +621,7 @@ func (p *AWS) GetInstanceLogs(ctx *lepton.Context, instanceName string) (string,
 		InstanceId: aws.String(*instance.InstanceId),
 	}
 
-	result, err := p.ec2.GetConsoleOutput(p.execCtx, input)
+	result, err := p.ec2.GetConsoleOutput(ctx.Context(), input)
 	if err != nil {
 		if aerr, ok := err.(smithy.APIError); ok {
 			switch aerr.ErrorCode() {
@@ -632,7 +644,7 @@ func (p *AWS) GetInstanceLogs(ctx *lepton.Context, instanceName string) (string,
 	return l, nil
 }
 
-func (p *AWS) findInstanceByName(name string) (*awsEc2Types.Instance, error) {
+func (p *AWS) findInstanceByName(execCtx context.Context, name string) (*awsEc2Types.Instance, error) {
 	filter := []awsEc2Types.Filter{
 		{Name: aws.String("tag:CreatedBy"), Values: []string{"ops"}},
 		{Name: aws.String("tag:Name"), Values: []string{name}},
@@ -642,7 +654,7 @@ func (p *AWS) findInstanceByName(name string) (*awsEc2Types.Instance, error) {
 	request := ec2.DescribeInstancesInput{
 		Filters: filter,
 	}
-	result, err := p.ec2.DescribeInstances(p.execCtx, &request)
+	result, err := p.ec2.DescribeInstances(execCtx, &request)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting instances: %v", err)
 	}
@@ -657,7 +669,7 @@ func (p *AWS) findInstanceByName(name string) (*awsEc2Types.Instance, error) {
 // bit of a hack here
 // can convert to explicit tag
 // currently only returns sgs created by ops
-func (p *AWS) findSGByName(name string) (*awsEc2Types.SecurityGroup, error) {
+func (p *AWS) findSGByName(execCtx context.Context, name string) (*awsEc2Types.SecurityGroup, error) {
 	filter := []awsEc2Types.Filter{
 		{Name: aws.String("tag:ops-created"), Values: []string{"true"}},
 		{Name: aws.String("description"), Values: []string{"security group for " + name}},
@@ -666,7 +678,7 @@ func (p *AWS) findSGByName(name string) (*awsEc2Types.SecurityGroup, error) {
 	request := ec2.DescribeSecurityGroupsInput{
 		Filters: filter,
 	}
-	result, err := p.ec2.DescribeSecurityGroups(p.execCtx, &request)
+	result, err := p.ec2.DescribeSecurityGroups(execCtx, &request)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting security group: %v", err)
 	}
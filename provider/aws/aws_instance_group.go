@@ -3,6 +3,7 @@
 package aws
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -50,25 +51,25 @@ func (lti LaunchTemplateInput) launchTemplateInstanceNetworkInterfaceSpecificati
 
 // autoScalingLaunchTemplate
 // the steps create launchTemplate - modify launchTemplate to default version and update launchTemplate to auto scaling group
-func (p *AWS) autoScalingLaunchTemplate(req *LaunchTemplateInput) error {
-	result, err := p.createLaunchTemplate(req)
+func (p *AWS) autoScalingLaunchTemplate(execCtx context.Context, req *LaunchTemplateInput) error {
+	result, err := p.createLaunchTemplate(execCtx, req)
 	if err != nil {
 		return err
 	}
 
 	log.Info("Created launch template", *result.LaunchTemplate.LaunchTemplateName)
 
-	if err := p.modifyLaunchTemplate(fmt.Sprintf("%d", *result.LaunchTemplate.LatestVersionNumber), req.LaunchTemplateName); err != nil {
+	if err := p.modifyLaunchTemplate(execCtx, fmt.Sprintf("%d", *result.LaunchTemplate.LatestVersionNumber), req.LaunchTemplateName); err != nil {
 		return err
 	}
 
-	return p.updateAutoScalingGroup(req.AutoScalingGroup, req.LaunchTemplateName)
+	return p.updateAutoScalingGroup(execCtx, req.AutoScalingGroup, req.LaunchTemplateName)
 }
 
 // createLaunchTemplate
 // build CreateLaunchTemplateInput struct from LaunchTemplateInput
 // and call CreateLaunchTemplate API for Amazon Elastic Compute Cloud.
-func (p *AWS) createLaunchTemplate(req *LaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
+func (p *AWS) createLaunchTemplate(execCtx context.Context, req *LaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
 	input := &ec2.CreateLaunchTemplateInput{
 		LaunchTemplateData: &awsEc2Types.RequestLaunchTemplateData{
 			BlockDeviceMappings: []awsEc2Types.LaunchTemplateBlockDeviceMappingRequest{
@@ -105,22 +106,22 @@ func (p *AWS) createLaunchTemplate(req *LaunchTemplateInput) (*ec2.CreateLaunchT
 		}
 	}
 
-	return p.ec2.CreateLaunchTemplate(p.execCtx, input)
+	return p.ec2.CreateLaunchTemplate(execCtx, input)
 }
 
 // modifyLaunchTemplate from one version to DefaultVersion
-func (p *AWS) modifyLaunchTemplate(version string, ltName string) error {
+func (p *AWS) modifyLaunchTemplate(execCtx context.Context, version string, ltName string) error {
 	params := &ec2.ModifyLaunchTemplateInput{
 		DefaultVersion:     aws.String(version),
 		LaunchTemplateName: aws.String(ltName),
 	}
-	_, err := p.ec2.ModifyLaunchTemplate(p.execCtx, params)
+	_, err := p.ec2.ModifyLaunchTemplate(execCtx, params)
 	return err
 }
 
 // updateAutoScalingGroup
 // build UpdateAutoScalingGroupInput and Updates the configuration for the specified Auto Scaling group.
-func (p *AWS) updateAutoScalingGroup(asgName string, ltName string) error {
+func (p *AWS) updateAutoScalingGroup(execCtx context.Context, asgName string, ltName string) error {
 	params := &autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: aws.String(asgName),
 		LaunchTemplate: &awsAutoscalingTypes.LaunchTemplateSpecification{
@@ -129,7 +130,7 @@ func (p *AWS) updateAutoScalingGroup(asgName string, ltName string) error {
 		},
 	}
 
-	if _, err := p.asg.UpdateAutoScalingGroup(p.execCtx, params); err != nil {
+	if _, err := p.asg.UpdateAutoScalingGroup(execCtx, params); err != nil {
 		return err
 	}
 
@@ -9,10 +9,13 @@ import (
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	smithy "github.com/aws/smithy-go"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
 )
@@ -21,10 +24,10 @@ import (
 type S3 struct{}
 
 // CopyToBucket copies archive to bucket
-func (s *S3) CopyToBucket(config *types.Config, archPath string) error {
-
+func (s *S3) CopyToBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
 	bucket := config.CloudConfig.BucketName
-	execCtx := context.Background()
+	execCtx := ctx.Context()
 	awsSdkConfig, err := GetAwsSdkConfig(execCtx, &config.CloudConfig.Zone)
 
 	// this verification/role creator can be skipped for users that
@@ -44,7 +47,12 @@ func (s *S3) CopyToBucket(config *types.Config, archPath string) error {
 	}
 	defer file.Close()
 
-	s3Client := s3.NewFromConfig(*awsSdkConfig)
+	// the parts of the upload which are streamed cannot be replayed by the retry transport, so the
+	// upload is sent through a plain transport and retried by the sdk, which seeks the file
+	s3Client := s3.NewFromConfig(*awsSdkConfig, func(o *s3.Options) {
+		o.HTTPClient = awshttp.NewBuildableClient()
+		o.Retryer = retry.NewStandard()
+	})
 
 	fileStats, _ := file.Stat()
 	log.Info("Uploading image with", fmt.Sprintf("%fMB", float64(fileStats.Size())/math.Pow(10, 6)))
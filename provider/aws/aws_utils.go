@@ -5,21 +5,24 @@ package aws
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	awsEc2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	smithyTime "github.com/aws/smithy-go/time"
 	smithyWaiter "github.com/aws/smithy-go/waiter"
+	"github.com/nanovms/ops/lepton"
 )
 
 // GetAwsSdkConfig creates and return an aws-sdk-v2 configuration
 // object.
 func GetAwsSdkConfig(execCtx context.Context, zone *string) (*aws.Config, error) {
-	awsSdkConfigOpts := []func(*awsConfig.LoadOptions) error{}
+	awsSdkConfigOpts := retryLoadOptions()
 	awsProfile := os.Getenv("AWS_PROFILE")
 	if awsProfile != "" {
 		awsSdkConfigOpts = append(awsSdkConfigOpts, awsConfig.WithSharedConfigProfile(awsProfile))
@@ -36,6 +39,20 @@ func GetAwsSdkConfig(execCtx context.Context, zone *string) (*aws.Config, error)
 	return &awsSdkConfig, nil
 }
 
+// retryLoadOptions returns the options sending the calls of the sdk through the retry transport of
+// ops, the sdk retries being disabled so that calls are not retried twice. Like the retryer of the
+// sdk, the transport retries every call which failed transiently, as the query APIs send every call
+// as a POST.
+func retryLoadOptions() []func(*awsConfig.LoadOptions) error {
+	transport := lepton.NewRetryTransport(ProviderName, awshttp.NewBuildableClient().GetTransport())
+	transport.Idempotent = lepton.AllIdempotent
+
+	return []func(*awsConfig.LoadOptions) error{
+		awsConfig.WithHTTPClient(&http.Client{Transport: transport}),
+		awsConfig.WithRetryer(func() aws.Retryer { return aws.NopRetryer{} }),
+	}
+}
+
 // WaitUntilEc2InstanceTerminated waits until an EC2 instance is terminated. This is a blocking operation on the provided execution context.
 // Ensure proper filters since this function expects a single EC2 given
 // the input.
@@ -3,6 +3,7 @@
 package aws
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -39,13 +40,16 @@ func (a *AWS) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data strin
 
 	config.CloudConfig.ImageName = vol.Name
 
-	err = a.Storage.CopyToBucket(config, vol.Path)
+	err = a.Storage.CopyToBucket(ctx, vol.Path)
 	if err != nil {
 		return vol, fmt.Errorf("copy volume archive to aws bucket: %v", err)
 	}
 
 	bucket := config.CloudConfig.BucketName
 	key := vol.Name
+	archiveDone := ctx.AddCleanup("volume archive "+key, func(ctx *lepton.Context) error {
+		return a.Storage.DeleteFromBucket(ctx.Context(), config, key)
+	})
 
 	input := &ec2.ImportSnapshotInput{
 		Description: aws.String("name"),
@@ -59,21 +63,31 @@ func (a *AWS) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data strin
 		},
 	}
 
-	res, err := a.ec2.ImportSnapshot(a.execCtx, input)
+	res, err := a.ec2.ImportSnapshot(ctx.Context(), input)
 	if err != nil {
 		return vol, fmt.Errorf("import snapshot: %v", err)
 	}
+	importDone := ctx.AddCleanup("snapshot import "+aws.ToString(res.ImportTaskId), func(ctx *lepton.Context) error {
+		_, err := a.ec2.CancelImportTask(ctx.Context(), &ec2.CancelImportTaskInput{ImportTaskId: res.ImportTaskId})
+		return err
+	})
 
-	snapshotID, err := a.waitSnapshotToBeReady(config, res.ImportTaskId)
+	snapshotID, err := a.waitSnapshotToBeReady(ctx.Context(), config, res.ImportTaskId)
 	if err != nil {
 		return vol, err
 	}
+	importDone()
+	snapshotDone := ctx.AddCleanup("snapshot "+aws.ToString(snapshotID), func(ctx *lepton.Context) error {
+		_, err := a.ec2.DeleteSnapshot(ctx.Context(), &ec2.DeleteSnapshotInput{SnapshotId: snapshotID})
+		return err
+	})
 
 	// delete the tmp s3 volume
-	err = a.Storage.DeleteFromBucket(a.execCtx, config, key)
+	err = a.Storage.DeleteFromBucket(ctx.Context(), config, key)
 	if err != nil {
 		return vol, err
 	}
+	archiveDone()
 
 	// Create tags to assign to the volume
 	tags, _ := buildAwsTags(config.CloudConfig.Tags, cv.Name)
@@ -115,10 +129,11 @@ func (a *AWS) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data strin
 	if sizeInGb != 0 {
 		createVolumeInput.Size = &sizeInGb
 	}
-	_, err = a.ec2.CreateVolume(a.execCtx, createVolumeInput)
+	_, err = a.ec2.CreateVolume(ctx.Context(), createVolumeInput)
 	if err != nil {
 		return vol, fmt.Errorf("create aws volume: %v", err)
 	}
+	snapshotDone()
 
 	return vol, nil
 }
@@ -133,7 +148,7 @@ func (a *AWS) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, error)
 		},
 	}
 
-	output, err := a.ec2.DescribeVolumes(a.execCtx, input)
+	output, err := a.ec2.DescribeVolumes(ctx.Context(), input)
 	if err != nil {
 		return nil, err
 	}
@@ -170,7 +185,7 @@ func (a *AWS) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, error)
 
 // DeleteVolume deletes a volume
 func (a *AWS) DeleteVolume(ctx *lepton.Context, name string) error {
-	vol, err := a.findVolumeByName(name)
+	vol, err := a.findVolumeByName(ctx.Context(), name)
 	if err != nil {
 		return err
 	}
@@ -178,7 +193,7 @@ func (a *AWS) DeleteVolume(ctx *lepton.Context, name string) error {
 	input := &ec2.DeleteVolumeInput{
 		VolumeId: aws.String(*vol.VolumeId),
 	}
-	_, err = a.ec2.DeleteVolume(a.execCtx, input)
+	_, err = a.ec2.DeleteVolume(ctx.Context(), input)
 	if err != nil {
 		return err
 	}
@@ -188,12 +203,12 @@ func (a *AWS) DeleteVolume(ctx *lepton.Context, name string) error {
 
 // AttachVolume attaches a volume to an instance
 func (a *AWS) AttachVolume(ctx *lepton.Context, instanceName, name string, attachID int) error {
-	vol, err := a.findVolumeByName(name)
+	vol, err := a.findVolumeByName(ctx.Context(), name)
 	if err != nil {
 		return err
 	}
 
-	instance, err := a.findInstanceByName(instanceName)
+	instance, err := a.findInstanceByName(ctx.Context(), instanceName)
 	if err != nil {
 		return err
 	}
@@ -231,7 +246,7 @@ func (a *AWS) AttachVolume(ctx *lepton.Context, instanceName, name string, attac
 		InstanceId: aws.String(*instance.InstanceId),
 		VolumeId:   aws.String(*vol.VolumeId),
 	}
-	_, err = a.ec2.AttachVolume(a.execCtx, input)
+	_, err = a.ec2.AttachVolume(ctx.Context(), input)
 	if err != nil {
 		return err
 	}
@@ -241,12 +256,12 @@ func (a *AWS) AttachVolume(ctx *lepton.Context, instanceName, name string, attac
 
 // DetachVolume detachs a volume from an instance
 func (a *AWS) DetachVolume(ctx *lepton.Context, instanceName, name string) error {
-	vol, err := a.findVolumeByName(name)
+	vol, err := a.findVolumeByName(ctx.Context(), name)
 	if err != nil {
 		return err
 	}
 
-	instance, err := a.findInstanceByName(instanceName)
+	instance, err := a.findInstanceByName(ctx.Context(), instanceName)
 	if err != nil {
 		return err
 	}
@@ -256,7 +271,7 @@ func (a *AWS) DetachVolume(ctx *lepton.Context, instanceName, name string) error
 		VolumeId:   aws.String(*vol.VolumeId),
 	}
 
-	_, err = a.ec2.DetachVolume(a.execCtx, input)
+	_, err = a.ec2.DetachVolume(ctx.Context(), input)
 	if err != nil {
 		return err
 	}
@@ -264,14 +279,14 @@ func (a *AWS) DetachVolume(ctx *lepton.Context, instanceName, name string) error
 	return nil
 }
 
-func (a *AWS) findVolumeByName(name string) (*awsEc2Types.Volume, error) {
+func (a *AWS) findVolumeByName(execCtx context.Context, name string) (*awsEc2Types.Volume, error) {
 	input := &ec2.DescribeVolumesInput{
 		Filters: []awsEc2Types.Filter{
 			{Name: aws.String("tag:CreatedBy"), Values: []string{"ops"}},
 		},
 	}
 
-	output, err := a.ec2.DescribeVolumes(a.execCtx, input)
+	output, err := a.ec2.DescribeVolumes(execCtx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -5,11 +5,14 @@ package azure
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
 
@@ -132,9 +135,37 @@ func (a *Azure) GetResourceManagementAuthorizer() (autorest.Authorizer, error) {
 	return armAuthorizer, err
 }
 
+// retryHTTPClient returns an http client retrying the calls of the azure apis; like the retries of
+// the sdks, it retries every call which failed transiently, including the actions on resources
+// which are POST, such as starting or stopping a vm
+func retryHTTPClient() *http.Client {
+	transport := lepton.NewRetryTransport(ProviderName, nil)
+	transport.Idempotent = lepton.AllIdempotent
+	return &http.Client{Transport: transport}
+}
+
+// withRetries sends the calls of an autorest client through the retry transport of ops, the retries
+// of autorest being disabled so that calls are not retried twice
+func withRetries(client *autorest.Client) {
+	client.Sender = retryHTTPClient()
+	client.RetryAttempts = 0
+}
+
+// armClientOptions returns the options sending the calls of the arm clients through the retry
+// transport of ops, the retries of the sdk being disabled
+func armClientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Transport: retryHTTPClient(),
+			Retry:     policy.RetryOptions{MaxRetries: -1},
+		},
+	}
+}
+
 func (a *Azure) getImagesClient() *compute.ImagesClient {
 	vmClient := compute.NewImagesClientWithBaseURI(compute.DefaultBaseURI, a.subID)
 	vmClient.Authorizer = *a.authorizer
+	withRetries(&vmClient.Client)
 	vmClient.AddToUserAgent(userAgent)
 	return &vmClient
 }
@@ -142,6 +173,7 @@ func (a *Azure) getImagesClient() *compute.ImagesClient {
 func (a *Azure) getVMClient() *compute.VirtualMachinesClient {
 	vmClient := compute.NewVirtualMachinesClient(a.subID)
 	vmClient.Authorizer = *a.authorizer
+	withRetries(&vmClient.Client)
 	vmClient.AddToUserAgent(userAgent)
 	return &vmClient
 }
@@ -149,6 +181,7 @@ func (a *Azure) getVMClient() *compute.VirtualMachinesClient {
 func (a *Azure) getVMExtensionsClient() compute.VirtualMachineExtensionsClient {
 	extClient := compute.NewVirtualMachineExtensionsClient(a.subID)
 	extClient.Authorizer = *a.authorizer
+	withRetries(&extClient.Client)
 	extClient.AddToUserAgent(userAgent)
 	return extClient
 }
@@ -243,7 +276,7 @@ func (a *Azure) Initialize(config *types.ProviderConfig) error {
 		log.Fatal(err)
 	}
 
-	a.clientFactory, err = armcompute.NewClientFactory(a.subID, a.cred, nil)
+	a.clientFactory, err = armcompute.NewClientFactory(a.subID, a.cred, armClientOptions())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -251,6 +284,15 @@ func (a *Azure) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by Azure
+func (a *Azure) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+		lepton.CapabilityVolumes,
+		lepton.CapabilityDNS,
+	}
+}
+
 func (a *Azure) getBucketName() (string, error) {
 	if a.storageAccount != "" {
 		return a.storageAccount, nil
@@ -3,22 +3,19 @@
 package azure
 
 import (
-	"context"
-
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2018-05-01/dns"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/nanovms/ops/lepton"
-	"github.com/nanovms/ops/types"
 )
 
 // FindOrCreateZoneIDByName searches for a DNS zone with the name passed by argument and if it doesn't exist it creates one
-func (a *Azure) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (string, error) {
+func (a *Azure) FindOrCreateZoneIDByName(ctx *lepton.Context, dnsName string) (string, error) {
 	service := dns.NewZonesClient(a.subID)
 	authr, _ := a.GetResourceManagementAuthorizer()
 	service.Authorizer = authr
+	withRetries(&service.Client)
 
-	ctx := context.TODO()
-	zonesListResponse, err := service.List(ctx, nil)
+	zonesListResponse, err := service.List(ctx.Context(), nil)
 	if err != nil {
 		return "", err
 	}
@@ -31,7 +28,7 @@ func (a *Azure) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (
 			Location: &location,
 		}
 
-		_, err := service.CreateOrUpdate(ctx, a.groupName, dnsName, zone, "", "")
+		_, err := service.CreateOrUpdate(ctx.Context(), a.groupName, dnsName, zone, "", "")
 		if err != nil {
 			return "", err
 		}
@@ -41,22 +38,22 @@ func (a *Azure) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (
 }
 
 // DeleteZoneRecordIfExists deletes a record from a DNS zone if it exists
-func (a *Azure) DeleteZoneRecordIfExists(config *types.Config, zoneID string, recordName string) error {
+func (a *Azure) DeleteZoneRecordIfExists(ctx *lepton.Context, zoneID string, recordName string) error {
 	return nil
 }
 
 // CreateZoneRecord creates a record in a DNS zone
-func (a *Azure) CreateZoneRecord(config *types.Config, zoneID string, record *lepton.DNSRecord) error {
+func (a *Azure) CreateZoneRecord(ctx *lepton.Context, zoneID string, record *lepton.DNSRecord) error {
 	service := dns.NewRecordSetsClient(a.subID)
 	authr, _ := a.GetResourceManagementAuthorizer()
 	service.Authorizer = authr
+	withRetries(&service.Client)
 
 	// remove trailing dot if it exists
 	if record.Name[len(record.Name)-1] == '.' {
 		record.Name = record.Name[:len(record.Name)-1]
 	}
 
-	ctx := context.TODO()
 	dnsRecord := dns.RecordSet{
 		Name: &record.Name,
 		Type: &record.Type,
@@ -68,7 +65,7 @@ func (a *Azure) CreateZoneRecord(config *types.Config, zoneID string, record *le
 		},
 	}
 
-	_, err := service.CreateOrUpdate(ctx, a.groupName, zoneID, record.Name, dns.RecordType(record.Type), dnsRecord, "", "")
+	_, err := service.CreateOrUpdate(ctx.Context(), a.groupName, zoneID, record.Name, dns.RecordType(record.Type), dnsRecord, "", "")
 	if err != nil {
 		return err
 	}
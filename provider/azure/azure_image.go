@@ -98,7 +98,7 @@ func (a *Azure) container() string {
 
 // CreateImage - Creates image on Azure using nanos images
 func (a *Azure) CreateImage(ctx *lepton.Context, imagePath string) error {
-	err := a.Storage.CopyToBucket(ctx.Config(), imagePath)
+	err := a.Storage.CopyToBucket(ctx, imagePath)
 	if err != nil {
 		return err
 	}
@@ -106,6 +106,12 @@ func (a *Azure) CreateImage(ctx *lepton.Context, imagePath string) error {
 	c := ctx.Config()
 	imgName := c.CloudConfig.ImageName
 
+	// the blob, snapshot and gallery image are named after the image, the deletions of those
+	// which were not created yet failing harmlessly
+	blobDone := ctx.AddCleanup("image blob "+imgName+".vhd", func(ctx *lepton.Context) error {
+		return a.Storage.DeleteFromBucket(ctx, imgName+".vhd")
+	})
+
 	bucket, err := a.getBucketName()
 	if err != nil {
 		return err
@@ -118,7 +124,7 @@ func (a *Azure) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	location := a.getLocation(ctx.Config())
 
-	ctx2 := context.Background()
+	ctx2 := ctx.Context()
 
 	dName := c.CloudConfig.ImageName + ".vhd"
 
@@ -134,32 +140,42 @@ func (a *Azure) CreateImage(ctx *lepton.Context, imagePath string) error {
 		}
 	}
 
+	snapshotDone := ctx.AddCleanup("image snapshot "+imgName, func(ctx *lepton.Context) error {
+		return a.deleteSnapshot(ctx.Context(), imgName)
+	})
 	snapshot, err := a.createSnapshot(ctx2, location, dName, c.CloudConfig.ImageName)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	ctx.Logger().Debugf("snapshot: %+v", *snapshot.ID)
 
 	gallery, err := a.createGallery(ctx2, location)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	ctx.Logger().Debugf("gallery:", *gallery.ID)
 
 	flavor := c.CloudConfig.Flavor
 
+	// the version of the gallery image is created last, it is deleted with the gallery image
+	galleryImageDone := ctx.AddCleanup("gallery image "+imgName, func(ctx *lepton.Context) error {
+		return a.deleteGalleryImage(ctx.Context(), imgName)
+	})
 	galleryImage, err := a.createGalleryImage(ctx2, location, c.CloudConfig.ImageName, flavor)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	ctx.Logger().Debugf("gallery image:", *galleryImage.ID)
 
 	galleryImageVersion, err := a.createGalleryImageVersion(ctx2, location, c.CloudConfig.ImageName, uri)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	ctx.Logger().Debugf("gallery image version:", *galleryImageVersion.ID)
 
+	galleryImageDone()
+	snapshotDone()
+	blobDone()
 	return nil
 }
 
@@ -169,7 +185,7 @@ func (a *Azure) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudIma
 
 	pager := a.clientFactory.NewGalleryImagesClient().NewListByGalleryPager(a.groupName, a.galleryName(), nil)
 	for pager.More() {
-		page, err := pager.NextPage(context.TODO())
+		page, err := pager.NextPage(ctx.Context())
 		if err != nil {
 			log.Fatalf("failed to advance page: %v", err)
 		}
@@ -238,7 +254,7 @@ func (a *Azure) DeleteImage(ctx *lepton.Context, imagename string) error {
 	// snapshot
 	// can/should we delete this on import?
 
-	ctx2 := context.Background()
+	ctx2 := ctx.Context()
 
 	// gallery image version
 	givp, err := a.clientFactory.NewGalleryImageVersionsClient().BeginDelete(ctx2, a.groupName, a.galleryName(), imagename, "1.0.0", nil)
@@ -275,7 +291,7 @@ func (a *Azure) DeleteImage(ctx *lepton.Context, imagename string) error {
 	}
 	ctx.Logger().Debug("deleted snapshot")
 
-	err = a.Storage.DeleteFromBucket(ctx.Config(), imagename+".vhd")
+	err = a.Storage.DeleteFromBucket(ctx, imagename+".vhd")
 	if err != nil {
 		return err
 	}
@@ -283,6 +299,34 @@ func (a *Azure) DeleteImage(ctx *lepton.Context, imagename string) error {
 	return nil
 }
 
+// deleteSnapshot deletes the snapshot of an image
+func (a *Azure) deleteSnapshot(ctx context.Context, imageName string) error {
+	poller, err := a.clientFactory.NewSnapshotsClient().BeginDelete(ctx, a.groupName, imageName, nil)
+	if err != nil {
+		return err
+	}
+	_, err = poller.PollUntilDone(ctx, nil)
+	return err
+}
+
+// deleteGalleryImage deletes the gallery image of an image, with its version
+func (a *Azure) deleteGalleryImage(ctx context.Context, imageName string) error {
+	versionPoller, err := a.clientFactory.NewGalleryImageVersionsClient().BeginDelete(ctx, a.groupName, a.galleryName(), imageName, "1.0.0", nil)
+	if err != nil {
+		return err
+	}
+	if _, err = versionPoller.PollUntilDone(ctx, nil); err != nil {
+		return err
+	}
+
+	poller, err := a.clientFactory.NewGalleryImagesClient().BeginDelete(ctx, a.groupName, a.galleryName(), imageName, nil)
+	if err != nil {
+		return err
+	}
+	_, err = poller.PollUntilDone(ctx, nil)
+	return err
+}
+
 func (a *Azure) createSnapshot(ctx context.Context, location string, dName string, imageName string) (*armcompute.Snapshot, error) {
 	snapshotsClient := a.clientFactory.NewSnapshotsClient()
 
@@ -76,74 +76,90 @@ func (a *Azure) CreateInstance(ctx *lepton.Context) error {
 	var vnet *network.VirtualNetwork
 	configVPC := ctx.Config().CloudConfig.VPC
 	if configVPC != "" {
-		vnet, err = a.GetVPC(configVPC)
+		vnet, err = a.GetVPC(ctx.Context(), configVPC)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return fmt.Errorf("error getting virtual network with id %s", configVPC)
 		}
 	} else {
 		ctx.Logger().Infof("creating virtual network with id %s", vmName)
-		vnet, err = a.CreateVirtualNetwork(context.TODO(), location, vmName, c)
+		vnet, err = a.CreateVirtualNetwork(ctx.Context(), location, vmName, c)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("error creating virtual network")
 		}
 	}
 
+	// the resources created for the instance are deleted if the creation is cancelled, the most
+	// recent first
+	var dones []func()
+
 	// create nsg
 	var nsg *network.SecurityGroup
 	configSecurityGroup := ctx.Config().CloudConfig.SecurityGroup
 	if configSecurityGroup != "" {
-		nsg, err = a.GetNetworkSecurityGroup(context.TODO(), configSecurityGroup)
+		nsg, err = a.GetNetworkSecurityGroup(ctx.Context(), configSecurityGroup)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("error getting security group")
 		}
 	} else {
 		ctx.Logger().Infof("creating network security group with id %s", vmName)
-		nsg, err = a.CreateNetworkSecurityGroup(context.TODO(), location, vmName, c)
+		nsg, err = a.CreateNetworkSecurityGroup(ctx.Context(), location, vmName, c)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("error creating network security group")
 		}
+		dones = append(dones, ctx.AddCleanup("network security group "+vmName, func(ctx *lepton.Context) error {
+			return a.DeleteNetworkSecurityGroup(ctx, *nsg.ID)
+		}))
 	}
 
 	// create subnet
 	var subnet *network.Subnet
 	configSubnet := ctx.Config().CloudConfig.Subnet
 	if configSubnet != "" {
-		subnet, err = a.GetVirtualNetworkSubnet(context.TODO(), *vnet.Name, configSubnet)
+		subnet, err = a.GetVirtualNetworkSubnet(ctx.Context(), *vnet.Name, configSubnet)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("error getting subnet")
 		}
 	} else {
 		ctx.Logger().Infof("creating subnet with id %s", vmName)
-		subnet, err = a.CreateSubnetWithNetworkSecurityGroup(context.TODO(), *vnet.Name, vmName, "10.0.0.0/24", *nsg.Name, c)
+		subnet, err = a.CreateSubnetWithNetworkSecurityGroup(ctx.Context(), *vnet.Name, vmName, "10.0.0.0/24", *nsg.Name, c)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("error creating subnet")
 		}
+		dones = append(dones, ctx.AddCleanup("subnet "+vmName, func(ctx *lepton.Context) error {
+			return a.DeleteSubnetwork(ctx, *subnet.ID)
+		}))
 	}
 
 	// create ip
 	ctx.Logger().Infof("creating public ip with id %s", vmName)
-	ip, err := a.CreatePublicIP(context.TODO(), location, vmName, false)
+	ip, err := a.CreatePublicIP(ctx.Context(), location, vmName, false)
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("error creating public ip")
 	}
+	dones = append(dones, ctx.AddCleanup("public ip "+*ip.Name, func(ctx *lepton.Context) error {
+		return a.deletePublicIP(ctx, &ip)
+	}))
 
 	var ipv6Name network.PublicIPAddress
 	v6name := ""
 
 	if ctx.Config().CloudConfig.EnableIPv6 {
 		ctx.Logger().Infof("creating public ip with id %s", vmName)
-		ipv6Name, err = a.CreatePublicIP(context.TODO(), location, vmName, true)
+		ipv6Name, err = a.CreatePublicIP(ctx.Context(), location, vmName, true)
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("error creating public ip")
 		}
+		dones = append(dones, ctx.AddCleanup("public ip "+*ipv6Name.Name, func(ctx *lepton.Context) error {
+			return a.deletePublicIP(ctx, &ipv6Name)
+		}))
 
 		if ipv6Name.Name != nil {
 			v6name = *ipv6Name.Name
@@ -155,11 +171,14 @@ func (a *Azure) CreateInstance(ctx *lepton.Context) error {
 	enableIPForwarding := c.RunConfig.CanIPForward
 	ctx.Logger().Infof("creating network interface controller with id %s", vmName)
 
-	nic, err := a.CreateNIC(context.TODO(), location, *vnet.Name, *subnet.Name, *nsg.Name, *ip.Name, v6name, vmName, enableIPForwarding, c)
+	nic, err := a.CreateNIC(ctx.Context(), location, *vnet.Name, *subnet.Name, *nsg.Name, *ip.Name, v6name, vmName, enableIPForwarding, c)
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("error creating network interface controller")
 	}
+	dones = append(dones, ctx.AddCleanup("network interface controller "+vmName, func(ctx *lepton.Context) error {
+		return a.DeleteNIC(ctx, &nic)
+	}))
 
 	sshKeyData := fakepubkey
 
@@ -173,7 +192,7 @@ func (a *Azure) CreateInstance(ctx *lepton.Context) error {
 		flavor = armcompute.VirtualMachineSizeTypesStandardB1S
 	}
 
-	computeClientFactory, err := armcompute.NewClientFactory(a.subID, a.cred, nil)
+	computeClientFactory, err := armcompute.NewClientFactory(a.subID, a.cred, armClientOptions())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -254,22 +273,32 @@ func (a *Azure) CreateInstance(ctx *lepton.Context) error {
 		},
 	}
 
-	ctx2 := context.Background()
+	ctx2 := ctx.Context()
 
 	pollerResponse, err := virtualMachinesClient.BeginCreateOrUpdate(ctx2, a.groupName, vmName, parameters, nil)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return err
 	}
+	// the instance is deleted without its DNS record, which may point to another instance
+	dones = append(dones, ctx.AddCleanup("instance "+vmName, func(ctx *lepton.Context) error {
+		poller, err := virtualMachinesClient.BeginDelete(ctx.Context(), a.groupName, vmName, nil)
+		if err != nil {
+			return err
+		}
+		_, err = poller.PollUntilDone(ctx.Context(), nil)
+		return err
+	}))
 
 	_, err = pollerResponse.PollUntilDone(ctx2, nil)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return err
+	}
+	for _, done := range dones {
+		done()
 	}
 
 	if ctx.Config().CloudConfig.DomainName != "" {
-		err = lepton.CreateDNSRecord(ctx.Config(), *ip.IPAddress, a)
+		err = lepton.CreateDNSRecord(ctx, *ip.IPAddress, a)
 		if err != nil {
 			return err
 		}
@@ -285,7 +314,7 @@ func (a *Azure) GetInstanceByName(ctx *lepton.Context, name string) (vm *lepton.
 	nicClient := a.getNicClient()
 	ipClient := a.getIPClient()
 
-	result, err := vmClient.Get(context.TODO(), a.groupName, name, compute.InstanceViewTypesInstanceView)
+	result, err := vmClient.Get(ctx.Context(), a.groupName, name, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		if management.IsResourceNotFoundError(err) {
 			return nil, lepton.ErrInstanceNotFound(name)
@@ -293,7 +322,7 @@ func (a *Azure) GetInstanceByName(ctx *lepton.Context, name string) (vm *lepton.
 		return nil, err
 	}
 
-	return a.convertToCloudInstance(&result, nicClient, ipClient)
+	return a.convertToCloudInstance(ctx, &result, nicClient, ipClient)
 }
 
 // GetInstances return all instances on Azure
@@ -302,7 +331,7 @@ func (a *Azure) GetInstances(ctx *lepton.Context) (cinstances []lepton.CloudInst
 	nicClient := a.getNicClient()
 	ipClient := a.getIPClient()
 
-	vmlist, err := vmClient.List(context.TODO(), a.groupName, "")
+	vmlist, err := vmClient.List(ctx.Context(), a.groupName, "")
 	if err != nil {
 		return
 	}
@@ -311,7 +340,7 @@ func (a *Azure) GetInstances(ctx *lepton.Context) (cinstances []lepton.CloudInst
 
 	for _, instance := range instances {
 		if hasAzureOpsTags(instance.Tags) {
-			cinstance, err := a.convertToCloudInstance(&instance, nicClient, ipClient)
+			cinstance, err := a.convertToCloudInstance(ctx, &instance, nicClient, ipClient)
 			if err != nil {
 				return nil, err
 			}
@@ -327,7 +356,7 @@ func (a *Azure) GetInstances(ctx *lepton.Context) (cinstances []lepton.CloudInst
 	return
 }
 
-func (a *Azure) convertToCloudInstance(instance *compute.VirtualMachine, nicClient *network.InterfacesClient, ipClient *network.PublicIPAddressesClient) (*lepton.CloudInstance, error) {
+func (a *Azure) convertToCloudInstance(ctx *lepton.Context, instance *compute.VirtualMachine, nicClient *network.InterfacesClient, ipClient *network.PublicIPAddressesClient) (*lepton.CloudInstance, error) {
 	cinstance := lepton.CloudInstance{
 		Name: *instance.Name,
 	}
@@ -338,7 +367,7 @@ func (a *Azure) convertToCloudInstance(instance *compute.VirtualMachine, nicClie
 		nifs := *((*(*instance.VirtualMachineProperties).NetworkProfile).NetworkInterfaces)
 
 		for i := 0; i < len(nifs); i++ {
-			nic, err := nicClient.Get(context.TODO(), a.groupName, cinstance.Name, "")
+			nic, err := nicClient.Get(ctx.Context(), a.groupName, cinstance.Name, "")
 			if err != nil {
 				return nil, err
 			}
@@ -353,7 +382,7 @@ func (a *Azure) convertToCloudInstance(instance *compute.VirtualMachine, nicClie
 		}
 	}
 
-	pubip, err := ipClient.Get(context.TODO(), a.groupName, cinstance.Name, "")
+	pubip, err := ipClient.Get(ctx.Context(), a.groupName, cinstance.Name, "")
 	if err != nil {
 		log.Error(err)
 	}
@@ -406,19 +435,19 @@ func (a *Azure) DeleteInstance(ctx *lepton.Context, instancename string) error {
 	vmClient := a.getVMClient()
 
 	ctx.Logger().Infof("Getting vm with ID %s...", instancename)
-	vm, err := a.GetVM(context.TODO(), instancename)
+	vm, err := a.GetVM(ctx.Context(), instancename)
 	if err != nil {
 		return err
 	}
 
 	ctx.Logger().Infof("Deleting vm with ID %s...", instancename)
-	future, err := vmClient.Delete(context.TODO(), a.groupName, instancename, nil)
+	future, err := vmClient.Delete(ctx.Context(), a.groupName, instancename, nil)
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("unable to delete instance")
 	}
 
-	err = future.WaitForCompletionRef(context.TODO(), vmClient.Client)
+	err = future.WaitForCompletionRef(ctx.Context(), vmClient.Client)
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("error waiting for vm deletion")
@@ -432,7 +461,7 @@ func (a *Azure) DeleteInstance(ctx *lepton.Context, instancename string) error {
 	for _, nicReference := range *vm.NetworkProfile.NetworkInterfaces {
 		nicID := getAzureResourceNameFromID(*nicReference.ID)
 
-		nic, err := nicClient.Get(context.TODO(), a.groupName, nicID, "")
+		nic, err := nicClient.Get(ctx.Context(), a.groupName, nicID, "")
 		if err != nil {
 			ctx.Logger().Error(err)
 			return errors.New("failed getting nic")
@@ -474,7 +503,7 @@ func (a *Azure) StartInstance(ctx *lepton.Context, instancename string) error {
 	vmClient := a.getVMClient()
 
 	fmt.Printf("Starting instance %s", instancename)
-	_, err := vmClient.Start(context.TODO(), a.groupName, instancename)
+	_, err := vmClient.Start(ctx.Context(), a.groupName, instancename)
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("failed starting virtual machine")
@@ -490,7 +519,7 @@ func (a *Azure) StopInstance(ctx *lepton.Context, instancename string) error {
 	// skipShutdown parameter is optional, we are taking its default
 	// value here
 	fmt.Printf("Stopping instance %s", instancename)
-	_, err := vmClient.PowerOff(context.TODO(), a.groupName, instancename, nil)
+	_, err := vmClient.PowerOff(ctx.Context(), a.groupName, instancename, nil)
 	if err != nil {
 		fmt.Printf("cannot power off vm: %v\n", err.Error())
 		return err
@@ -535,7 +564,7 @@ func (a *Azure) GetInstanceLogs(ctx *lepton.Context, instancename string) (strin
 
 	vmClient := a.getVMClient()
 
-	vm, err := vmClient.Get(context.TODO(), a.groupName, vmName, compute.InstanceViewTypesInstanceView)
+	vm, err := vmClient.Get(ctx.Context(), a.groupName, vmName, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -556,7 +585,7 @@ func (a *Azure) GetInstanceLogs(ctx *lepton.Context, instancename string) (strin
 
 	blobURL := containerURL.NewBlockBlobURL(fname)
 
-	get, err := blobURL.Download(context.TODO(), 0, 0, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+	get, err := blobURL.Download(ctx.Context(), 0, 0, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
 	if err != nil {
 		return "", err
 	}
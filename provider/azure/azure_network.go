@@ -37,6 +37,7 @@ func getAzureVirtualNetworkFromID(id string) string {
 func (a *Azure) getNicClient() *network.InterfacesClient {
 	nicClient := network.NewInterfacesClient(a.subID)
 	nicClient.Authorizer = *a.authorizer
+	withRetries(&nicClient.Client)
 	nicClient.AddToUserAgent(userAgent)
 	return &nicClient
 }
@@ -126,13 +127,13 @@ func (a *Azure) DeleteNIC(ctx *lepton.Context, nic *network.Interface) error {
 
 	logger.Infof("Deleting %s...", *nic.ID)
 	nicName := getAzureResourceNameFromID(*nic.ID)
-	nicDeleteTask, err := nicClient.Delete(context.TODO(), a.groupName, nicName)
+	nicDeleteTask, err := nicClient.Delete(ctx.Context(), a.groupName, nicName)
 	if err != nil {
 		logger.Error(err)
 		return errors.New("error deleting network interface controller")
 	}
 
-	err = nicDeleteTask.WaitForCompletionRef(context.TODO(), nicClient.Client)
+	err = nicDeleteTask.WaitForCompletionRef(ctx.Context(), nicClient.Client)
 	if err != nil {
 		logger.Error(err)
 		return errors.New("error waiting for network interface controller deleting")
@@ -152,13 +153,13 @@ func (a *Azure) DeleteIP(ctx *lepton.Context, ipConfiguration *network.Interface
 		ipClient := a.getIPClient()
 
 		logger.Infof("Deleting public IP %s...", ipID)
-		deleteIPTask, err := ipClient.Delete(context.TODO(), a.groupName, ipID)
+		deleteIPTask, err := ipClient.Delete(ctx.Context(), a.groupName, ipID)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("failed deleting ip")
 		}
 
-		err = deleteIPTask.WaitForCompletionRef(context.TODO(), ipClient.Client)
+		err = deleteIPTask.WaitForCompletionRef(ctx.Context(), ipClient.Client)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("failed waiting for ip deletion")
@@ -168,6 +169,15 @@ func (a *Azure) DeleteIP(ctx *lepton.Context, ipConfiguration *network.Interface
 	return nil
 }
 
+// deletePublicIP deletes a public ip which is not assigned to a network interface controller yet
+func (a *Azure) deletePublicIP(ctx *lepton.Context, ip *network.PublicIPAddress) error {
+	return a.DeleteIP(ctx, &network.InterfaceIPConfiguration{
+		InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+			PublicIPAddress: ip,
+		},
+	})
+}
+
 // DeleteNetworkSecurityGroup deletes the security group
 func (a *Azure) DeleteNetworkSecurityGroup(ctx *lepton.Context, securityGroupID string) error {
 	logger := ctx.Logger()
@@ -178,7 +188,7 @@ func (a *Azure) DeleteNetworkSecurityGroup(ctx *lepton.Context, securityGroupID
 	}
 
 	securityGroupName := getAzureResourceNameFromID(securityGroupID)
-	securityGroup, err := nsgClient.Get(context.TODO(), a.groupName, securityGroupName, "")
+	securityGroup, err := nsgClient.Get(ctx.Context(), a.groupName, securityGroupName, "")
 	if err != nil {
 		logger.Error(err)
 		return errors.New("error getting network security group")
@@ -190,13 +200,13 @@ func (a *Azure) DeleteNetworkSecurityGroup(ctx *lepton.Context, securityGroupID
 		return errors.New("existing subnetworks are using this security group")
 	} else {
 		logger.Infof("Deleting %s...", *securityGroup.ID)
-		nsgTask, err := nsgClient.Delete(context.TODO(), a.groupName, *securityGroup.Name)
+		nsgTask, err := nsgClient.Delete(ctx.Context(), a.groupName, *securityGroup.Name)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("failed deleting security group")
 		}
 
-		err = nsgTask.WaitForCompletionRef(context.TODO(), nsgClient.Client)
+		err = nsgTask.WaitForCompletionRef(ctx.Context(), nsgClient.Client)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("failed waiting for security group deletion")
@@ -223,13 +233,13 @@ func (a *Azure) DeleteSubnetwork(ctx *lepton.Context, subnetID string) error {
 	subnetName := getAzureResourceNameFromID(subnetID)
 	vnName := getAzureVirtualNetworkFromID(subnetID)
 
-	subnet, err := subnetsClient.Get(context.TODO(), a.groupName, vnName, subnetName, "")
+	subnet, err := subnetsClient.Get(ctx.Context(), a.groupName, vnName, subnetName, "")
 	if err != nil {
 		ctx.Logger().Error(err)
 		return fmt.Errorf("failed getting subnet")
 	}
 
-	virtualNetwork, err := vnetClient.Get(context.TODO(), a.groupName, vnName, "")
+	virtualNetwork, err := vnetClient.Get(ctx.Context(), a.groupName, vnName, "")
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("failed getting virtual network")
@@ -237,26 +247,26 @@ func (a *Azure) DeleteSubnetwork(ctx *lepton.Context, subnetID string) error {
 
 	if hasAzureOpsTags(virtualNetwork.Tags) && (subnet.IPConfigurations == nil || len(*subnet.IPConfigurations) == 0) {
 		logger.Infof("Deleting %s...", *subnet.ID)
-		subnetDeleteTask, err := subnetsClient.Delete(context.TODO(), a.groupName, subnetName, subnetName)
+		subnetDeleteTask, err := subnetsClient.Delete(ctx.Context(), a.groupName, subnetName, subnetName)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("error deleting subnet")
 		}
 
-		err = subnetDeleteTask.WaitForCompletionRef(context.TODO(), subnetsClient.Client)
+		err = subnetDeleteTask.WaitForCompletionRef(ctx.Context(), subnetsClient.Client)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("error waiting for subnet deletion")
 		}
 
 		logger.Infof("Deleting virtualNetworks/%s", vnName)
-		vnDeleteTask, err := vnetClient.Delete(context.TODO(), a.groupName, vnName)
+		vnDeleteTask, err := vnetClient.Delete(ctx.Context(), a.groupName, vnName)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("error deleting virtual network")
 		}
 
-		err = vnDeleteTask.WaitForCompletionRef(context.TODO(), vnetClient.Client)
+		err = vnDeleteTask.WaitForCompletionRef(ctx.Context(), vnetClient.Client)
 		if err != nil {
 			logger.Error(err)
 			return errors.New("error waiting for virtual network deletion")
@@ -271,6 +281,7 @@ func (a *Azure) DeleteSubnetwork(ctx *lepton.Context, subnetID string) error {
 func (a *Azure) getIPClient() *network.PublicIPAddressesClient {
 	ipClient := network.NewPublicIPAddressesClient(a.subID)
 	ipClient.Authorizer = *a.authorizer
+	withRetries(&ipClient.Client)
 	ipClient.AddToUserAgent(userAgent)
 	return &ipClient
 }
@@ -334,18 +345,19 @@ func (a *Azure) getVnetClient() (*network.VirtualNetworksClient, error) {
 	}
 
 	vnetClient.Authorizer = authr
+	withRetries(&vnetClient.Client)
 	vnetClient.AddToUserAgent(userAgent)
 	return &vnetClient, nil
 }
 
 // GetVPC finds the virtual network by id
-func (a *Azure) GetVPC(vnetName string) (vnet *network.VirtualNetwork, err error) {
+func (a *Azure) GetVPC(ctx context.Context, vnetName string) (vnet *network.VirtualNetwork, err error) {
 	vnetClient, err := a.getVnetClient()
 	if err != nil {
 		return
 	}
 
-	result, err := vnetClient.Get(context.TODO(), a.groupName, vnetName, "")
+	result, err := vnetClient.Get(ctx, a.groupName, vnetName, "")
 	vnet = &result
 	return
 }
@@ -447,6 +459,7 @@ func (a *Azure) getSubnetsClient() (*network.SubnetsClient, error) {
 		return nil, err
 	}
 	subnetsClient.Authorizer = auth
+	withRetries(&subnetsClient.Client)
 	subnetsClient.AddToUserAgent(userAgent)
 	return &subnetsClient, nil
 }
@@ -546,6 +559,7 @@ func (a *Azure) getNsgClient() (*network.SecurityGroupsClient, error) {
 		return nil, err
 	}
 	nsgClient.Authorizer = authr
+	withRetries(&nsgClient.Client)
 	nsgClient.AddToUserAgent(userAgent)
 	return &nsgClient, nil
 }
@@ -15,8 +15,8 @@ import (
 	"strings"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
-	"github.com/nanovms/ops/types"
 )
 
 // Storage provides Azure storage related operations
@@ -108,7 +108,8 @@ func (az *Storage) resizeImage(basePath string, newPath string, resizeSz uint32)
 }
 
 // CopyToBucket copies archive to bucket
-func (az *Storage) CopyToBucket(config *types.Config, imgPath string) error {
+func (az *Storage) CopyToBucket(ctx *lepton.Context, imgPath string) error {
+	config := ctx.Config()
 
 	base := filepath.Base(imgPath)
 
@@ -145,12 +146,12 @@ func (az *Storage) CopyToBucket(config *types.Config, imgPath string) error {
 		log.Error(err)
 	}
 
-	ctx := context.Background()
+	execCtx := ctx.Context()
 	containerURL := getContainerURL(containerName)
 
-	if !containerExists(containerURL) {
+	if !containerExists(execCtx, containerURL) {
 		fmt.Printf("Creating a container named %s\n", containerName)
-		_, err = containerURL.Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone)
+		_, err = containerURL.Create(execCtx, azblob.Metadata{}, azblob.PublicAccessNone)
 		if err != nil {
 			log.Error(err)
 		}
@@ -178,7 +179,7 @@ func (az *Storage) CopyToBucket(config *types.Config, imgPath string) error {
 		q++
 	}
 
-	_, err = blobURL.Create(ctx, length, 0, azblob.BlobHTTPHeaders{},
+	_, err = blobURL.Create(execCtx, length, 0, azblob.BlobHTTPHeaders{},
 		azblob.Metadata{}, azblob.BlobAccessConditions{}, azblob.PremiumPageBlobAccessTierNone, nil, azblob.ClientProvidedKeyOptions{}, azblob.ImmutabilityPolicyOptions{})
 	if err != nil {
 		log.Fatal(err)
@@ -192,7 +193,7 @@ func (az *Storage) CopyToBucket(config *types.Config, imgPath string) error {
 			log.Fatal(err)
 		}
 
-		_, err = blobURL.UploadPages(ctx, int64(i*max), bytes.NewReader(page[:n]), azblob.PageBlobAccessConditions{}, nil, azblob.ClientProvidedKeyOptions{})
+		_, err = blobURL.UploadPages(execCtx, int64(i*max), bytes.NewReader(page[:n]), azblob.PageBlobAccessConditions{}, nil, azblob.ClientProvidedKeyOptions{})
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -202,13 +203,12 @@ func (az *Storage) CopyToBucket(config *types.Config, imgPath string) error {
 }
 
 // DeleteFromBucket deletes key from config's bucket
-func (az *Storage) DeleteFromBucket(config *types.Config, key string) error {
+func (az *Storage) DeleteFromBucket(ctx *lepton.Context, key string) error {
 
 	fmt.Printf("Started deleting image from container\n")
 	blobURL := getBlobURL(containerName, key)
 
-	ctx := context.Background()
-	_, err := blobURL.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+	_, err := blobURL.Delete(ctx.Context(), azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
 
 	if err != nil {
 		return err
@@ -218,8 +218,8 @@ func (az *Storage) DeleteFromBucket(config *types.Config, key string) error {
 }
 
 // Exists() function not available in sdk. So for now this is a work around
-func containerExists(containerURL azblob.ContainerURL) bool {
-	_, err := containerURL.GetProperties(context.Background(), azblob.LeaseAccessConditions{})
+func containerExists(ctx context.Context, containerURL azblob.ContainerURL) bool {
+	_, err := containerURL.GetProperties(ctx, azblob.LeaseAccessConditions{})
 	return err == nil
 }
 
@@ -3,7 +3,6 @@
 package azure
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -47,10 +46,13 @@ func (a *Azure) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data str
 
 	config.CloudConfig.ImageName = cv.Name
 
-	err = a.Storage.CopyToBucket(config, vol.Path)
+	err = a.Storage.CopyToBucket(ctx, vol.Path)
 	if err != nil {
 		return vol, fmt.Errorf("copy volume archive to azure bucket: %v", err)
 	}
+	done := ctx.AddCleanup("volume archive "+cv.Name+".vhd", func(ctx *lepton.Context) error {
+		return a.Storage.DeleteFromBucket(ctx, cv.Name+".vhd")
+	})
 
 	bucket, err := a.getBucketName()
 	if err != nil {
@@ -76,10 +78,11 @@ func (a *Azure) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data str
 		},
 	}
 
-	_, err = disksClient.CreateOrUpdate(context.TODO(), a.groupName, cv.Name, diskParams)
+	_, err = disksClient.CreateOrUpdate(ctx.Context(), a.groupName, cv.Name, diskParams)
 	if err != nil {
 		return vol, err
 	}
+	done()
 
 	return vol, nil
 }
@@ -93,7 +96,7 @@ func (a *Azure) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, error
 		return nil, err
 	}
 
-	azureDisksPage, err := volumesService.List(context.TODO())
+	azureDisksPage, err := volumesService.List(ctx.Context())
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +141,7 @@ func (a *Azure) DeleteVolume(ctx *lepton.Context, name string) error {
 		return err
 	}
 
-	_, err = volumesService.Delete(context.TODO(), a.groupName, name)
+	_, err = volumesService.Delete(ctx.Context(), a.groupName, name)
 	if err != nil {
 		return err
 	}
@@ -150,7 +153,7 @@ func (a *Azure) DeleteVolume(ctx *lepton.Context, name string) error {
 func (a *Azure) AttachVolume(ctx *lepton.Context, image, name string, attachID int) error {
 	vmClient := a.getVMClient()
 
-	vm, err := vmClient.Get(context.TODO(), a.groupName, image, compute.InstanceViewTypesInstanceView)
+	vm, err := vmClient.Get(ctx.Context(), a.groupName, image, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		return err
 	}
@@ -160,7 +163,7 @@ func (a *Azure) AttachVolume(ctx *lepton.Context, image, name string, attachID i
 		return err
 	}
 
-	disk, err := disksClient.Get(context.TODO(), a.groupName, name)
+	disk, err := disksClient.Get(ctx.Context(), a.groupName, name)
 	if err != nil {
 		return err
 	}
@@ -180,14 +183,14 @@ func (a *Azure) AttachVolume(ctx *lepton.Context, image, name string, attachID i
 		}
 	}
 	*vm.StorageProfile.DataDisks = append(*vm.StorageProfile.DataDisks, newDisk)
-	future, err := vmClient.CreateOrUpdate(context.TODO(), a.groupName, image, vm)
+	future, err := vmClient.CreateOrUpdate(ctx.Context(), a.groupName, image, vm)
 	if err != nil {
 		return fmt.Errorf("cannot update vm: %v", err)
 	}
 
 	log.Info("attaching the volume - this can take a few minutes - you can ctrl-c this after a bit")
 
-	err = future.WaitForCompletionRef(context.TODO(), vmClient.Client)
+	err = future.WaitForCompletionRef(ctx.Context(), vmClient.Client)
 	if err != nil {
 		return fmt.Errorf("cannot get the vm create or update future response: %v", err)
 	}
@@ -199,7 +202,7 @@ func (a *Azure) AttachVolume(ctx *lepton.Context, image, name string, attachID i
 func (a *Azure) DetachVolume(ctx *lepton.Context, image, name string) error {
 	vmClient := a.getVMClient()
 
-	vm, err := vmClient.Get(context.TODO(), a.groupName, image, compute.InstanceViewTypesInstanceView)
+	vm, err := vmClient.Get(ctx.Context(), a.groupName, image, compute.InstanceViewTypesInstanceView)
 	if err != nil {
 		return err
 	}
@@ -214,14 +217,14 @@ func (a *Azure) DetachVolume(ctx *lepton.Context, image, name string) error {
 
 	vm.StorageProfile.DataDisks = dataDisks
 
-	future, err := vmClient.CreateOrUpdate(context.TODO(), a.groupName, image, vm)
+	future, err := vmClient.CreateOrUpdate(ctx.Context(), a.groupName, image, vm)
 	if err != nil {
 		return fmt.Errorf("cannot update vm: %v", err)
 	}
 
 	log.Info("detaching the volume - this can take a few minutes - you can ctrl-c this after a bit")
 
-	err = future.WaitForCompletionRef(context.TODO(), vmClient.Client)
+	err = future.WaitForCompletionRef(ctx.Context(), vmClient.Client)
 	if err != nil {
 		return fmt.Errorf("cannot get the vm create or update future response: %v", err)
 	}
@@ -236,6 +239,7 @@ func (a *Azure) getDisksClient() (*compute.DisksClient, error) {
 		return nil, err
 	}
 	vmClient.Authorizer = authr
+	withRetries(&vmClient.Client)
 	vmClient.AddToUserAgent(userAgent)
 	return &vmClient, nil
 }
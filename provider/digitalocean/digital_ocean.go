@@ -3,12 +3,14 @@
 package digitalocean
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/digitalocean/godo"
 	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
+	"golang.org/x/oauth2"
 )
 
 // ProviderName of the cloud platform provider
@@ -31,7 +33,15 @@ func (do *DigitalOcean) Initialize(c *types.ProviderConfig) error {
 	if doToken == "" {
 		return fmt.Errorf("set DO_TOKEN")
 	}
-	do.Client = godo.NewFromToken(doToken)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, lepton.NewHTTPClient(ProviderName))
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: doToken})
+	do.Client = godo.NewClient(oauth2.NewClient(ctx, ts))
+	return nil
+}
+
+// Capabilities returns the optional operations supported by DigitalOcean: none, droplets
+// being managed through the basic image and instance operations only
+func (do *DigitalOcean) Capabilities() lepton.Capabilities {
 	return nil
 }
 
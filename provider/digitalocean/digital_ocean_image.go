@@ -3,7 +3,6 @@
 package digitalocean
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -41,10 +40,13 @@ func (do *DigitalOcean) CreateImage(ctx *lepton.Context, imagePath string) error
 		return err
 	}
 
-	err = do.Storage.CopyToBucket(ctx.Config(), opshome+"/images/"+newPath)
+	err = do.Storage.CopyToBucket(ctx, opshome+"/images/"+newPath)
 	if err != nil {
 		return err
 	}
+	objectDone := ctx.AddCleanup("image object "+newPath, func(ctx *lepton.Context) error {
+		return do.Storage.DeleteFromBucket(ctx.Config(), filepath.Base(newPath))
+	})
 
 	publicURL := do.Storage.getImageSpacesURL(c, newPath)
 	publicURL = do.Storage.getSignedURL(newPath, c.CloudConfig.BucketName, c.CloudConfig.Zone)
@@ -59,21 +61,28 @@ func (do *DigitalOcean) CreateImage(ctx *lepton.Context, imagePath string) error
 		Tags:         []string{opsTag},
 	}
 
-	i, _, err := do.Client.Images.Create(context.TODO(), createImageRequest)
+	i, _, err := do.Client.Images.Create(ctx.Context(), createImageRequest)
 	if err != nil {
 		return err
 	}
 
 	log.Infof("%+v\n", i)
+	imageID := i.ID
+	imageDone := ctx.AddCleanup("image "+imageName, func(ctx *lepton.Context) error {
+		_, err := do.Client.Images.Delete(ctx.Context(), imageID)
+		return err
+	})
 
 	for i.Status != "available" {
 		time.Sleep(250 * time.Millisecond)
-		i, _, err = do.Client.Images.GetByID(context.TODO(), i.ID)
+		i, _, err = do.Client.Images.GetByID(ctx.Context(), i.ID)
 		if err != nil {
 			return err
 		}
 	}
+	imageDone()
 
+	objectDone()
 	err = do.Storage.DeleteFromBucket(c, filepath.Base(newPath))
 	if err != nil {
 		return err
@@ -85,7 +94,7 @@ func (do *DigitalOcean) CreateImage(ctx *lepton.Context, imagePath string) error
 // GetImages return all images on DigitalOcean
 func (do *DigitalOcean) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
 	opt := &godo.ListOptions{}
-	list, _, err := do.Client.Images.ListByTag(context.TODO(), opsTag, opt)
+	list, _, err := do.Client.Images.ListByTag(ctx.Context(), opsTag, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +140,7 @@ func (do *DigitalOcean) DeleteImage(ctx *lepton.Context, imagename string) error
 
 	id, _ := strconv.Atoi(image.ID)
 
-	_, err = do.Client.Images.Delete(context.TODO(), id)
+	_, err = do.Client.Images.Delete(ctx.Context(), id)
 	if err != nil {
 		return err
 	}
@@ -3,7 +3,6 @@
 package digitalocean
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,7 +35,7 @@ func (do *DigitalOcean) CreateInstance(ctx *lepton.Context) error {
 	instanceName := config.RunConfig.InstanceName
 	imageName := "image:" + image.Name
 
-	keys, _, err := do.Client.Keys.List(context.TODO(), &godo.ListOptions{
+	keys, _, err := do.Client.Keys.List(ctx.Context(), &godo.ListOptions{
 		Page:    1,
 		PerPage: 200,
 	})
@@ -92,7 +91,7 @@ func (do *DigitalOcean) CreateInstance(ctx *lepton.Context) error {
 		createReq.VPCUUID = vpcUUID
 	}
 
-	_, _, err = do.Client.Droplets.Create(context.TODO(), createReq)
+	_, _, err = do.Client.Droplets.Create(ctx.Context(), createReq)
 	if err != nil {
 		return err
 	}
@@ -124,7 +123,7 @@ func (do *DigitalOcean) GetInstanceByName(ctx *lepton.Context, name string) (*le
 // TODO
 func (do *DigitalOcean) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
 	opt := &godo.ListOptions{}
-	list, _, err := do.Client.Droplets.ListByTag(context.TODO(), opsTag, opt)
+	list, _, err := do.Client.Droplets.ListByTag(ctx.Context(), opsTag, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +211,7 @@ func (do *DigitalOcean) DeleteInstance(ctx *lepton.Context, instancename string)
 
 	instanceID, _ := strconv.Atoi(instance.ID)
 
-	_, err = do.Client.Droplets.Delete(context.TODO(), instanceID)
+	_, err = do.Client.Droplets.Delete(ctx.Context(), instanceID)
 	if err != nil {
 		return err
 	}
@@ -234,7 +233,7 @@ func (do *DigitalOcean) StartInstance(ctx *lepton.Context, instancename string)
 
 	instanceID, _ := strconv.Atoi(instance.ID)
 
-	_, _, err = do.Client.DropletActions.PowerOn(context.TODO(), instanceID)
+	_, _, err = do.Client.DropletActions.PowerOn(ctx.Context(), instanceID)
 	if err != nil {
 		return err
 	}
@@ -251,7 +250,7 @@ func (do *DigitalOcean) StopInstance(ctx *lepton.Context, instancename string) e
 
 	instanceID, _ := strconv.Atoi(instance.ID)
 
-	_, _, err = do.Client.DropletActions.PowerOff(context.TODO(), instanceID)
+	_, _, err = do.Client.DropletActions.PowerOff(ctx.Context(), instanceID)
 	if err != nil {
 		return err
 	}
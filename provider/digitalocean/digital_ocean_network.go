@@ -3,7 +3,6 @@
 package digitalocean
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/digitalocean/godo"
@@ -26,7 +25,7 @@ func (do *DigitalOcean) GetVPC(ctx *lepton.Context, vpcName string) (*godo.VPC,
 			PerPage: 200, // max allowed by DO
 		}
 
-		vpcs, _, err := do.Client.VPCs.List(context.TODO(), opts)
+		vpcs, _, err := do.Client.VPCs.List(ctx.Context(), opts)
 		if err != nil {
 			return nil, err
 		}
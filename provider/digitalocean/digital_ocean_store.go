@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/minio/minio-go"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
 )
@@ -47,8 +48,8 @@ func (s *Spaces) getImageSpacesURL(config *types.Config, imageName string) strin
 }
 
 // CopyToBucket copies archive to bucket
-func (s *Spaces) CopyToBucket(config *types.Config, archPath string) error {
-
+func (s *Spaces) CopyToBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
 	file, err := os.Open(archPath)
 	if err != nil {
 		return err
@@ -80,7 +81,7 @@ func (s *Spaces) CopyToBucket(config *types.Config, archPath string) error {
 
 	key := filepath.Base(archPath)
 
-	n, err := client.PutObject(bucket, key, file, stat.Size(), minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	n, err := client.PutObjectWithContext(ctx.Context(), bucket, key, file, stat.Size(), minio.PutObjectOptions{ContentType: "application/octet-stream"})
 	if err != nil {
 		log.Fatal(err)
 	}
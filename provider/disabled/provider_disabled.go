@@ -24,6 +24,11 @@ func (p *Provider) Initialize(config *types.ProviderConfig) error {
 	return fmt.Errorf("[%s] provider - disabled", p.name)
 }
 
+// Capabilities returns no capabilities, the provider being excluded from the build
+func (p *Provider) Capabilities() lepton.Capabilities {
+	return nil
+}
+
 // BuildImage ...
 func (p *Provider) BuildImage(ctx *lepton.Context) (string, error) {
 	return "", nil
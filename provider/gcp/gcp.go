@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -35,6 +36,18 @@ type GCloudOperation struct {
 	operationType string
 }
 
+// newHTTPClient returns a client of the Google Cloud APIs authenticated with the default
+// credentials, retrying the calls which are throttled or fail transiently
+func newHTTPClient(ctx context.Context) (*http.Client, error) {
+	client, err := google.DefaultClient(ctx, compute.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Transport = lepton.NewRetryTransport(ProviderName, client.Transport)
+	return client, nil
+}
+
 func buildGcpLabels(tags []types.Tag, imageORinstance string) map[string]string {
 	labels := map[string]string{"createdby": "ops"}
 	for _, tag := range tags {
@@ -171,7 +184,7 @@ func (p *GCloud) Initialize(config *types.ProviderConfig) error {
 		return err
 	}
 
-	client, err := google.DefaultClient(context.Background(), compute.CloudPlatformScope)
+	client, err := newHTTPClient(context.Background())
 	if err != nil {
 		return err
 	}
@@ -191,6 +204,20 @@ func (p *GCloud) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by Google Cloud
+func (p *GCloud) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceReboot,
+		lepton.CapabilityInstanceLogs,
+		lepton.CapabilityLogsWatch,
+		lepton.CapabilityVolumes,
+		lepton.CapabilityDNS,
+		lepton.CapabilitySpot,
+		lepton.CapabilityGPU,
+		lepton.CapabilityInstanceGroups,
+	}
+}
+
 // GetStorage returns storage interface for cloud provider
 func (p *GCloud) GetStorage() lepton.Storage {
 	return p.Storage
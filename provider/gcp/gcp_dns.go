@@ -7,15 +7,15 @@ import (
 	"strings"
 
 	"github.com/nanovms/ops/lepton"
-	"github.com/nanovms/ops/types"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
 )
 
 // FindOrCreateZoneIDByName searches for a DNS zone with the name passed by argument and if it doesn't exist it creates one
-func (p *GCloud) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (string, error) {
+func (p *GCloud) FindOrCreateZoneIDByName(ctx *lepton.Context, dnsName string) (string, error) {
 	zoneName := strings.Split(dnsName, ".")[0]
-	zones, err := p.dnsService.ManagedZones.List(config.CloudConfig.ProjectID).Do()
+	zones, err := p.dnsService.ManagedZones.List(ctx.Config().CloudConfig.ProjectID).Context(ctx.Context()).Do()
 	if err != nil {
 		return "", err
 	}
@@ -36,7 +36,7 @@ func (p *GCloud) FindOrCreateZoneIDByName(config *types.Config, dnsName string)
 			DnsName:     dnsName + ".",
 		}
 
-		_, err = p.dnsService.ManagedZones.Create(config.CloudConfig.ProjectID, managedZone).Do()
+		_, err = p.dnsService.ManagedZones.Create(ctx.Config().CloudConfig.ProjectID, managedZone).Context(ctx.Context()).Do()
 		if err != nil {
 			return "", err
 		}
@@ -46,17 +46,17 @@ func (p *GCloud) FindOrCreateZoneIDByName(config *types.Config, dnsName string)
 }
 
 // DeleteZoneRecordIfExists deletes a record from a DNS zone if it exists
-func (p *GCloud) DeleteZoneRecordIfExists(config *types.Config, zoneID string, recordName string) error {
-	recordsResponse, err := p.dnsService.ResourceRecordSets.List(config.CloudConfig.ProjectID, zoneID).Do()
+func (p *GCloud) DeleteZoneRecordIfExists(ctx *lepton.Context, zoneID string, recordName string) error {
+	recordsResponse, err := p.dnsService.ResourceRecordSets.List(ctx.Config().CloudConfig.ProjectID, zoneID).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
 
 	for _, record := range recordsResponse.Rrsets {
 		if record.Name == recordName && record.Type == "A" {
-			_, err = p.dnsService.Changes.Create(config.CloudConfig.ProjectID, zoneID, &dns.Change{
+			_, err = p.dnsService.Changes.Create(ctx.Config().CloudConfig.ProjectID, zoneID, &dns.Change{
 				Deletions: []*dns.ResourceRecordSet{record},
-			}).Do()
+			}).Context(ctx.Context()).Do()
 			if err != nil {
 				return err
 			}
@@ -67,7 +67,7 @@ func (p *GCloud) DeleteZoneRecordIfExists(config *types.Config, zoneID string, r
 }
 
 // CreateZoneRecord creates a record in a DNS zone
-func (p *GCloud) CreateZoneRecord(config *types.Config, zoneID string, record *lepton.DNSRecord) error {
+func (p *GCloud) CreateZoneRecord(ctx *lepton.Context, zoneID string, record *lepton.DNSRecord) error {
 	resource := &dns.ResourceRecordSet{
 		Name:    record.Name,
 		Type:    record.Type,
@@ -75,9 +75,9 @@ func (p *GCloud) CreateZoneRecord(config *types.Config, zoneID string, record *l
 		Ttl:     int64(record.TTL),
 	}
 
-	_, err := p.dnsService.Changes.Create(config.CloudConfig.ProjectID, zoneID, &dns.Change{
+	_, err := p.dnsService.Changes.Create(ctx.Config().CloudConfig.ProjectID, zoneID, &dns.Change{
 		Additions: []*dns.ResourceRecordSet{resource},
-	}).Do()
+	}).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
@@ -91,7 +91,12 @@ func (p *GCloud) getDNSService() (*dns.Service, error) {
 		return nil, err
 	}
 
-	dnsService, err := dns.NewService(context)
+	client, err := newHTTPClient(context)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsService, err := dns.NewService(context, option.WithHTTPClient(client))
 	if err != nil {
 		return nil, err
 	}
@@ -3,11 +3,11 @@
 package gcp
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -88,17 +88,17 @@ func (p *GCloud) BuildImageWithPackage(ctx *lepton.Context, pkgpath string) (str
 // CreateImage - Creates image on GCP using nanos images
 // TODO : re-use and cache DefaultClient and instances.
 func (p *GCloud) CreateImage(ctx *lepton.Context, imagePath string) error {
-	err := p.Storage.CopyToBucket(ctx.Config(), imagePath)
+	err := p.Storage.CopyToBucket(ctx, imagePath)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		p.Storage.DeleteFromBucket(ctx.Config(), imagePath)
+		p.Storage.DeleteFromBucket(ctx, imagePath)
 		defer os.Remove(imagePath)
 	}()
 
 	c := ctx.Config()
-	context := context.TODO()
+	context := ctx.Context()
 
 	sourceURL := fmt.Sprintf(GCPStorageURL,
 		c.CloudConfig.BucketName, p.getArchiveName(ctx))
@@ -135,11 +135,16 @@ func (p *GCloud) CreateImage(ctx *lepton.Context, imagePath string) error {
 	if err != nil {
 		return fmt.Errorf("error:%+v", err)
 	}
+	done := ctx.AddCleanup("image "+rb.Name, func(ctx *lepton.Context) error {
+		_, err := p.Service.Images.Delete(c.CloudConfig.ProjectID, rb.Name).Context(ctx.Context()).Do()
+		return err
+	})
 	fmt.Printf("Image creation started. Monitoring operation %s.\n", op.Name)
 	err = p.pollOperation(context, c.CloudConfig.ProjectID, p.Service, *op)
 	if err != nil {
 		return err
 	}
+	done()
 
 	fmt.Printf("Image creation succeeded %s.\n", c.CloudConfig.ImageName)
 	return nil
@@ -147,12 +152,12 @@ func (p *GCloud) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 // GetImages return all images on GCloud
 func (p *GCloud) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
-	context := context.TODO()
+	context := ctx.Context()
 	creds, err := google.FindDefaultCredentials(context)
 	if err != nil {
 		return nil, err
 	}
-	client, err := google.DefaultClient(context, compute.CloudPlatformScope)
+	client, err := newHTTPClient(context)
 	if err != nil {
 		return nil, err
 	}
@@ -175,6 +180,7 @@ func (p *GCloud) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudIm
 				}
 
 				ci := lepton.CloudImage{
+					ID:      strconv.FormatUint(image.Id, 10),
 					Name:    image.Name,
 					Status:  fmt.Sprintf("%v", image.Status),
 					Created: imageCreatedAt,
@@ -226,12 +232,12 @@ func (p *GCloud) ListImages(ctx *lepton.Context, filter string) error {
 
 // DeleteImage deletes image from Gcloud
 func (p *GCloud) DeleteImage(ctx *lepton.Context, imagename string) error {
-	context := context.TODO()
+	context := ctx.Context()
 	creds, err := google.FindDefaultCredentials(context)
 	if err != nil {
 		return err
 	}
-	client, err := google.DefaultClient(context, compute.CloudPlatformScope)
+	client, err := newHTTPClient(context)
 	if err != nil {
 		return err
 	}
@@ -3,7 +3,6 @@
 package gcp
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -218,15 +217,21 @@ func (p *GCloud) CreateInstance(ctx *lepton.Context) error {
 			},
 		}
 	}
-	op, err := p.Service.Instances.Insert(c.CloudConfig.ProjectID, c.CloudConfig.Zone, rb).Context(context.TODO()).Do()
+	op, err := p.Service.Instances.Insert(c.CloudConfig.ProjectID, c.CloudConfig.Zone, rb).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
+	// the instance is deleted without its DNS record, which may point to another instance
+	done := ctx.AddCleanup("instance "+instanceName, func(ctx *lepton.Context) error {
+		_, err := p.Service.Instances.Delete(c.CloudConfig.ProjectID, c.CloudConfig.Zone, instanceName).Context(ctx.Context()).Do()
+		return err
+	})
 	fmt.Printf("Instance creation started using image %s. Monitoring operation %s.\n", imageName, op.Name)
-	err = p.pollOperation(context.TODO(), c.CloudConfig.ProjectID, p.Service, *op)
+	err = p.pollOperation(ctx.Context(), c.CloudConfig.ProjectID, p.Service, *op)
 	if err != nil {
 		return err
 	}
+	done()
 	fmt.Printf("Instance creation succeeded %s.\n", instanceName)
 
 	// create dns zones/records to associate DNS record to instance IP
@@ -241,7 +246,7 @@ func (p *GCloud) CreateInstance(ctx *lepton.Context) error {
 
 		if len(cinstance.PublicIps) != 0 {
 			ctx.Logger().Infof("Assigning IP %s to %s", cinstance.PublicIps[0], c.CloudConfig.DomainName)
-			err := lepton.CreateDNSRecord(ctx.Config(), cinstance.PublicIps[0], p)
+			err := lepton.CreateDNSRecord(ctx, cinstance.PublicIps[0], p)
 			if err != nil {
 				return err
 			}
@@ -254,7 +259,7 @@ func (p *GCloud) CreateInstance(ctx *lepton.Context) error {
 		if ctx.Config().CloudConfig.EnableIPv6 {
 			rule := p.buildFirewallRule("tcp", ctx.Config().RunConfig.Ports, instanceName, ctx.Config().CloudConfig.VPC, true)
 
-			_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(context.TODO()).Do()
+			_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(ctx.Context()).Do()
 
 			if err != nil {
 				fmt.Println(err)
@@ -266,7 +271,7 @@ func (p *GCloud) CreateInstance(ctx *lepton.Context) error {
 
 		rule := p.buildFirewallRule("tcp", ctx.Config().RunConfig.Ports, instanceName, ctx.Config().CloudConfig.VPC, false)
 
-		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(context.TODO()).Do()
+		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(ctx.Context()).Do()
 
 		if err != nil {
 			fmt.Println(err)
@@ -280,7 +285,7 @@ func (p *GCloud) CreateInstance(ctx *lepton.Context) error {
 		if ctx.Config().CloudConfig.EnableIPv6 {
 			rule := p.buildFirewallRule("udp", ctx.Config().RunConfig.UDPPorts, instanceName, ctx.Config().CloudConfig.VPC, true)
 
-			_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(context.TODO()).Do()
+			_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(ctx.Context()).Do()
 
 			if err != nil {
 				fmt.Println(err)
@@ -293,7 +298,7 @@ func (p *GCloud) CreateInstance(ctx *lepton.Context) error {
 
 		rule := p.buildFirewallRule("udp", ctx.Config().RunConfig.UDPPorts, instanceName, ctx.Config().CloudConfig.VPC, false)
 
-		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(context.TODO()).Do()
+		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(ctx.Context()).Do()
 
 		if err != nil {
 			ctx.Logger().Errorf("%v", err)
@@ -357,7 +362,7 @@ func (p *GCloud) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.Cl
 
 // GetInstances return all instances on GCloud
 func (p *GCloud) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
-	context := context.TODO()
+	context := ctx.Context()
 	var (
 		cinstances []lepton.CloudInstance
 		req        = p.Service.Instances.List(ctx.Config().CloudConfig.ProjectID, ctx.Config().CloudConfig.Zone)
@@ -410,7 +415,7 @@ func (p *GCloud) convertToCloudInstance(instance *compute.Instance) *lepton.Clou
 
 // DeleteInstance deletes instance from Gcloud
 func (p *GCloud) DeleteInstance(ctx *lepton.Context, instancename string) error {
-	context := context.TODO()
+	context := ctx.Context()
 	cloudConfig := ctx.Config().CloudConfig
 
 	// these are auto-created so we can rm freely
@@ -446,11 +451,11 @@ func (p *GCloud) DeleteInstance(ctx *lepton.Context, instancename string) error
 		dnsName := zoneName + "." + domainParts[len(domainParts)-1]
 		aRecordName := domainName + "."
 
-		zoneID, err := p.FindOrCreateZoneIDByName(ctx.Config(), dnsName)
+		zoneID, err := p.FindOrCreateZoneIDByName(ctx, dnsName)
 		if err != nil {
 			return err
 		}
-		err = p.DeleteZoneRecordIfExists(ctx.Config(), zoneID, aRecordName)
+		err = p.DeleteZoneRecordIfExists(ctx, zoneID, aRecordName)
 		if err != nil {
 			return err
 		}
@@ -463,7 +468,7 @@ func (p *GCloud) DeleteInstance(ctx *lepton.Context, instancename string) error
 
 // RebootInstance reboots the instance.
 func (p *GCloud) RebootInstance(ctx *lepton.Context, instancename string) error {
-	context := context.TODO()
+	context := ctx.Context()
 
 	cloudConfig := ctx.Config().CloudConfig
 	_, err := p.Service.Instances.Reset(cloudConfig.ProjectID, cloudConfig.Zone, instancename).Context(context).Do()
@@ -473,7 +478,7 @@ func (p *GCloud) RebootInstance(ctx *lepton.Context, instancename string) error
 // StartInstance starts an instance in GCloud
 func (p *GCloud) StartInstance(ctx *lepton.Context, instancename string) error {
 
-	context := context.TODO()
+	context := ctx.Context()
 
 	cloudConfig := ctx.Config().CloudConfig
 	op, err := p.Service.Instances.Start(cloudConfig.ProjectID, cloudConfig.Zone, instancename).Context(context).Do()
@@ -494,7 +499,7 @@ func (p *GCloud) StartInstance(ctx *lepton.Context, instancename string) error {
 
 // StopInstance stops instance
 func (p *GCloud) StopInstance(ctx *lepton.Context, instancename string) error {
-	context := context.TODO()
+	context := ctx.Context()
 
 	cloudConfig := ctx.Config().CloudConfig
 	op, err := p.Service.Instances.Stop(cloudConfig.ProjectID, cloudConfig.Zone, instancename).Context(context).Do()
@@ -514,7 +519,7 @@ func (p *GCloud) StopInstance(ctx *lepton.Context, instancename string) error {
 
 // ResetInstance resets instance
 func (p *GCloud) ResetInstance(ctx *lepton.Context, instancename string) error {
-	context := context.TODO()
+	context := ctx.Context()
 
 	cloudConfig := ctx.Config().CloudConfig
 	op, err := p.Service.Instances.Reset(cloudConfig.ProjectID, cloudConfig.Zone, instancename).Context(context).Do()
@@ -563,7 +568,7 @@ func (p *GCloud) GetInstanceLogs(ctx *lepton.Context, instancename string) (stri
 }
 
 func (p *GCloud) getLogs(ctx *lepton.Context, instancename string, start int64) (string, int64, error) {
-	context := context.TODO()
+	context := ctx.Context()
 	cloudConfig := ctx.Config().CloudConfig
 	resp, err := p.Service.Instances.GetSerialPortOutput(cloudConfig.ProjectID, cloudConfig.Zone, instancename).Start(start).Context(context).Do()
 	if err != nil {
@@ -3,7 +3,6 @@
 package gcp
 
 import (
-	"context"
 	"fmt"
 	"os"
 
@@ -60,13 +59,13 @@ func (p *GCloud) createInstanceTemplate(ctx *lepton.Context, instanceGroup strin
 		},
 	}
 
-	op, err := p.Service.InstanceTemplates.Insert(c.CloudConfig.ProjectID, it).Context(context.TODO()).Do()
+	op, err := p.Service.InstanceTemplates.Insert(c.CloudConfig.ProjectID, it).Context(ctx.Context()).Do()
 	if err != nil {
 		return "", err
 	}
 	fmt.Printf("Instance template creation started.")
 
-	err = p.pollOperation(context.TODO(), c.CloudConfig.ProjectID, p.Service, *op)
+	err = p.pollOperation(ctx.Context(), c.CloudConfig.ProjectID, p.Service, *op)
 	if err != nil {
 		return "", err
 	}
@@ -76,7 +75,7 @@ func (p *GCloud) createInstanceTemplate(ctx *lepton.Context, instanceGroup strin
 	if len(ctx.Config().RunConfig.Ports) != 0 {
 		rule := p.buildFirewallRule("tcp", ctx.Config().RunConfig.Ports, instanceName, ctx.Config().CloudConfig.VPC, false)
 
-		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(context.TODO()).Do()
+		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(ctx.Context()).Do()
 
 		if err != nil {
 			return "", err
@@ -86,7 +85,7 @@ func (p *GCloud) createInstanceTemplate(ctx *lepton.Context, instanceGroup strin
 	if len(ctx.Config().RunConfig.UDPPorts) != 0 {
 		rule := p.buildFirewallRule("udp", ctx.Config().RunConfig.UDPPorts, instanceName, ctx.Config().CloudConfig.VPC, false)
 
-		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(context.TODO()).Do()
+		_, err = p.Service.Firewalls.Insert(c.CloudConfig.ProjectID, rule).Context(ctx.Context()).Do()
 
 		if err != nil {
 			return "", err
@@ -111,13 +110,13 @@ func (p *GCloud) replaceInstanceTemplate(ctx *lepton.Context, instanceGroup stri
 		InstanceTemplate: it.SelfLink,
 	}
 
-	op, err := p.Service.InstanceGroupManagers.SetInstanceTemplate(c.CloudConfig.ProjectID, c.CloudConfig.Zone, instanceGroup, tmp).Context(context.TODO()).Do()
+	op, err := p.Service.InstanceGroupManagers.SetInstanceTemplate(c.CloudConfig.ProjectID, c.CloudConfig.Zone, instanceGroup, tmp).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
 	fmt.Printf("replacing instance template in instance group")
 
-	err = p.pollOperation(context.TODO(), c.CloudConfig.ProjectID, p.Service, *op)
+	err = p.pollOperation(ctx.Context(), c.CloudConfig.ProjectID, p.Service, *op)
 	if err != nil {
 		return err
 	}
@@ -146,12 +145,12 @@ func (p *GCloud) updateInstanceGroup(ctx *lepton.Context, instanceGroup string)
 		Instances: instances,
 	}
 
-	op, err := p.Service.InstanceGroupManagers.RecreateInstances(c.CloudConfig.ProjectID, c.CloudConfig.Zone, instanceGroup, tmp).Context(context.TODO()).Do()
+	op, err := p.Service.InstanceGroupManagers.RecreateInstances(c.CloudConfig.ProjectID, c.CloudConfig.Zone, instanceGroup, tmp).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
 
-	err = p.pollOperation(context.TODO(), c.CloudConfig.ProjectID, p.Service, *op)
+	err = p.pollOperation(ctx.Context(), c.CloudConfig.ProjectID, p.Service, *op)
 	if err != nil {
 		return err
 	}
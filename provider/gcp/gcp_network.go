@@ -12,18 +12,18 @@ import (
 )
 
 // CreateVPC creates a legacy virtual network with the name specified
-func (p *GCloud) CreateVPC(computeService *compute.Service, project string, name string) (network *compute.Network, err error) {
+func (p *GCloud) CreateVPC(execCtx context.Context, computeService *compute.Service, project string, name string) (network *compute.Network, err error) {
 	networkPayload := &compute.Network{
 		Name:                  name,
 		AutoCreateSubnetworks: false,
 	}
 
-	createOperation, err := computeService.Networks.Insert(project, networkPayload).Do()
+	createOperation, err := computeService.Networks.Insert(project, networkPayload).Context(execCtx).Do()
 	if err != nil {
 		return
 	}
 
-	err = p.pollOperation(context.TODO(), project, computeService, *createOperation)
+	err = p.pollOperation(execCtx, project, computeService, *createOperation)
 	if err != nil {
 		return
 	}
@@ -48,7 +48,7 @@ func (p *GCloud) findOrCreateVPC(ctx *lepton.Context, computeService *compute.Se
 		ctx.Logger().Warn(err.Error())
 
 		ctx.Logger().Infof("Creating vpc with name %s", vpcName)
-		network, err = p.CreateVPC(computeService, c.CloudConfig.ProjectID, vpcName)
+		network, err = p.CreateVPC(ctx.Context(), computeService, c.CloudConfig.ProjectID, vpcName)
 		if err != nil {
 			ctx.Logger().Error(err)
 			err = fmt.Errorf("failed creating vpc %s", vpcName)
@@ -67,7 +67,7 @@ func (p *GCloud) findOrCreateVPC(ctx *lepton.Context, computeService *compute.Se
 // CreateSubnet creates a subnet with the name specified
 // TODO: Specify required subnet IpCidrRange without overlapping other subnetworks ip range.
 // Requires fetching every subnet and find an unused Ip range
-func (p *GCloud) CreateSubnet(computeService *compute.Service, project string, region string, name string, vpc *compute.Network) (network *compute.Subnetwork, err error) {
+func (p *GCloud) CreateSubnet(execCtx context.Context, computeService *compute.Service, project string, region string, name string, vpc *compute.Network) (network *compute.Subnetwork, err error) {
 	subnetPayload := &compute.Subnetwork{
 		Name:    name,
 		Region:  region,
@@ -75,12 +75,12 @@ func (p *GCloud) CreateSubnet(computeService *compute.Service, project string, r
 		//IpCidrRange: ,
 	}
 
-	createOperation, err := computeService.Subnetworks.Insert(project, region, subnetPayload).Do()
+	createOperation, err := computeService.Subnetworks.Insert(project, region, subnetPayload).Context(execCtx).Do()
 	if err != nil {
 		return
 	}
 
-	err = p.pollOperation(context.TODO(), project, computeService, *createOperation)
+	err = p.pollOperation(execCtx, project, computeService, *createOperation)
 	if err != nil {
 		return
 	}
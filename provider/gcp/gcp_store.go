@@ -3,24 +3,24 @@
 package gcp
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
 	storage "cloud.google.com/go/storage"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
-	"github.com/nanovms/ops/types"
 )
 
 // Storage provides GCP storage related operations
 type Storage struct{}
 
 // DeleteFromBucket delete archive from bucket
-func (s *Storage) DeleteFromBucket(config *types.Config, archPath string) error {
-	ctx := context.Background()
-	client, err := storage.NewClient(ctx)
+func (s *Storage) DeleteFromBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
+	execCtx := ctx.Context()
+	client, err := storage.NewClient(execCtx)
 	if err != nil {
 		log.Error(err)
 		log.Fatalf("Have you set GOOGLE_APPLICATION_CREDENTIALS?")
@@ -28,22 +28,23 @@ func (s *Storage) DeleteFromBucket(config *types.Config, archPath string) error
 	defer client.Close()
 
 	bucket := client.Bucket(config.CloudConfig.BucketName)
-	_, err = bucket.Attrs(ctx)
+	_, err = bucket.Attrs(execCtx)
 	if err != nil {
 		return fmt.Errorf("bucket not found: %s", config.CloudConfig.BucketName)
 	}
 
 	obj := bucket.Object(filepath.Base(archPath))
-	if err := obj.Delete(ctx); err != nil {
+	if err := obj.Delete(execCtx); err != nil {
 		return err
 	}
 	return nil
 }
 
 // CopyToBucket copies archive to bucket
-func (s *Storage) CopyToBucket(config *types.Config, archPath string) error {
-	ctx := context.Background()
-	client, err := storage.NewClient(ctx)
+func (s *Storage) CopyToBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
+	execCtx := ctx.Context()
+	client, err := storage.NewClient(execCtx)
 	if err != nil {
 		log.Error(err)
 		log.Fatalf("Have you set GOOGLE_APPLICATION_CREDENTIALS?")
@@ -52,18 +53,18 @@ func (s *Storage) CopyToBucket(config *types.Config, archPath string) error {
 	defer client.Close()
 
 	bucket := client.Bucket(config.CloudConfig.BucketName)
-	_, err = bucket.Attrs(ctx)
+	_, err = bucket.Attrs(execCtx)
 	if err != nil {
 		// Creates the new bucket.
 		log.Info("creating bucket:", config.CloudConfig.BucketName)
-		if err := bucket.Create(ctx, config.CloudConfig.ProjectID, nil); err != nil {
+		if err := bucket.Create(execCtx, config.CloudConfig.ProjectID, nil); err != nil {
 			return fmt.Errorf("failed to create bucket: %+v", err)
 		}
 	} else {
 		log.Info("bucket found:", config.CloudConfig.BucketName)
 	}
 
-	wr := bucket.Object(filepath.Base(archPath)).NewWriter(ctx)
+	wr := bucket.Object(filepath.Base(archPath)).NewWriter(execCtx)
 	f, err := os.Open(archPath)
 	if err != nil {
 		return err
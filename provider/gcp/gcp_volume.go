@@ -3,7 +3,6 @@
 package gcp
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -49,11 +48,11 @@ func (g *GCloud) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data st
 	}
 	defer os.Remove(archPath)
 
-	err = g.Storage.CopyToBucket(config, archPath)
+	err = g.Storage.CopyToBucket(ctx, archPath)
 	if err != nil {
 		return lv, err
 	}
-	defer g.Storage.DeleteFromBucket(config, filepath.Base(archPath))
+	defer g.Storage.DeleteFromBucket(ctx, filepath.Base(archPath))
 
 	labels := buildGcpLabels(nil, "")
 	img := &compute.Image{
@@ -63,14 +62,19 @@ func (g *GCloud) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data st
 			Source: fmt.Sprintf(GCPStorageURL, config.CloudConfig.BucketName, arch),
 		},
 	}
-	op, err := g.Service.Images.Insert(config.CloudConfig.ProjectID, img).Context(context.TODO()).Do()
+	op, err := g.Service.Images.Insert(config.CloudConfig.ProjectID, img).Context(ctx.Context()).Do()
 	if err != nil {
 		return lv, err
 	}
-	err = g.pollOperation(context.TODO(), config.CloudConfig.ProjectID, g.Service, *op)
+	imageDone := ctx.AddCleanup("volume image "+cv.Name, func(ctx *lepton.Context) error {
+		_, err := g.Service.Images.Delete(config.CloudConfig.ProjectID, cv.Name).Context(ctx.Context()).Do()
+		return err
+	})
+	err = g.pollOperation(ctx.Context(), config.CloudConfig.ProjectID, g.Service, *op)
 	if err != nil {
 		return lv, err
 	}
+	imageDone()
 
 	disk := &compute.Disk{
 		Name:        cv.Name,
@@ -80,14 +84,19 @@ func (g *GCloud) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data st
 		Type:        fmt.Sprintf("projects/%s/zones/%s/diskTypes/pd-standard", config.CloudConfig.ProjectID, config.CloudConfig.Zone),
 	}
 
-	op, err = g.Service.Disks.Insert(config.CloudConfig.ProjectID, config.CloudConfig.Zone, disk).Context(context.TODO()).Do()
+	op, err = g.Service.Disks.Insert(config.CloudConfig.ProjectID, config.CloudConfig.Zone, disk).Context(ctx.Context()).Do()
 	if err != nil {
 		return lv, err
 	}
-	err = g.pollOperation(context.TODO(), config.CloudConfig.ProjectID, g.Service, *op)
+	done := ctx.AddCleanup("volume "+cv.Name, func(ctx *lepton.Context) error {
+		_, err := g.Service.Disks.Delete(config.CloudConfig.ProjectID, config.CloudConfig.Zone, cv.Name).Context(ctx.Context()).Do()
+		return err
+	})
+	err = g.pollOperation(ctx.Context(), config.CloudConfig.ProjectID, g.Service, *op)
 	if err != nil {
 		return lv, err
 	}
+	done()
 	return lv, nil
 }
 
@@ -107,7 +116,7 @@ func (g *GCloud) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, erro
 		return nil, errGCloudZoneMissing()
 	}
 
-	dl, err := g.Service.Disks.List(projectID, zone).Context(context.TODO()).Do()
+	dl, err := g.Service.Disks.List(projectID, zone).Context(ctx.Context()).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -139,20 +148,20 @@ func (g *GCloud) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, erro
 func (g *GCloud) DeleteVolume(ctx *lepton.Context, name string) error {
 	config := ctx.Config()
 
-	op, err := g.Service.Disks.Delete(config.CloudConfig.ProjectID, config.CloudConfig.Zone, name).Context(context.TODO()).Do()
+	op, err := g.Service.Disks.Delete(config.CloudConfig.ProjectID, config.CloudConfig.Zone, name).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
-	err = g.pollOperation(context.TODO(), config.CloudConfig.ProjectID, g.Service, *op)
+	err = g.pollOperation(ctx.Context(), config.CloudConfig.ProjectID, g.Service, *op)
 	if err != nil {
 		return err
 	}
 
-	op, err = g.Service.Images.Delete(config.CloudConfig.ProjectID, name).Context(context.TODO()).Do()
+	op, err = g.Service.Images.Delete(config.CloudConfig.ProjectID, name).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
-	err = g.pollOperation(context.TODO(), config.CloudConfig.ProjectID, g.Service, *op)
+	err = g.pollOperation(ctx.Context(), config.CloudConfig.ProjectID, g.Service, *op)
 	if err != nil {
 		return err
 	}
@@ -175,11 +184,11 @@ func (g *GCloud) AttachVolume(ctx *lepton.Context, image, name string, attachID
 			return fmt.Errorf("attachment point 0 is reserved for the boot disk")
 		}
 	}
-	op, err := g.Service.Instances.AttachDisk(config.CloudConfig.ProjectID, config.CloudConfig.Zone, image, disk).Context(context.TODO()).Do()
+	op, err := g.Service.Instances.AttachDisk(config.CloudConfig.ProjectID, config.CloudConfig.Zone, image, disk).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
-	err = g.pollOperation(context.TODO(), config.CloudConfig.ProjectID, g.Service, *op)
+	err = g.pollOperation(ctx.Context(), config.CloudConfig.ProjectID, g.Service, *op)
 	if err != nil {
 		return err
 	}
@@ -198,7 +207,7 @@ func (g *GCloud) DetachVolume(ctx *lepton.Context, image, volumeName string) err
 
 	var mount string
 
-	ins, err := g.Service.Instances.Get(config.CloudConfig.ProjectID, config.CloudConfig.Zone, image).Context(context.TODO()).Do()
+	ins, err := g.Service.Instances.Get(config.CloudConfig.ProjectID, config.CloudConfig.Zone, image).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
@@ -212,11 +221,11 @@ func (g *GCloud) DetachVolume(ctx *lepton.Context, image, volumeName string) err
 	if mount == "" {
 		return fmt.Errorf("volume %s not found in %s", volumeName, image)
 	}
-	op, err := g.Service.Instances.DetachDisk(config.CloudConfig.ProjectID, config.CloudConfig.Zone, image, mount).Context(context.TODO()).Do()
+	op, err := g.Service.Instances.DetachDisk(config.CloudConfig.ProjectID, config.CloudConfig.Zone, image, mount).Context(ctx.Context()).Do()
 	if err != nil {
 		return err
 	}
-	err = g.pollOperation(context.TODO(), config.CloudConfig.ProjectID, g.Service, *op)
+	err = g.pollOperation(ctx.Context(), config.CloudConfig.ProjectID, g.Service, *op)
 	if err != nil {
 		return err
 	}
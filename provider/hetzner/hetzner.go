@@ -41,11 +41,22 @@ func (h *Hetzner) Initialize(c *types.ProviderConfig) error {
 	if hetznerToken == "" {
 		return fmt.Errorf("set HCLOUD_TOKEN")
 	}
-	h.Client = hcloud.NewClient(hcloud.WithToken(hetznerToken))
+	h.Client = hcloud.NewClient(
+		hcloud.WithToken(hetznerToken),
+		hcloud.WithHTTPClient(lepton.NewHTTPClient(ProviderName)),
+		hcloud.WithRetryOpts(hcloud.RetryOpts{MaxRetries: 0}),
+	)
 	h.Storage = &ObjectStorage{}
 	return nil
 }
 
+// Capabilities returns the optional operations supported by Hetzner
+func (h *Hetzner) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceReboot,
+	}
+}
+
 // GetStorage returns storage interface for cloud provider
 func (h *Hetzner) GetStorage() lepton.Storage {
 	return h.Storage
@@ -100,11 +100,14 @@ func (h *Hetzner) CreateImage(ctx *lepton.Context, imagePath string) error {
 		imagePath = filepath.Join(opshome, "images", config.CloudConfig.ImageName)
 	}
 
-	if err := h.Storage.CopyToBucket(config, imagePath); err != nil {
+	if err := h.Storage.CopyToBucket(ctx, imagePath); err != nil {
 		return err
 	}
 
 	objectKey := filepath.Base(imagePath)
+	objectDone := ctx.AddCleanup("image object "+objectKey, func(ctx *lepton.Context) error {
+		return h.Storage.DeleteFromBucket(ctx.Config(), objectKey)
+	})
 	publicURL := h.Storage.getImageObjectStorageURL(config, objectKey)
 	if publicURL == "" {
 		return errors.New("hetzner object storage url could not be derived; check bucket/zone configuration")
@@ -112,7 +115,7 @@ func (h *Hetzner) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	logger.Infof("uploaded image to %s", publicURL)
 
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctxWithTimeout, cancel := context.WithTimeout(ctx.Context(), 10*time.Minute)
 	defer cancel()
 
 	serverTypeName := strings.TrimSpace(config.CloudConfig.Flavor)
@@ -150,10 +153,18 @@ func (h *Hetzner) CreateImage(ctx *lepton.Context, imagePath string) error {
 	if sshKey, err = h.createEphemeralSSHKey(ctxWithTimeout); err != nil {
 		return err
 	}
+	// the temporary resources are deleted by the cleanup if the operation is cancelled, its
+	// context being cancelled too
+	sshKeyDone := ctx.AddCleanup("ssh key "+sshKey.Name, func(ctx *lepton.Context) error {
+		_, err := h.Client.SSHKey.Delete(ctx.Context(), sshKey)
+		return err
+	})
 	defer func() {
-		_, err := h.Client.SSHKey.Delete(context.Background(), sshKey)
+		_, err := h.Client.SSHKey.Delete(ctx.Context(), sshKey)
 		if err != nil {
 			logger.Warnf("failed to delete temporary ssh key: %v", err)
+		} else {
+			sshKeyDone()
 		}
 	}()
 
@@ -176,9 +187,15 @@ func (h *Hetzner) CreateImage(ctx *lepton.Context, imagePath string) error {
 		return err
 	}
 
+	builderDone := ctx.AddCleanup("builder server "+builderServer.Name, func(ctx *lepton.Context) error {
+		_, _, err := h.Client.Server.DeleteWithResult(ctx.Context(), builderServer)
+		return err
+	})
 	defer func() {
-		if _, _, derr := h.Client.Server.DeleteWithResult(context.Background(), builderServer); derr != nil {
+		if _, _, derr := h.Client.Server.DeleteWithResult(ctx.Context(), builderServer); derr != nil {
 			logger.Warnf("failed to delete builder server %q: %v", builderServer.Name, derr)
+		} else {
+			builderDone()
 		}
 	}()
 
@@ -204,15 +221,21 @@ func (h *Hetzner) CreateImage(ctx *lepton.Context, imagePath string) error {
 	if err != nil {
 		return err
 	}
+	imageDone := ctx.AddCleanup("snapshot "+createImage.Image.Name, func(ctx *lepton.Context) error {
+		_, err := h.Client.Image.Delete(ctx.Context(), createImage.Image)
+		return err
+	})
 
 	if createImage.Action != nil {
 		if err := h.Client.Action.WaitFor(ctxWithTimeout, createImage.Action); err != nil {
 			return err
 		}
 	}
+	imageDone()
 
 	logger.Infof("snapshot %q (%d) created", createImage.Image.Name, createImage.Image.ID)
 
+	objectDone()
 	if err := h.Storage.DeleteFromBucket(config, objectKey); err != nil {
 		logger.Warnf("failed to delete object %q from bucket: %v", objectKey, err)
 	}
@@ -268,7 +291,7 @@ func (h *Hetzner) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudI
 		Type: []hcloud.ImageType{hcloud.ImageTypeSnapshot},
 	}
 
-	images, err := h.Client.Image.AllWithOpts(context.Background(), opts)
+	images, err := h.Client.Image.AllWithOpts(ctx.Context(), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -295,7 +318,7 @@ func (h *Hetzner) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudI
 // DeleteImage removes the Hetzner snapshot and associated object storage artifact.
 func (h *Hetzner) DeleteImage(ctx *lepton.Context, imagename string) error {
 	config := ctx.Config()
-	image, err := h.fetchSnapshotByName(context.Background(), imagename)
+	image, err := h.fetchSnapshotByName(ctx.Context(), imagename)
 	if err != nil {
 		return err
 	}
@@ -303,7 +326,7 @@ func (h *Hetzner) DeleteImage(ctx *lepton.Context, imagename string) error {
 		return fmt.Errorf(`image with name "%s" not found`, imagename)
 	}
 
-	if _, err := h.Client.Image.Delete(context.Background(), image); err != nil {
+	if _, err := h.Client.Image.Delete(ctx.Context(), image); err != nil {
 		return err
 	}
 
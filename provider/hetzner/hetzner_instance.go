@@ -22,7 +22,7 @@ import (
 func (h *Hetzner) CreateInstance(ctx *lepton.Context) error {
 	config := ctx.Config()
 
-	image, err := h.fetchSnapshotByName(context.Background(), config.CloudConfig.ImageName)
+	image, err := h.fetchSnapshotByName(ctx.Context(), config.CloudConfig.ImageName)
 	if err != nil {
 		return err
 	}
@@ -35,7 +35,7 @@ func (h *Hetzner) CreateInstance(ctx *lepton.Context) error {
 		flavor = defaultServerType
 	}
 
-	serverType, _, err := h.Client.ServerType.GetByName(context.Background(), flavor)
+	serverType, _, err := h.Client.ServerType.GetByName(ctx.Context(), flavor)
 	if err != nil {
 		return err
 	}
@@ -50,7 +50,7 @@ func (h *Hetzner) CreateInstance(ctx *lepton.Context) error {
 
 	zone := strings.TrimSpace(config.CloudConfig.Zone)
 
-	_, err = h.createServer(context.Background(), serverCreateParams{
+	_, err = h.createServer(ctx.Context(), serverCreateParams{
 		Name:       instanceName,
 		ServerType: serverType,
 		Image:      image,
@@ -112,7 +112,7 @@ func (h *Hetzner) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, err
 		},
 	}
 
-	servers, err := h.Client.Server.AllWithOpts(context.Background(), opts)
+	servers, err := h.Client.Server.AllWithOpts(ctx.Context(), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +133,7 @@ func (h *Hetzner) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, err
 
 // GetInstanceByName looks up a managed Hetzner instance by its name label.
 func (h *Hetzner) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.CloudInstance, error) {
-	server, err := h.fetchServerByName(context.Background(), name)
+	server, err := h.fetchServerByName(ctx.Context(), name)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +147,7 @@ func (h *Hetzner) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.C
 
 // DeleteInstance removes the specified Hetzner server.
 func (h *Hetzner) DeleteInstance(ctx *lepton.Context, instancename string) error {
-	server, err := h.fetchServerByName(context.Background(), instancename)
+	server, err := h.fetchServerByName(ctx.Context(), instancename)
 	if err != nil {
 		return err
 	}
@@ -155,7 +155,7 @@ func (h *Hetzner) DeleteInstance(ctx *lepton.Context, instancename string) error
 		return lepton.ErrInstanceNotFound(instancename)
 	}
 
-	if _, _, err := h.Client.Server.DeleteWithResult(context.Background(), server); err != nil {
+	if _, _, err := h.Client.Server.DeleteWithResult(ctx.Context(), server); err != nil {
 		return err
 	}
 
@@ -164,7 +164,7 @@ func (h *Hetzner) DeleteInstance(ctx *lepton.Context, instancename string) error
 
 // StopInstance powers off the target Hetzner server.
 func (h *Hetzner) StopInstance(ctx *lepton.Context, instancename string) error {
-	server, err := h.fetchServerByName(context.Background(), instancename)
+	server, err := h.fetchServerByName(ctx.Context(), instancename)
 	if err != nil {
 		return err
 	}
@@ -172,19 +172,19 @@ func (h *Hetzner) StopInstance(ctx *lepton.Context, instancename string) error {
 		return lepton.ErrInstanceNotFound(instancename)
 	}
 
-	action, _, err := h.Client.Server.Poweroff(context.Background(), server)
+	action, _, err := h.Client.Server.Poweroff(ctx.Context(), server)
 	if err != nil {
 		return err
 	}
 	if action != nil {
-		return h.Client.Action.WaitFor(context.Background(), action)
+		return h.Client.Action.WaitFor(ctx.Context(), action)
 	}
 	return nil
 }
 
 // StartInstance powers on the target Hetzner server.
 func (h *Hetzner) StartInstance(ctx *lepton.Context, instancename string) error {
-	server, err := h.fetchServerByName(context.Background(), instancename)
+	server, err := h.fetchServerByName(ctx.Context(), instancename)
 	if err != nil {
 		return err
 	}
@@ -192,19 +192,19 @@ func (h *Hetzner) StartInstance(ctx *lepton.Context, instancename string) error
 		return lepton.ErrInstanceNotFound(instancename)
 	}
 
-	action, _, err := h.Client.Server.Poweron(context.Background(), server)
+	action, _, err := h.Client.Server.Poweron(ctx.Context(), server)
 	if err != nil {
 		return err
 	}
 	if action != nil {
-		return h.Client.Action.WaitFor(context.Background(), action)
+		return h.Client.Action.WaitFor(ctx.Context(), action)
 	}
 	return nil
 }
 
 // RebootInstance restarts the target Hetzner server.
 func (h *Hetzner) RebootInstance(ctx *lepton.Context, instancename string) error {
-	server, err := h.fetchServerByName(context.Background(), instancename)
+	server, err := h.fetchServerByName(ctx.Context(), instancename)
 	if err != nil {
 		return err
 	}
@@ -212,12 +212,12 @@ func (h *Hetzner) RebootInstance(ctx *lepton.Context, instancename string) error
 		return lepton.ErrInstanceNotFound(instancename)
 	}
 
-	action, _, err := h.Client.Server.Reboot(context.Background(), server)
+	action, _, err := h.Client.Server.Reboot(ctx.Context(), server)
 	if err != nil {
 		return err
 	}
 	if action != nil {
-		return h.Client.Action.WaitFor(context.Background(), action)
+		return h.Client.Action.WaitFor(ctx.Context(), action)
 	}
 	return nil
 }
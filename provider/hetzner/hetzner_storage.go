@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/minio/minio-go"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
 )
@@ -65,7 +66,8 @@ func (s *ObjectStorage) getImageObjectStorageURL(config *types.Config, key strin
 }
 
 // CopyToBucket copies archive to bucket.
-func (s *ObjectStorage) CopyToBucket(config *types.Config, archPath string) error {
+func (s *ObjectStorage) CopyToBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
 	file, err := os.Open(archPath)
 	if err != nil {
 		return err
@@ -96,7 +98,7 @@ func (s *ObjectStorage) CopyToBucket(config *types.Config, archPath string) erro
 	}
 
 	key := filepath.Base(archPath)
-	n, err := client.PutObject(bucket, key, file, stat.Size(), minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	n, err := client.PutObjectWithContext(ctx.Context(), bucket, key, file, stat.Size(), minio.PutObjectOptions{ContentType: "application/octet-stream"})
 	if err != nil {
 		return err
 	}
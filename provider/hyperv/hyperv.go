@@ -41,6 +41,12 @@ func (p *Provider) Initialize(c *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by Hyper-V, which has none as
+// its VMs are driven through PowerShell for the basic operations only
+func (p *Provider) Capabilities() lepton.Capabilities {
+	return nil
+}
+
 // CreateVolume is a stub
 func (p *Provider) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data string, provider string) (lepton.NanosVolume, error) {
 	return lepton.NanosVolume{}, errors.New("Unsupported")
@@ -41,6 +41,12 @@ func (v *IBM) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by IBM Cloud; volumes, logs and
+// stats are not wired to the VPC API, so the list is empty
+func (v *IBM) Capabilities() lepton.Capabilities {
+	return nil
+}
+
 // Token is the return type for a new IAM token.
 type Token struct {
 	AccessToken string `json:"access_token"`
@@ -55,7 +61,7 @@ func (v *IBM) setIAMToken() {
 	data.Set("response_type", "cloud_iam")
 	data.Set("grant_type", "urn:ibm:params:oauth:grant-type:apikey")
 
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 	r, err := http.NewRequest(http.MethodPost, uri, strings.NewReader(data.Encode()))
 	if err != nil {
 		fmt.Println(err)
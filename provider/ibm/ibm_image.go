@@ -65,18 +65,17 @@ func (v *IBM) CreateImage(ctx *lepton.Context, imagePath string) error {
 	}
 
 	v.Storage = store
-	err = v.Storage.CopyToBucket(ctx.Config(), icow)
+	err = v.Storage.CopyToBucket(ctx, icow)
 	if err != nil {
 		return err
 	}
 
 	imgName := ctx.Config().CloudConfig.ImageName
 
-	v.createImage(ctx, icow, imgName)
-	return nil
+	return v.createImage(ctx, icow, imgName)
 }
 
-func (v *IBM) createImage(ctx *lepton.Context, icow string, imgName string) {
+func (v *IBM) createImage(ctx *lepton.Context, icow string, imgName string) error {
 	baseName := filepath.Base(icow)
 
 	c := ctx.Config()
@@ -88,7 +87,7 @@ func (v *IBM) createImage(ctx *lepton.Context, icow string, imgName string) {
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/images?version=2023-02-16&generation=2"
 
-	rgroup := v.getDefaultResourceGroup()
+	rgroup := v.getDefaultResourceGroup(ctx)
 
 	j := `{
 	     "name": "` + imgName + `",
@@ -105,10 +104,10 @@ func (v *IBM) createImage(ctx *lepton.Context, icow string, imgName string) {
 
 	reqBody := []byte(j)
 
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", uri, bytes.NewBuffer(reqBody))
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", uri, bytes.NewBuffer(reqBody))
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 
 	req.Header.Add("Content-Type", "application/json")
@@ -117,16 +116,17 @@ func (v *IBM) createImage(ctx *lepton.Context, icow string, imgName string) {
 
 	res, err := client.Do(req)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 
 	fmt.Println(string(body))
+	return nil
 }
 
 // ImageListResponse is the set of instances available from IBM in an
@@ -146,7 +146,7 @@ type Image struct {
 // GetImages return all images on IBM
 // needs tags added
 func (v *IBM) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 
 	c := ctx.Config()
 	zone := c.CloudConfig.Zone
@@ -155,7 +155,7 @@ func (v *IBM) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/images?version=2023-02-28&generation=2&visibility=private"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
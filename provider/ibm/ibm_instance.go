@@ -49,8 +49,8 @@ func (v *IBM) CreateInstance(ctx *lepton.Context) error {
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/instances?version=2023-02-28&generation=2"
 
-	vpcID := v.getDefaultVPC(region)
-	subnetID := v.getDefaultSubnet(region)
+	vpcID := v.getDefaultVPC(ctx, region)
+	subnetID := v.getDefaultSubnet(ctx, region)
 
 	t := time.Now().Unix()
 	st := strconv.FormatInt(t, 10)
@@ -95,8 +95,8 @@ func (v *IBM) CreateInstance(ctx *lepton.Context) error {
 
 	reqBody := []byte(stuff)
 
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", uri, bytes.NewBuffer(reqBody))
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", uri, bytes.NewBuffer(reqBody))
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -148,7 +148,7 @@ func (v *IBM) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error)
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/instances?version=2023-02-28&generation=2"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -156,7 +156,7 @@ func (v *IBM) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+v.iam)
 
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 
 	res, err := client.Do(req)
 	if err != nil {
@@ -230,8 +230,8 @@ func (v *IBM) DeleteInstance(ctx *lepton.Context, instanceID string) error {
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/instances/$instance_id?version=2023-02-28&generation=2"
 
-	client := &http.Client{}
-	req, err := http.NewRequest("DELETE", uri, nil)
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "DELETE", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
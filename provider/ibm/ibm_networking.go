@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/nanovms/ops/lepton"
 )
 
 // VPCListResponse is the response type for the vpc list endpoint.
@@ -47,12 +49,12 @@ type ResourceGroup struct {
 	Name string `json:"name"`
 }
 
-func (v *IBM) getDefaultResourceGroup() string {
-	client := &http.Client{}
+func (v *IBM) getDefaultResourceGroup(ctx *lepton.Context) string {
+	client := lepton.NewHTTPClient(ProviderName)
 
 	uri := "https://resource-controller.cloud.ibm.com/v2/resource_groups"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -87,12 +89,12 @@ func (v *IBM) getDefaultResourceGroup() string {
 	return rid
 }
 
-func (v *IBM) getDefaultVPC(region string) string {
-	client := &http.Client{}
+func (v *IBM) getDefaultVPC(ctx *lepton.Context, region string) string {
+	client := lepton.NewHTTPClient(ProviderName)
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/vpcs?version=2023-02-28&generation=2"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -121,12 +123,12 @@ func (v *IBM) getDefaultVPC(region string) string {
 	return ilr.VPCs[0].ID
 }
 
-func (v *IBM) getDefaultSubnet(region string) string {
-	client := &http.Client{}
+func (v *IBM) getDefaultSubnet(ctx *lepton.Context, region string) string {
+	client := lepton.NewHTTPClient(ProviderName)
 
 	uri := "https://" + region + ".iaas.cloud.ibm.com/v1/subnets?version=2023-02-28&generation=2"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 )
 
@@ -20,7 +21,8 @@ type Objects struct {
 }
 
 // CopyToBucket copies archive to bucket
-func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
+func (s *Objects) CopyToBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
 	zone := config.CloudConfig.Zone
 
 	region := extractRegionFromZone(zone)
@@ -37,8 +39,8 @@ func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
 
 	reader := bufio.NewReader(f)
 
-	client := &http.Client{}
-	r, err := http.NewRequest(http.MethodPut, uri, reader)
+	client := lepton.NewHTTPClient(ProviderName)
+	r, err := http.NewRequestWithContext(ctx.Context(), http.MethodPut, uri, reader)
 	if err != nil {
 		fmt.Println(err)
 	}
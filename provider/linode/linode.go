@@ -39,15 +39,24 @@ func (v *Linode) Initialize(config *types.ProviderConfig) error {
 	oauth2Client := &http.Client{
 		Transport: &oauth2.Transport{
 			Source: tokenSource,
+			Base:   lepton.NewRetryTransport(ProviderName, nil),
 		},
 	}
 
 	v.Client = linodego.NewClient(oauth2Client)
+	v.Client.SetRetryCount(0)
 
 	//	ctx := context.Background()
 	return nil
 }
 
+// Capabilities returns the optional operations supported by Linode
+func (v *Linode) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceReboot,
+	}
+}
+
 // GetStorage returns storage interface for cloud provider
 func (v *Linode) GetStorage() lepton.Storage {
 	return v.Storage
@@ -45,7 +45,7 @@ func (v *Linode) destroyImage(snapshotid string) {
 
 // CreateImage - Creates image on linode using nanos images
 func (v *Linode) CreateImage(ctx *lepton.Context, imagePath string) error {
-	err := v.Storage.CopyToBucket(ctx.Config(), imagePath)
+	err := v.Storage.CopyToBucket(ctx, imagePath)
 	if err != nil {
 		return err
 	}
@@ -71,11 +71,11 @@ type Image struct {
 // GetImages return all images on Linode
 func (v *Linode) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
 	token := os.Getenv("TOKEN")
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 
 	uri := "https://api.linode.com/v4/images"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -3,7 +3,6 @@
 package linode
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -16,7 +15,7 @@ import (
 	"github.com/olekukonko/tablewriter"
 )
 
-func (v *Linode) addDisk(instanceID int, imageID int, imgName string) (int, error) {
+func (v *Linode) addDisk(ctx *lepton.Context, instanceID int, imageID int, imgName string) (int, error) {
 	diskOpts := linodego.InstanceDiskCreateOptions{
 		Label:    imgName,
 		Image:    "private/" + strconv.Itoa(imageID),
@@ -24,13 +23,13 @@ func (v *Linode) addDisk(instanceID int, imageID int, imgName string) (int, erro
 		RootPass: "__aC0mpl3xP@ssW0rd123__",
 	}
 
-	disk, err := v.Client.CreateInstanceDisk(context.Background(), instanceID, diskOpts)
+	disk, err := v.Client.CreateInstanceDisk(ctx.Context(), instanceID, diskOpts)
 	if err != nil {
 		return 0, err
 	}
 
 	for i := 0; i < 30; i++ {
-		status := v.getStatusForDisk(instanceID, disk.ID)
+		status := v.getStatusForDisk(ctx, instanceID, disk.ID)
 		time.Sleep(2 * time.Second) // hack
 
 		if status == linodego.DiskReady {
@@ -40,7 +39,7 @@ func (v *Linode) addDisk(instanceID int, imageID int, imgName string) (int, erro
 	return 0, fmt.Errorf("err: timed out waiting for disk to be ready")
 }
 
-func (v *Linode) addConfig(instanceID int, diskID int, imgName string) {
+func (v *Linode) addConfig(ctx *lepton.Context, instanceID int, diskID int, imgName string) {
 	createOpts := linodego.InstanceConfigCreateOptions{
 		Devices: linodego.InstanceConfigDeviceMap{
 			SDA: &linodego.InstanceConfigDevice{DiskID: diskID},
@@ -59,13 +58,13 @@ func (v *Linode) addConfig(instanceID int, diskID int, imgName string) {
 			DevTmpFsAutomount: false,
 		},
 	}
-	config, err := v.Client.CreateInstanceConfig(context.Background(), instanceID, createOpts)
+	config, err := v.Client.CreateInstanceConfig(ctx.Context(), instanceID, createOpts)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	_, err = v.Client.UpdateInstanceConfig(context.Background(), instanceID, config.ID, linodego.InstanceConfigUpdateOptions{})
+	_, err = v.Client.UpdateInstanceConfig(ctx.Context(), instanceID, config.ID, linodego.InstanceConfigUpdateOptions{})
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -120,13 +119,19 @@ func (v *Linode) CreateInstance(ctx *lepton.Context) error {
 		Booted:   &booted,
 		SwapSize: &swapSize,
 	}
-	linode, err := v.Client.CreateInstance(context.Background(), instance)
+	linode, err := v.Client.CreateInstance(ctx.Context(), instance)
 	if err != nil {
 		return fmt.Errorf("error creating instance: %w", err)
 	}
+	done := ctx.AddCleanup("instance "+imgName, func(ctx *lepton.Context) error {
+		return v.Client.DeleteInstance(ctx.Context(), linode.ID)
+	})
 
 	for i := 0; i < 30; i++ {
-		status := v.getStatusForLinode(linode.ID)
+		status := v.getStatusForLinode(ctx, linode.ID)
+		if err := ctx.Context().Err(); err != nil {
+			return err
+		}
 		time.Sleep(2 * time.Second) // hack
 
 		if status == linodego.InstanceOffline {
@@ -139,20 +144,21 @@ func (v *Linode) CreateInstance(ctx *lepton.Context) error {
 		return fmt.Errorf("error fetching image id: %w", err)
 	}
 
-	diskID, err := v.addDisk(linode.ID, imgID, imgName)
+	diskID, err := v.addDisk(ctx, linode.ID, imgID, imgName)
 	if err != nil {
 		return fmt.Errorf("error adding disk: %w", err)
 	}
 
-	v.addConfig(linode.ID, diskID, imgName)
+	v.addConfig(ctx, linode.ID, diskID, imgName)
 	sinstanceID := strconv.Itoa(linode.ID)
 	v.StartInstance(ctx, sinstanceID)
+	done()
 
 	return nil
 }
 
-func (v *Linode) getStatusForDisk(instanceID, diskID int) linodego.DiskStatus {
-	disk, err := v.Client.GetInstanceDisk(context.Background(), instanceID, diskID)
+func (v *Linode) getStatusForDisk(ctx *lepton.Context, instanceID, diskID int) linodego.DiskStatus {
+	disk, err := v.Client.GetInstanceDisk(ctx.Context(), instanceID, diskID)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -160,8 +166,8 @@ func (v *Linode) getStatusForDisk(instanceID, diskID int) linodego.DiskStatus {
 	return disk.Status
 }
 
-func (v *Linode) getStatusForLinode(id int) linodego.InstanceStatus {
-	instance, err := v.Client.GetInstance(context.Background(), id)
+func (v *Linode) getStatusForLinode(ctx *lepton.Context, id int) linodego.InstanceStatus {
+	instance, err := v.Client.GetInstance(ctx.Context(), id)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -176,7 +182,7 @@ func (v *Linode) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.Cl
 
 // GetInstances return all instances on Linode
 func (v *Linode) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
-	instances, err := v.Client.ListInstances(context.Background(), &linodego.ListOptions{})
+	instances, err := v.Client.ListInstances(ctx.Context(), &linodego.ListOptions{})
 	if err != nil {
 		fmt.Println(err)
 		return nil, err
@@ -234,7 +240,7 @@ func (v *Linode) DeleteInstance(ctx *lepton.Context, instanceID string) error {
 		fmt.Println(err)
 		return err
 	}
-	v.Client.DeleteInstance(context.Background(), linodeID)
+	v.Client.DeleteInstance(ctx.Context(), linodeID)
 
 	return nil
 }
@@ -243,12 +249,12 @@ func (v *Linode) DeleteInstance(ctx *lepton.Context, instanceID string) error {
 func (v *Linode) RebootInstance(ctx *lepton.Context, instanceName string) error {
 	filter := fmt.Sprintf("{\"label\": \"%s\"}", instanceName)
 	opts := linodego.NewListOptions(0, filter)
-	linodes, err := v.Client.ListInstances(context.Background(), opts)
+	linodes, err := v.Client.ListInstances(ctx.Context(), opts)
 	if err != nil || len(linodes) == 0 {
 		return fmt.Errorf("error fetching linode id by name")
 	}
 
-	err = v.Client.RebootInstance(context.Background(), linodes[0].ID, 0)
+	err = v.Client.RebootInstance(ctx.Context(), linodes[0].ID, 0)
 	if err != nil {
 		return fmt.Errorf("error rebooting instance: %w", err)
 	}
@@ -263,7 +269,7 @@ func (v *Linode) StartInstance(ctx *lepton.Context, instanceID string) error {
 		return fmt.Errorf("error converting instanceID to int: %w", err)
 	}
 
-	err = v.Client.BootInstance(context.Background(), linodeID, 0)
+	err = v.Client.BootInstance(ctx.Context(), linodeID, 0)
 	if err != nil {
 		return fmt.Errorf("error booting instance: %w", err)
 	}
@@ -278,7 +284,7 @@ func (v *Linode) StopInstance(ctx *lepton.Context, instanceID string) error {
 		return fmt.Errorf("error converting instanceID to str: %w", err)
 	}
 
-	err = v.Client.ShutdownInstance(context.Background(), linodeID)
+	err = v.Client.ShutdownInstance(ctx.Context(), linodeID)
 	if err != nil {
 		return fmt.Errorf("error shutting down instance: %w", err)
 	}
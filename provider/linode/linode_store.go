@@ -6,6 +6,7 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 )
 
@@ -22,12 +24,13 @@ type Objects struct{}
 
 // URLResponse provides a url for uploading an image to Linode.
 type URLResponse struct {
-	Link string `json:"upload_to"`
+	Link  string `json:"upload_to"`
+	Image Image  `json:"image"`
 }
 
-func getURL(imgName string) string {
+func getURL(ctx context.Context, imgName string) (string, string) {
 	token := os.Getenv("TOKEN")
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 
 	s := `{
 		"label": "` + imgName + `",
@@ -37,7 +40,7 @@ func getURL(imgName string) string {
 	reqBody := []byte(s)
 
 	uri := "https://api.linode.com/v4/images/upload"
-	req, err := http.NewRequest("POST", uri, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewBuffer(reqBody))
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -67,10 +70,35 @@ func getURL(imgName string) string {
 		fmt.Println(err)
 	}
 
-	return delval
+	return delval, ur.Image.ID
 }
 
-func uploadImage(uri string, archPath string) {
+// deleteImage deletes the image created to upload an archive
+func deleteImage(ctx context.Context, imageID string) error {
+	token := os.Getenv("TOKEN")
+	client := lepton.NewHTTPClient(ProviderName)
+
+	uri := "https://api.linode.com/v4/images/" + imageID
+	req, err := http.NewRequestWithContext(ctx, "DELETE", uri, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot delete image %s: %s", imageID, res.Status)
+	}
+	return nil
+}
+
+func uploadImage(ctx context.Context, uri string, archPath string) error {
 	f, err := os.Open(archPath)
 	if err != nil {
 		fmt.Println(err)
@@ -89,36 +117,41 @@ func uploadImage(uri string, archPath string) {
 
 	slen := strconv.Itoa(len(buf.Bytes()))
 
-	req, err := http.NewRequest("PUT", uri, bytes.NewReader(buf.Bytes()))
+	req, err := http.NewRequestWithContext(ctx, "PUT", uri, bytes.NewReader(buf.Bytes()))
 	if err != nil {
 		fmt.Println(err)
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
 	req.Header.Set("Content-Length", slen)
 
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 	res, err := client.Do(req)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	defer res.Body.Close()
 
 	_, err = io.ReadAll(res.Body)
-	if err != nil {
-		fmt.Println(err)
-	}
-
+	return err
 }
 
 // CopyToBucket copies archive to bucket
-func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
+func (s *Objects) CopyToBucket(ctx *lepton.Context, archPath string) error {
 	fmt.Println("copying to bucket..")
 
-	imgName := config.CloudConfig.ImageName
+	imgName := ctx.Config().CloudConfig.ImageName
 
-	link := getURL(imgName)
+	link, imageID := getURL(ctx.Context(), imgName)
+	// the image is created before its archive is uploaded
+	done := ctx.AddCleanup("image "+imgName, func(ctx *lepton.Context) error {
+		return deleteImage(ctx.Context(), imageID)
+	})
 
-	uploadImage(link, archPath)
+	err := uploadImage(ctx.Context(), link, archPath)
+	if err != nil {
+		return err
+	}
+	done()
 
 	return nil
 }
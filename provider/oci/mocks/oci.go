@@ -260,6 +260,21 @@ func (mr *MockNetworkServiceMockRecorder) CreateNetworkSecurityGroup(ctx, reques
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNetworkSecurityGroup", reflect.TypeOf((*MockNetworkService)(nil).CreateNetworkSecurityGroup), ctx, request)
 }
 
+// DeleteNetworkSecurityGroup mocks base method.
+func (m *MockNetworkService) DeleteNetworkSecurityGroup(ctx context.Context, request core.DeleteNetworkSecurityGroupRequest) (core.DeleteNetworkSecurityGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNetworkSecurityGroup", ctx, request)
+	ret0, _ := ret[0].(core.DeleteNetworkSecurityGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteNetworkSecurityGroup indicates an expected call of DeleteNetworkSecurityGroup.
+func (mr *MockNetworkServiceMockRecorder) DeleteNetworkSecurityGroup(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNetworkSecurityGroup", reflect.TypeOf((*MockNetworkService)(nil).DeleteNetworkSecurityGroup), ctx, request)
+}
+
 // GetVnic mocks base method.
 func (m *MockNetworkService) GetVnic(ctx context.Context, request core.GetVnicRequest) (core.GetVnicResponse, error) {
 	m.ctrl.T.Helper()
@@ -366,6 +381,21 @@ func (m *MockStorageService) EXPECT() *MockStorageServiceMockRecorder {
 	return m.recorder
 }
 
+// DeleteObject mocks base method.
+func (m *MockStorageService) DeleteObject(ctx context.Context, request objectstorage.DeleteObjectRequest) (objectstorage.DeleteObjectResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObject", ctx, request)
+	ret0, _ := ret[0].(objectstorage.DeleteObjectResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteObject indicates an expected call of DeleteObject.
+func (mr *MockStorageServiceMockRecorder) DeleteObject(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObject", reflect.TypeOf((*MockStorageService)(nil).DeleteObject), ctx, request)
+}
+
 // PutObject mocks base method.
 func (m *MockStorageService) PutObject(ctx context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error) {
 	m.ctrl.T.Helper()
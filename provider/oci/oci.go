@@ -6,7 +6,10 @@ package oci
 
 import (
 	"context"
+	"net/http"
+	"time"
 
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/core"
@@ -19,6 +22,9 @@ import (
 // ProviderName of the cloud platform provider
 const ProviderName = "oci"
 
+// initializeTimeout bounds the calls made to initialize the provider
+const initializeTimeout = time.Minute
+
 var (
 	ociOpsTags = map[string]string{"CreatedBy": "OPS"}
 )
@@ -49,6 +55,7 @@ type NetworkService interface {
 	ListSubnets(ctx context.Context, request core.ListSubnetsRequest) (response core.ListSubnetsResponse, err error)
 	GetVnic(ctx context.Context, request core.GetVnicRequest) (response core.GetVnicResponse, err error)
 	CreateNetworkSecurityGroup(ctx context.Context, request core.CreateNetworkSecurityGroupRequest) (response core.CreateNetworkSecurityGroupResponse, err error)
+	DeleteNetworkSecurityGroup(ctx context.Context, request core.DeleteNetworkSecurityGroupRequest) (response core.DeleteNetworkSecurityGroupResponse, err error)
 	AddNetworkSecurityGroupSecurityRules(ctx context.Context, request core.AddNetworkSecurityGroupSecurityRulesRequest) (response core.AddNetworkSecurityGroupSecurityRulesResponse, err error)
 }
 
@@ -60,6 +67,7 @@ type WorkRequestService interface {
 // StorageService has OCI client methods to manage storage block, required to upload images
 type StorageService interface {
 	PutObject(ctx context.Context, request objectstorage.PutObjectRequest) (response objectstorage.PutObjectResponse, err error)
+	DeleteObject(ctx context.Context, request objectstorage.DeleteObjectRequest) (response objectstorage.DeleteObjectResponse, err error)
 }
 
 // BlockstorageService has OCI client methods to manage volumes
@@ -99,30 +107,40 @@ func NewProviderWithClients(c ComputeService, s StorageService, w WorkRequestSer
 func (p *Provider) Initialize(providerConfig *types.ProviderConfig) (err error) {
 	config := common.DefaultConfigProvider()
 
-	p.computeClient, err = core.NewComputeClientWithConfigurationProvider(config)
+	computeClient, err := core.NewComputeClientWithConfigurationProvider(config)
 	if err != nil {
 		return
 	}
+	withRetries(&computeClient.BaseClient)
+	p.computeClient = computeClient
 
-	p.storageClient, err = objectstorage.NewObjectStorageClientWithConfigurationProvider(config)
+	storageClient, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(config)
 	if err != nil {
 		return
 	}
+	withRetries(&storageClient.BaseClient)
+	p.storageClient = storageClient
 
-	p.workRequestClient, err = workrequests.NewWorkRequestClientWithConfigurationProvider(config)
+	workRequestClient, err := workrequests.NewWorkRequestClientWithConfigurationProvider(config)
 	if err != nil {
 		return
 	}
+	withRetries(&workRequestClient.BaseClient)
+	p.workRequestClient = workRequestClient
 
-	p.networkClient, err = core.NewVirtualNetworkClientWithConfigurationProvider(config)
+	networkClient, err := core.NewVirtualNetworkClientWithConfigurationProvider(config)
 	if err != nil {
 		return
 	}
+	withRetries(&networkClient.BaseClient)
+	p.networkClient = networkClient
 
-	p.blockstorageClient, err = core.NewBlockstorageClientWithConfigurationProvider(config)
+	blockstorageClient, err := core.NewBlockstorageClientWithConfigurationProvider(config)
 	if err != nil {
 		return
 	}
+	withRetries(&blockstorageClient.BaseClient)
+	p.blockstorageClient = blockstorageClient
 
 	p.compartmentID, _ = config.TenancyOCID()
 
@@ -130,8 +148,13 @@ func (p *Provider) Initialize(providerConfig *types.ProviderConfig) (err error)
 	if err != nil {
 		return
 	}
+	withRetries(&identityClient.BaseClient)
 
-	domains, err := identityClient.ListAvailabilityDomains(context.TODO(), identity.ListAvailabilityDomainsRequest{CompartmentId: &p.compartmentID})
+	// providers are initialized before interrupts are trapped for their operations, the call is
+	// only bounded so that a hung API does not block the command
+	execCtx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+	defer cancel()
+	domains, err := identityClient.ListAvailabilityDomains(execCtx, identity.ListAvailabilityDomainsRequest{CompartmentId: &p.compartmentID})
 	if err != nil {
 		return
 	}
@@ -142,3 +165,21 @@ func (p *Provider) Initialize(providerConfig *types.ProviderConfig) (err error)
 
 	return
 }
+
+// withRetries sends the calls of an oci client through the retry transport of ops, the retries of
+// the sdk being disabled so that calls are not retried twice
+func withRetries(client *common.BaseClient) {
+	if httpClient, ok := client.HTTPClient.(*http.Client); ok {
+		httpClient.Transport = lepton.NewRetryTransport(ProviderName, httpClient.Transport)
+	}
+
+	noRetry := common.NoRetryPolicy()
+	client.Configuration.RetryPolicy = &noRetry
+}
+
+// Capabilities returns the optional operations supported by Oracle Cloud
+func (p *Provider) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+	}
+}
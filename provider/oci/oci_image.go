@@ -3,7 +3,6 @@
 package oci
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -140,7 +139,7 @@ func (p *Provider) CreateImage(ctx *lepton.Context, imagePath string) (err error
 
 	imageSize := imageStats.Size()
 
-	_, err = p.storageClient.PutObject(context.TODO(), objectstorage.PutObjectRequest{
+	_, err = p.storageClient.PutObject(ctx.Context(), objectstorage.PutObjectRequest{
 		NamespaceName: &bucketNamespace,
 		BucketName:    &bucketName,
 		ContentLength: &imageSize,
@@ -151,8 +150,17 @@ func (p *Provider) CreateImage(ctx *lepton.Context, imagePath string) (err error
 		ctx.Logger().Error(err)
 		return errors.New("failed uploading image")
 	}
+	// the object is kept once the image is imported, as it was before
+	objectDone := ctx.AddCleanup("image object "+imageName, func(ctx *lepton.Context) error {
+		_, err := p.storageClient.DeleteObject(ctx.Context(), objectstorage.DeleteObjectRequest{
+			NamespaceName: &bucketNamespace,
+			BucketName:    &bucketName,
+			ObjectName:    &imageName,
+		})
+		return err
+	})
 
-	job, err := p.computeClient.CreateImage(context.TODO(), core.CreateImageRequest{
+	job, err := p.computeClient.CreateImage(ctx.Context(), core.CreateImageRequest{
 		CreateImageDetails: core.CreateImageDetails{
 			CompartmentId: &p.compartmentID,
 			DisplayName:   &imageName,
@@ -170,6 +178,10 @@ func (p *Provider) CreateImage(ctx *lepton.Context, imagePath string) (err error
 		ctx.Logger().Error(err)
 		return errors.New("failed importing image from storage")
 	}
+	imageDone := ctx.AddCleanup("image "+imageName, func(ctx *lepton.Context) error {
+		_, err := p.computeClient.DeleteImage(ctx.Context(), core.DeleteImageRequest{ImageId: job.Image.Id})
+		return err
+	})
 
 	fmt.Println("It will take a while to import the image.")
 	bar := progressbar.New(100)
@@ -181,7 +193,7 @@ func (p *Provider) CreateImage(ctx *lepton.Context, imagePath string) (err error
 	quit := make(chan bool)
 	getProgress := func() {
 		var res workrequests.GetWorkRequestResponse
-		res, err = p.workRequestClient.GetWorkRequest(context.TODO(), workrequests.GetWorkRequestRequest{WorkRequestId: job.OpcWorkRequestId})
+		res, err = p.workRequestClient.GetWorkRequest(ctx.Context(), workrequests.GetWorkRequestRequest{WorkRequestId: job.OpcWorkRequestId})
 		if err != nil {
 			quit <- true
 			return
@@ -214,9 +226,14 @@ bloop:
 			break bloop
 		}
 	}
+	if err != nil {
+		return err
+	}
+	imageDone()
+	objectDone()
 
 	if getArchitecture(ctx.Config().CloudConfig.Flavor) == "arm64" {
-		p.updateCapability(imgID)
+		p.updateCapability(ctx, imgID)
 
 		req := core.AddImageShapeCompatibilityEntryRequest{
 			AddImageShapeCompatibilityEntryDetails: core.AddImageShapeCompatibilityEntryDetails{
@@ -233,7 +250,7 @@ bloop:
 			ShapeName: common.String("VM.Standard.A1.Flex"),
 		}
 
-		_, err := p.computeClient.AddImageShapeCompatibilityEntry(context.Background(), req)
+		_, err := p.computeClient.AddImageShapeCompatibilityEntry(ctx.Context(), req)
 		if err != nil {
 			fmt.Println(err)
 			return err
@@ -243,16 +260,17 @@ bloop:
 	return nil
 }
 
-func (p *Provider) updateCapability(imageID string) {
+func (p *Provider) updateCapability(ctx *lepton.Context, imageID string) {
 
 	client, err := core.NewComputeClientWithConfigurationProvider(common.DefaultConfigProvider())
 	if err != nil {
 		fmt.Println(err)
 	}
+	withRetries(&client.BaseClient)
 
 	req := core.ListComputeGlobalImageCapabilitySchemasRequest{}
 
-	resp, err := client.ListComputeGlobalImageCapabilitySchemas(context.Background(), req)
+	resp, err := client.ListComputeGlobalImageCapabilitySchemas(ctx.Context(), req)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -276,7 +294,7 @@ func (p *Provider) updateCapability(imageID string) {
 		},
 	}
 
-	resp2, err := client.CreateComputeImageCapabilitySchema(context.Background(), req2)
+	resp2, err := client.CreateComputeImageCapabilitySchema(ctx.Context(), req2)
 	if err != nil {
 		fmt.Println(resp2)
 		fmt.Println(err)
@@ -315,7 +333,7 @@ func (p *Provider) ListImages(ctx *lepton.Context, filter string) error {
 func (p *Provider) GetImages(ctx *lepton.Context, filter string) (images []lepton.CloudImage, err error) {
 	images = []lepton.CloudImage{}
 
-	imagesList, err := p.computeClient.ListImages(context.TODO(), core.ListImagesRequest{OperatingSystem: types.StringPtr("Custom"), CompartmentId: types.StringPtr(p.compartmentID)})
+	imagesList, err := p.computeClient.ListImages(ctx.Context(), core.ListImagesRequest{OperatingSystem: types.StringPtr("Custom"), CompartmentId: types.StringPtr(p.compartmentID)})
 	if err != nil {
 		ctx.Logger().Error(err)
 		return nil, errors.New("failed getting images")
@@ -348,7 +366,7 @@ func (p *Provider) DeleteImage(ctx *lepton.Context, imagename string) (err error
 		return
 	}
 
-	_, err = p.computeClient.DeleteImage(context.TODO(), core.DeleteImageRequest{ImageId: &image.ID})
+	_, err = p.computeClient.DeleteImage(ctx.Context(), core.DeleteImageRequest{ImageId: &image.ID})
 
 	return
 }
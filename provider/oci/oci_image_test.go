@@ -3,7 +3,6 @@
 package oci_test
 
 import (
-	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -88,11 +87,11 @@ func TestCreateImage(t *testing.T) {
 	ctx.Config().CloudConfig.BucketNamespace = bucketNamespace
 
 	storageService.EXPECT().
-		PutObject(context.TODO(), PutObjectMatcher(objectstorage.PutObjectRequest{NamespaceName: &bucketNamespace, ObjectName: &cloudImageName, BucketName: &bucketName, ContentLength: types.Int64Ptr(0)})).
+		PutObject(ctx.Context(), PutObjectMatcher(objectstorage.PutObjectRequest{NamespaceName: &bucketNamespace, ObjectName: &cloudImageName, BucketName: &bucketName, ContentLength: types.Int64Ptr(0)})).
 		Return(objectstorage.PutObjectResponse{}, nil)
 
 	computeService.EXPECT().
-		CreateImage(context.TODO(), core.CreateImageRequest{
+		CreateImage(ctx.Context(), core.CreateImageRequest{
 			CreateImageDetails: core.CreateImageDetails{
 				CompartmentId: types.StringPtr(""),
 				DisplayName:   &cloudImageName,
@@ -109,7 +108,7 @@ func TestCreateImage(t *testing.T) {
 		Return(core.CreateImageResponse{OpcWorkRequestId: types.StringPtr("WorkID")}, nil)
 
 	workRequestService.EXPECT().
-		GetWorkRequest(context.TODO(), workrequests.GetWorkRequestRequest{WorkRequestId: types.StringPtr(("WorkID"))}).
+		GetWorkRequest(ctx.Context(), workrequests.GetWorkRequestRequest{WorkRequestId: types.StringPtr(("WorkID"))}).
 		Return(workrequests.GetWorkRequestResponse{WorkRequest: workrequests.WorkRequest{PercentComplete: types.Float32Ptr(100)}}, nil)
 
 	err := p.CreateImage(ctx, imagePath)
@@ -122,7 +121,7 @@ func TestListImages(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	computeService.EXPECT().
-		ListImages(context.TODO(), core.ListImagesRequest{OperatingSystem: types.StringPtr("Custom"), CompartmentId: types.StringPtr("")}).
+		ListImages(ctx.Context(), core.ListImagesRequest{OperatingSystem: types.StringPtr("Custom"), CompartmentId: types.StringPtr("")}).
 		Return(core.ListImagesResponse{
 			Items: []core.Image{
 				{
@@ -167,7 +166,7 @@ func TestDeleteImage(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	computeService.EXPECT().
-		ListImages(context.TODO(), core.ListImagesRequest{OperatingSystem: types.StringPtr("Custom"), CompartmentId: types.StringPtr("")}).
+		ListImages(ctx.Context(), core.ListImagesRequest{OperatingSystem: types.StringPtr("Custom"), CompartmentId: types.StringPtr("")}).
 		Return(core.ListImagesResponse{
 			Items: []core.Image{
 				{
@@ -190,7 +189,7 @@ func TestDeleteImage(t *testing.T) {
 		}, nil)
 
 	computeService.EXPECT().
-		DeleteImage(context.TODO(), core.DeleteImageRequest{ImageId: types.StringPtr("2")}).
+		DeleteImage(ctx.Context(), core.DeleteImageRequest{ImageId: types.StringPtr("2")}).
 		Return(core.DeleteImageResponse{}, nil)
 
 	p.DeleteImage(ctx, "test")
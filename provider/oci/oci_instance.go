@@ -3,7 +3,6 @@
 package oci
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -43,6 +42,10 @@ func (p *Provider) CreateInstance(ctx *lepton.Context) error {
 		ctx.Logger().Error(err)
 		return errors.New("failed creating network security group")
 	}
+	sgDone := ctx.AddCleanup("network security group "+instanceName+"-sg", func(ctx *lepton.Context) error {
+		_, err := p.networkClient.DeleteNetworkSecurityGroup(ctx.Context(), core.DeleteNetworkSecurityGroupRequest{NetworkSecurityGroupId: sg.Id})
+		return err
+	})
 
 	securityGroups = append(securityGroups, *sg.Id)
 
@@ -88,11 +91,12 @@ func (p *Provider) CreateInstance(ctx *lepton.Context) error {
 		}
 	}
 
-	_, err = p.computeClient.LaunchInstance(context.TODO(), lir)
+	_, err = p.computeClient.LaunchInstance(ctx.Context(), lir)
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("failed launching instance")
 	}
+	sgDone()
 
 	return nil
 }
@@ -146,14 +150,14 @@ func (p *Provider) AddInstancesNetworkDetails(ctx *lepton.Context, instances *[]
 
 	for i := 0; i < len(*instances); i++ {
 		instance := (*instances)[i]
-		vnicsAttachments, err := p.computeClient.ListVnicAttachments(context.TODO(), core.ListVnicAttachmentsRequest{CompartmentId: &p.compartmentID, InstanceId: &instance.ID})
+		vnicsAttachments, err := p.computeClient.ListVnicAttachments(ctx.Context(), core.ListVnicAttachmentsRequest{CompartmentId: &p.compartmentID, InstanceId: &instance.ID})
 		if err != nil {
 			ctx.Logger().Error(err)
 			return err
 		}
 
 		for _, vnic := range vnicsAttachments.Items {
-			vnicDetails, err := p.networkClient.GetVnic(context.TODO(), core.GetVnicRequest{VnicId: vnic.VnicId})
+			vnicDetails, err := p.networkClient.GetVnic(ctx.Context(), core.GetVnicRequest{VnicId: vnic.VnicId})
 			if err != nil {
 				ctx.Logger().Error(err)
 				return err
@@ -174,7 +178,7 @@ func (p *Provider) AddInstancesNetworkDetails(ctx *lepton.Context, instances *[]
 func (p *Provider) GetInstances(ctx *lepton.Context) (instances []lepton.CloudInstance, err error) {
 	instances = []lepton.CloudInstance{}
 
-	result, err := p.computeClient.ListInstances(context.TODO(), core.ListInstancesRequest{
+	result, err := p.computeClient.ListInstances(ctx.Context(), core.ListInstancesRequest{
 		CompartmentId: types.StringPtr(p.compartmentID),
 	})
 	if err != nil {
@@ -222,7 +226,7 @@ func (p *Provider) DeleteInstance(ctx *lepton.Context, instancename string) erro
 		return errors.New("failed getting instance")
 	}
 
-	_, err = p.computeClient.TerminateInstance(context.TODO(), core.TerminateInstanceRequest{InstanceId: &instance.ID})
+	_, err = p.computeClient.TerminateInstance(ctx.Context(), core.TerminateInstanceRequest{InstanceId: &instance.ID})
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("failed terminate instance")
@@ -239,7 +243,7 @@ func (p *Provider) StopInstance(ctx *lepton.Context, instancename string) error
 		return errors.New("failed getting instance")
 	}
 
-	_, err = p.computeClient.InstanceAction(context.TODO(), core.InstanceActionRequest{Action: core.InstanceActionActionStop, InstanceId: &instance.ID})
+	_, err = p.computeClient.InstanceAction(ctx.Context(), core.InstanceActionRequest{Action: core.InstanceActionActionStop, InstanceId: &instance.ID})
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("failed terminate instance")
@@ -261,7 +265,7 @@ func (p *Provider) StartInstance(ctx *lepton.Context, instancename string) error
 		return errors.New("failed getting instance")
 	}
 
-	_, err = p.computeClient.InstanceAction(context.TODO(), core.InstanceActionRequest{Action: core.InstanceActionActionStart, InstanceId: &instance.ID})
+	_, err = p.computeClient.InstanceAction(ctx.Context(), core.InstanceActionRequest{Action: core.InstanceActionActionStart, InstanceId: &instance.ID})
 	if err != nil {
 		ctx.Logger().Error(err)
 		return errors.New("failed terminate instance")
@@ -282,6 +286,7 @@ func (p *Provider) GetInstanceLogs(ctx *lepton.Context, instancename string) (st
 	if err != nil {
 		fmt.Println(err)
 	}
+	withRetries(&client.BaseClient)
 
 	instanceid := instance.ID
 
@@ -291,7 +296,7 @@ func (p *Provider) GetInstanceLogs(ctx *lepton.Context, instancename string) (st
 		},
 	}
 
-	resp, err := client.CaptureConsoleHistory(context.Background(), req)
+	resp, err := client.CaptureConsoleHistory(ctx.Context(), req)
 	if err != nil {
 		fmt.Println(resp)
 	}
@@ -305,7 +310,7 @@ func (p *Provider) GetInstanceLogs(ctx *lepton.Context, instancename string) (st
 		InstanceConsoleHistoryId: common.String(id),
 	}
 
-	hresp, err := client.GetConsoleHistoryContent(context.Background(), hreq)
+	hresp, err := client.GetConsoleHistoryContent(ctx.Context(), hreq)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -3,7 +3,6 @@
 package oci_test
 
 import (
-	"context"
 	"testing"
 	"time"
 
@@ -19,7 +18,7 @@ func TestGetInstances(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	c.EXPECT().
-		ListInstances(context.TODO(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
+		ListInstances(ctx.Context(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
 		Return(defaultInstancesList(), nil)
 
 	instances, err := p.GetInstances(ctx)
@@ -39,7 +38,7 @@ func TestGetInstanceByID(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	c.EXPECT().
-		ListInstances(context.TODO(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
+		ListInstances(ctx.Context(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
 		Return(defaultInstancesList(), nil)
 
 	instance, err := p.GetInstanceByName(ctx, "instance-2")
@@ -56,11 +55,11 @@ func TestDeleteInstance(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	c.EXPECT().
-		ListInstances(context.TODO(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
+		ListInstances(ctx.Context(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
 		Return(defaultInstancesList(), nil)
 
 	c.EXPECT().
-		TerminateInstance(context.TODO(), core.TerminateInstanceRequest{InstanceId: types.StringPtr("2")}).
+		TerminateInstance(ctx.Context(), core.TerminateInstanceRequest{InstanceId: types.StringPtr("2")}).
 		Return(core.TerminateInstanceResponse{}, nil)
 
 	err := p.DeleteInstance(ctx, "instance-2")
@@ -73,11 +72,11 @@ func TestStartInstance(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	c.EXPECT().
-		ListInstances(context.TODO(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
+		ListInstances(ctx.Context(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
 		Return(defaultInstancesList(), nil)
 
 	c.EXPECT().
-		InstanceAction(context.TODO(), core.InstanceActionRequest{Action: core.InstanceActionActionStart, InstanceId: types.StringPtr("2")}).
+		InstanceAction(ctx.Context(), core.InstanceActionRequest{Action: core.InstanceActionActionStart, InstanceId: types.StringPtr("2")}).
 		Return(core.InstanceActionResponse{}, nil)
 
 	err := p.StartInstance(ctx, "instance-2")
@@ -90,11 +89,11 @@ func TestStopInstance(t *testing.T) {
 	ctx := lepton.NewContext(lepton.NewConfig())
 
 	c.EXPECT().
-		ListInstances(context.TODO(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
+		ListInstances(ctx.Context(), core.ListInstancesRequest{CompartmentId: types.StringPtr("")}).
 		Return(defaultInstancesList(), nil)
 
 	c.EXPECT().
-		InstanceAction(context.TODO(), core.InstanceActionRequest{Action: core.InstanceActionActionStop, InstanceId: types.StringPtr("2")}).
+		InstanceAction(ctx.Context(), core.InstanceActionRequest{Action: core.InstanceActionActionStop, InstanceId: types.StringPtr("2")}).
 		Return(core.InstanceActionResponse{}, nil)
 
 	err := p.StopInstance(ctx, "instance-2")
@@ -109,9 +108,10 @@ func TestAddInstancesNetworkDetails(t *testing.T) {
 	}
 
 	p, c, _, _, n, _, _ := NewProvider(t)
+	ctx := lepton.NewContext(lepton.NewConfig())
 
 	c.EXPECT().
-		ListVnicAttachments(context.TODO(), core.ListVnicAttachmentsRequest{CompartmentId: types.StringPtr(""), InstanceId: types.StringPtr("1")}).
+		ListVnicAttachments(ctx.Context(), core.ListVnicAttachmentsRequest{CompartmentId: types.StringPtr(""), InstanceId: types.StringPtr("1")}).
 		Return(core.ListVnicAttachmentsResponse{
 			Items: []core.VnicAttachment{
 				{VnicId: types.StringPtr("1")},
@@ -120,13 +120,13 @@ func TestAddInstancesNetworkDetails(t *testing.T) {
 		}, nil)
 
 	c.EXPECT().
-		ListVnicAttachments(context.TODO(), core.ListVnicAttachmentsRequest{CompartmentId: types.StringPtr(""), InstanceId: types.StringPtr("2")}).
+		ListVnicAttachments(ctx.Context(), core.ListVnicAttachmentsRequest{CompartmentId: types.StringPtr(""), InstanceId: types.StringPtr("2")}).
 		Return(core.ListVnicAttachmentsResponse{
 			Items: []core.VnicAttachment{},
 		}, nil)
 
 	n.EXPECT().
-		GetVnic(context.TODO(), core.GetVnicRequest{VnicId: types.StringPtr("1")}).
+		GetVnic(ctx.Context(), core.GetVnicRequest{VnicId: types.StringPtr("1")}).
 		Return(core.GetVnicResponse{
 			Vnic: core.Vnic{
 				PrivateIp: types.StringPtr("10.10.10.10"),
@@ -135,7 +135,7 @@ func TestAddInstancesNetworkDetails(t *testing.T) {
 		}, nil)
 
 	n.EXPECT().
-		GetVnic(context.TODO(), core.GetVnicRequest{VnicId: types.StringPtr("2")}).
+		GetVnic(ctx.Context(), core.GetVnicRequest{VnicId: types.StringPtr("2")}).
 		Return(core.GetVnicResponse{
 			Vnic: core.Vnic{
 				PrivateIp: types.StringPtr("10.10.10.20"),
@@ -143,8 +143,6 @@ func TestAddInstancesNetworkDetails(t *testing.T) {
 			},
 		}, nil)
 
-	ctx := lepton.NewContext(lepton.NewConfig())
-
 	err := p.AddInstancesNetworkDetails(ctx, instances)
 
 	assert.NilError(t, err)
@@ -3,7 +3,6 @@
 package oci
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -17,7 +16,7 @@ import (
 
 // GetSubnet returns a public subnet
 func (p *Provider) GetSubnet(ctx *lepton.Context) (subnet *core.Subnet, err error) {
-	listSubnetsResponse, err := p.networkClient.ListSubnets(context.TODO(), core.ListSubnetsRequest{CompartmentId: &p.compartmentID})
+	listSubnetsResponse, err := p.networkClient.ListSubnets(ctx.Context(), core.ListSubnetsRequest{CompartmentId: &p.compartmentID})
 	if err != nil {
 		return
 	}
@@ -40,7 +39,7 @@ func (p *Provider) CreateNetworkSecurityGroup(ctx *lepton.Context, vcnID string)
 	instanceName := ctx.Config().RunConfig.InstanceName
 	var sgResponse core.CreateNetworkSecurityGroupResponse
 
-	sgResponse, err = p.networkClient.CreateNetworkSecurityGroup(context.TODO(), core.CreateNetworkSecurityGroupRequest{
+	sgResponse, err = p.networkClient.CreateNetworkSecurityGroup(ctx.Context(), core.CreateNetworkSecurityGroupRequest{
 		CreateNetworkSecurityGroupDetails: core.CreateNetworkSecurityGroupDetails{
 			CompartmentId: &p.compartmentID,
 			VcnId:         &vcnID,
@@ -84,7 +83,7 @@ func (p *Provider) CreateNetworkSecurityGroup(ctx *lepton.Context, vcnID string)
 	}
 
 	if len(sgRules) > 0 {
-		_, err = p.networkClient.AddNetworkSecurityGroupSecurityRules(context.TODO(), core.AddNetworkSecurityGroupSecurityRulesRequest{
+		_, err = p.networkClient.AddNetworkSecurityGroupSecurityRules(ctx.Context(), core.AddNetworkSecurityGroupSecurityRulesRequest{
 			NetworkSecurityGroupId: sg.Id,
 			AddNetworkSecurityGroupSecurityRulesDetails: core.AddNetworkSecurityGroupSecurityRulesDetails{
 				SecurityRules: sgRules,
@@ -3,7 +3,6 @@
 package oci
 
 import (
-	"context"
 	"errors"
 	"strconv"
 
@@ -29,7 +28,7 @@ func (p *Provider) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data
 		sizeInGBs = 50
 	}
 
-	createVolumeRes, err := p.blockstorageClient.CreateVolume(context.TODO(), core.CreateVolumeRequest{
+	createVolumeRes, err := p.blockstorageClient.CreateVolume(ctx.Context(), core.CreateVolumeRequest{
 		CreateVolumeDetails: core.CreateVolumeDetails{
 			AvailabilityDomain: &p.availabilityDomain,
 			CompartmentId:      &p.compartmentID,
@@ -55,7 +54,7 @@ func (p *Provider) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data
 func (p *Provider) GetAllVolumes(ctx *lepton.Context) (vols *[]lepton.NanosVolume, err error) {
 	return nil, errors.New("Unsupported")
 
-	listVolumesResponse, err := p.blockstorageClient.ListVolumes(context.TODO(), core.ListVolumesRequest{CompartmentId: &p.compartmentID})
+	listVolumesResponse, err := p.blockstorageClient.ListVolumes(ctx.Context(), core.ListVolumesRequest{CompartmentId: &p.compartmentID})
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +104,7 @@ func (p *Provider) DeleteVolume(ctx *lepton.Context, name string) (err error) {
 		return
 	}
 
-	_, err = p.blockstorageClient.DeleteVolume(context.TODO(), core.DeleteVolumeRequest{VolumeId: &vol.ID})
+	_, err = p.blockstorageClient.DeleteVolume(ctx.Context(), core.DeleteVolumeRequest{VolumeId: &vol.ID})
 
 	return
 }
@@ -114,7 +113,7 @@ func (p *Provider) DeleteVolume(ctx *lepton.Context, name string) (err error) {
 func (p *Provider) AttachVolume(ctx *lepton.Context, image, name string, attachID int) (err error) {
 	return errors.New("Unsupported")
 
-	_, err = p.computeClient.AttachVolume(context.TODO(), core.AttachVolumeRequest{
+	_, err = p.computeClient.AttachVolume(ctx.Context(), core.AttachVolumeRequest{
 		AttachVolumeDetails: core.AttachParavirtualizedVolumeDetails{},
 	})
 
@@ -125,7 +124,7 @@ func (p *Provider) AttachVolume(ctx *lepton.Context, image, name string, attachI
 func (p *Provider) DetachVolume(ctx *lepton.Context, image, name string) (err error) {
 	return errors.New("Unsupported")
 
-	_, err = p.computeClient.DetachVolume(context.TODO(), core.DetachVolumeRequest{})
+	_, err = p.computeClient.DetachVolume(ctx.Context(), core.DetachVolumeRequest{})
 
 	return
 }
@@ -1,7 +1,13 @@
 package onprem
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/types"
 )
 
 // for now only assumes a single interface
@@ -9,18 +15,50 @@ import (
 // instance stores metadata for on-prem instances and contains
 // different/extra fields than what one would find in cloudInstance
 type instance struct {
+	// ID identifies the instance for as long as it is not deleted, across relaunches
+	ID string `json:"id"`
+
 	Instance  string   `json:"instance"` // instance name
 	Image     string   `json:"image"`
 	Ports     []string `json:"ports"`
 	Bridged   bool     `json:"bridged"`
 	PrivateIP string   `json:"private_ip"` // assume only loopback unless bridged is set, only set if bridged is set
 	Mac       string   `json:"mac"`
-	Pid       string   `json:"pid"`
+	Pid       string   `json:"pid"` // empty if the instance is not running
 	Mgmt      string   `json:"mgmt"`
 	Arch      string   `json:"arch"`
 
-	FreeMemory  int64
-	TotalMemory int64
+	// PidStart identifies the process of Pid, see processStart
+	PidStart string `json:"pid_start,omitempty"`
+
+	// Hypervisor is the hypervisor running the instance, empty for qemu
+	Hypervisor string `json:"hypervisor,omitempty"`
+
+	// RunConfig is the configuration the instance was launched with
+	RunConfig *types.RunConfig `json:"run_config,omitempty"`
+
+	Status      string       `json:"status"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Transitions []transition `json:"transitions,omitempty"`
+
+	// Owner is the pid of the ops process launching the instance, or of its supervisor
+	Owner      int    `json:"owner,omitempty"`
+	OwnerStart string `json:"owner_start,omitempty"`
+
+	// Volumes are the volumes attached to the instance, which are attached again when the
+	// instance is relaunched
+	Volumes []volumeAttachment `json:"volumes,omitempty"`
+
+	// Supervisor is set for instances restarted according to their restart policy
+	Supervisor *supervisor `json:"supervisor,omitempty"`
+
+	FreeMemory  int64 `json:"-"`
+	TotalMemory int64 `json:"-"`
+}
+
+// isQemu returns whether the instance is run by qemu, which is managed through QMP
+func (in *instance) isQemu() bool {
+	return in.Hypervisor == "" || in.Hypervisor == "qemu"
 }
 
 func (in *instance) portList() string {
@@ -31,3 +69,95 @@ func (in *instance) portList() string {
 
 	return strings.TrimRight(s, ", ")
 }
+
+// setStatus records the instance entering status
+func (in *instance) setStatus(status string) {
+	if in.Status == status {
+		return
+	}
+	in.Status = status
+	in.Transitions = append(in.Transitions, transition{Status: status, At: time.Now()})
+	if len(in.Transitions) > maxTransitions {
+		in.Transitions = in.Transitions[len(in.Transitions)-maxTransitions:]
+	}
+}
+
+// setPid records the process of the instance, or that it is not running if pid is empty
+func (in *instance) setPid(pid string) {
+	in.Pid = pid
+	in.PidStart = ""
+	if n, err := strconv.Atoi(pid); err == nil {
+		in.PidStart = processStart(n)
+	}
+}
+
+// setOwner records the process owning the instance, or that it has none if pid is 0
+func (in *instance) setOwner(pid int) {
+	in.Owner = pid
+	in.OwnerStart = ""
+	if pid != 0 {
+		in.OwnerStart = processStart(pid)
+	}
+}
+
+// ownedBy returns whether the instance is owned by the process pid
+func (in *instance) ownedBy(pid int) bool {
+	return in.Owner == pid && processAlive(strconv.Itoa(pid), in.OwnerStart)
+}
+
+// processAlive returns whether the process of the instance is alive
+func (in *instance) processAlive() bool {
+	return in.Pid != "" && processAlive(in.Pid, in.PidStart)
+}
+
+// refresh marks the instance as exited if neither its process nor the ops process owning it
+// is alive
+func (in *instance) refresh() {
+	if !in.active() {
+		return
+	}
+	if in.Owner != 0 && in.ownedBy(in.Owner) {
+		return
+	}
+	if in.processAlive() {
+		return
+	}
+	in.setPid("")
+	in.setOwner(0)
+	in.setStatus(statusExited)
+}
+
+// active returns whether the instance is running or being launched
+func (in *instance) active() bool {
+	return in.Status != statusExited && in.Status != statusFailed
+}
+
+// running returns whether the process of the instance is running, even if it is paused
+func (in *instance) running() bool {
+	return in.Pid != "" && (in.Status == statusRunning || in.Status == statusPaused)
+}
+
+// displayStatus returns the status of the instance shown in instance lists
+func (in *instance) displayStatus() string {
+	if in.Status == statusExited && in.Supervisor != nil && in.Supervisor.ExitCode != nil {
+		return fmt.Sprintf("%s (%d)", in.Status, *in.Supervisor.ExitCode)
+	}
+	return in.Status
+}
+
+// cloudInstance returns the instance as listed by providers
+func (in *instance) cloudInstance() lepton.CloudInstance {
+	pips := []string{"127.0.0.1"}
+	if in.Bridged {
+		pips = []string{in.PrivateIP}
+	}
+	return lepton.CloudInstance{
+		ID:         in.ID,
+		Name:       in.Instance,
+		Image:      in.Image,
+		Status:     in.displayStatus(),
+		Created:    lepton.Time2Human(in.CreatedAt),
+		PrivateIps: pips,
+		Ports:      strings.Split(in.portList(), ","),
+	}
+}
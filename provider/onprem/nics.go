@@ -0,0 +1,151 @@
+package onprem
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/nanovms/ops/log"
+	"github.com/nanovms/ops/network"
+	"github.com/nanovms/ops/qemu"
+	"github.com/nanovms/ops/types"
+)
+
+// configureNics sets the defaults of the nics of an instance: each nic gets a mac address derived
+// from the mac address of the instance, a tap device named after its mac address and a bridge of
+// its own; the first nic defaults to the tap device and bridge of the instance
+func configureNics(rconfig *types.RunConfig) error {
+	for i := range rconfig.Nics {
+		nic := &rconfig.Nics[i]
+		if nic.Mac == "" {
+			nic.Mac = qemu.NicMac(rconfig.Mac, i)
+		}
+		mac, err := net.ParseMAC(nic.Mac)
+		if err != nil {
+			return fmt.Errorf("invalid mac address of nic %d: %w", i, err)
+		}
+
+		if nic.TapName == "" {
+			if i == 0 && rconfig.TapName != "" {
+				nic.TapName = rconfig.TapName
+			} else {
+				nic.TapName = fmt.Sprintf("tap%x", []byte(mac[3:]))
+			}
+		}
+
+		if nic.BridgeName == "" {
+			if i == 0 && rconfig.BridgeName != "" {
+				nic.BridgeName = rconfig.BridgeName
+			} else {
+				nic.BridgeName = fmt.Sprintf("br%d", i)
+			}
+		}
+
+		if nic.IPAddress != "" {
+			if ip := net.ParseIP(nic.IPAddress); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("invalid ip address %s of nic %d", nic.IPAddress, i)
+			}
+			if nic.NetMask == "" {
+				nic.NetMask = "255.255.255.0"
+			}
+		}
+	}
+	return nil
+}
+
+// setupTaps sets up the tap devices of an instance with their firewall: the tap device of a bridged
+// instance, or those of its nics; the taps set up before a failure are left for removeTaps
+func setupTaps(networkService network.Service, rconfig *types.RunConfig) error {
+	if len(rconfig.Nics) > 0 {
+		return setupNics(networkService, rconfig)
+	}
+	if !rconfig.Bridged || rconfig.TapName == "" {
+		return nil
+	}
+
+	bridgeName := rconfig.BridgeName
+	if bridgeName == "" {
+		bridgeName = "br0"
+	}
+	err := network.SetupNetworkInterfaces(networkService, rconfig.TapName, bridgeName, rconfig.IPAddress, rconfig.NetMask, rconfig.BridgeIPAddress)
+	if err != nil {
+		return err
+	}
+	return setupFirewall(networkService, rconfig.TapName, rconfig)
+}
+
+// setupNics attaches each nic of an instance to its tap device and bridge, which are created if
+// they do not exist; the host address of a bridge is the gateway of its nic
+func setupNics(networkService network.Service, rconfig *types.RunConfig) error {
+	if err := configureNics(rconfig); err != nil {
+		return err
+	}
+
+	for i, nic := range rconfig.Nics {
+		err := network.SetupNetworkInterfaces(networkService, nic.TapName, nic.BridgeName, nic.IPAddress, nic.NetMask, nic.Gateway)
+		if err == nil {
+			err = setupFirewall(networkService, nic.TapName, rconfig)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot set up nic %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// removeTaps removes the tap devices of an instance which could not be launched, with their
+// firewall
+func removeTaps(networkService network.Service, rconfig *types.RunConfig) {
+	for _, tap := range instanceTaps(rconfig) {
+		if err := network.RemoveTap(networkService, tap); err != nil {
+			log.Warnf("cannot remove tap %s: %v", tap, err)
+		}
+	}
+}
+
+// setupFirewall filters the ports of an instance on a tap device; if the firewall cannot be set up,
+// the instance is only launched with every port reachable if RunConfig.UnfilteredPorts is set
+func setupFirewall(networkService network.Service, tapDeviceName string, rconfig *types.RunConfig) error {
+	rules := network.FirewallRules{
+		TCPPorts: rconfig.Ports,
+		UDPPorts: rconfig.UDPPorts,
+		Sources:  rconfig.AllowedSources,
+	}
+	err := network.SetupFirewall(networkService, tapDeviceName, rules)
+	if err != nil && rconfig.UnfilteredPorts {
+		log.Warnf("%v, all the ports of the instance are reachable", err)
+		return nil
+	}
+	return err
+}
+
+// instanceTaps returns the tap devices of an instance
+func instanceTaps(rconfig *types.RunConfig) []string {
+	if len(rconfig.Nics) == 0 {
+		if rconfig.Bridged && rconfig.TapName != "" {
+			return []string{rconfig.TapName}
+		}
+		return nil
+	}
+	var taps []string
+	for _, nic := range rconfig.Nics {
+		if nic.TapName != "" {
+			taps = append(taps, nic.TapName)
+		}
+	}
+	return taps
+}
+
+// nicMacs returns the mac addresses of the network cards of an instance
+func nicMacs(rconfig *types.RunConfig) []string {
+	var macs []string
+	if rconfig.Mac != "" {
+		macs = append(macs, strings.ToLower(rconfig.Mac))
+	}
+	for _, nic := range rconfig.Nics {
+		if nic.Mac != "" {
+			macs = append(macs, strings.ToLower(nic.Mac))
+		}
+	}
+	return macs
+}
@@ -0,0 +1,80 @@
+package onprem
+
+import (
+	"errors"
+	"testing"
+
+	mock_network "github.com/nanovms/ops/network/mocks"
+	"github.com/nanovms/ops/qemu"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConfigureNics(t *testing.T) {
+	rconfig := &types.RunConfig{
+		Mac:     "52:54:00:12:34:56",
+		TapName: "tap0",
+		Nics: []types.Nic{
+			{IPAddress: "10.0.0.2", Gateway: "10.0.0.1"},
+			{IPAddress: "10.1.0.2", NetMask: "255.255.0.0", BridgeName: "data"},
+			{TapName: "backup"},
+		},
+	}
+	assert.Nil(t, configureNics(rconfig))
+
+	mac1 := qemu.NicMac(rconfig.Mac, 1)
+	assert.Equal(t, []types.Nic{
+		{IPAddress: "10.0.0.2", Gateway: "10.0.0.1", NetMask: "255.255.255.0", BridgeName: "br0", TapName: "tap0", Mac: "52:54:00:12:34:56"},
+		{IPAddress: "10.1.0.2", NetMask: "255.255.0.0", BridgeName: "data", TapName: "tap" + mac1[9:11] + mac1[12:14] + mac1[15:17], Mac: mac1},
+		{BridgeName: "br2", TapName: "backup", Mac: qemu.NicMac(rconfig.Mac, 2)},
+	}, rconfig.Nics)
+	assert.Equal(t, []string{"tap0", rconfig.Nics[1].TapName, "backup"}, instanceTaps(rconfig))
+
+	// nics keep their settings when the instance is relaunched
+	nics := append([]types.Nic{}, rconfig.Nics...)
+	assert.Nil(t, configureNics(rconfig))
+	assert.Equal(t, nics, rconfig.Nics)
+
+	rconfig.Nics = []types.Nic{{IPAddress: "10.0.0"}}
+	assert.EqualError(t, configureNics(rconfig), "invalid ip address 10.0.0 of nic 0")
+}
+
+func TestSetupFirewall(t *testing.T) {
+	networkService := mock_network.NewMockService(gomock.NewController(t))
+	networkService.
+		EXPECT().
+		AddFirewallRules("tap0", gomock.Any()).
+		Return("", errors.New("nft: command not found")).
+		Times(2)
+
+	rconfig := &types.RunConfig{Ports: []string{"80"}}
+	assert.EqualError(t, setupFirewall(networkService, "tap0", rconfig), "Not able to set up firewall of tap tap0: nft: command not found")
+
+	rconfig.UnfilteredPorts = true
+	assert.Nil(t, setupFirewall(networkService, "tap0", rconfig))
+}
+
+func TestSetupTaps(t *testing.T) {
+	networkService := mock_network.NewMockService(gomock.NewController(t))
+	rconfig := &types.RunConfig{Bridged: true, TapName: "tap0", BridgeName: "br0"}
+
+	// the tap is left for removeTaps if its firewall cannot be set up
+	networkService.EXPECT().CheckNetworkInterfaceExists(gomock.Any()).Return(true, nil).AnyTimes()
+	networkService.EXPECT().CheckBridgeHasInterface("br0", "tap0").Return(true, nil)
+	networkService.EXPECT().IsNIUp(gomock.Any()).Return(true, nil).AnyTimes()
+	networkService.
+		EXPECT().
+		AddFirewallRules("tap0", gomock.Any()).
+		Return("", errors.New("nft: command not found"))
+	assert.EqualError(t, setupTaps(networkService, rconfig), "Not able to set up firewall of tap tap0: nft: command not found")
+
+	gomock.InOrder(
+		networkService.EXPECT().DeleteFirewallRules("tap0").Return("", nil),
+		networkService.EXPECT().DeleteNIC("tap0").Return("", nil),
+	)
+	removeTaps(networkService, rconfig)
+
+	// instances which are not bridged have no taps
+	assert.Nil(t, setupTaps(networkService, &types.RunConfig{TapName: "tap0"}))
+}
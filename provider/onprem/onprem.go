@@ -21,6 +21,19 @@ func (p *OnPrem) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by on premise
+func (p *OnPrem) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityImageResize,
+		lepton.CapabilityImageSync,
+		lepton.CapabilityInstanceReboot,
+		lepton.CapabilityInstanceStats,
+		lepton.CapabilityInstanceLogs,
+		lepton.CapabilityLogsWatch,
+		lepton.CapabilityVolumes,
+	}
+}
+
 // GetStorage returns storage interface for cloud provider
 func (p *OnPrem) GetStorage() lepton.Storage {
 	return nil
@@ -7,7 +7,9 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 
+	"github.com/nanovms/ops/fs"
 	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 	"github.com/olekukonko/tablewriter"
@@ -94,6 +96,10 @@ func (p *OnPrem) GetImages(ctx *lepton.Context, search string) (images []lepton.
 			return nil
 		}
 
+		if isImageSidecar(info.Name()) {
+			return nil
+		}
+
 		if filter {
 			if r.MatchString(info.Name()) {
 				images = append(images, lepton.CloudImage{
@@ -162,9 +168,21 @@ func (p *OnPrem) DeleteImage(ctx *lepton.Context, imagename string) error {
 	if err != nil {
 		return err
 	}
+	os.Remove(fs.IndexPath(imgpath))
+	os.Remove(lepton.ImageSignaturePath(imgpath))
+	os.Remove(lepton.SBOMPath(imgpath, lepton.SBOMFormatSPDX))
+	os.Remove(lepton.SBOMPath(imgpath, lepton.SBOMFormatCycloneDX))
 	return nil
 }
 
+// isImageSidecar returns true for the files that ops stores next to an image (index, signature, SBOM)
+func isImageSidecar(name string) bool {
+	return strings.HasSuffix(name, fs.ImageIndexExt) ||
+		strings.HasSuffix(name, lepton.ImageSignatureExt) ||
+		strings.HasSuffix(name, lepton.SBOMExtSPDX) ||
+		strings.HasSuffix(name, lepton.SBOMExtCycloneDX)
+}
+
 // SyncImage syncs image from onprem to target provider provided in Context
 func (p *OnPrem) SyncImage(config *types.Config, target lepton.Provider, image string) error {
 	imagePath := path.Join(lepton.LocalImageDir, image)
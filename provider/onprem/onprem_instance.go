@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -14,16 +13,16 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/network"
+	"github.com/nanovms/ops/network/dhcp"
 	"github.com/nanovms/ops/qemu"
+	"github.com/nanovms/ops/types"
 
 	"github.com/olekukonko/tablewriter"
-	"golang.org/x/sys/unix"
 )
 
 // CreateInstancePID creates an instance and returns the pid.
@@ -33,11 +32,29 @@ func (p *OnPrem) CreateInstancePID(ctx *lepton.Context) (string, error) {
 
 func (p *OnPrem) createInstance(ctx *lepton.Context) (string, error) {
 	c := ctx.Config()
+	if err := ValidateRestartPolicy(c.RunConfig.Restart); err != nil {
+		return "", err
+	}
+	if isSupervised(&c.RunConfig) {
+		if c.RunConfig.InstanceName == "" {
+			c.RunConfig.InstanceName = strings.Split(c.CloudConfig.ImageName, ".")[0]
+		}
+		return startSupervisor(c)
+	}
+	return p.launchInstance(ctx, nil)
+}
+
+// launchInstance starts an instance, booting it from its image or, if snap is not nil, restoring
+// it from a snapshot
+func (p *OnPrem) launchInstance(ctx *lepton.Context, snap *snapshot) (string, error) {
+	c := ctx.Config()
 
 	imageName := c.CloudConfig.ImageName
 
-	if _, err := os.Stat(path.Join(lepton.GetOpsHome(), "images", c.CloudConfig.ImageName)); os.IsNotExist(err) {
-		return "", fmt.Errorf("image \"%s\" not found", imageName)
+	if snap == nil {
+		if _, err := os.Stat(path.Join(lepton.GetOpsHome(), "images", c.CloudConfig.ImageName)); os.IsNotExist(err) {
+			return "", fmt.Errorf("image \"%s\" not found", imageName)
+		}
 	}
 
 	// hack - should figure out how to conjoin these 2 together
@@ -56,34 +73,9 @@ func (p *OnPrem) createInstance(ctx *lepton.Context) (string, error) {
 		}
 	}
 
-	// linux local only; mac uses diff bridge
-	if runtime.GOOS == "linux" && c.RunConfig.Bridged {
-		tapDeviceName := c.RunConfig.TapName
-		bridged := c.RunConfig.Bridged
-		ipaddress := c.RunConfig.IPAddress
-		netmask := c.RunConfig.NetMask
-		bridgeipaddress := c.RunConfig.BridgeIPAddress
-
-		bridgeName := c.RunConfig.BridgeName
-		if bridged && bridgeName == "" {
-			bridgeName = "br0"
-		}
-
-		networkService := network.NewIprouteNetworkService()
-
-		if tapDeviceName != "" {
-			err := network.SetupNetworkInterfaces(networkService, tapDeviceName, bridgeName, ipaddress, netmask, bridgeipaddress)
-			if err != nil {
-				return "", err
-			}
-		}
-	}
-
-	hypervisor := qemu.HypervisorInstance()
-	if hypervisor == nil {
-		fmt.Println("No hypervisor found on $PATH")
-		fmt.Println("Please install OPS using curl https://ops.city/get.sh -sSfL | sh")
-		os.Exit(1)
+	hypervisor, err := qemu.HypervisorByName(c.RunConfig.Hypervisor)
+	if err != nil {
+		return "", fmt.Errorf("%w\nPlease install OPS using curl https://ops.city/get.sh -sSfL | sh", err)
 	}
 
 	if c.RunConfig.InstanceName == "" {
@@ -95,18 +87,74 @@ func (p *OnPrem) createInstance(ctx *lepton.Context) (string, error) {
 	opshome := lepton.GetOpsHome()
 	imgpath := path.Join(opshome, "images", c.CloudConfig.ImageName)
 
+	if snap != nil {
+		if c.RunConfig.Hypervisor != "" && c.RunConfig.Hypervisor != "qemu" {
+			return "", fmt.Errorf("snapshots cannot be restored into %s instances", c.RunConfig.Hypervisor)
+		}
+		imgpath = snap.image
+		c.RunConfig.QMP = true
+		c.RunConfig.Incoming = "defer"
+	}
+
 	c.RunConfig.ImageName = imgpath
 	c.RunConfig.Background = true
 
 	c.RunConfig.Mgmt = qemu.GenMgmtPort()
 
-	err := hypervisor.Start(&c.RunConfig)
+	// the instance is recorded as starting so that concurrent launches of the same name fail; it
+	// keeps its ID, MAC address and volumes if it was launched before
+	var id string
+	var created bool
+	var attachments []volumeAttachment
+	err = updateState(func(s *state) error {
+		i := s.find(c.RunConfig.InstanceName)
+		if i != nil && i.active() && !i.ownedBy(os.Getpid()) {
+			return fmt.Errorf("instance %s already exists", c.RunConfig.InstanceName)
+		}
+		if i == nil {
+			i = s.add(c.RunConfig.InstanceName)
+			created = true
+		}
+		if snap != nil {
+			i.Volumes = snap.Volumes
+			attachments = snap.Volumes
+		} else {
+			attachments = relaunchAttachments(&c.RunConfig, i.Volumes)
+		}
+		if c.RunConfig.Mac == "" {
+			if i.Mac == "" {
+				i.Mac = qemu.GenerateMac()
+			}
+			c.RunConfig.Mac = i.Mac
+		}
+		i.setOwner(os.Getpid())
+		i.setStatus(statusStarting)
+		id = i.ID
+		return nil
+	})
 	if err != nil {
 		return "", err
 	}
 
+	// linux local only; mac uses diff bridge. The taps are set up once the instance is known not
+	// to exist, as those of a running instance would be replaced, and once the mac addresses of its
+	// nics are known
+	if runtime.GOOS == "linux" {
+		if err = setupTaps(network.NewIprouteNetworkService(), &c.RunConfig); err != nil {
+			failLaunch(id, created, &c.RunConfig)
+			return "", err
+		}
+	}
+
+	err = hypervisor.Start(&c.RunConfig)
+	if err != nil {
+		failLaunch(id, created, &c.RunConfig)
+		return "", err
+	}
+
 	pid, err := hypervisor.PID()
 	if err != nil {
+		failLaunch(id, created, &c.RunConfig)
 		return "", err
 	}
 
@@ -115,42 +163,120 @@ func (p *OnPrem) createInstance(ctx *lepton.Context) (string, error) {
 	if c.RunConfig.AtExit != "" {
 		pid = findPIDFromHook(pid)
 	}
-
-	instances := path.Join(opshome, "instances")
+	pid = strings.TrimSpace(pid)
 
 	arch := "arm64"
 	if qemu.ArchCheck() {
 		arch = "amd64"
 	}
 
-	i := instance{
-		Instance: c.RunConfig.InstanceName,
-		Image:    c.RunConfig.ImageName,
-		Ports:    c.RunConfig.Ports,
-		Pid:      pid,
-		Mgmt:     c.RunConfig.Mgmt,
-		Arch:     arch,
+	err = updateInstance(id, func(i *instance) error {
+		i.Image = c.RunConfig.ImageName
+		i.Ports = c.RunConfig.Ports
+		i.setPid(pid)
+		i.Mgmt = c.RunConfig.Mgmt
+		i.Arch = arch
+		i.Mac = c.RunConfig.Mac
+		// the address is resolved again as the instance may not get the same one
+		i.PrivateIP = ""
+		i.Hypervisor = c.RunConfig.Hypervisor
+		i.RunConfig = &c.RunConfig
+		i.Bridged = c.RunConfig.Bridged || qemu.OPSD != ""
+		if i.Supervisor == nil {
+			i.setOwner(0)
+		}
+		i.setStatus(statusRunning)
+		return nil
+	})
+	if err != nil {
+		return pid, err
 	}
 
-	if c.RunConfig.Bridged {
-		i.Bridged = true
+	if len(attachments) > 0 {
+		if err = reattachVolumes(c.RunConfig.Mgmt, attachments); err != nil {
+			err = fmt.Errorf("cannot attach volumes to instance %s: %w", c.RunConfig.InstanceName, err)
+		}
 	}
 
-	if qemu.OPSD != "" {
-		i.Bridged = true
+	if snap != nil {
+		if err == nil {
+			if err = restoreState(c.RunConfig.Mgmt, snap.statePath()); err != nil {
+				err = fmt.Errorf("cannot restore instance %s from snapshot %s: %w", c.RunConfig.InstanceName, snap.Name, err)
+			}
+		}
+		if err != nil {
+			// the instance waits for its state until it is killed
+			killLaunch(pid, id, created, &c.RunConfig)
+			return "", err
+		}
 	}
 
-	d1, err := json.Marshal(i)
-	if err != nil {
-		log.Error(err)
+	return pid, err
+}
+
+// failLaunch removes the taps of an instance which could not be launched, and forgets the instance
+// if it was created by the launch, or records that it failed
+func failLaunch(id string, created bool, rconfig *types.RunConfig) {
+	if runtime.GOOS == "linux" {
+		removeTaps(network.NewIprouteNetworkService(), rconfig)
 	}
 
-	err = os.WriteFile(instances+"/"+pid, d1, 0644)
+	err := updateState(func(s *state) error {
+		if created {
+			s.remove(id)
+			return nil
+		}
+		if i := s.get(id); i != nil {
+			if i.Supervisor == nil {
+				i.setOwner(0)
+			}
+			i.setStatus(statusFailed)
+		}
+		return nil
+	})
 	if err != nil {
 		log.Error(err)
 	}
+}
 
-	return pid, err
+// killLaunch kills an instance which failed once qemu was started, and forgets it as failLaunch
+func killLaunch(pid string, id string, created bool, rconfig *types.RunConfig) {
+	if n, _ := strconv.Atoi(pid); n != 0 {
+		if err := sysKill(n); err != nil {
+			log.Error(err)
+		}
+	}
+	failLaunch(id, created, rconfig)
+}
+
+// relaunchAttachments returns the volumes recorded as attached to an instance which is launched
+// again, enabling QMP so that they can be hot-plugged once qemu is started; volumes which are
+// already mounted by the run configuration are skipped
+func relaunchAttachments(rconfig *types.RunConfig, recorded []volumeAttachment) []volumeAttachment {
+	if len(recorded) == 0 {
+		return nil
+	}
+	if rconfig.Hypervisor != "" && rconfig.Hypervisor != "qemu" {
+		log.Warnf("volumes attached to instance %s cannot be hot-plugged into %s instances, they are not attached", rconfig.InstanceName, rconfig.Hypervisor)
+		return nil
+	}
+
+	var attachments []volumeAttachment
+	for _, a := range recorded {
+		mounted := false
+		for _, mount := range rconfig.Mounts {
+			if path.Clean(mount) == path.Clean(a.Path) {
+				mounted = true
+			}
+		}
+		if !mounted {
+			attachments = append(attachments, a)
+		}
+	}
+	if len(attachments) > 0 {
+		rconfig.QMP = true
+	}
+	return attachments
 }
 
 func findPIDFromHook(ppid string) string {
@@ -174,20 +300,23 @@ func (p *OnPrem) CreateInstance(ctx *lepton.Context) error {
 	return err
 }
 
-// GetMetaInstanceByName returns onprem metadata about a given named instance.
+// GetMetaInstanceByName returns onprem metadata about a given named instance, which must be
+// running.
 func (p *OnPrem) GetMetaInstanceByName(ctx *lepton.Context, name string) (*instance, error) {
-	instances, err := p.GetMetaInstances(ctx)
+	var found *instance
+	err := viewState(func(s *state) error {
+		if i := s.find(name); i != nil && i.running() {
+			found = i
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	for _, i := range instances {
-		if i.Instance == name {
-			return &i, nil
-		}
+	if found == nil {
+		return nil, lepton.ErrInstanceNotFound(name)
 	}
-
-	return nil, lepton.ErrInstanceNotFound(name)
+	return found, nil
 }
 
 // GetInstanceByName returns instance with given name
@@ -198,7 +327,7 @@ func (p *OnPrem) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.Cl
 	}
 
 	for _, i := range instances {
-		if i.Name == name {
+		if i.Name == name || i.ID == name {
 			return &i, nil
 		}
 	}
@@ -206,40 +335,69 @@ func (p *OnPrem) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.Cl
 	return nil, lepton.ErrInstanceNotFound(name)
 }
 
-// FindBridgedIPByPID finds a qemu process with the pid and returns the
-// ip.
+// FindBridgedIPByPID returns the ip of the bridged instance with the pid,
+// resolved from its mac.
 func FindBridgedIPByPID(pid string) string {
-	cmd := exec.Command("ps", "ww", "-p", pid)
-	out, err := cmd.CombinedOutput()
+	var found *instance
+	err := viewState(func(s *state) error {
+		found = s.byPid(pid)
+		return nil
+	})
 	if err != nil {
-		if 1 == 2 {
-			fmt.Println(err)
-			fmt.Printf("%s\n", out)
-		}
+		fmt.Println(err)
+		return ""
 	}
-
-	sout := string(out)
-
-	devspl := ""
-	if runtime.GOOS == "darwin" {
-		devspl = "netdev=vmnet,mac="
-	} else {
-		devspl = ",mac="
+	if found == nil {
+		return ""
 	}
+	return resolveIP(found)
+}
 
-	oo := strings.Split(sout, devspl)
-	mac := ""
-	if len(oo) > 1 {
-		ooz := strings.Split(oo[1], " ")
-		mac = ooz[0]
-	} else {
-		fmt.Println("couldn't find mac")
+// resolveIP returns the ip of a bridged instance, leased by the DHCP server of its network or
+// else resolved from its mac by arp, and records it
+func resolveIP(i *instance) string {
+	ip := leasedIP(i)
+	if ip == "" {
+		if i.PrivateIP != "" {
+			return i.PrivateIP
+		}
+		ip = arpMac(i.Mac)
+	}
+	if ip == "" || ip == i.PrivateIP {
+		return ip
+	}
+	i.PrivateIP = ip
+	err := updateInstance(i.ID, func(recorded *instance) error {
+		if recorded.Mac == i.Mac {
+			recorded.PrivateIP = ip
+		}
+		return nil
+	})
+	if err != nil && !lepton.IsInstanceNotFoundError(err) {
+		fmt.Println(err)
 	}
+	return ip
+}
 
-	return arpMac(pid, mac)
+// leasedIP returns the ip leased to an instance by the DHCP server of the network of its bridge,
+// or an empty string
+func leasedIP(i *instance) string {
+	bridge := "br0"
+	if i.RunConfig != nil && i.RunConfig.BridgeName != "" {
+		bridge = i.RunConfig.BridgeName
+	}
+	leases, err := dhcp.ReadLeases(lepton.NetworkLeasesPath(bridge))
+	if err != nil {
+		fmt.Println(err)
+		return ""
+	}
+	if l := dhcp.FindLease(leases, i.Mac); l != nil {
+		return l.IP
+	}
+	return ""
 }
 
-func arpMac(pid string, mac string) string {
+func arpMac(mac string) string {
 	/// only use for resolution not for storage
 	dmac, err := formatOctet(mac)
 	if err != nil {
@@ -260,81 +418,12 @@ func arpMac(pid string, mac string) string {
 	if strings.Contains(out, "(") {
 		oo := strings.Split(out, "(")
 		ooz := strings.Split(oo[1], ")")
-		ip := ooz[0]
-
-		logMac(pid, mac, ip)
-
-		return ip
+		return ooz[0]
 	}
 
 	return ""
 }
 
-// FindBridgedIP returns the ip for an instance by forcing arp
-// resolution. You should probably use the other function that takes a
-// pid though.
-//
-// super hacky mac extraction, arp resolution; revisit in future
-// could also potentially extract from logs || could have nanos ping
-// upon boot
-func FindBridgedIP(instanceID string) string {
-	ps := getPS()
-
-	out := getLines(ps, instanceID)
-
-	devspl := ""
-	if runtime.GOOS == "darwin" {
-		devspl = "netdev=vmnet,mac="
-	} else {
-		devspl = ",mac="
-	}
-
-	oo := strings.Split(out, devspl)
-	mac := ""
-	if len(oo) > 1 {
-		ooz := strings.Split(oo[1], " ")
-		mac = ooz[0]
-	} else {
-		fmt.Println("couldn't find mac")
-	}
-
-	qlines := getQemuLines(out)
-
-	pid := getPidFromQemu(qlines)
-
-	return arpMac(pid, mac)
-}
-
-func getPidFromQemu(body string) string {
-	fields := strings.Fields(body)
-
-	return strings.TrimSpace(fields[1])
-}
-
-func getQemuLines(body string) string {
-	glines := ""
-
-	lines := strings.Split(body, "\n")
-	for i := 0; i < len(lines); i++ {
-		if strings.Contains(lines[i], "qemu") {
-			glines += lines[i] + "\n"
-		}
-	}
-	return glines
-}
-
-func getLines(body string, instanceID string) string {
-	glines := ""
-
-	lines := strings.Split(body, "\n")
-	for i := 0; i < len(lines); i++ {
-		if strings.Contains(lines[i], instanceID) {
-			glines += lines[i] + "\n"
-		}
-	}
-	return glines
-}
-
 func getArp() string {
 	cmd := exec.Command("arp", "-a")
 	out, err := cmd.CombinedOutput()
@@ -348,19 +437,6 @@ func getArp() string {
 	return string(out)
 }
 
-func getPS() string {
-	cmd := exec.Command("ps", "aux")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		if 1 == 2 {
-			fmt.Println(err)
-			fmt.Printf("%s\n", out)
-		}
-	}
-
-	return string(out)
-}
-
 // returns a mac with leading zeros dropped which is what mac does
 // d6:3f:9b:0f:0c:c8
 // d6:3f:9b:f:c:c8
@@ -380,97 +456,22 @@ func formatOctet(mac string) (string, error) {
 	return newmac, nil
 }
 
-// log our mac,ip,pid so we don't have to lookup again
-// FIXME: for bridged we can store in a more proper fashion
-// we still want to support daemon-less as much as we can
-//
-// also should throw a lock on this at some point unless we migrate to
-// something else that is a bit more industrial
-func logMac(pid string, mac string, ip string) {
-
-	opshome := lepton.GetOpsHome()
-	instancesPath := path.Join(opshome, "instances")
-
-	fullpath := path.Join(instancesPath, pid)
-
-	body, err := os.ReadFile(fullpath)
-	if err != nil {
-		fmt.Println(err)
-	}
-
-	var i instance
-	if err := json.Unmarshal(body, &i); err != nil {
-		fmt.Println(err)
-	}
-
-	i.PrivateIP = ip
-	i.Pid = pid
-	i.Mac = mac
-
-	d1, err := json.Marshal(i)
-	if err != nil {
-		fmt.Println(err)
-	}
-
-	err = os.WriteFile(instancesPath+"/"+pid, d1, 0644)
-	if err != nil {
-		fmt.Println(err)
-	}
-}
-
-// GetMetaInstances returns instance data for onprem metadata found in
-// ~/.ops/instances .
+// GetMetaInstances returns the onprem metadata of running instances.
 func (p *OnPrem) GetMetaInstances(ctx *lepton.Context) (instances []instance, err error) {
-	opshome := lepton.GetOpsHome()
-	instancesPath := path.Join(opshome, "instances")
-
-	files, err := os.ReadDir(instancesPath)
-	if err != nil {
-		return
-	}
-
-	for _, f := range files {
-		fullpath := path.Join(instancesPath, f.Name())
-
-		// this is a cleanup helper
-		pid, err := strconv.ParseInt(f.Name(), 10, 32)
-		if err != nil {
-			return nil, err
-		}
-
-		process, err := os.FindProcess(int(pid))
-		if err != nil {
-			return nil, err
-		}
-
-		if err = process.Signal(syscall.Signal(0)); err != nil {
-			errMsg := strings.ToLower(err.Error())
-			if strings.Contains(errMsg, "already finished") ||
-				strings.Contains(errMsg, "already released") ||
-				strings.Contains(errMsg, "not initialized") {
-				os.Remove(fullpath)
-				continue
+	err = viewState(func(s *state) error {
+		for _, i := range s.Instances {
+			if i.running() {
+				instances = append(instances, *i)
 			}
 		}
-
-		body, err := os.ReadFile(fullpath)
-		if err != nil {
-			return nil, err
-		}
-
-		var i instance
-		if err := json.Unmarshal(body, &i); err != nil {
-			return nil, err
-		}
-
-		instances = append(instances, i)
-	}
-
-	return instances, err
+		return nil
+	})
+	return
 }
 
-// GetInstances return all instances on prem
-func (p *OnPrem) GetInstances(ctx *lepton.Context) (instances []lepton.CloudInstance, err error) {
+// allInstances returns the onprem metadata of all instances, resolving the ips of running bridged
+// instances which are not known yet
+func allInstances(ctx *lepton.Context) ([]*instance, error) {
 	opshome := ""
 	if ctx.Config().Home != "" {
 		opshome = filepath.Join(ctx.Config().Home, ".ops")
@@ -478,123 +479,55 @@ func (p *OnPrem) GetInstances(ctx *lepton.Context) (instances []lepton.CloudInst
 		opshome = lepton.GetOpsHome()
 	}
 
-	instancesPath := path.Join(opshome, "instances")
-
-	files, err := os.ReadDir(instancesPath)
+	var instances []*instance
+	err := viewStateAt(opshome, func(s *state) error {
+		instances = s.Instances
+		return nil
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	// this logic is duped in GetMetaInstances - need to de-dupe.
-	for _, f := range files {
-		fullpath := path.Join(instancesPath, f.Name())
-
-		pid, err := strconv.ParseInt(f.Name(), 10, 32)
-		if err != nil {
-			return nil, err
-		}
-		process, err := os.FindProcess(int(pid))
-		if err != nil {
-			return nil, err
-		}
-		if err = process.Signal(syscall.Signal(0)); err != nil {
-			errMsg := strings.ToLower(err.Error())
-			if strings.Contains(errMsg, "already finished") ||
-				strings.Contains(errMsg, "already released") ||
-				strings.Contains(errMsg, "not initialized") {
-				os.Remove(fullpath)
-				continue
-			}
-		}
-
-		body, err := os.ReadFile(fullpath)
-		if err != nil {
-			return nil, err
-		}
-
-		var i instance
-		if err := json.Unmarshal(body, &i); err != nil {
-			return nil, err
-		}
-
-		pips := []string{}
-		if i.Bridged {
-			if i.PrivateIP == "" || i.Mac == "" {
-				i.PrivateIP = FindBridgedIP(i.Instance)
-			}
-
-			pips = append(pips, i.PrivateIP)
-		} else {
-			pips = append(pips, "127.0.0.1")
-		}
-
-		// relying on file ctime; prob should actually store the date in
-		// the metadata file.
-		file, err := os.Open(fullpath)
-		if err != nil {
-			return nil, err
-		}
-		defer file.Close()
-
-		stat := &unix.Stat_t{}
-		err = unix.Fstat(int(file.Fd()), stat)
-		if err != nil {
-			return nil, err
+	for _, i := range instances {
+		if i.Bridged && i.running() && opshome == lepton.GetOpsHome() {
+			resolveIP(i)
 		}
+	}
+	return instances, nil
+}
 
-		// rather un-helpful if we're just overwriting it though
-		ctime := time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))
-
-		// perhaps return proto'd version here instead then wrap
-		// w/cloudinstance for cli
-		instances = append(instances, lepton.CloudInstance{
-			ID:         f.Name(), // pid
-			Name:       i.Instance,
-			Image:      i.Image,
-			Status:     "Running",
-			Created:    lepton.Time2Human(ctime),
-			PrivateIps: pips,
-			Ports:      strings.Split(i.portList(), ","),
-		})
+// GetInstances return all instances on prem
+func (p *OnPrem) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
+	all, err := allInstances(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return
+	var instances []lepton.CloudInstance
+	for _, i := range all {
+		instances = append(instances, i.cloudInstance())
+	}
+	return instances, nil
 }
 
 func (p *OnPrem) getInstancesStats(ctx *lepton.Context, rinstances []lepton.CloudInstance) ([]lepton.CloudInstance, error) {
 	for i := 0; i < len(rinstances); i++ {
 		instance, err := p.GetMetaInstanceByName(ctx, rinstances[i].Name)
 		if err != nil {
-			fmt.Println(err)
-		}
-
-		last := instance.Mgmt
-
-		devid := "2"
-		if instance.Arch == "amd64" {
-			devid = "3"
+			return nil, err
 		}
 
-		commands := []string{
-			`{ "execute": "qmp_capabilities" }`,
-			`{ "execute": "qom-set", "arguments": { "path": "/machine/peripheral-anon/device[` + devid + `]", "property": "guest-stats-polling-interval", "value": 2}}`,
-			`{ "execute": "qom-get", "arguments": { "path": "/machine/peripheral-anon/device[` + devid + `]", "property": "guest-stats" } }`,
+		if !instance.isQemu() {
+			continue
 		}
 
-		s := executeQMPLastRead(commands, last)
-
-		var lr qmpResponse
-
-		err = json.Unmarshal([]byte(s), &lr)
+		stats, err := queryMemoryStats(instance)
 		if err != nil {
-			// silently fail here as sometimes we get invalid values
-			// (eg: at instance start)
-			//
-			//			fmt.Println(err)
+			return nil, fmt.Errorf("cannot query stats of instance %s: %w", instance.Instance, err)
 		}
 
-		rinstances[i].FreeMemory = (lr.qmpReturn.Stats.FreeMemory / int64(1000000))
-		rinstances[i].TotalMemory = (lr.qmpReturn.Stats.TotalMemory / int64(1000000))
+		rinstances[i].FreeMemory = stats.FreeMemory / int64(1000000)
+		rinstances[i].TotalMemory = stats.TotalMemory / int64(1000000)
 	}
 
 	return rinstances, nil
@@ -610,6 +543,9 @@ func (p *OnPrem) InstanceStats(ctx *lepton.Context, iname string, watch bool) er
 	rinstances := []lepton.CloudInstance{}
 
 	for i := 0; i < len(instances); i++ {
+		if instances[i].Status != statusRunning && instances[i].Status != statusPaused {
+			continue
+		}
 		if iname != "" {
 			if iname != instances[i].Name {
 				continue
@@ -648,7 +584,7 @@ func (p *OnPrem) InstanceStats(ctx *lepton.Context, iname string, watch bool) er
 		}
 
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"PID", "Name", "Memory"})
+		table.SetHeader([]string{"ID", "Name", "Memory"})
 		table.SetHeaderColor(
 			tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
 			tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
@@ -674,7 +610,7 @@ func (p *OnPrem) InstanceStats(ctx *lepton.Context, iname string, watch bool) er
 
 // ListInstances on premise
 func (p *OnPrem) ListInstances(ctx *lepton.Context) error {
-	instances, err := p.GetInstances(ctx)
+	instances, err := allInstances(ctx)
 	if err != nil {
 		return err
 	}
@@ -685,11 +621,15 @@ func (p *OnPrem) ListInstances(ctx *lepton.Context) error {
 			fmt.Println("[]")
 			return nil
 		}
-		return json.NewEncoder(os.Stdout).Encode(instances)
+		cinstances := []lepton.CloudInstance{}
+		for _, i := range instances {
+			cinstances = append(cinstances, i.cloudInstance())
+		}
+		return json.NewEncoder(os.Stdout).Encode(cinstances)
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"PID", "Name", "Image", "Status", "Created", "Private Ips", "Port"})
+	table.SetHeader([]string{"ID", "PID", "Name", "Image", "Status", "Created", "Private Ips", "Port", "Restarts"})
 	table.SetHeaderColor(
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
@@ -697,6 +637,8 @@ func (p *OnPrem) ListInstances(ctx *lepton.Context) error {
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgCyanColor})
 
 	table.SetRowLine(true)
@@ -704,13 +646,24 @@ func (p *OnPrem) ListInstances(ctx *lepton.Context) error {
 	for _, i := range instances {
 		var rows []string
 
-		rows = append(rows, i.ID)
-		rows = append(rows, i.Name)
-		rows = append(rows, i.Image)
-		rows = append(rows, i.Status)
-		rows = append(rows, i.Created)
-		rows = append(rows, strings.Join(i.PrivateIps, ","))
-		rows = append(rows, strings.Join(i.Ports, ","))
+		ci := i.cloudInstance()
+		if i.Status == statusExited && i.Supervisor != nil && i.Supervisor.CrashedAt != nil {
+			ci.Created = "crashed " + lepton.Time2Human(*i.Supervisor.CrashedAt)
+		}
+
+		rows = append(rows, ci.ID)
+		rows = append(rows, i.Pid)
+		rows = append(rows, ci.Name)
+		rows = append(rows, ci.Image)
+		rows = append(rows, ci.Status)
+		rows = append(rows, ci.Created)
+		rows = append(rows, strings.Join(ci.PrivateIps, ","))
+		rows = append(rows, strings.Join(ci.Ports, ","))
+		if i.Supervisor != nil {
+			rows = append(rows, strconv.Itoa(i.Supervisor.Restarts))
+		} else {
+			rows = append(rows, "")
+		}
 
 		table.Append(rows)
 	}
@@ -725,33 +678,44 @@ func (p *OnPrem) ListInstances(ctx *lepton.Context) error {
 // right now this assumes it was paused; not a boot; there's another
 // call we can use here to get the status first
 func (p *OnPrem) StartInstance(ctx *lepton.Context, instancename string) error {
-	instance, err := p.GetMetaInstanceByName(ctx, instancename)
+	controller, err := p.instanceController(ctx, instancename)
 	if err != nil {
 		return err
 	}
 
-	last := instance.Mgmt
-
-	commands := []string{
-		`{ "execute": "qmp_capabilities" }`,
-		`{ "execute": "cont" }`,
+	if err = controller.Resume(); err != nil {
+		return err
 	}
+	return p.setInstanceStatus(instancename, statusRunning)
+}
 
-	qemu.ExecuteQMP(commands, last)
-	return nil
+// setInstanceStatus records the status of a running instance which is paused or resumed
+func (p *OnPrem) setInstanceStatus(instancename string, status string) error {
+	return updateState(func(s *state) error {
+		if i := s.find(instancename); i != nil && i.running() {
+			i.setStatus(status)
+		}
+		return nil
+	})
 }
 
-type qmpResponse struct {
-	qmpReturn `json:"return"`
+// instanceController returns the controller of the hypervisor running an instance
+func (p *OnPrem) instanceController(ctx *lepton.Context, instancename string) (qemu.Controller, error) {
+	instance, err := p.GetMetaInstanceByName(ctx, instancename)
+	if err != nil {
+		return nil, err
+	}
+
+	return qemu.InstanceController(instance.Hypervisor, instance.Mgmt)
 }
 
-type qmpReturn struct {
-	Stats      qmpStats `json:"stats"`
-	LastUpdate int64    `json:"last-update"`
+type balloonStats struct {
+	Stats      memoryStats `json:"stats"`
+	LastUpdate int64       `json:"last-update"`
 }
 
-type qmpStats struct {
-	HtlbPGalloc     int64 `"json:stat-htlb-pgalloc"`
+type memoryStats struct {
+	HtlbPGalloc     int64 `json:"stat-htlb-pgalloc"`
 	SwapOut         int64 `json:"stat-swap-out"`
 	AvailableMemory int64 `json:"stat-available-memory"`
 	HtlbPgfail      int64 `json:"stat-htlb-pgfail"`
@@ -763,103 +727,112 @@ type qmpStats struct {
 	DiskCaches      int64 `json:"stat-disk-caches"`
 }
 
-// bit of a hack
-func executeQMPLastRead(commands []string, last string) string {
-	lo := ""
-
-	c, err := net.Dial("tcp", "localhost:"+last)
+// queryMemoryStats returns the memory statistics reported by the balloon device of a qemu instance;
+// the total memory falls back to the memory of the instance when the guest does not report it
+func queryMemoryStats(i *instance) (*memoryStats, error) {
+	c, err := qemu.DialQMP(i.Mgmt)
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
 	defer c.Close()
 
-	str, err := bufio.NewReader(c).ReadString('\n')
-	if err != nil {
-		fmt.Println(err)
-		fmt.Println(str)
+	devid := "2"
+	if i.Arch == "amd64" {
+		devid = "3"
 	}
+	balloon := "/machine/peripheral-anon/device[" + devid + "]"
 
-	for i := 0; i < len(commands); i++ {
-		_, err := c.Write([]byte(commands[i] + "\n"))
-		if err != nil {
-			fmt.Println(err)
-		}
-		str, err := bufio.NewReader(c).ReadString('\n')
-		lo = str
+	if err = c.QOMSet(balloon, "guest-stats-polling-interval", 2); err != nil {
+		return nil, err
+	}
+	var stats balloonStats
+	// the guest reports invalid values until it has polled once (eg: at instance start), so only
+	// the memory size is reported
+	c.QOMGet(balloon, "guest-stats", &stats)
+
+	if stats.Stats.TotalMemory <= 0 {
+		summary, err := c.QueryMemorySizeSummary()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return nil, err
 		}
+		stats.Stats.TotalMemory = int64(summary.BaseMemory + summary.PluggedMemory)
 	}
-
-	return lo
+	return &stats.Stats, nil
 }
 
 // RebootInstance from on premise
 func (p *OnPrem) RebootInstance(ctx *lepton.Context, instancename string) error {
-	instance, err := p.GetMetaInstanceByName(ctx, instancename)
+	controller, err := p.instanceController(ctx, instancename)
 	if err != nil {
 		return err
 	}
 
-	last := instance.Mgmt
-
-	commands := []string{
-		`{ "execute": "qmp_capabilities" }`,
-		`{ "execute": "system_reset" }`,
-	}
-
-	qemu.ExecuteQMP(commands, last)
-
-	return nil
+	return controller.Reboot()
 }
 
 // StopInstance from on premise
 func (p *OnPrem) StopInstance(ctx *lepton.Context, instancename string) error {
-	instance, err := p.GetMetaInstanceByName(ctx, instancename)
+	controller, err := p.instanceController(ctx, instancename)
 	if err != nil {
 		return err
 	}
 
-	last := instance.Mgmt
-
-	commands := []string{
-		`{ "execute": "qmp_capabilities" }`,
-		`{ "execute": "stop" }`,
+	if err = controller.Pause(); err != nil {
+		return err
 	}
-
-	qemu.ExecuteQMP(commands, last)
-	return nil
+	return p.setInstanceStatus(instancename, statusPaused)
 }
 
 // DeleteInstance from on premise
 func (p *OnPrem) DeleteInstance(ctx *lepton.Context, instancename string) error {
-
-	var pid int
-	instance, err := p.GetInstanceByName(ctx, instancename)
+	// the supervisor exits instead of restarting the instance once the instance is removed, and
+	// volumes are detached from deleted instances
+	var deleted *instance
+	err := updateState(func(s *state) error {
+		deleted = s.find(instancename)
+		if deleted == nil {
+			return lepton.ErrInstanceNotFound(instancename)
+		}
+		s.remove(deleted.ID)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	pid, _ = strconv.Atoi(instance.ID)
+	if runtime.GOOS == "linux" && deleted.RunConfig != nil {
+		for _, tap := range instanceTaps(deleted.RunConfig) {
+			if _, err := network.NewIprouteNetworkService().DeleteFirewallRules(tap); err != nil {
+				log.Warnf("cannot remove firewall rules of tap %s: %v", tap, err)
+			}
+		}
+	}
+
+	// restored instances have their own copy of the image of the snapshot
+	if isRestoredImage(deleted.Image) {
+		if err := os.Remove(deleted.Image); err != nil && !os.IsNotExist(err) {
+			log.Warnf("cannot remove image %s of instance %s: %v", deleted.Image, instancename, err)
+		}
+	}
 
+	if deleted.Pid == "" {
+		return nil
+	}
+	pid, _ := strconv.Atoi(deleted.Pid)
 	if pid == 0 {
 		fmt.Printf("did not find pid of instance \"%s\"\n", instancename)
 		return nil
 	}
+	// the pid may have been reused by another process since the instance exited
+	if !deleted.processAlive() {
+		return nil
+	}
 
 	err = sysKill(pid)
 	if err != nil {
 		log.Error(err)
 	}
 
-	opshome := lepton.GetOpsHome()
-	ipath := path.Join(opshome, "instances", strconv.Itoa(pid))
-	err = os.Remove(ipath)
-	if err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -0,0 +1,321 @@
+package onprem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/qemu"
+	"github.com/nanovms/ops/types"
+)
+
+// snapshot is a checkpoint of a running instance: the migration stream of its state, including
+// memory, a copy of its image and what is needed to launch it again
+type snapshot struct {
+	Name      string             `json:"name"`
+	Instance  string             `json:"instance"`
+	Image     string             `json:"image"` // name of the originating image
+	CreatedAt time.Time          `json:"created_at"`
+	RunConfig types.RunConfig    `json:"run_config"`
+	Volumes   []volumeAttachment `json:"volumes,omitempty"`
+
+	// image is the image the snapshot is restored with
+	image string
+}
+
+// snapshotsDir is where snapshots are kept, one directory per snapshot
+func snapshotsDir() string {
+	return path.Join(lepton.GetOpsHome(), "snapshots")
+}
+
+func (s *snapshot) dir() string {
+	return path.Join(snapshotsDir(), s.Name)
+}
+
+// statePath is the path of the migration stream
+func (s *snapshot) statePath() string {
+	return path.Join(s.dir(), "state")
+}
+
+// imageCopyPath is the path of the copy of the image taken with the state
+func (s *snapshot) imageCopyPath() string {
+	return path.Join(s.dir(), s.Image)
+}
+
+func readSnapshot(name string) (*snapshot, error) {
+	body, err := os.ReadFile(path.Join(snapshotsDir(), name, "snapshot.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("snapshot %s not found", name)
+		}
+		return nil, err
+	}
+	s := &snapshot{}
+	if err = json.Unmarshal(body, s); err != nil {
+		return nil, fmt.Errorf("cannot read snapshot %s: %w", name, err)
+	}
+	return s, nil
+}
+
+func (s *snapshot) write() error {
+	body, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(s.dir(), "snapshot.json"), body, 0644)
+}
+
+// SnapshotInstance checkpoints a running instance into a snapshot named snapshotName, or named
+// after the instance and the current time if empty, and returns the name of the snapshot. The
+// instance keeps running; volumes are recorded with the snapshot but their content is not saved.
+func (p *OnPrem) SnapshotInstance(ctx *lepton.Context, instanceName, snapshotName string) (string, error) {
+	i, err := p.GetMetaInstanceByName(ctx, instanceName)
+	if err != nil {
+		return "", err
+	}
+	if !i.isQemu() {
+		return "", fmt.Errorf("%s instances cannot be snapshotted", i.Hypervisor)
+	}
+	if i.RunConfig == nil {
+		return "", fmt.Errorf("the configuration of instance %s is unknown, create the instance again to snapshot it", instanceName)
+	}
+
+	if snapshotName == "" {
+		snapshotName = instanceName + "-" + time.Now().Format("20060102150405")
+	}
+	if strings.ContainsAny(snapshotName, "/\\") || snapshotName == "." || snapshotName == ".." {
+		return "", fmt.Errorf("invalid snapshot name %q", snapshotName)
+	}
+
+	snap := &snapshot{
+		Name:      snapshotName,
+		Instance:  instanceName,
+		Image:     originImage(i),
+		CreatedAt: time.Now(),
+		RunConfig: *i.RunConfig,
+		Volumes:   i.Volumes,
+	}
+
+	if err = os.MkdirAll(snapshotsDir(), 0755); err != nil {
+		return "", err
+	}
+	if err = os.Mkdir(snap.dir(), 0755); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("snapshot %s already exists", snapshotName)
+		}
+		return "", err
+	}
+	if err = p.saveInstance(i, snap); err != nil {
+		os.RemoveAll(snap.dir())
+		return "", fmt.Errorf("cannot snapshot instance %s: %w", instanceName, err)
+	}
+	return snapshotName, nil
+}
+
+// saveInstance saves the state and image of an instance into a snapshot
+func (p *OnPrem) saveInstance(i *instance, snap *snapshot) error {
+	c, err := qemu.DialQMP(i.Mgmt)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	status, err := c.QueryStatus()
+	if err != nil {
+		return err
+	}
+
+	if err = c.Migrate("exec:cat > " + shellQuote(snap.statePath())); err != nil {
+		return err
+	}
+	for {
+		info, err := c.QueryMigrate()
+		if err != nil {
+			return err
+		}
+		if info.Status == "completed" {
+			break
+		}
+		if info.Status == "failed" || info.Status == "cancelled" {
+			return fmt.Errorf("migration %s: %s", info.Status, info.ErrorDesc)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	// the instance stays paused after its state is saved, so its image is copied consistently
+	err = copyFile(i.Image, snap.imageCopyPath())
+	if status.Running {
+		if contErr := c.Cont(); err == nil {
+			err = contErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return snap.write()
+}
+
+// restoreState migrates the state saved in a snapshot into a qemu instance started with
+// "-incoming defer", and waits for the instance to run
+func restoreState(mgmt string, statePath string) error {
+	c, err := waitQMP(mgmt)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err = c.MigrateIncoming("exec:cat " + shellQuote(statePath)); err != nil {
+		return err
+	}
+	for {
+		status, err := c.QueryStatus()
+		if err != nil {
+			return err
+		}
+		if status.Status != "inmigrate" {
+			if !status.Running {
+				return fmt.Errorf("instance is %s", status.Status)
+			}
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// RestoreSnapshot launches an instance from a snapshot and returns its pid; the instance is named
+// instanceName, or after the snapshotted instance if empty. Each restored instance gets its own
+// copy of the image of the snapshot, removed when the instance is deleted. The volumes of the
+// snapshot must not be attached to another instance. The instance keeps the tap devices and the
+// mac addresses of the snapshotted instance, which the state of its network cards carries, so a
+// bridged snapshot is not restored while another instance uses them.
+// If signed images are required, the image the snapshotted instance was created from must have a
+// valid signature, as restoring bypasses the signing wrapper of the provider.
+func (p *OnPrem) RestoreSnapshot(ctx *lepton.Context, snapshotName, instanceName string) (string, error) {
+	snap, err := readSnapshot(snapshotName)
+	if err != nil {
+		return "", err
+	}
+	if instanceName == "" {
+		instanceName = snap.Instance
+	}
+	if _, err = p.GetMetaInstanceByName(ctx, instanceName); err == nil {
+		return "", fmt.Errorf("instance %s already exists", instanceName)
+	}
+	for _, a := range snap.Volumes {
+		attachedTo, err := p.VolumeAttachedTo(ctx, a.Path)
+		if err != nil {
+			return "", err
+		}
+		if attachedTo != "" && attachedTo != instanceName {
+			return "", fmt.Errorf("volume %s of snapshot %s is attached to instance %s", a.Volume, snapshotName, attachedTo)
+		}
+	}
+
+	if err = snap.checkNetwork(instanceName); err != nil {
+		return "", err
+	}
+	// the image of the snapshot was modified by the running instance, so the signature policy
+	// applies to the image the instance was created from
+	if err = lepton.VerifyImagePolicy(ctx, path.Join(lepton.GetOpsHome(), "images", snap.Image)); err != nil {
+		return "", err
+	}
+
+	snap.image = path.Join(snap.dir(), instanceName+"-"+snap.Image)
+	if err = copyFile(snap.imageCopyPath(), snap.image); err != nil {
+		return "", fmt.Errorf("cannot copy image of snapshot %s: %w", snapshotName, err)
+	}
+
+	c := ctx.Config()
+	c.RunConfig = snap.RunConfig
+	c.RunConfig.InstanceName = instanceName
+	c.CloudConfig.ImageName = snap.Image
+	// the volumes mounted by the instance are in the run configuration of the snapshot
+	c.Mounts = nil
+
+	pid, err := p.launchInstance(ctx, snap)
+	if err != nil {
+		os.Remove(snap.image)
+		return "", err
+	}
+	return pid, nil
+}
+
+// checkNetwork checks that the tap devices and mac addresses of a bridged snapshot are not used by
+// an active instance other than instanceName
+func (s *snapshot) checkNetwork(instanceName string) error {
+	taps := instanceTaps(&s.RunConfig)
+	if len(taps) == 0 {
+		return nil
+	}
+	macs := nicMacs(&s.RunConfig)
+
+	return viewState(func(st *state) error {
+		for _, i := range st.Instances {
+			if i.Instance == instanceName || !i.active() || i.RunConfig == nil {
+				continue
+			}
+			for _, tap := range instanceTaps(i.RunConfig) {
+				if slices.Contains(taps, tap) {
+					return fmt.Errorf("tap device %s of snapshot %s is used by instance %s, which must be stopped first", tap, s.Name, i.Instance)
+				}
+			}
+			for _, mac := range nicMacs(i.RunConfig) {
+				if slices.Contains(macs, mac) {
+					return fmt.Errorf("mac address %s of snapshot %s is used by instance %s, which must be stopped first", mac, s.Name, i.Instance)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// isRestoredImage returns whether an image is the copy of the image of a snapshot made for a
+// restored instance
+// originImage returns the name of the image an instance was created from, which a restored instance
+// runs a copy of, named after the instance
+func originImage(i *instance) string {
+	if isRestoredImage(i.Image) {
+		return strings.TrimPrefix(path.Base(i.Image), i.Instance+"-")
+	}
+	return path.Base(i.Image)
+}
+
+func isRestoredImage(image string) bool {
+	dir, file := path.Split(path.Clean(image))
+	dir = path.Clean(dir)
+	if path.Dir(dir) != snapshotsDir() {
+		return false
+	}
+	snap, err := readSnapshot(path.Base(dir))
+	return err == nil && strings.HasSuffix(file, "-"+snap.Image)
+}
+
+// shellQuote quotes a path for the shell running the commands of exec migration URIs
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,114 @@
+package onprem
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	instancesDir := path.Join(lepton.GetOpsHome(), "instances")
+	assert.Nil(t, os.MkdirAll(instancesDir, 0755))
+	ctx := lepton.NewContext(&types.Config{})
+	op := &OnPrem{}
+
+	// an instance created by a previous version, whose configuration is unknown
+	pid := strconv.Itoa(os.Getpid())
+	body, err := json.Marshal(instance{Instance: "web", Image: "/images/web.img", Pid: pid, Mgmt: "1"})
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(path.Join(instancesDir, pid), body, 0644))
+	_, err = op.SnapshotInstance(ctx, "web", "")
+	assert.EqualError(t, err, "the configuration of instance web is unknown, create the instance again to snapshot it")
+	_, err = op.SnapshotInstance(ctx, "db", "")
+	assert.True(t, lepton.IsInstanceNotFoundError(err))
+
+	_, err = op.RestoreSnapshot(ctx, "web-1", "")
+	assert.EqualError(t, err, "snapshot web-1 not found")
+
+	snap := &snapshot{
+		Name:      "web-1",
+		Instance:  "web",
+		Image:     "web.img",
+		CreatedAt: time.Unix(1700000000, 0).UTC(),
+		RunConfig: types.RunConfig{Memory: "2G", CPUs: 2, QMP: true},
+		Volumes:   []volumeAttachment{{Volume: "data", ID: "1", Path: "/volumes/data:1.raw", AttachID: -1}},
+	}
+	assert.Nil(t, os.MkdirAll(snap.dir(), 0755))
+	assert.Nil(t, snap.write())
+	read, err := readSnapshot("web-1")
+	assert.Nil(t, err)
+	assert.Equal(t, snap, read)
+	assert.Equal(t, path.Join(lepton.GetOpsHome(), "snapshots", "web-1", "web.img"), read.imageCopyPath())
+
+	// the snapshotted instance is still running
+	_, err = op.RestoreSnapshot(ctx, "web-1", "")
+	assert.EqualError(t, err, "instance web already exists")
+
+	// the volumes of the snapshot are attached to another instance
+	assert.Nil(t, updateState(func(s *state) error {
+		s.add("worker").Volumes = []volumeAttachment{{Volume: "data", ID: "1", Path: "/volumes/data:1.raw"}}
+		return nil
+	}))
+	_, err = op.RestoreSnapshot(ctx, "web-1", "web2")
+	assert.EqualError(t, err, "volume data of snapshot web-1 is attached to instance worker")
+
+	// the tap device and mac address of a bridged snapshot are used by another running instance
+	bridged := &snapshot{Name: "web-2", RunConfig: types.RunConfig{Bridged: true, TapName: "tap0", Mac: "52:54:00:12:34:56"}}
+	assert.Nil(t, updateState(func(s *state) error {
+		worker := s.find("worker")
+		worker.Pid = pid
+		worker.setStatus(statusRunning)
+		worker.RunConfig = &types.RunConfig{Bridged: true, TapName: "tap0", Mac: "52:54:00:00:00:01"}
+		return nil
+	}))
+	assert.EqualError(t, bridged.checkNetwork("web2"), "tap device tap0 of snapshot web-2 is used by instance worker, which must be stopped first")
+	assert.Nil(t, updateState(func(s *state) error {
+		s.find("worker").RunConfig = &types.RunConfig{Bridged: true, TapName: "tap1", Mac: "52:54:00:12:34:56"}
+		return nil
+	}))
+	assert.EqualError(t, bridged.checkNetwork("web2"), "mac address 52:54:00:12:34:56 of snapshot web-2 is used by instance worker, which must be stopped first")
+	assert.Nil(t, bridged.checkNetwork("worker"))
+	assert.Nil(t, updateState(func(s *state) error {
+		s.find("worker").setStatus(statusExited)
+		return nil
+	}))
+	assert.Nil(t, bridged.checkNetwork("web2"))
+
+	// the image copies of restored instances are removed with the instances
+	restored := path.Join(snap.dir(), "web2-web.img")
+	assert.True(t, isRestoredImage(restored))
+	assert.False(t, isRestoredImage(read.imageCopyPath()))
+	assert.False(t, isRestoredImage("/images/web2-web.img"))
+	// a snapshot of a restored instance is made from the image of the first snapshot
+	assert.Equal(t, "web.img", originImage(&instance{Instance: "web2", Image: restored}))
+	assert.Equal(t, "web2-web.img", originImage(&instance{Instance: "web2", Image: "/images/web2-web.img"}))
+	assert.Nil(t, os.WriteFile(restored, nil, 0644))
+	assert.Nil(t, updateState(func(s *state) error {
+		s.add("web2").Image = restored
+		return nil
+	}))
+	assert.Nil(t, op.DeleteInstance(ctx, "web2"))
+	assert.NoFileExists(t, restored)
+
+	// the image the snapshotted instance was created from must be signed if signed images are
+	// required
+	unsigned := &snapshot{Name: "web-3", Instance: "web", Image: "web.img"}
+	assert.Nil(t, os.MkdirAll(unsigned.dir(), 0755))
+	assert.Nil(t, unsigned.write())
+	signed := lepton.NewContext(&types.Config{CloudConfig: types.ProviderConfig{RequireSignedImages: true}})
+	_, err = op.RestoreSnapshot(signed, "web-3", "web3")
+	assert.EqualError(t, err, "signed images are required, but no image verification key is configured")
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'/home/user/.ops/snapshots/a b/state'`, shellQuote("/home/user/.ops/snapshots/a b/state"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
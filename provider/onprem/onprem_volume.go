@@ -2,16 +2,17 @@ package onprem
 
 import (
 	"fmt"
-	"net"
 	"os"
 	"path"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/qemu"
+	"github.com/nanovms/ops/qemu/qmp"
 	"github.com/nanovms/ops/types"
 )
 
@@ -36,6 +37,21 @@ func (op *OnPrem) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, err
 		return nil, err
 	}
 
+	err = viewState(func(s *state) error {
+		for i := range vols {
+			vols[i].AttachedTo = s.attachedInstance(vols[i].ID)
+			if vols[i].AttachedTo != "" {
+				vols[i].Status = "in-use"
+			} else {
+				vols[i].Status = "available"
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &vols, nil
 }
 
@@ -52,15 +68,19 @@ func (op *OnPrem) DeleteVolume(ctx *lepton.Context, name string) error {
 		return err
 	}
 
-	if len(volumes) == 1 {
-		volumePath := path.Join(volumes[0].Path)
-		err := os.Remove(volumePath)
-		if err != nil {
-			return err
-		}
+	if len(volumes) != 1 {
+		return nil
 	}
 
-	return nil
+	// the state stays locked so that the volume is not attached while it is deleted
+	return updateState(func(s *state) error {
+		if instanceName := s.attachedInstance(volumes[0].ID); instanceName != "" {
+			return fmt.Errorf("volume %s is attached to instance %s, detach it first", name, instanceName)
+		}
+
+		volumePath := path.Join(volumes[0].Path)
+		return os.Remove(volumePath)
+	})
 }
 
 // AttachVolume attaches volume to instance on `ops instance create -t onprem`
@@ -76,98 +96,243 @@ func (op *OnPrem) DeleteVolume(ctx *lepton.Context, name string) error {
 //	  }
 //	}
 //
-// this currently requires instance name to be unique
+// The attachment is recorded with the instance, and the volume is attached again when the
+// instance is relaunched.
+//
+// the instance is named or identified by instanceName
 func (op *OnPrem) AttachVolume(ctx *lepton.Context, instanceName string, volumeName string, attachID int) error {
-	vol := ""
-
-	buildDir := ctx.Config().VolumesDir
-	vols, err := GetVolumes(buildDir, nil)
+	vols, err := GetVolumes(ctx.Config().VolumesDir, map[string]string{
+		"name": volumeName,
+		"id":   volumeName,
+	})
 	if err != nil {
-		fmt.Println(err)
+		return err
+	}
+	if len(vols) == 0 {
+		return fmt.Errorf("volume %s not found", volumeName)
+	} else if len(vols) > 1 {
+		return fmt.Errorf("ambiguous volume name %s: multiple volumes found", volumeName)
 	}
+	vol := vols[0]
 
-	for i := 0; i < len(vols); i++ {
-		if vols[i].Name == volumeName {
-			vol = vols[i].Path
+	return updateState(func(s *state) error {
+		if attachedTo := s.attachedInstance(vol.ID); attachedTo != "" {
+			return fmt.Errorf("volume %s is already attached to instance %s", volumeName, attachedTo)
 		}
-	}
 
-	instance, err := op.GetMetaInstanceByName(ctx, instanceName)
-	if err != nil {
-		return err
-	}
+		instance := s.find(instanceName)
+		if instance == nil || !instance.running() {
+			return lepton.ErrInstanceNotFound(instanceName)
+		}
 
-	last := instance.Mgmt
+		if !instance.isQemu() {
+			return fmt.Errorf("volumes cannot be hot-plugged into %s instances", instance.Hypervisor)
+		}
 
-	deviceAddCmd := `{ "execute": "device_add", "arguments": {"driver": "scsi-hd", "bus": "scsi0.0", "drive": "` + volumeName + `", "id": "` + volumeName + `"`
+		attachment := volumeAttachment{
+			Volume:     vol.Name,
+			ID:         vol.ID,
+			Path:       vol.Path,
+			AttachID:   attachID,
+			MountPoint: mountPoint(ctx.Config().Mounts, vol, attachID),
+		}
+
+		c, err := qemu.DialQMP(instance.Mgmt)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		if err = hotplugVolume(c, attachment); err != nil {
+			return err
+		}
+
+		instance.Volumes = append(instance.Volumes, attachment)
+		return nil
+	})
+}
+
+// mountPoint returns the directory a volume is mounted at according to the mounts of the image
+// configuration, or an empty string if the configuration does not mount it
+func mountPoint(mounts map[string]string, vol lepton.NanosVolume, attachID int) string {
+	if dir, ok := mounts[vol.Name]; ok {
+		return dir
+	}
+	if dir, ok := mounts[vol.ID]; ok {
+		return dir
+	}
 	if attachID >= 0 {
-		deviceAddCmd += fmt.Sprintf(`, "device_id": "persistent-disk-%d"`, attachID)
+		return mounts[fmt.Sprintf("%%%d", attachID)]
 	}
-	deviceAddCmd += `}}`
-	commands := []string{
-		`{ "execute": "qmp_capabilities" }`,
-		`{ "execute": "blockdev-add", "arguments": {"driver": "raw", "node-name":"` + volumeName + `", "file": {"driver": "file", "filename": "` + vol + `"} } }`,
-		deviceAddCmd,
+	return ""
+}
+
+// hotplugVolume adds the block device and the scsi disk of a volume to a running qemu instance
+func hotplugVolume(c *qmp.Client, a volumeAttachment) error {
+	err := c.BlockdevAdd(qmp.BlockdevRawFile{NodeName: a.Volume, Filename: a.Path})
+	if err != nil {
+		return fmt.Errorf("cannot attach volume %s: %w", a.Volume, err)
+	}
+	device := qmp.Device{
+		Driver:     "scsi-hd",
+		ID:         a.Volume,
+		Bus:        "scsi0.0",
+		Properties: map[string]interface{}{"drive": a.Volume},
+	}
+	if a.AttachID >= 0 {
+		device.Properties["device_id"] = fmt.Sprintf("persistent-disk-%d", a.AttachID)
+	}
+	if err = c.DeviceAdd(device); err != nil {
+		c.BlockdevDel(a.Volume)
+		return fmt.Errorf("cannot attach volume %s: %w", a.Volume, err)
 	}
+	return nil
+}
 
-	qemu.ExecuteQMP(commands, last)
+// reattachVolumes attaches the volumes recorded as attached to an instance to the relaunched qemu
+// process of the instance
+func reattachVolumes(mgmt string, attachments []volumeAttachment) error {
+	c, err := waitQMP(mgmt)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
 
+	for _, a := range attachments {
+		if err = hotplugVolume(c, a); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// waitQMP connects to the QMP server of a qemu instance which has just been started, as the
+// server is not listening until qemu is initialized
+func waitQMP(mgmt string) (c *qmp.Client, err error) {
+	for retry := 0; retry < 50; retry++ {
+		if c, err = qemu.DialQMP(mgmt); err == nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return
+}
+
 // DetachVolume detaches volume
 func (op *OnPrem) DetachVolume(ctx *lepton.Context, instanceName string, volumeName string) error {
-	vol := ""
+	return updateState(func(s *state) error {
+		instance := s.find(instanceName)
+		if instance == nil {
+			return lepton.ErrInstanceNotFound(instanceName)
+		}
 
-	buildDir := ctx.Config().VolumesDir
-	vols, err := GetVolumes(buildDir, nil)
-	if err != nil {
-		fmt.Println(err)
-	}
+		recorded := -1
+		for i, a := range instance.Volumes {
+			if a.Volume == volumeName || a.ID == volumeName {
+				recorded = i
+				volumeName = a.Volume
+			}
+		}
+		forget := func() {
+			if recorded >= 0 {
+				instance.Volumes = append(instance.Volumes[:recorded], instance.Volumes[recorded+1:]...)
+			}
+		}
 
-	for i := 0; i < len(vols); i++ {
-		if vols[i].Name == volumeName {
-			vol = vols[i].Path
+		if !instance.running() {
+			// the volume of an instance which is not running only needs to be forgotten
+			if recorded < 0 {
+				return fmt.Errorf("volume %s is not attached to instance %s", volumeName, instanceName)
+			}
+			forget()
+			return nil
 		}
-	}
 
-	if vol != "" {
-		fmt.Printf("removing %s\n", vol)
-	}
+		if !instance.isQemu() {
+			return fmt.Errorf("volumes cannot be hot-plugged into %s instances", instance.Hypervisor)
+		}
 
-	instance, err := op.GetMetaInstanceByName(ctx, instanceName)
-	if err != nil {
-		return err
-	}
+		c, err := qemu.DialQMP(instance.Mgmt)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
 
-	last := instance.Mgmt
+		if err = c.DeviceDel(volumeName); err != nil {
+			return fmt.Errorf("cannot detach volume %s: %w", volumeName, err)
+		}
+		if err = c.BlockdevDel(volumeName); err != nil {
+			return fmt.Errorf("cannot detach volume %s: %w", volumeName, err)
+		}
+
+		forget()
+		return nil
+	})
+}
 
-	commands := []string{
-		`{ "execute": "qmp_capabilities" }`,
-		`{ "execute": "device_del", "arguments": {"id": "` + volumeName + `"}}`,
-		`{ "execute": "blockdev-del", "arguments": {"node-name":"` + volumeName + `" } }`,
+// VolumeAttachedTo returns the name of the instance that a local volume is attached to, or an
+// empty string if the volume is not in use
+func (op *OnPrem) VolumeAttachedTo(ctx *lepton.Context, volumePath string) (string, error) {
+	volumePath = path.Clean(volumePath)
+	attachedTo := ""
+	err := viewState(func(s *state) error {
+		for _, i := range s.Instances {
+			for _, a := range i.Volumes {
+				if path.Clean(a.Path) == volumePath {
+					attachedTo = i.Instance
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil || attachedTo != "" {
+		return attachedTo, err
 	}
 
-	c, err := net.Dial("tcp", "localhost:"+last)
+	instances, err := op.GetMetaInstances(ctx)
 	if err != nil {
-		fmt.Println(err)
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
 	}
-	defer c.Close()
-
-	for i := 0; i < len(commands); i++ {
-		_, err := c.Write([]byte(commands[i] + "\n"))
-		if err != nil {
-			fmt.Println(err)
+	for _, i := range instances {
+		// only qemu instances report their volumes
+		if i.Mgmt == "" || !i.isQemu() {
+			continue
 		}
-		received := make([]byte, 1024)
-		_, err = c.Read(received)
+		files, err := queryBlockFiles(i.Mgmt)
 		if err != nil {
-			println("Read data failed:", err.Error())
-			os.Exit(1)
+			return "", fmt.Errorf("cannot query volumes of instance %s: %w", i.Instance, err)
+		}
+		for _, file := range files {
+			if path.Clean(file) == volumePath {
+				return i.Instance, nil
+			}
 		}
 	}
+	return "", nil
+}
 
-	return nil
+// queryBlockFiles returns the files backing the block devices of an instance, as reported by QMP
+func queryBlockFiles(mgmt string) ([]string, error) {
+	c, err := qemu.DialQMP(mgmt)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	c.Timeout = 10 * time.Second
+	devices, err := c.QueryBlock()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, device := range devices {
+		if device.Inserted != nil {
+			files = append(files, device.Inserted.File)
+		}
+	}
+	return files, nil
 }
 
 // parseSize parses the size of the lepton.NanosVolume to human readable format.
@@ -0,0 +1,316 @@
+package onprem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/google/uuid"
+	"github.com/nanovms/ops/lepton"
+)
+
+// Statuses of onprem instances
+const (
+	statusStarting   = "Starting"
+	statusRunning    = "Running"
+	statusPaused     = "Paused"
+	statusRestarting = "Restarting"
+	statusExited     = "Exited"
+	statusFailed     = "Failed"
+)
+
+// maxTransitions is the number of status transitions kept for each instance
+const maxTransitions = 20
+
+// transition records an instance entering a status
+type transition struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// state is the state of all onprem instances. It is kept in a JSON file shared by every ops
+// process, and is only read and written through viewState and updateState, which lock the file.
+type state struct {
+	Instances []*instance `json:"instances"`
+
+	// legacy are the pid-named instance files imported into the state, removed once it is saved
+	legacy []string
+}
+
+func stateFile(opshome string) string {
+	return path.Join(opshome, "instances.json")
+}
+
+// viewState calls f with the state of the instances, which f must not modify
+func viewState(f func(s *state) error) error {
+	return viewStateAt(lepton.GetOpsHome(), f)
+}
+
+func viewStateAt(opshome string, f func(s *state) error) error {
+	lock, err := lockState(opshome, false)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	s, err := readState(opshome)
+	if err != nil {
+		return err
+	}
+	return f(s)
+}
+
+// updateState calls f with the state of the instances and saves the state modified by f, unless f
+// returns an error. Other ops processes wait for the update to complete before accessing the
+// state, so f must not call viewState or updateState.
+func updateState(f func(s *state) error) error {
+	opshome := lepton.GetOpsHome()
+	lock, err := lockState(opshome, true)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	s, err := readState(opshome)
+	if err != nil {
+		return err
+	}
+	if err = f(s); err != nil {
+		return err
+	}
+	return s.write(opshome)
+}
+
+// updateInstance calls f with the instance identified by id and saves the state modified by f
+func updateInstance(id string, f func(i *instance) error) error {
+	return updateState(func(s *state) error {
+		i := s.get(id)
+		if i == nil {
+			return lepton.ErrInstanceNotFound(id)
+		}
+		return f(i)
+	})
+}
+
+// lockState locks the state for reading, or for writing if exclusive is set; the lock is taken on
+// a separate file as the state file is replaced on writes
+func lockState(opshome string, exclusive bool) (*flock.Flock, error) {
+	if err := os.MkdirAll(opshome, 0755); err != nil {
+		return nil, err
+	}
+	lock := flock.New(stateFile(opshome) + ".lock")
+	var err error
+	if exclusive {
+		err = lock.Lock()
+	} else {
+		err = lock.RLock()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot lock instance state: %w", err)
+	}
+	return lock, nil
+}
+
+// readState reads the state of the instances and updates the status of instances whose process
+// exited since the state was saved
+func readState(opshome string) (*state, error) {
+	s := &state{}
+	body, err := os.ReadFile(stateFile(opshome))
+	if errors.Is(err, os.ErrNotExist) {
+		if err = s.importLegacy(opshome); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if err = json.Unmarshal(body, s); err != nil {
+		return nil, fmt.Errorf("cannot read instance state %s: %w", stateFile(opshome), err)
+	}
+
+	for _, i := range s.Instances {
+		i.refresh()
+	}
+	return s, nil
+}
+
+// write replaces the state file atomically, so that the state is not lost if ops is interrupted
+func (s *state) write(opshome string) error {
+	body, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := stateFile(opshome) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(body); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, stateFile(opshome))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("cannot save instance state: %w", err)
+	}
+
+	for _, legacy := range s.legacy {
+		os.Remove(legacy)
+	}
+	s.legacy = nil
+	return nil
+}
+
+// importLegacy imports the running instances recorded by previous versions of ops, which wrote
+// one file per instance named after the pid of the instance
+func (s *state) importLegacy(opshome string) error {
+	dir := path.Join(opshome, "instances")
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, f := range files {
+		if _, err := strconv.Atoi(f.Name()); err != nil || f.IsDir() {
+			continue
+		}
+		file := path.Join(dir, f.Name())
+		s.legacy = append(s.legacy, file)
+		if !processAlive(f.Name(), "") {
+			continue
+		}
+
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		i := &instance{}
+		if err = json.Unmarshal(body, i); err != nil {
+			return fmt.Errorf("cannot import instance file %s: %w", file, err)
+		}
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		// the state is only saved by updates, so the instance is imported again on each read
+		// until then and keeps the same ID
+		i.ID = legacyID(f.Name(), info.ModTime())
+		i.setPid(f.Name())
+		i.CreatedAt = info.ModTime()
+		i.setStatus(statusRunning)
+		s.Instances = append(s.Instances, i)
+	}
+	return nil
+}
+
+// legacyNamespace is the namespace of the IDs of the imported instances
+var legacyNamespace = uuid.MustParse("6f1c1b5e-2f4d-4c36-9a57-5c3e0d8a4f21")
+
+// legacyID returns the ID of the instance imported from the file of pid, written at modTime
+func legacyID(pid string, modTime time.Time) string {
+	return uuid.NewSHA1(legacyNamespace, []byte(pid+"@"+modTime.UTC().Format(time.RFC3339Nano))).String()
+}
+
+// add adds a new instance named name
+func (s *state) add(name string) *instance {
+	i := &instance{
+		ID:        uuid.New().String(),
+		Instance:  name,
+		CreatedAt: time.Now(),
+	}
+	s.Instances = append(s.Instances, i)
+	return i
+}
+
+// get returns the instance identified by id, or nil
+func (s *state) get(id string) *instance {
+	for _, i := range s.Instances {
+		if i.ID == id {
+			return i
+		}
+	}
+	return nil
+}
+
+// find returns the instance named or identified by nameOrID, or nil
+func (s *state) find(nameOrID string) *instance {
+	for _, i := range s.Instances {
+		if i.Instance == nameOrID {
+			return i
+		}
+	}
+	return s.get(nameOrID)
+}
+
+// byPid returns the running instance with the process pid, or nil
+func (s *state) byPid(pid string) *instance {
+	pid = strings.TrimSpace(pid)
+	for _, i := range s.Instances {
+		if i.Pid == pid {
+			return i
+		}
+	}
+	return nil
+}
+
+func (s *state) remove(id string) {
+	for n, i := range s.Instances {
+		if i.ID == id {
+			s.Instances = append(s.Instances[:n], s.Instances[n+1:]...)
+			return
+		}
+	}
+}
+
+// volumeAttachment records a volume attached to an instance, so that the volume is attached again
+// when the instance is relaunched
+type volumeAttachment struct {
+	Volume     string `json:"volume"` // volume name
+	ID         string `json:"id"`     // volume UUID
+	Path       string `json:"path"`
+	AttachID   int    `json:"attach_id"`
+	MountPoint string `json:"mount_point,omitempty"`
+}
+
+// attachedInstance returns the name of the instance a volume is attached to, or an empty string
+func (s *state) attachedInstance(volumeID string) string {
+	for _, i := range s.Instances {
+		for _, a := range i.Volumes {
+			if a.ID == volumeID {
+				return i.Instance
+			}
+		}
+	}
+	return ""
+}
+
+// processAlive returns whether the process pid exists and, if start is set, is the process which
+// processStart identified by start rather than a process reusing its pid
+func processAlive(pid string, start string) bool {
+	n, err := strconv.Atoi(strings.TrimSpace(pid))
+	if err != nil || n <= 0 {
+		return false
+	}
+	if start != "" && processStart(n) != start {
+		return false
+	}
+	process, err := os.FindProcess(n)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	// the process of another user cannot be signaled
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,226 @@
+package onprem
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/network/dhcp"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentStateUpdates(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			err := updateState(func(s *state) error {
+				s.add("web-" + strconv.Itoa(n)).setStatus(statusExited)
+				return nil
+			})
+			assert.Nil(t, err)
+		}(n)
+	}
+	wg.Wait()
+
+	err := viewState(func(s *state) error {
+		assert.Len(t, s.Instances, 20)
+		ids := make(map[string]bool)
+		for _, i := range s.Instances {
+			ids[i.ID] = true
+		}
+		assert.Len(t, ids, 20)
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestInstanceTransitions(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+
+	// the process of the instance is this test, then a pid which cannot exist
+	var id string
+	err := updateState(func(s *state) error {
+		i := s.add("web")
+		i.Pid = strconv.Itoa(os.Getpid())
+		i.setStatus(statusStarting)
+		i.setStatus(statusRunning)
+		id = i.ID
+		return nil
+	})
+	assert.Nil(t, err)
+
+	err = updateInstance(id, func(i *instance) error {
+		assert.True(t, i.running())
+		assert.Equal(t, statusRunning, i.Status)
+		i.Pid = "-1"
+		return nil
+	})
+	assert.Nil(t, err)
+
+	err = viewState(func(s *state) error {
+		i := s.find(id)
+		assert.Equal(t, "web", i.Instance)
+		assert.Equal(t, statusExited, i.Status)
+		assert.Equal(t, "", i.Pid)
+		var statuses []string
+		for _, tr := range i.Transitions {
+			statuses = append(statuses, tr.Status)
+		}
+		assert.Equal(t, []string{statusStarting, statusRunning, statusExited}, statuses)
+		return nil
+	})
+	assert.Nil(t, err)
+
+	assert.True(t, lepton.IsInstanceNotFoundError(updateInstance("db", func(i *instance) error { return nil })))
+}
+
+func TestInstanceProcessIdentity(t *testing.T) {
+	i := &instance{}
+	i.setPid(strconv.Itoa(os.Getpid()))
+	i.setStatus(statusRunning)
+	i.refresh()
+	assert.Equal(t, statusRunning, i.Status)
+
+	// the pid of the exited instance is reused by another process
+	i.PidStart = "another process"
+	i.refresh()
+	assert.Equal(t, statusExited, i.Status)
+	assert.Equal(t, "", i.Pid)
+	assert.Equal(t, "", i.PidStart)
+}
+
+func TestImportLegacyInstances(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	instancesDir := path.Join(lepton.GetOpsHome(), "instances")
+	assert.Nil(t, os.MkdirAll(instancesDir, 0755))
+
+	// the instance of this pid is running, the other one exited
+	pid := strconv.Itoa(os.Getpid())
+	body, err := json.Marshal(instance{Instance: "web", Image: "/images/web.img", Pid: pid, Mac: "52:54:00:12:34:56"})
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(path.Join(instancesDir, pid), body, 0644))
+	assert.Nil(t, os.WriteFile(path.Join(instancesDir, "999999999"), body, 0644))
+
+	// the instance keeps its ID until the imported state is saved
+	var id string
+	err = viewState(func(s *state) error {
+		assert.Len(t, s.Instances, 1)
+		id = s.find("web").ID
+		return nil
+	})
+	assert.Nil(t, err)
+
+	err = updateState(func(s *state) error {
+		assert.Len(t, s.Instances, 1)
+		i := s.find("web")
+		assert.Equal(t, id, i.ID)
+		assert.Equal(t, pid, i.Pid)
+		assert.Equal(t, "52:54:00:12:34:56", i.Mac)
+		assert.Equal(t, statusRunning, i.Status)
+		return nil
+	})
+	assert.Nil(t, err)
+
+	files, err := os.ReadDir(instancesDir)
+	assert.Nil(t, err)
+	assert.Empty(t, files)
+}
+
+func TestLeasedIP(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	assert.Nil(t, os.MkdirAll(path.Join(lepton.GetOpsHome(), "networks"), 0755))
+
+	err := dhcp.WriteLeases(lepton.NetworkLeasesPath("compose"), []dhcp.Lease{
+		{MAC: "52:54:00:12:34:56", IP: "192.168.34.7", Expires: time.Now().Add(time.Hour)},
+	})
+	assert.Nil(t, err)
+
+	i := &instance{Mac: "52:54:00:12:34:56", RunConfig: &types.RunConfig{BridgeName: "compose"}}
+	assert.Equal(t, "192.168.34.7", leasedIP(i))
+	i.Mac = "52:54:00:12:34:57"
+	assert.Equal(t, "", leasedIP(i))
+	i.RunConfig = nil
+	assert.Equal(t, "", leasedIP(i))
+}
+
+func TestVolumeAttachments(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+
+	volumesDir := t.TempDir()
+	volumePath := path.Join(volumesDir, "data:0f4c5bd4-3b44-4a55-a1e2-a6f9a3f4a1b2.raw")
+	assert.Nil(t, os.WriteFile(volumePath, nil, 0644))
+	ctx := lepton.NewContext(&types.Config{VolumesDir: volumesDir})
+	op := &OnPrem{}
+
+	// an instance which exited
+	err := updateState(func(s *state) error {
+		i := s.add("web")
+		i.setStatus(statusExited)
+		i.Volumes = []volumeAttachment{{
+			Volume:     "data",
+			ID:         "0f4c5bd4-3b44-4a55-a1e2-a6f9a3f4a1b2",
+			Path:       volumePath,
+			AttachID:   1,
+			MountPoint: "/data",
+		}}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	vols, err := op.GetAllVolumes(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, *vols, 1)
+	assert.Equal(t, "web", (*vols)[0].AttachedTo)
+	assert.Equal(t, "in-use", (*vols)[0].Status)
+
+	instanceName, err := op.VolumeAttachedTo(ctx, volumePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "web", instanceName)
+
+	err = op.DeleteVolume(ctx, "data")
+	assert.EqualError(t, err, "volume data is attached to instance web, detach it first")
+
+	// the instance is not running, so the attachment is only forgotten
+	assert.Nil(t, op.DetachVolume(ctx, "web", "data"))
+	err = viewState(func(s *state) error {
+		assert.Empty(t, s.find("web").Volumes)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.EqualError(t, op.DetachVolume(ctx, "web", "data"), "volume data is not attached to instance web")
+
+	vols, err = op.GetAllVolumes(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "", (*vols)[0].AttachedTo)
+	assert.Equal(t, "available", (*vols)[0].Status)
+	assert.Nil(t, op.DeleteVolume(ctx, "data"))
+}
+
+func TestRelaunchAttachments(t *testing.T) {
+	recorded := []volumeAttachment{
+		{Volume: "data", Path: "/volumes/data:1.raw", AttachID: -1},
+		{Volume: "logs", Path: "/volumes/logs:2.raw", AttachID: 0},
+	}
+
+	rconfig := &types.RunConfig{InstanceName: "web", Mounts: []string{"/volumes/data:1.raw"}}
+	attachments := relaunchAttachments(rconfig, recorded)
+	assert.Equal(t, []volumeAttachment{{Volume: "logs", Path: "/volumes/logs:2.raw", AttachID: 0}}, attachments)
+	assert.True(t, rconfig.QMP)
+
+	rconfig = &types.RunConfig{InstanceName: "web", Hypervisor: "firecracker"}
+	assert.Empty(t, relaunchAttachments(rconfig, recorded))
+
+	rconfig = &types.RunConfig{InstanceName: "db"}
+	assert.Empty(t, relaunchAttachments(rconfig, nil))
+	assert.False(t, rconfig.QMP)
+}
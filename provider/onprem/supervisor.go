@@ -0,0 +1,294 @@
+package onprem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/types"
+)
+
+// Restart policies of onprem instances, set in RunConfig.Restart
+const (
+	// RestartNo does not restart instances, which are not supervised
+	RestartNo = "no"
+
+	// RestartOnFailure restarts instances which crash, up to RunConfig.RestartMaxRetries times
+	// if it is set
+	RestartOnFailure = "on-failure"
+
+	// RestartAlways restarts instances whenever they exit
+	RestartAlways = "always"
+)
+
+const maxRestartBackoff = time.Minute
+
+// supervisor is the restart state of an instance run by a supervisor process, which launches the
+// instance again when it exits according to its restart policy; the pid of the supervisor is the
+// owner of the instance
+type supervisor struct {
+	Config    types.Config `json:"config"`
+	Restarts  int          `json:"restarts"`
+	ExitCode  *int         `json:"exit_code,omitempty"`
+	CrashedAt *time.Time   `json:"crashed_at,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// ValidateRestartPolicy returns an error if policy is not a restart policy
+func ValidateRestartPolicy(policy string) error {
+	switch policy {
+	case "", RestartNo, RestartOnFailure, RestartAlways:
+		return nil
+	}
+	return fmt.Errorf("invalid restart policy %q, expected one of %s, %s, %s", policy, RestartNo, RestartOnFailure, RestartAlways)
+}
+
+// isSupervised returns whether instances run with a configuration are supervised
+func isSupervised(rconfig *types.RunConfig) bool {
+	return rconfig.Restart == RestartOnFailure || rconfig.Restart == RestartAlways
+}
+
+// exitedSuccessfully returns whether a qemu exit code is a clean exit: qemu exits with 0 when the
+// guest is powered off, and with 1 when the program exits with 0 through the isa-debug-exit device
+func exitedSuccessfully(code int) bool {
+	return code == 0 || code == 1
+}
+
+// shouldRestart returns whether an instance which exited with code is restarted, after restarts
+// previous restarts
+func shouldRestart(rconfig *types.RunConfig, code int, restarts int) bool {
+	switch rconfig.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		if exitedSuccessfully(code) {
+			return false
+		}
+		return rconfig.RestartMaxRetries <= 0 || restarts < rconfig.RestartMaxRetries
+	}
+	return false
+}
+
+// restartBackoff returns the time to wait before restarting an instance which failed failures
+// times in a row
+func restartBackoff(failures int) time.Duration {
+	if failures > 6 {
+		return maxRestartBackoff
+	}
+	backoff := time.Second << failures
+	if backoff > maxRestartBackoff {
+		return maxRestartBackoff
+	}
+	return backoff
+}
+
+// startSupervisor starts the supervisor process of an instance and returns the pid of the instance
+// once the supervisor has launched it
+func startSupervisor(c *types.Config) (string, error) {
+	name := c.RunConfig.InstanceName
+	var id string
+	err := updateState(func(s *state) error {
+		i := s.find(name)
+		if i != nil && i.active() {
+			return fmt.Errorf("instance %s already exists", name)
+		}
+		if i == nil {
+			i = s.add(name)
+		}
+		// the instance is owned by this process until the supervisor is started
+		i.setOwner(os.Getpid())
+		i.Supervisor = &supervisor{Config: *c}
+		i.setStatus(statusStarting)
+		id = i.ID
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ops, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	logFile, err := os.Create("/tmp/" + name + "-supervisor.log")
+	if err != nil {
+		return "", err
+	}
+	defer logFile.Close()
+	cmd := exec.Command(ops, "instance", "supervise", id)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+	}
+	if err = cmd.Start(); err != nil {
+		err = fmt.Errorf("cannot start supervisor of instance %s: %w", name, err)
+		updateInstance(id, func(i *instance) error {
+			i.setOwner(0)
+			i.Supervisor.Error = err.Error()
+			i.setStatus(statusFailed)
+			return nil
+		})
+		return "", err
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	err = updateInstance(id, func(i *instance) error {
+		if i.ownedBy(os.Getpid()) {
+			i.setOwner(cmd.Process.Pid)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for retry := 0; retry < 300; retry++ {
+		var supervised instance
+		err = viewState(func(s *state) error {
+			if i := s.get(id); i != nil {
+				supervised = *i
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if supervised.Supervisor != nil && supervised.Supervisor.Error != "" {
+			return "", errors.New(supervised.Supervisor.Error)
+		}
+		if supervised.Pid != "" {
+			return supervised.Pid, nil
+		}
+		select {
+		case <-exited:
+			var supervisorErr string
+			viewState(func(s *state) error {
+				if i := s.get(id); i != nil && i.Supervisor != nil {
+					supervisorErr = i.Supervisor.Error
+				}
+				return nil
+			})
+			if supervisorErr != "" {
+				return "", errors.New(supervisorErr)
+			}
+			return "", fmt.Errorf("supervisor of instance %s exited, see /tmp/%s-supervisor.log", name, name)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return "", fmt.Errorf("timed out waiting for supervisor of instance %s, see /tmp/%s-supervisor.log", name, name)
+}
+
+// SuperviseInstance runs the supervisor of an instance, named or identified by nameOrID: it
+// launches the instance and launches it again when it exits, according to the restart policy of
+// the instance, until the policy gives up or the instance is deleted
+func (p *OnPrem) SuperviseInstance(nameOrID string) error {
+	var id string
+	err := viewState(func(s *state) error {
+		i := s.find(nameOrID)
+		if i == nil || i.Supervisor == nil {
+			return fmt.Errorf("instance %s is not supervised", nameOrID)
+		}
+		id = i.ID
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for {
+		// the configuration is read again for every launch as launching changes it
+		var c types.Config
+		err = updateInstance(id, func(i *instance) error {
+			i.setOwner(os.Getpid())
+			c = i.Supervisor.Config
+			return nil
+		})
+		if err != nil {
+			if lepton.IsInstanceNotFoundError(err) {
+				// the instance was deleted
+				return nil
+			}
+			return err
+		}
+
+		pid, err := p.launchInstance(lepton.NewContext(&c), nil)
+		if err != nil {
+			updateInstance(id, func(i *instance) error {
+				i.setOwner(0)
+				i.Supervisor.Error = err.Error()
+				i.setStatus(statusFailed)
+				return nil
+			})
+			return err
+		}
+
+		code := waitInstance(pid)
+
+		exited := false
+		err = updateInstance(id, func(i *instance) error {
+			i.setPid("")
+			i.Supervisor.ExitCode = &code
+			i.Supervisor.Error = ""
+			if exitedSuccessfully(code) {
+				failures = 0
+			} else {
+				now := time.Now()
+				i.Supervisor.CrashedAt = &now
+				failures++
+			}
+
+			if !shouldRestart(&c.RunConfig, code, i.Supervisor.Restarts) {
+				i.setOwner(0)
+				i.setStatus(statusExited)
+				exited = true
+				return nil
+			}
+			i.Supervisor.Restarts++
+			i.setStatus(statusRestarting)
+			return nil
+		})
+		if err != nil {
+			if lepton.IsInstanceNotFoundError(err) {
+				return nil
+			}
+			return err
+		}
+		if exited {
+			return nil
+		}
+		time.Sleep(restartBackoff(failures))
+	}
+}
+
+// waitInstance waits for the process of an instance launched by this process to exit and returns
+// its exit code, which is -1 if the process was killed or is not a child of this process
+func waitInstance(pid string) int {
+	n, err := strconv.Atoi(pid)
+	if err != nil {
+		return -1
+	}
+	process, err := os.FindProcess(n)
+	if err != nil {
+		return -1
+	}
+	state, err := process.Wait()
+	if err == nil {
+		return state.ExitCode()
+	}
+	// the process is not a child, eg: it was launched through an AtExit hook
+	start := processStart(n)
+	for processAlive(pid, start) {
+		time.Sleep(time.Second)
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package onprem
+
+import (
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	assert.Nil(t, ValidateRestartPolicy(""))
+	assert.Nil(t, ValidateRestartPolicy(RestartOnFailure))
+	assert.EqualError(t, ValidateRestartPolicy("unless-stopped"), `invalid restart policy "unless-stopped", expected one of no, on-failure, always`)
+
+	assert.False(t, isSupervised(&types.RunConfig{}))
+	assert.False(t, isSupervised(&types.RunConfig{Restart: RestartNo}))
+	assert.True(t, isSupervised(&types.RunConfig{Restart: RestartAlways}))
+
+	onFailure := &types.RunConfig{Restart: RestartOnFailure, RestartMaxRetries: 2}
+	assert.False(t, shouldRestart(onFailure, 0, 0))
+	assert.False(t, shouldRestart(onFailure, 1, 0))
+	assert.True(t, shouldRestart(onFailure, 253, 1))
+	assert.True(t, shouldRestart(onFailure, -1, 1))
+	assert.False(t, shouldRestart(onFailure, 253, 2))
+	assert.True(t, shouldRestart(&types.RunConfig{Restart: RestartOnFailure}, 253, 100))
+	assert.True(t, shouldRestart(&types.RunConfig{Restart: RestartAlways}, 1, 100))
+	assert.False(t, shouldRestart(&types.RunConfig{}, 253, 0))
+
+	assert.Equal(t, time.Second, restartBackoff(0))
+	assert.Equal(t, 8*time.Second, restartBackoff(3))
+	assert.Equal(t, maxRestartBackoff, restartBackoff(6))
+	assert.Equal(t, maxRestartBackoff, restartBackoff(100))
+}
+
+func TestSupervisedStatus(t *testing.T) {
+	code := 253
+	i := &instance{Supervisor: &supervisor{
+		Config:   types.Config{RunConfig: types.RunConfig{InstanceName: "web", Restart: RestartAlways}},
+		Restarts: 3,
+		ExitCode: &code,
+	}}
+	i.setStatus(statusRestarting)
+	assert.Equal(t, "Restarting", i.displayStatus())
+	i.setStatus(statusExited)
+	assert.Equal(t, "Exited (253)", i.displayStatus())
+}
+
+func TestWaitInstance(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 3")
+	assert.Nil(t, cmd.Start())
+	assert.Equal(t, 3, waitInstance(strconv.Itoa(cmd.Process.Pid)))
+	assert.Equal(t, -1, waitInstance("not a pid"))
+}
@@ -8,3 +8,8 @@ import (
 func sysKill(pid int) error {
 	return syscall.Kill(pid, 9)
 }
+
+// processStart does not identify processes on this platform, only their pids are checked
+func processStart(pid int) string {
+	return ""
+}
@@ -1,6 +1,10 @@
 package onprem
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
 	"syscall"
 )
 
@@ -8,3 +12,23 @@ import (
 func sysKill(pid int) error {
 	return syscall.Kill(pid, 9)
 }
+
+// processStart returns the boot and the time since boot at which the process pid started, which
+// identify it across pid reuse and reboots, or an empty string if it does not exist
+func processStart(pid int) string {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// the name of the command may contain spaces and parentheses, the start time is the 20th
+	// field after it
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) < 20 {
+		return ""
+	}
+	bootID, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bootID)) + "/" + fields[19]
+}
@@ -8,3 +8,8 @@ import (
 func sysKill(pid int) error {
 	return errors.New("not supported")
 }
+
+// processStart does not identify processes on this platform, only their pids are checked
+func processStart(pid int) string {
+	return ""
+}
@@ -5,8 +5,10 @@ package openshift
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"time"
 
+	"github.com/nanovms/ops/lepton"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -67,6 +69,9 @@ func NewForConfig(config clientcmd.ClientConfig) (client *Client, err error) {
 	// and reference implementation: https://github.com/vmware-tanzu/tanzu-framework/pull/1656
 	client.KubeClientConfig.QPS = defaultQPS
 	client.KubeClientConfig.Burst = defaultBurst
+	client.KubeClientConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return lepton.NewRetryTransport(ProviderName, rt)
+	})
 
 	client.KubeClient, err = kubernetes.NewForConfig(client.KubeClientConfig)
 	if err != nil {
@@ -42,6 +42,12 @@ func (oc *OpenShift) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns nil, OpenShift pods only supporting the basic image and instance
+// operations
+func (oc *OpenShift) Capabilities() lepton.Capabilities {
+	return nil
+}
+
 // BuildImage builds the image
 func (oc *OpenShift) BuildImage(ctx *lepton.Context) (string, error) {
 	return "", nil
@@ -10,6 +10,7 @@ import (
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
 )
@@ -40,7 +41,14 @@ func (o *OpenStack) Initialize(config *types.ProviderConfig) error {
 		return err
 	}
 
-	o.provider, err = openstack.AuthenticatedClient(opts)
+	o.provider, err = openstack.NewClient(opts.IdentityEndpoint)
+	if err != nil {
+		return err
+	}
+
+	o.provider.HTTPClient = *lepton.NewHTTPClient(ProviderName)
+
+	err = openstack.Authenticate(o.provider, opts)
 	if err != nil {
 		return err
 	}
@@ -48,8 +56,17 @@ func (o *OpenStack) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
-func (o *OpenStack) findFlavorByName(name string) (id string, err error) {
-	client, err := o.getComputeClient()
+// Capabilities returns the optional operations supported by OpenStack
+func (o *OpenStack) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+		lepton.CapabilityVolumes,
+		lepton.CapabilityDNS,
+	}
+}
+
+func (o *OpenStack) findFlavorByName(ctx *lepton.Context, name string) (id string, err error) {
+	client, err := o.getComputeClient(ctx)
 	if err != nil {
 		log.Error(err)
 	}
@@ -82,8 +99,15 @@ func (o *OpenStack) findFlavorByName(name string) (id string, err error) {
 	return "", errors.New("flavor " + name + " not found")
 }
 
-func (o *OpenStack) getComputeClient() (*gophercloud.ServiceClient, error) {
-	return openstack.NewComputeV2(o.provider, gophercloud.EndpointOpts{
+// providerClient returns the provider client with the context of the operation, which its
+// requests are made with; the operations of a provider are run one at a time
+func (o *OpenStack) providerClient(ctx *lepton.Context) *gophercloud.ProviderClient {
+	o.provider.Context = ctx.Context()
+	return o.provider
+}
+
+func (o *OpenStack) getComputeClient(ctx *lepton.Context) (*gophercloud.ServiceClient, error) {
+	return openstack.NewComputeV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 }
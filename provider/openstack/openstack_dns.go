@@ -10,12 +10,11 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/dns/v2/recordsets"
 	"github.com/gophercloud/gophercloud/openstack/dns/v2/zones"
 	"github.com/nanovms/ops/lepton"
-	"github.com/nanovms/ops/types"
 )
 
 // FindOrCreateZoneIDByName searches for a DNS zone with the name passed by argument and if it doesn't exist it creates one
-func (o *OpenStack) FindOrCreateZoneIDByName(config *types.Config, dnsName string) (string, error) {
-	dnsClient, err := o.getDNSClient()
+func (o *OpenStack) FindOrCreateZoneIDByName(ctx *lepton.Context, dnsName string) (string, error) {
+	dnsClient, err := o.getDNSClient(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -54,8 +53,8 @@ func (o *OpenStack) FindOrCreateZoneIDByName(config *types.Config, dnsName strin
 }
 
 // DeleteZoneRecordIfExists deletes a record from a DNS zone if it exists
-func (o *OpenStack) DeleteZoneRecordIfExists(config *types.Config, zoneID string, recordName string) error {
-	dnsClient, err := o.getDNSClient()
+func (o *OpenStack) DeleteZoneRecordIfExists(ctx *lepton.Context, zoneID string, recordName string) error {
+	dnsClient, err := o.getDNSClient(ctx)
 	if err != nil {
 		return err
 	}
@@ -82,8 +81,8 @@ func (o *OpenStack) DeleteZoneRecordIfExists(config *types.Config, zoneID string
 }
 
 // CreateZoneRecord creates a record in a DNS zone
-func (o *OpenStack) CreateZoneRecord(config *types.Config, zoneID string, record *lepton.DNSRecord) error {
-	dnsClient, err := o.getDNSClient()
+func (o *OpenStack) CreateZoneRecord(ctx *lepton.Context, zoneID string, record *lepton.DNSRecord) error {
+	dnsClient, err := o.getDNSClient(ctx)
 	if err != nil {
 		return err
 	}
@@ -103,8 +102,8 @@ func (o *OpenStack) CreateZoneRecord(config *types.Config, zoneID string, record
 	return nil
 }
 
-func (o *OpenStack) getDNSClient() (*gophercloud.ServiceClient, error) {
-	return openstack.NewDNSV2(o.provider, gophercloud.EndpointOpts{
+func (o *OpenStack) getDNSClient(ctx *lepton.Context) (*gophercloud.ServiceClient, error) {
+	return openstack.NewDNSV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 }
@@ -44,9 +44,9 @@ func (o *OpenStack) BuildImageWithPackage(ctx *lepton.Context, pkgpath string) (
 	return o.CustomizeImage(ctx)
 }
 
-func (o *OpenStack) findImage(name string) (id string, err error) {
+func (o *OpenStack) findImage(ctx *lepton.Context, name string) (id string, err error) {
 
-	imageClient, err := openstack.NewImageServiceV2(o.provider, gophercloud.EndpointOpts{
+	imageClient, err := openstack.NewImageServiceV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 	if err != nil {
@@ -78,8 +78,8 @@ func (o *OpenStack) findImage(name string) (id string, err error) {
 	return "", errors.New("not found")
 }
 
-func (o *OpenStack) getImagesClient() (*gophercloud.ServiceClient, error) {
-	return openstack.NewImageServiceV2(o.provider, gophercloud.EndpointOpts{
+func (o *OpenStack) getImagesClient(ctx *lepton.Context) (*gophercloud.ServiceClient, error) {
+	return openstack.NewImageServiceV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 }
@@ -117,21 +117,29 @@ func (o *OpenStack) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	log.Info("creating image:\t" + imgName)
 
-	imagesClient, err := o.getImagesClient()
+	imagesClient, err := o.getImagesClient(ctx)
 	if err != nil {
-		log.Error(err)
+		return err
 	}
 
 	image, err := o.createImage(imagesClient, imgName)
 	if err != nil {
-		log.Error(err)
+		return err
 	}
+	done := ctx.AddCleanup("image "+imgName, func(ctx *lepton.Context) error {
+		imagesClient, err := o.getImagesClient(ctx)
+		if err != nil {
+			return err
+		}
+		return o.deleteImage(imagesClient, image.ID)
+	})
 
 	imagePath = lepton.LocalImageDir + "/" + imgName
 	err = o.uploadImage(imagesClient, image.ID, imagePath)
 	if err != nil {
 		return err
 	}
+	done()
 
 	return nil
 }
@@ -140,7 +148,7 @@ func (o *OpenStack) CreateImage(ctx *lepton.Context, imagePath string) error {
 func (o *OpenStack) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
 	var cimages []lepton.CloudImage
 
-	imageClient, err := openstack.NewImageServiceV2(o.provider, gophercloud.EndpointOpts{
+	imageClient, err := openstack.NewImageServiceV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 	if err != nil {
@@ -213,13 +221,13 @@ func (o *OpenStack) deleteImage(imagesClient *gophercloud.ServiceClient, imageID
 
 // DeleteImage deletes image from OpenStack
 func (o *OpenStack) DeleteImage(ctx *lepton.Context, imagename string) error {
-	imageID, err := o.findImage(imagename)
+	imageID, err := o.findImage(ctx, imagename)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
-	imageClient, err := o.getImagesClient()
+	imageClient, err := o.getImagesClient(ctx)
 	if err != nil {
 		log.Error(err)
 	}
@@ -88,14 +88,14 @@ func getOpenStackInstances(provider *gophercloud.ProviderClient, opts servers.Li
 
 // CreateInstance - Creates instance on OpenStack.
 func (o *OpenStack) CreateInstance(ctx *lepton.Context) error {
-	client, err := o.getComputeClient()
+	client, err := o.getComputeClient(ctx)
 	if err != nil {
 		log.Error(err)
 	}
 
 	imageName := ctx.Config().CloudConfig.ImageName
 
-	imageID, err := o.findImage(imageName)
+	imageID, err := o.findImage(ctx, imageName)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -103,7 +103,7 @@ func (o *OpenStack) CreateInstance(ctx *lepton.Context) error {
 
 	fmt.Printf("deploying imageID %s\n", imageID)
 
-	flavorID, err := o.findFlavorByName(ctx.Config().CloudConfig.Flavor)
+	flavorID, err := o.findFlavorByName(ctx, ctx.Config().CloudConfig.Flavor)
 
 	if err != nil {
 		log.Error(err)
@@ -144,6 +144,9 @@ func (o *OpenStack) CreateInstance(ctx *lepton.Context) error {
 		for pollCount > 0 {
 			fmt.Printf(".")
 			time.Sleep(2 * time.Second)
+			if err := ctx.Context().Err(); err != nil {
+				return err
+			}
 
 			instance, err := o.GetInstanceByName(ctx, server.Name)
 			if err != nil || len(instance.PublicIps) == 0 {
@@ -152,7 +155,7 @@ func (o *OpenStack) CreateInstance(ctx *lepton.Context) error {
 			}
 
 			if len(instance.PublicIps) != 0 {
-				err := lepton.CreateDNSRecord(ctx.Config(), instance.PublicIps[0], o)
+				err := lepton.CreateDNSRecord(ctx, instance.PublicIps[0], o)
 				if err != nil {
 					return err
 				}
@@ -192,7 +195,7 @@ func (o *OpenStack) GetInstanceByName(ctx *lepton.Context, name string) (*lepton
 		Name: name,
 	}
 
-	instances, err := getOpenStackInstances(o.provider, opts)
+	instances, err := getOpenStackInstances(o.providerClient(ctx), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -206,7 +209,7 @@ func (o *OpenStack) GetInstanceByName(ctx *lepton.Context, name string) (*lepton
 
 // GetInstances return all instances on OpenStack
 func (o *OpenStack) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
-	return getOpenStackInstances(o.provider, servers.ListOpts{})
+	return getOpenStackInstances(o.providerClient(ctx), servers.ListOpts{})
 }
 
 // ListInstances lists instances on OpenStack.
@@ -251,7 +254,7 @@ func (o *OpenStack) DeleteInstance(ctx *lepton.Context, instancename string) err
 
 	instances, err := o.GetInstances(ctx)
 
-	client, err := openstack.NewComputeV2(o.provider, gophercloud.EndpointOpts{
+	client, err := openstack.NewComputeV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 
@@ -286,12 +289,12 @@ func (o *OpenStack) RebootInstance(ctx *lepton.Context, instancename string) err
 
 // StartInstance starts an instance in OpenStack.
 func (o *OpenStack) StartInstance(ctx *lepton.Context, instancename string) error {
-	client, err := o.getComputeClient()
+	client, err := o.getComputeClient(ctx)
 	if err != nil {
 		log.Error(err)
 	}
 
-	server, err := o.findInstance(instancename)
+	server, err := o.findInstance(ctx, instancename)
 	if err != nil {
 		log.Error(err)
 	}
@@ -306,12 +309,12 @@ func (o *OpenStack) StartInstance(ctx *lepton.Context, instancename string) erro
 
 // StopInstance stops an instance from OpenStack
 func (o *OpenStack) StopInstance(ctx *lepton.Context, instancename string) error {
-	client, err := o.getComputeClient()
+	client, err := o.getComputeClient(ctx)
 	if err != nil {
 		log.Error(err)
 	}
 
-	server, err := o.findInstance(instancename)
+	server, err := o.findInstance(ctx, instancename)
 	if err != nil {
 		log.Error(err)
 	}
@@ -324,10 +327,10 @@ func (o *OpenStack) StopInstance(ctx *lepton.Context, instancename string) error
 	return nil
 }
 
-func (o *OpenStack) findInstance(name string) (volume *servers.Server, err error) {
+func (o *OpenStack) findInstance(ctx *lepton.Context, name string) (volume *servers.Server, err error) {
 	var server *servers.Server
 
-	client, err := o.getComputeClient()
+	client, err := o.getComputeClient(ctx)
 	if err != nil {
 		log.Error(err)
 	}
@@ -373,12 +376,12 @@ func (o *OpenStack) PrintInstanceLogs(ctx *lepton.Context, instancename string,
 // GetInstanceLogs gets instance related logs.
 func (o *OpenStack) GetInstanceLogs(ctx *lepton.Context, instancename string) (string, error) {
 
-	client, err := o.getComputeClient()
+	client, err := o.getComputeClient(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	server, err := o.findInstance(instancename)
+	server, err := o.findInstance(ctx, instancename)
 	if err != nil {
 		return "", err
 	}
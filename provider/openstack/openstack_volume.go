@@ -20,8 +20,8 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 )
 
-func (o *OpenStack) getVolumesClient() (*gophercloud.ServiceClient, error) {
-	return openstack.NewBlockStorageV2(o.provider, gophercloud.EndpointOpts{
+func (o *OpenStack) getVolumesClient(ctx *lepton.Context) (*gophercloud.ServiceClient, error) {
+	return openstack.NewBlockStorageV2(o.providerClient(ctx), gophercloud.EndpointOpts{
 		Region: os.Getenv("OS_REGION_NAME"),
 	})
 }
@@ -30,7 +30,7 @@ func (o *OpenStack) getVolumesClient() (*gophercloud.ServiceClient, error) {
 func (o *OpenStack) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data string, provider string) (lepton.NanosVolume, error) {
 	var vol lepton.NanosVolume
 
-	imagesClient, err := o.getImagesClient()
+	imagesClient, err := o.getImagesClient(ctx)
 	if err != nil {
 		return vol, err
 	}
@@ -39,6 +39,13 @@ func (o *OpenStack) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data
 	if err != nil {
 		return vol, err
 	}
+	imageDone := ctx.AddCleanup("image "+cv.Name, func(ctx *lepton.Context) error {
+		imagesClient, err := o.getImagesClient(ctx)
+		if err != nil {
+			return err
+		}
+		return o.deleteImage(imagesClient, image.ID)
+	})
 
 	vol, err = lepton.CreateLocalVolume(ctx.Config(), cv.Name, data, provider)
 	if err != nil {
@@ -51,7 +58,7 @@ func (o *OpenStack) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data
 		return vol, err
 	}
 
-	volumesClient, err := o.getVolumesClient()
+	volumesClient, err := o.getVolumesClient(ctx)
 	if err != nil {
 		return vol, err
 	}
@@ -76,17 +83,26 @@ func (o *OpenStack) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data
 	if err != nil {
 		return vol, err
 	}
+	volumeDone := ctx.AddCleanup("volume "+cv.Name, func(ctx *lepton.Context) error {
+		volumesClient, err := o.getVolumesClient(ctx)
+		if err != nil {
+			return err
+		}
+		return volumes.Delete(volumesClient, r.ID, volumes.DeleteOpts{Cascade: true}).ExtractErr()
+	})
 
 	log.Info("creating volume...")
 	err = volumes.WaitForStatus(volumesClient, r.ID, "available", 60)
 	if err != nil {
 		return vol, err
 	}
+	volumeDone()
 
 	err = o.deleteImage(imagesClient, image.ID)
 	if err != nil {
 		return vol, err
 	}
+	imageDone()
 
 	return vol, nil
 }
@@ -95,7 +111,7 @@ func (o *OpenStack) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data
 func (o *OpenStack) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, error) {
 	var vols []lepton.NanosVolume
 
-	client, err := o.getVolumesClient()
+	client, err := o.getVolumesClient(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +169,7 @@ func (o *OpenStack) getVolumeByName(volumesClient *gophercloud.ServiceClient, na
 
 // DeleteVolume is a stub to satisfy VolumeService interface
 func (o *OpenStack) DeleteVolume(ctx *lepton.Context, name string) error {
-	volumesClient, err := o.getVolumesClient()
+	volumesClient, err := o.getVolumesClient(ctx)
 	if err != nil {
 		return err
 	}
@@ -177,12 +193,12 @@ func (o *OpenStack) DeleteVolume(ctx *lepton.Context, name string) error {
 
 // AttachVolume is a stub to satisfy VolumeService interface
 func (o *OpenStack) AttachVolume(ctx *lepton.Context, image, name string, attachID int) error {
-	computeClient, err := o.getComputeClient()
+	computeClient, err := o.getComputeClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	volumesClient, err := o.getVolumesClient()
+	volumesClient, err := o.getVolumesClient(ctx)
 	if err != nil {
 		return err
 	}
@@ -192,7 +208,7 @@ func (o *OpenStack) AttachVolume(ctx *lepton.Context, image, name string, attach
 		return err
 	}
 
-	server, err := o.findInstance(image)
+	server, err := o.findInstance(ctx, image)
 	if err != nil {
 		return err
 	}
@@ -223,12 +239,12 @@ func (o *OpenStack) AttachVolume(ctx *lepton.Context, image, name string, attach
 
 // DetachVolume is a stub to satisfy VolumeService interface
 func (o *OpenStack) DetachVolume(ctx *lepton.Context, image, name string) error {
-	computeClient, err := o.getComputeClient()
+	computeClient, err := o.getComputeClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	volumesClient, err := o.getVolumesClient()
+	volumesClient, err := o.getVolumesClient(ctx)
 	if err != nil {
 		return err
 	}
@@ -238,7 +254,7 @@ func (o *OpenStack) DetachVolume(ctx *lepton.Context, image, name string) error
 		return err
 	}
 
-	server, err := o.findInstance(image)
+	server, err := o.findInstance(ctx, image)
 	if err != nil {
 		return err
 	}
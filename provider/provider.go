@@ -27,8 +27,54 @@ import (
 	"github.com/nanovms/ops/provider/vultr"
 )
 
+// Names are the names of the providers
+var Names = []string{
+	aws.ProviderName,
+	azure.ProviderName,
+	digitalocean.ProviderName,
+	gcp.ProviderName,
+	hetzner.ProviderName,
+	hyperv.ProviderName,
+	ibm.ProviderName,
+	linode.ProviderName,
+	oci.ProviderName,
+	onprem.ProviderName,
+	openshift.ProviderName,
+	openstack.ProviderName,
+	proxmox.ProviderName,
+	relayered.ProviderName,
+	scaleway.ProviderName,
+	upcloud.ProviderName,
+	vbox.ProviderName,
+	vsphere.ProviderName,
+	vultr.ProviderName,
+}
+
 // CloudProvider is a factory that returns an existing provider based on provider type passed by argument
 func CloudProvider(providerName string, c *types.ProviderConfig) (lepton.Provider, error) {
+	p, err := newProvider(providerName)
+	if err != nil {
+		return p, err
+	}
+
+	err = p.Initialize(c)
+	if c.RequireSignedImages || c.ImageSigningKey != "" {
+		p = lepton.NewSignedImageProvider(p)
+	}
+	return p, err
+}
+
+// Capabilities returns the capabilities of a provider, without initializing it
+func Capabilities(providerName string) (lepton.Capabilities, error) {
+	p, err := newProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return p.Capabilities(), nil
+}
+
+// newProvider returns a provider which is not initialized
+func newProvider(providerName string) (lepton.Provider, error) {
 	var p lepton.Provider
 
 	switch providerName {
@@ -93,6 +139,5 @@ func CloudProvider(providerName string, c *types.ProviderConfig) (lepton.Provide
 		return p, fmt.Errorf("error:Unknown provider %s", providerName)
 	}
 
-	err := p.Initialize(c)
-	return p, err
+	return p, nil
 }
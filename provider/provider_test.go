@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitiesMatchInterfaces(t *testing.T) {
+	for _, name := range Names {
+		p, err := newProvider(name)
+		assert.Nil(t, err, name)
+
+		caps := p.Capabilities()
+		for _, c := range caps {
+			assert.Contains(t, lepton.AllCapabilities, c, name)
+		}
+
+		// some providers create the records of instances without implementing DNSService
+		if _, ok := p.(lepton.DNSService); ok {
+			assert.True(t, caps.Has(lepton.CapabilityDNS), name)
+		}
+
+		_, mirror := p.(lepton.Mirrorer)
+		assert.Equal(t, mirror, caps.Has(lepton.CapabilityMirror), name)
+	}
+}
+
+func TestCapabilitiesUnknownProvider(t *testing.T) {
+	_, err := Capabilities("unknown")
+	assert.Error(t, err)
+}
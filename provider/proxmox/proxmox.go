@@ -102,6 +102,12 @@ func (p *ProxMox) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns nil: ProxMox VMs are only created, listed, started, stopped and
+// deleted
+func (p *ProxMox) Capabilities() lepton.Capabilities {
+	return nil
+}
+
 // GetStorage returns storage interface for cloud provider
 func (p *ProxMox) GetStorage() lepton.Storage {
 	return p.Storage
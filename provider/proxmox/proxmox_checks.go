@@ -11,6 +11,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/nanovms/ops/lepton"
 )
 
 type pData struct {
@@ -59,7 +61,7 @@ func (p *ProxMox) CheckInit() error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 
@@ -89,7 +91,7 @@ func (p *ProxMox) CheckInit() error {
 }
 
 // CheckStorage return error when not found configured storage or any storages via ProxMox API
-func (p *ProxMox) CheckStorage(storage string, stype string) error {
+func (p *ProxMox) CheckStorage(ctx *lepton.Context, storage string, stype string) error {
 
 	var err error
 
@@ -104,7 +106,7 @@ func (p *ProxMox) CheckStorage(storage string, stype string) error {
 	eim := errors.New("storage is not configured for containing disk images: " + storage)
 	eis := errors.New("storage is not configured for containing iso images: " + storage)
 
-	req, err := http.NewRequest("GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/storage", &b)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/storage", &b)
 	if err != nil {
 		return err
 	}
@@ -112,7 +114,7 @@ func (p *ProxMox) CheckStorage(storage string, stype string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 
@@ -179,7 +181,7 @@ func (p *ProxMox) CheckStorage(storage string, stype string) error {
 }
 
 // CheckBridge return error when not found configured bridge any network interfaces via ProxMox API
-func (p *ProxMox) CheckBridge(bridge string) error {
+func (p *ProxMox) CheckBridge(ctx *lepton.Context, bridge string) error {
 
 	var err error
 
@@ -192,7 +194,7 @@ func (p *ProxMox) CheckBridge(bridge string) error {
 	ect := errors.New("bridge is not active: " + bridge)
 	ecs := errors.New("not found bridge: " + bridge)
 
-	req, err := http.NewRequest("GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/network", &b)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/network", &b)
 	if err != nil {
 		return err
 	}
@@ -200,7 +202,7 @@ func (p *ProxMox) CheckBridge(bridge string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 
@@ -72,7 +72,7 @@ func (p *ProxMox) CreateImage(ctx *lepton.Context, imagePath string) error {
 		p.isoStorageName = config.TargetConfig["isoStorageName"]
 	}
 
-	err = p.CheckStorage(p.isoStorageName, "iso")
+	err = p.CheckStorage(ctx, p.isoStorageName, "iso")
 	if err != nil {
 		return err
 	}
@@ -110,7 +110,7 @@ func (p *ProxMox) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	w.Close()
 
-	req, err := http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/storage/"+p.isoStorageName+"/upload", &b)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/storage/"+p.isoStorageName+"/upload", &b)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -119,7 +119,7 @@ func (p *ProxMox) CreateImage(ctx *lepton.Context, imagePath string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Set("Content-Type", w.FormDataContentType())
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
@@ -177,12 +177,12 @@ func (p *ProxMox) ListImages(ctx *lepton.Context, filter string) error {
 		p.isoStorageName = config.TargetConfig["isoStorageName"]
 	}
 
-	err = p.CheckStorage(p.isoStorageName, "iso")
+	err = p.CheckStorage(ctx, p.isoStorageName, "iso")
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/storage/"+p.isoStorageName+"/content", nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/storage/"+p.isoStorageName+"/content", nil)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -191,7 +191,7 @@ func (p *ProxMox) ListImages(ctx *lepton.Context, filter string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -23,8 +23,8 @@ type NextIDResponse struct {
 	Data string `json:"data"`
 }
 
-func (p *ProxMox) getNextID() string {
-	req, err := http.NewRequest("GET", p.apiURL+"/api2/json/cluster/nextid", nil)
+func (p *ProxMox) getNextID(ctx *lepton.Context) string {
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", p.apiURL+"/api2/json/cluster/nextid", nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -32,7 +32,7 @@ func (p *ProxMox) getNextID() string {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -63,7 +63,7 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 
 	config := ctx.Config()
 
-	nextid := p.getNextID()
+	nextid := p.getNextID(ctx)
 
 	p.instanceName = config.RunConfig.InstanceName
 
@@ -169,12 +169,12 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 	// These two preventive checks here, because Proxmox will not return
 	// an error if the storage is missing and a misconfigured instance will be created.
 
-	err = p.CheckStorage(p.storageName, "images")
+	err = p.CheckStorage(ctx, p.storageName, "images")
 	if err != nil {
 		return err
 	}
 
-	err = p.CheckStorage(p.isoStorageName, "iso")
+	err = p.CheckStorage(ctx, p.isoStorageName, "iso")
 	if err != nil {
 		return err
 	}
@@ -202,7 +202,7 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 			brName = p.bridgePrefix + is
 		}
 
-		err = p.CheckBridge(brName)
+		err = p.CheckBridge(ctx, brName)
 		if err != nil {
 			return err
 		}
@@ -231,7 +231,7 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 		data.Set("net0", "model=virtio,bridge=vmbr0")
 	}
 
-	req, err := http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu", bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu", bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -240,7 +240,7 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -264,6 +264,9 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 	if err != nil {
 		return err
 	}
+	done := ctx.AddCleanup("instance "+nextid, func(ctx *lepton.Context) error {
+		return p.DeleteInstance(ctx, nextid)
+	})
 
 	err = p.addVirtioDisk(ctx, nextid)
 	if err != nil {
@@ -271,8 +274,12 @@ func (p *ProxMox) CreateInstance(ctx *lepton.Context) error {
 	}
 
 	err = p.movDisk(ctx, nextid)
+	if err != nil {
+		return err
+	}
+	done()
 
-	return err
+	return nil
 }
 
 func (p *ProxMox) movDisk(ctx *lepton.Context, vmid string) error {
@@ -284,7 +291,7 @@ func (p *ProxMox) movDisk(ctx *lepton.Context, vmid string) error {
 	data.Set("storage", p.storageName)
 	data.Set("vmid", vmid)
 
-	req, err := http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+vmid+"/move_disk", bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+vmid+"/move_disk", bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -293,7 +300,7 @@ func (p *ProxMox) movDisk(ctx *lepton.Context, vmid string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -328,7 +335,7 @@ func (p *ProxMox) addVirtioDisk(ctx *lepton.Context, vmid string) error {
 	// attach disk
 	data.Set("virtio0", "file="+p.isoStorageName+":iso/"+p.imageName+".iso")
 
-	req, err := http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+vmid+"/config", bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+vmid+"/config", bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -337,7 +344,7 @@ func (p *ProxMox) addVirtioDisk(ctx *lepton.Context, vmid string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -360,7 +367,7 @@ func (p *ProxMox) addVirtioDisk(ctx *lepton.Context, vmid string) error {
 	// set boot order, needs to come after attaching disk
 	data.Set("boot", "order=virtio0")
 
-	req, err = http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+vmid+"/config", bytes.NewBufferString(data.Encode()))
+	req, err = http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+vmid+"/config", bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -418,7 +425,7 @@ type InstanceInfo struct {
 // ListInstances lists instances on Proxmox.
 func (p *ProxMox) ListInstances(ctx *lepton.Context) error {
 
-	req, err := http.NewRequest("GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu", nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu", nil)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -427,7 +434,7 @@ func (p *ProxMox) ListInstances(ctx *lepton.Context) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -473,7 +480,7 @@ func (p *ProxMox) ListInstances(ctx *lepton.Context) error {
 // DeleteInstance deletes instance from Proxmox.
 func (p *ProxMox) DeleteInstance(ctx *lepton.Context, instanceID string) error {
 
-	req, err := http.NewRequest("DELETE", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+instanceID, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "DELETE", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+instanceID, nil)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -482,7 +489,7 @@ func (p *ProxMox) DeleteInstance(ctx *lepton.Context, instanceID string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -510,7 +517,7 @@ func (p *ProxMox) RebootInstance(ctx *lepton.Context, instanceName string) error
 // StartInstance starts an instance in Proxmox
 func (p *ProxMox) StartInstance(ctx *lepton.Context, instanceID string) error {
 
-	req, err := http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+instanceID+"/status/start", nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+instanceID+"/status/start", nil)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -519,7 +526,7 @@ func (p *ProxMox) StartInstance(ctx *lepton.Context, instanceID string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
@@ -540,7 +547,7 @@ func (p *ProxMox) StartInstance(ctx *lepton.Context, instanceID string) error {
 // StopInstance halts instance from Proxmox.
 func (p *ProxMox) StopInstance(ctx *lepton.Context, instanceID string) error {
 
-	req, err := http.NewRequest("POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+instanceID+"/status/stop", nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", p.apiURL+"/api2/json/nodes/"+p.nodeNAME+"/qemu/"+instanceID+"/status/stop", nil)
 	if err != nil {
 		fmt.Println(err)
 		return err
@@ -549,7 +556,7 @@ func (p *ProxMox) StopInstance(ctx *lepton.Context, instanceID string) error {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	client := &http.Client{Transport: tr}
+	client := &http.Client{Transport: lepton.NewRetryTransport(ProviderName, tr)}
 
 	req.Header.Add("Authorization", "PVEAPIToken="+p.tokenID+"="+p.secret)
 	resp, err := client.Do(req)
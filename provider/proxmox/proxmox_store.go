@@ -3,6 +3,7 @@
 package proxmox
 
 import (
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 )
 
@@ -10,7 +11,7 @@ import (
 type Objects struct{}
 
 // CopyToBucket copies archive to bucket
-func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
+func (s *Objects) CopyToBucket(ctx *lepton.Context, archPath string) error {
 	return nil
 }
 
@@ -34,6 +34,13 @@ func (v *Relayered) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by relayered
+func (v *Relayered) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+	}
+}
+
 // GetStorage returns storage interface for cloud provider
 func (v *Relayered) GetStorage() lepton.Storage {
 	return v.Storage
@@ -47,11 +47,10 @@ func (v *Relayered) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	imgName := ctx.Config().CloudConfig.ImageName
 
-	v.createImage(ctx, "", imgName)
-	return nil
+	return v.createImage(ctx, "", imgName)
 }
 
-func (v *Relayered) createImage(ctx *lepton.Context, icow string, imgName string) {
+func (v *Relayered) createImage(ctx *lepton.Context, icow string, imgName string) error {
 	filename := lepton.GetOpsHome() + "/" + "images/" + imgName
 
 	bodyBuf := &bytes.Buffer{}
@@ -78,8 +77,8 @@ func (v *Relayered) createImage(ctx *lepton.Context, icow string, imgName string
 
 	uri := baseURI + "/images/create"
 
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", uri, bodyBuf)
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", uri, bodyBuf)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -90,17 +89,17 @@ func (v *Relayered) createImage(ctx *lepton.Context, icow string, imgName string
 
 	res, err := client.Do(req)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 
 	fmt.Println(string(body))
-
+	return nil
 }
 
 // ImageListResponse is the set of instances available from relayered in an
@@ -118,11 +117,11 @@ type Image struct {
 // GetImages return all images on relayered
 // needs tags added
 func (v *Relayered) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 
 	uri := baseURI + "/images/list"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
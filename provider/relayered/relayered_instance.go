@@ -26,8 +26,8 @@ func (v *Relayered) CreateInstance(ctx *lepton.Context) error {
 
 	reqBody := []byte(stuff)
 
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", uri, bytes.NewBuffer(reqBody))
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "POST", uri, bytes.NewBuffer(reqBody))
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -77,7 +77,7 @@ func (v *Relayered) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, e
 
 	uri := baseURI + "/instances/list"
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -86,7 +86,7 @@ func (v *Relayered) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, e
 	req.Header.Set("RELAYERED_TOKEN", v.token)
 	req.Header.Add("Accept", "application/json")
 
-	client := &http.Client{}
+	client := lepton.NewHTTPClient(ProviderName)
 
 	res, err := client.Do(req)
 	if err != nil {
@@ -158,8 +158,8 @@ func (v *Relayered) DeleteInstance(ctx *lepton.Context, instanceID string) error
 
 	uri := baseURI + "/instances/delete/" + instanceID
 
-	client := &http.Client{}
-	req, err := http.NewRequest("DELETE", uri, nil)
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "DELETE", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -215,8 +215,8 @@ func (v *Relayered) PrintInstanceLogs(ctx *lepton.Context, instancename string,
 func (v *Relayered) GetInstanceLogs(ctx *lepton.Context, instancename string) (string, error) {
 	uri := baseURI + "/instances/logs/" + instancename
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", uri, nil)
+	client := lepton.NewHTTPClient(ProviderName)
+	req, err := http.NewRequestWithContext(ctx.Context(), "GET", uri, nil)
 	if err != nil {
 		fmt.Println(err)
 	}
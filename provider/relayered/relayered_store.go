@@ -3,6 +3,7 @@
 package relayered
 
 import (
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 )
 
@@ -13,7 +14,7 @@ type Objects struct {
 }
 
 // CopyToBucket copies archive to bucket
-func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
+func (s *Objects) CopyToBucket(ctx *lepton.Context, archPath string) error {
 	return nil
 }
 
@@ -42,12 +42,19 @@ func (h *Scaleway) Initialize(c *types.ProviderConfig) error {
 		scw.WithAuth(accessKeyID, secretAccessKey),
 		scw.WithDefaultOrganizationID(os.Getenv("SCALEWAY_ORGANIZATION_ID")),
 		scw.WithDefaultZone(scw.Zone(c.Zone)),
+		scw.WithHTTPClient(lepton.NewHTTPClient(ProviderName)),
 	)
 
 	h.Storage = &ObjectStorage{}
 	return err
 }
 
+// Capabilities returns the optional operations supported by Scaleway. Only images and
+// instances are implemented for it, so there are none.
+func (h *Scaleway) Capabilities() lepton.Capabilities {
+	return nil
+}
+
 // GetStorage returns storage interface for cloud provider
 func (h *Scaleway) GetStorage() lepton.Storage {
 	return h.Storage
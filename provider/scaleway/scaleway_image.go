@@ -110,7 +110,7 @@ func (h *Scaleway) CreateImage(ctx *lepton.Context, imagePath string) error {
 		return err
 	}
 
-	_, err = svc.PutObject(&s3.PutObjectInput{
+	_, err = svc.PutObjectWithContext(ctx.Context(), &s3.PutObjectInput{
 		Bucket: aws.String(bucketName),
 		Key:    aws.String(newPath),
 		Body:   bytes.NewReader(fileContent),
@@ -119,6 +119,14 @@ func (h *Scaleway) CreateImage(ctx *lepton.Context, imagePath string) error {
 		fmt.Printf("Error uploading object: %v\n", err)
 		return err
 	}
+	// the object is kept once the image is created, as it was before
+	objectDone := ctx.AddCleanup("image object "+newPath, func(ctx *lepton.Context) error {
+		_, err := svc.DeleteObjectWithContext(ctx.Context(), &s3.DeleteObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(newPath),
+		})
+		return err
+	})
 
 	blockAPI := block.NewAPI(h.client)
 
@@ -126,7 +134,7 @@ func (h *Scaleway) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	listSnapshotsResponse, err := blockAPI.ListSnapshots(&block.ListSnapshotsRequest{
 		Zone: scw.Zone(c.CloudConfig.Zone),
-	})
+	}, scw.WithContext(ctx.Context()))
 	if err != nil {
 		fmt.Println(err)
 		fmt.Printf("%v\n", listSnapshotsResponse)
@@ -139,10 +147,16 @@ func (h *Scaleway) CreateImage(ctx *lepton.Context, imagePath string) error {
 		Name:      imageName,
 		ProjectID: projectID,
 		Size:      scw.SizePtr(1 * 1024 * 1024 * 1024),
-	})
+	}, scw.WithContext(ctx.Context()))
 	if err != nil {
-		log.Fatalf("failed to import snapshot: %v", err)
+		return fmt.Errorf("failed to import snapshot: %v", err)
 	}
+	snapshotDone := ctx.AddCleanup("snapshot "+imageName, func(ctx *lepton.Context) error {
+		return blockAPI.DeleteSnapshot(&block.DeleteSnapshotRequest{
+			Zone:       scw.Zone(c.CloudConfig.Zone),
+			SnapshotID: importedSnapshot.ID,
+		}, scw.WithContext(ctx.Context()))
+	})
 
 	fmt.Printf("Successfully imported snapshot: %s\n", importedSnapshot.ID)
 
@@ -155,7 +169,7 @@ func (h *Scaleway) CreateImage(ctx *lepton.Context, imagePath string) error {
 	fmt.Println("waiting..")
 	res, err := instanceAPI.WaitForSnapshot(&instance.WaitForSnapshotRequest{
 		SnapshotID: importedSnapshot.ID,
-	})
+	}, scw.WithContext(ctx.Context()))
 	if err != nil {
 		fmt.Printf("%+v", res)
 	}
@@ -173,10 +187,12 @@ func (h *Scaleway) CreateImage(ctx *lepton.Context, imagePath string) error {
 		RootVolume: snapshotID,
 	}
 
-	image, err := instanceAPI.CreateImage(createImageReq)
+	image, err := instanceAPI.CreateImage(createImageReq, scw.WithContext(ctx.Context()))
 	if err != nil {
-		log.Fatalf("Failed to create image from snapshot: %v", err)
+		return fmt.Errorf("failed to create image from snapshot: %v", err)
 	}
+	snapshotDone()
+	objectDone()
 
 	fmt.Printf("%+v", image)
 
@@ -243,7 +259,7 @@ func (h *Scaleway) GetImages(ctx *lepton.Context, filter string) ([]lepton.Cloud
 		Organization: scw.StringPtr(os.Getenv("SCALEWAY_ORGANIZATION_ID")),
 	}
 
-	res, err := instanceAPI.ListImages(listImagesRequest)
+	res, err := instanceAPI.ListImages(listImagesRequest, scw.WithContext(ctx.Context()))
 	if err != nil {
 		return images, err
 	}
@@ -273,7 +289,7 @@ func (h *Scaleway) DeleteImage(ctx *lepton.Context, imagename string) error {
 	return instanceAPI.DeleteImage(&instance.DeleteImageRequest{
 		Zone:    scw.Zone(c.CloudConfig.Zone),
 		ImageID: i.ID,
-	})
+	}, scw.WithContext(ctx.Context()))
 
 	return nil
 }
@@ -47,21 +47,28 @@ func (h *Scaleway) CreateInstance(ctx *lepton.Context) error {
 		Image:             scw.StringPtr(i.ID),
 		DynamicIPRequired: scw.BoolPtr(true),
 		Project:           scw.StringPtr(projectID),
-	})
+	}, scw.WithContext(ctx.Context()))
 	if err != nil {
-		panic(err)
+		return err
 	}
+	done := ctx.AddCleanup("instance "+instanceName, func(ctx *lepton.Context) error {
+		return instanceAPI.DeleteServer(&instance.DeleteServerRequest{
+			Zone:     createRes.Server.Zone,
+			ServerID: createRes.Server.ID,
+		}, scw.WithContext(ctx.Context()))
+	})
 
 	timeout := 5 * time.Minute
 	err = instanceAPI.ServerActionAndWait(&instance.ServerActionAndWaitRequest{
 		ServerID: createRes.Server.ID,
 		Action:   instance.ServerActionPoweron,
 		Timeout:  &timeout,
-	})
+	}, scw.WithContext(ctx.Context()))
 	if err != nil {
-		panic(err)
+		return err
 	}
-	return err
+	done()
+	return nil
 }
 
 // ListInstances prints all managed Scaleway instances in table or JSON form.
@@ -112,7 +119,7 @@ func (h *Scaleway) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, er
 
 	response, err := instanceAPI.ListServers(&instance.ListServersRequest{
 		Zone: scw.Zone(c.CloudConfig.Zone),
-	})
+	}, scw.WithContext(ctx.Context()))
 	if err != nil {
 		return instances, err
 	}
@@ -178,7 +185,7 @@ func (h *Scaleway) DeleteInstance(ctx *lepton.Context, instancename string) erro
 	return instanceAPI.DeleteServer(&instance.DeleteServerRequest{
 		Zone:     scw.Zone(c.CloudConfig.Zone),
 		ServerID: i.ID,
-	})
+	}, scw.WithContext(ctx.Context()))
 }
 
 // StopInstance powers off the target Scaleway server.
@@ -199,7 +206,7 @@ func (h *Scaleway) StopInstance(ctx *lepton.Context, instancename string) error
 		Timeout:  scw.TimeDurationPtr(10 * time.Minute),
 	}
 
-	return instanceAPI.ServerActionAndWait(actionRequest)
+	return instanceAPI.ServerActionAndWait(actionRequest, scw.WithContext(ctx.Context()))
 }
 
 // StartInstance powers on the target Scaleway server.
@@ -5,6 +5,7 @@ package scaleway
 import (
 	"os"
 
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 )
 
@@ -12,7 +13,7 @@ import (
 type ObjectStorage struct{}
 
 // CopyToBucket copies archive to bucket.
-func (s *ObjectStorage) CopyToBucket(config *types.Config, archPath string) error {
+func (s *ObjectStorage) CopyToBucket(ctx *lepton.Context, archPath string) error {
 	file, err := os.Open(archPath)
 	if err != nil {
 		return err
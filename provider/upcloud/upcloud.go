@@ -14,6 +14,7 @@ import (
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/client"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/request"
 	"github.com/UpCloudLtd/upcloud-go-api/v6/upcloud/service"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/types"
 )
 
@@ -65,7 +66,7 @@ func (p *Provider) Initialize(c *types.ProviderConfig) error {
 	}
 
 	if p.upcloud == nil {
-		c := client.New(user, password, client.WithTimeout(time.Second*600))
+		c := client.New(user, password, client.WithHTTPClient(lepton.NewHTTPClient(ProviderName)), client.WithTimeout(time.Second*600))
 		p.upcloud = service.New(c)
 	}
 
@@ -81,8 +82,15 @@ func (p *Provider) Initialize(c *types.ProviderConfig) error {
 	return nil
 }
 
-func (p *Provider) findOrCreateTag(tag upcloud.Tag) (upcloudTag *upcloud.Tag, err error) {
-	tagsResponse, err := p.upcloud.GetTags(context.Background())
+// Capabilities returns the optional operations supported by UpCloud
+func (p *Provider) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityVolumes,
+	}
+}
+
+func (p *Provider) findOrCreateTag(ctx *lepton.Context, tag upcloud.Tag) (upcloudTag *upcloud.Tag, err error) {
+	tagsResponse, err := p.upcloud.GetTags(ctx.Context())
 	if err != nil {
 		return
 	}
@@ -96,7 +104,7 @@ func (p *Provider) findOrCreateTag(tag upcloud.Tag) (upcloudTag *upcloud.Tag, er
 
 	createTagReq := &request.CreateTagRequest{Tag: tag}
 
-	upcloudTag, err = p.upcloud.CreateTag(context.Background(), createTagReq)
+	upcloudTag, err = p.upcloud.CreateTag(ctx.Context(), createTagReq)
 	if err != nil {
 		return
 	}
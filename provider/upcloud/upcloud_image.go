@@ -3,7 +3,6 @@
 package upcloud
 
 import (
-	"context"
 	"errors"
 	"math"
 	"os"
@@ -45,6 +44,9 @@ func (p *Provider) CreateImage(ctx *lepton.Context, imagePath string) error {
 	if err != nil {
 		return err
 	}
+	storageDone := ctx.AddCleanup("storage "+ctx.Config().CloudConfig.ImageName, func(ctx *lepton.Context) error {
+		return p.deleteStorage(ctx, storageDetails.UUID)
+	})
 
 	ctx.Logger().Info("creating custom image")
 	templatizeReq := &request.TemplatizeStorageRequest{
@@ -52,22 +54,27 @@ func (p *Provider) CreateImage(ctx *lepton.Context, imagePath string) error {
 		Title: ctx.Config().CloudConfig.ImageName,
 	}
 
-	templateDetails, err := p.upcloud.TemplatizeStorage(context.Background(), templatizeReq)
+	templateDetails, err := p.upcloud.TemplatizeStorage(ctx.Context(), templatizeReq)
 	if err != nil {
 		return err
 	}
 
 	ctx.Logger().Debugf("%+v", templateDetails)
+	templateDone := ctx.AddCleanup("image "+ctx.Config().CloudConfig.ImageName, func(ctx *lepton.Context) error {
+		return p.deleteStorage(ctx, templateDetails.UUID)
+	})
 
-	err = p.waitForStorageState(storageDetails.UUID, "online")
+	err = p.waitForStorageState(ctx, storageDetails.UUID, "online")
 	if err != nil {
 		return err
 	}
+	templateDone()
 
-	err = p.deleteStorage(storageDetails.UUID)
+	err = p.deleteStorage(ctx, storageDetails.UUID)
 	if err != nil {
 		return err
 	}
+	storageDone()
 
 	return nil
 }
@@ -110,7 +117,7 @@ func (p *Provider) GetImages(ctx *lepton.Context, filter string) (images []lepto
 		Access: "private",
 	}
 
-	templates, err := p.upcloud.GetStorages(context.Background(), listTemplatesReq)
+	templates, err := p.upcloud.GetStorages(ctx.Context(), listTemplatesReq)
 	if err != nil {
 		return
 	}
@@ -141,7 +148,7 @@ func (p *Provider) DeleteImage(ctx *lepton.Context, imagename string) (err error
 		return
 	}
 
-	err = p.deleteStorage(image.ID)
+	err = p.deleteStorage(ctx, image.ID)
 
 	return
 }
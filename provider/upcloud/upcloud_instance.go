@@ -3,7 +3,6 @@
 package upcloud
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -52,7 +51,7 @@ func (p *Provider) CreateInstance(ctx *lepton.Context) error {
 
 	ctx.Logger().Info("creating server")
 
-	serverDetails, err := p.upcloud.CreateServer(context.Background(), createInstanceReq)
+	serverDetails, err := p.upcloud.CreateServer(ctx.Context(), createInstanceReq)
 	if err != nil {
 		return err
 	}
@@ -60,13 +59,13 @@ func (p *Provider) CreateInstance(ctx *lepton.Context) error {
 	ctx.Logger().Debugf("%+v", serverDetails)
 
 	ctx.Logger().Info("getting ops tags")
-	opsTag, err := p.findOrCreateTag(opsTag)
+	opsTag, err := p.findOrCreateTag(ctx, opsTag)
 	if err != nil {
 		ctx.Logger().Warnf("failed creating ops tag: %s", err)
 		return nil
 	}
 
-	imageTag, err := p.findOrCreateTag(upcloud.Tag{
+	imageTag, err := p.findOrCreateTag(ctx, upcloud.Tag{
 		Name:        "image-" + image.Name,
 		Description: "Creted with image " + image.Name,
 	})
@@ -82,7 +81,7 @@ func (p *Provider) CreateInstance(ctx *lepton.Context) error {
 		Tags: []string{opsTag.Name, imageTag.Name},
 	}
 
-	_, err = p.upcloud.TagServer(context.Background(), assignOpsTagsRequest)
+	_, err = p.upcloud.TagServer(ctx.Context(), assignOpsTagsRequest)
 	if err != nil {
 		ctx.Logger().Warnf("failed assigning ops tags: %s", err)
 		return nil
@@ -133,12 +132,12 @@ func (p *Provider) GetInstances(ctx *lepton.Context) (instances []lepton.CloudIn
 	instances = []lepton.CloudInstance{}
 	serversIDs := []string{}
 
-	opsTag, err := p.findOrCreateTag(opsTag)
+	opsTag, err := p.findOrCreateTag(ctx, opsTag)
 	if err != nil {
 		ctx.Logger().Warnf("failed creating tags: %s", err)
 
 		var servers *upcloud.Servers
-		servers, err = p.upcloud.GetServers(context.Background())
+		servers, err = p.upcloud.GetServers(ctx.Context())
 		if err != nil {
 			return
 		}
@@ -191,12 +190,12 @@ func (p *Provider) DeleteInstance(ctx *lepton.Context, instancename string) (err
 	}
 
 	if instance.Status != "stopped" {
-		err = p.stopServer(instance.ID)
+		err = p.stopServer(ctx, instance.ID)
 		if err != nil {
 			ctx.Logger().Warnf("failed stopping server: %s", err)
 		}
 
-		err = p.waitForServerState(instance.ID, "stopped")
+		err = p.waitForServerState(ctx, instance.ID, "stopped")
 		if err != nil {
 			return
 		}
@@ -207,7 +206,7 @@ func (p *Provider) DeleteInstance(ctx *lepton.Context, instancename string) (err
 	}
 
 	ctx.Logger().Debugf(`deleting server with uuid "%s"`, instance.ID)
-	err = p.upcloud.DeleteServer(context.Background(), deleteServerReq)
+	err = p.upcloud.DeleteServer(ctx.Context(), deleteServerReq)
 
 	return
 }
@@ -220,17 +219,17 @@ func (p *Provider) StopInstance(ctx *lepton.Context, instancename string) (err e
 	}
 
 	ctx.Logger().Debugf(`stopping server with uuid "%s"`, instance.ID)
-	err = p.stopServer(instance.ID)
+	err = p.stopServer(ctx, instance.ID)
 
 	return
 }
 
-func (p *Provider) stopServer(uuid string) (err error) {
+func (p *Provider) stopServer(ctx *lepton.Context, uuid string) (err error) {
 	stopServerReq := &request.StopServerRequest{
 		UUID: uuid,
 	}
 
-	_, err = p.upcloud.StopServer(context.Background(), stopServerReq)
+	_, err = p.upcloud.StopServer(ctx.Context(), stopServerReq)
 
 	return
 }
@@ -249,17 +248,17 @@ func (p *Provider) StartInstance(ctx *lepton.Context, instancename string) (err
 
 	ctx.Logger().Debugf(`starting server with uuid "%s"`, instance.ID)
 
-	err = p.startServer(instance.ID)
+	err = p.startServer(ctx, instance.ID)
 
 	return
 }
 
-func (p *Provider) startServer(uuid string) (err error) {
+func (p *Provider) startServer(ctx *lepton.Context, uuid string) (err error) {
 	startServerReq := &request.StartServerRequest{
 		UUID: uuid,
 	}
 
-	_, err = p.upcloud.StartServer(context.Background(), startServerReq)
+	_, err = p.upcloud.StartServer(ctx.Context(), startServerReq)
 
 	return
 }
@@ -278,7 +277,7 @@ func (p *Provider) GetInstanceByName(ctx *lepton.Context, name string) (instance
 }
 
 func (p *Provider) getServerByName(ctx *lepton.Context, name string) (server *upcloud.Server, err error) {
-	servers, err := p.upcloud.GetServers(context.Background())
+	servers, err := p.upcloud.GetServers(ctx.Context())
 	if err != nil {
 		return
 	}
@@ -302,7 +301,7 @@ func (p *Provider) GetInstanceByID(ctx *lepton.Context, id string) (instance *le
 
 	serverDetailsReq := &request.GetServerDetailsRequest{UUID: id}
 
-	serverDetails, err = p.upcloud.GetServerDetails(context.Background(), serverDetailsReq)
+	serverDetails, err = p.upcloud.GetServerDetails(ctx.Context(), serverDetailsReq)
 	if err != nil {
 		return
 	}
@@ -350,7 +349,7 @@ func (p *Provider) PrintInstanceLogs(ctx *lepton.Context, instancename string, w
 	return errors.New("Unsupported")
 }
 
-func (p *Provider) waitForServerState(uuid, state string) (err error) {
+func (p *Provider) waitForServerState(ctx *lepton.Context, uuid, state string) (err error) {
 
 	waitReq := &request.WaitForServerStateRequest{
 		UUID:         uuid,
@@ -358,7 +357,7 @@ func (p *Provider) waitForServerState(uuid, state string) (err error) {
 		Timeout:      1 * time.Minute,
 	}
 
-	_, err = p.upcloud.WaitForServerState(context.Background(), waitReq)
+	_, err = p.upcloud.WaitForServerState(ctx.Context(), waitReq)
 
 	return
 }
@@ -3,7 +3,6 @@
 package upcloud
 
 import (
-	"context"
 	"math"
 	"os"
 	"time"
@@ -35,10 +34,14 @@ func (p *Provider) createStorage(ctx *lepton.Context, storageName, filePath stri
 		Title: storageName,
 	}
 
-	storageDetails, err = p.upcloud.CreateStorage(context.Background(), createReq)
+	storageDetails, err = p.upcloud.CreateStorage(ctx.Context(), createReq)
 	if err != nil {
 		return nil, err
 	}
+	uuid := storageDetails.UUID
+	done := ctx.AddCleanup("storage "+storageName, func(ctx *lepton.Context) error {
+		return p.deleteStorage(ctx, uuid)
+	})
 
 	ctx.Logger().Debugf("%+v", storageDetails)
 
@@ -49,39 +52,40 @@ func (p *Provider) createStorage(ctx *lepton.Context, storageName, filePath stri
 		SourceLocation: filePath,
 	}
 
-	importDetails, err := p.upcloud.CreateStorageImport(context.Background(), importReq)
+	importDetails, err := p.upcloud.CreateStorageImport(ctx.Context(), importReq)
 	if err != nil {
 		return nil, err
 	}
 
 	ctx.Logger().Debugf("%+v", importDetails)
 
-	err = p.waitForStorageState(storageDetails.UUID, "online")
+	err = p.waitForStorageState(ctx, storageDetails.UUID, "online")
 	if err != nil {
 		return nil, err
 	}
 
+	done()
 	ctx.Logger().Info("import completed")
 
 	return
 }
 
-func (p *Provider) waitForStorageState(uuid, state string) (err error) {
+func (p *Provider) waitForStorageState(ctx *lepton.Context, uuid, state string) (err error) {
 	waitStateReq := &request.WaitForStorageStateRequest{
 		UUID:         uuid,
 		DesiredState: state,
 		Timeout:      10 * time.Minute,
 	}
 
-	_, err = p.upcloud.WaitForStorageState(context.Background(), waitStateReq)
+	_, err = p.upcloud.WaitForStorageState(ctx.Context(), waitStateReq)
 
 	return
 }
 
-func (p *Provider) deleteStorage(uuid string) error {
+func (p *Provider) deleteStorage(ctx *lepton.Context, uuid string) error {
 	deleteStorageReq := &request.DeleteStorageRequest{
 		UUID: uuid,
 	}
 
-	return p.upcloud.DeleteStorage(context.Background(), deleteStorageReq)
+	return p.upcloud.DeleteStorage(ctx.Context(), deleteStorageReq)
 }
@@ -3,7 +3,6 @@
 package upcloud
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -21,13 +20,13 @@ import (
 func (p *Provider) CreateVolume(ctx *lepton.Context, cv types.CloudVolume, data string, provider string) (lepton.NanosVolume, error) {
 	vol, err := lepton.CreateLocalVolume(ctx.Config(), cv.Name, data, provider)
 	if err != nil {
-		return vol, nil
+		return vol, err
 	}
 	defer os.Remove(vol.Path)
 
 	storageDetails, err := p.createStorage(ctx, cv.Name, vol.Path)
 	if err != nil {
-		return vol, nil
+		return vol, err
 	}
 
 	ctx.Logger().Debugf("%+v", storageDetails)
@@ -44,7 +43,7 @@ func (p *Provider) GetAllVolumes(ctx *lepton.Context) (volumes *[]lepton.NanosVo
 		Access: "private",
 	}
 
-	templates, err := p.upcloud.GetStorages(context.Background(), listTemplatesReq)
+	templates, err := p.upcloud.GetStorages(ctx.Context(), listTemplatesReq)
 	if err != nil {
 		return
 	}
@@ -55,7 +54,7 @@ func (p *Provider) GetAllVolumes(ctx *lepton.Context) (volumes *[]lepton.NanosVo
 	errCh := make(chan error)
 
 	for _, s := range templates.Storages {
-		go p.asyncGetVolume(s.UUID, volumesCh, errCh)
+		go p.asyncGetVolume(ctx, s.UUID, volumesCh, errCh)
 	}
 
 	for i := 0; i < len(templates.Storages); i++ {
@@ -77,12 +76,12 @@ func (p *Provider) GetAllVolumes(ctx *lepton.Context) (volumes *[]lepton.NanosVo
 	return
 }
 
-func (p *Provider) asyncGetVolume(uuid string, volumesCh chan *upcloud.StorageDetails, errCh chan error) {
+func (p *Provider) asyncGetVolume(ctx *lepton.Context, uuid string, volumesCh chan *upcloud.StorageDetails, errCh chan error) {
 	volumeReq := &request.GetStorageDetailsRequest{
 		UUID: uuid,
 	}
 
-	details, err := p.upcloud.GetStorageDetails(context.Background(), volumeReq)
+	details, err := p.upcloud.GetStorageDetails(ctx.Context(), volumeReq)
 	if err != nil {
 		errCh <- err
 		return
@@ -98,7 +97,7 @@ func (p *Provider) DeleteVolume(ctx *lepton.Context, name string) (err error) {
 		return
 	}
 
-	err = p.deleteStorage(volume.ID)
+	err = p.deleteStorage(ctx, volume.ID)
 
 	return
 }
@@ -112,12 +111,12 @@ func (p *Provider) AttachVolume(ctx *lepton.Context, image, name string, attachI
 
 	if instance.Status != "stopped" {
 		ctx.Logger().Log("stopping instance")
-		err = p.stopServer(instance.ID)
+		err = p.stopServer(ctx, instance.ID)
 		if err != nil {
 			return
 		}
 
-		err = p.waitForServerState(instance.ID, "stopped")
+		err = p.waitForServerState(ctx, instance.ID, "stopped")
 		if err != nil {
 			return
 		}
@@ -134,12 +133,12 @@ func (p *Provider) AttachVolume(ctx *lepton.Context, image, name string, attachI
 		StorageUUID: volume.ID,
 	}
 
-	_, err = p.upcloud.AttachStorage(context.Background(), attachReq)
+	_, err = p.upcloud.AttachStorage(ctx.Context(), attachReq)
 	if err != nil {
 		return
 	}
 	ctx.Logger().Log("starting instance")
-	err = p.startServer(instance.ID)
+	err = p.startServer(ctx, instance.ID)
 
 	return
 }
@@ -153,7 +152,7 @@ func (p *Provider) DetachVolume(ctx *lepton.Context, image, name string) (err er
 
 	serverDetailsReq := &request.GetServerDetailsRequest{UUID: server.UUID}
 
-	serverDetails, err := p.upcloud.GetServerDetails(context.Background(), serverDetailsReq)
+	serverDetails, err := p.upcloud.GetServerDetails(ctx.Context(), serverDetailsReq)
 	if err != nil {
 		return
 	}
@@ -164,12 +163,12 @@ func (p *Provider) DetachVolume(ctx *lepton.Context, image, name string) (err er
 		if s.Title == name {
 			if server.State != "stopped" {
 				ctx.Logger().Log("stopping server")
-				err = p.stopServer(server.UUID)
+				err = p.stopServer(ctx, server.UUID)
 				if err != nil {
 					return
 				}
 
-				err = p.waitForServerState(server.UUID, "stopped")
+				err = p.waitForServerState(ctx, server.UUID, "stopped")
 				if err != nil {
 					return
 				}
@@ -180,12 +179,12 @@ func (p *Provider) DetachVolume(ctx *lepton.Context, image, name string) (err er
 				Address:    s.Address,
 			}
 
-			_, err = p.upcloud.DetachStorage(context.Background(), detachReq)
+			_, err = p.upcloud.DetachStorage(ctx.Context(), detachReq)
 			if err != nil {
 				return
 			}
 			ctx.Logger().Log("starting server")
-			err = p.startServer(server.UUID)
+			err = p.startServer(ctx, server.UUID)
 			return
 		}
 	}
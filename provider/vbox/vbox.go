@@ -2,7 +2,10 @@
 
 package vbox
 
-import "github.com/nanovms/ops/types"
+import (
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/types"
+)
 
 // ProviderName of the cloud platform provider
 const ProviderName = "vbox"
@@ -19,3 +22,10 @@ func NewProvider() *Provider {
 func (p *Provider) Initialize(c *types.ProviderConfig) error {
 	return nil
 }
+
+// Capabilities returns the optional operations supported by VirtualBox
+func (p *Provider) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+	}
+}
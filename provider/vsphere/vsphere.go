@@ -72,6 +72,9 @@ func (v *Vsphere) Initialize(config *types.ProviderConfig) error {
 	un := u.User.Username()
 	pw, _ := u.User.Password()
 	soapClient := soap.NewClient(u, true)
+	// the service clients of govmomi, such as pbm or vapi, expect the transport to be an
+	// http.Transport and cannot be created from this client
+	soapClient.Client.Transport = lepton.NewRetryTransport(ProviderName, soapClient.Client.Transport)
 	v.client, err = vim25.NewClient(context.Background(), soapClient)
 	if err != nil {
 		return err
@@ -91,6 +94,14 @@ func (v *Vsphere) Initialize(config *types.ProviderConfig) error {
 	return nil
 }
 
+// Capabilities returns the optional operations supported by VSphere
+func (v *Vsphere) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityInstanceLogs,
+		lepton.CapabilityVolumes,
+	}
+}
+
 func (v *Vsphere) getCredentials() (*url.URL, error) {
 	var tempURL string
 	gu := os.Getenv("GOVC_URL")
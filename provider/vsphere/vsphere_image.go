@@ -3,7 +3,6 @@
 package vsphere
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -53,7 +52,7 @@ func (v *Vsphere) createImage(key string, bucket string, region string) {
 // does not do this by default). This sidesteps the vmfkstools
 // transformation.
 func (v *Vsphere) CreateImage(ctx *lepton.Context, imagePath string) error {
-	err := v.Storage.CopyToBucket(ctx.Config(), imagePath)
+	err := v.Storage.CopyToBucket(ctx, imagePath)
 	if err != nil {
 		return err
 	}
@@ -67,24 +66,33 @@ func (v *Vsphere) CreateImage(ctx *lepton.Context, imagePath string) error {
 	imgPath := "/tmp/" + base
 
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
+	// the directory of the image is deleted with the files uploaded to it
+	done := ctx.AddCleanup("image "+vmdkBase, func(ctx *lepton.Context) error {
+		dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
+		if err != nil {
+			return err
+		}
+		return ds.NewFileManager(dc, true).Delete(ctx.Context(), vmdkBase)
+	})
+
 	p := soap.DefaultUpload
-	err = ds.UploadFile(context.TODO(), flatPath, vmdkBase+"/"+flat, &p)
+	err = ds.UploadFile(ctx.Context(), flatPath, vmdkBase+"/"+flat, &p)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	err = ds.UploadFile(context.TODO(), imgPath, vmdkBase+"/"+base, &p)
+	err = ds.UploadFile(ctx.Context(), imgPath, vmdkBase+"/"+base, &p)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -92,11 +100,12 @@ func (v *Vsphere) CreateImage(ctx *lepton.Context, imagePath string) error {
 
 	m := ds.NewFileManager(dc, true)
 
-	err = m.Copy(context.TODO(), vmdkBase+"/"+base, vmdkBase+"/"+vmdkBase+"2.vmdk")
+	err = m.Copy(ctx.Context(), vmdkBase+"/"+base, vmdkBase+"/"+vmdkBase+"2.vmdk")
 	if err != nil {
 		log.Error(err)
 		return err
 	}
+	done()
 
 	return nil
 }
@@ -106,13 +115,13 @@ func (v *Vsphere) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudI
 	var cimages []lepton.CloudImage
 
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		log.Error(err)
 		return nil, err
 	}
 
-	b, err := ds.Browser(context.TODO())
+	b, err := ds.Browser(ctx.Context())
 	if err != nil {
 		return nil, err
 	}
@@ -123,12 +132,12 @@ func (v *Vsphere) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudI
 
 	search := b.SearchDatastore
 
-	task, err := search(context.TODO(), ds.Path(""), &spec)
+	task, err := search(ctx.Context(), ds.Path(""), &spec)
 	if err != nil {
 		log.Error(err)
 	}
 
-	info, err := task.WaitForResult(context.TODO(), nil)
+	info, err := task.WaitForResult(ctx.Context(), nil)
 	if err != nil {
 		log.Error(err)
 	}
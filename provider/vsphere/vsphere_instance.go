@@ -4,7 +4,6 @@ package vsphere
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -61,14 +60,14 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 	}
 
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
 	dpath := ds.Path(imgName + "/" + imgName + "2.vmdk")
-	_, err = ds.Stat(context.TODO(), dpath)
+	_, err = ds.Stat(ctx.Context(), dpath)
 	if err != nil {
 		log.Debug(err)
 		return errors.New("Image " + imgName + " not found")
@@ -82,12 +81,12 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 
 	// add network
 	// infer network stub
-	net, err := f.NetworkOrDefault(context.TODO(), v.network)
+	net, err := f.NetworkOrDefault(ctx.Context(), v.network)
 	if err != nil {
 		log.Error(err)
 	}
 
-	backing, err := net.EthernetCardBackingInfo(context.TODO())
+	backing, err := net.EthernetCardBackingInfo(ctx.Context())
 	if err != nil {
 		log.Error(err)
 	}
@@ -110,13 +109,13 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 
 	datastorez = ds
 
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
-	folders, err := dc.Folders(context.TODO())
+	folders, err := dc.Folders(ctx.Context())
 	if err != nil {
 		log.Error(err)
 	}
@@ -127,20 +126,20 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 
 	folder := folders.VmFolder
 
-	pool, err := f.ResourcePoolOrDefault(context.TODO(), v.resourcePool)
+	pool, err := f.ResourcePoolOrDefault(ctx.Context(), v.resourcePool)
 	if err != nil {
 		log.Error(err)
 		fmt.Println("Did you set the correct Resource Pool? https://nanovms.gitbook.io/ops/vsphere#create-instance ")
 		os.Exit(1)
 	}
 
-	task, err := folder.CreateVM(context.TODO(), *spec, pool, nil)
+	task, err := folder.CreateVM(ctx.Context(), *spec, pool, nil)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
-	info, err := task.WaitForResult(context.TODO(), nil)
+	info, err := task.WaitForResult(ctx.Context(), nil)
 	if err != nil {
 		fmt.Printf("%+v", info)
 		fmt.Printf("%+v", info.Reason)
@@ -149,8 +148,11 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 	}
 
 	vm := object.NewVirtualMachine(v.client, info.Result.(types.ManagedObjectReference))
+	done := ctx.AddCleanup("instance "+imgName, func(ctx *lepton.Context) error {
+		return v.DeleteInstance(ctx, imgName)
+	})
 
-	devices, err = vm.Device(context.TODO())
+	devices, err = vm.Device(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -161,12 +163,12 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 		log.Error(err)
 	}
 
-	err = vm.AddDevice(context.TODO(), serial)
+	err = vm.AddDevice(ctx.Context(), serial)
 	if err != nil {
 		return err
 	}
 
-	devices, err = vm.Device(context.TODO())
+	devices, err = vm.Device(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -179,27 +181,28 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 	devices = devices.SelectByType(d)
 
 	var mvm mo.VirtualMachine
-	err = vm.Properties(context.TODO(), vm.Reference(), []string{"config.files.logDirectory"}, &mvm)
+	err = vm.Properties(ctx.Context(), vm.Reference(), []string{"config.files.logDirectory"}, &mvm)
 	if err != nil {
 		return err
 	}
 
 	uri := path.Join(mvm.Config.Files.LogDirectory, "console.log")
 
-	err = vm.EditDevice(context.TODO(), devices.ConnectSerialPort(d, uri, false, ""))
+	err = vm.EditDevice(ctx.Context(), devices.ConnectSerialPort(d, uri, false, ""))
 	if err != nil {
 		log.Error(err)
 	}
 
-	task, err = vm.PowerOn(context.TODO())
+	task, err = vm.PowerOn(ctx.Context())
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(context.TODO())
+	_, err = task.WaitForResult(ctx.Context())
 	if err != nil {
 		return err
 	}
+	done()
 
 	return nil
 }
@@ -208,15 +211,15 @@ func (v *Vsphere) CreateInstance(ctx *lepton.Context) error {
 func (v *Vsphere) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.CloudInstance, error) {
 	m := view.NewManager(v.client)
 
-	cv, err := m.CreateContainerView(context.TODO(), v.client.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	cv, err := m.CreateContainerView(ctx.Context(), v.client.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
 	if err != nil {
 		return nil, err
 	}
 
-	defer cv.Destroy(context.TODO())
+	defer cv.Destroy(ctx.Context())
 
 	var vms []mo.VirtualMachine
-	err = cv.RetrieveWithFilter(context.TODO(), []string{"VirtualMachine"}, []string{"summary"}, &vms, property.Filter{"name": name})
+	err = cv.RetrieveWithFilter(ctx.Context(), []string{"VirtualMachine"}, []string{"summary"}, &vms, property.Filter{"name": name})
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +228,7 @@ func (v *Vsphere) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.C
 		return nil, lepton.ErrInstanceNotFound(name)
 	}
 
-	return v.convertToCloudInstance(&vms[0]), nil
+	return v.convertToCloudInstance(ctx, &vms[0]), nil
 }
 
 // GetInstances return all instances on vSphere
@@ -234,21 +237,21 @@ func (v *Vsphere) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, err
 
 	m := view.NewManager(v.client)
 
-	cv, err := m.CreateContainerView(context.TODO(), v.client.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	cv, err := m.CreateContainerView(ctx.Context(), v.client.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
 	if err != nil {
 		return nil, err
 	}
 
-	defer cv.Destroy(context.TODO())
+	defer cv.Destroy(ctx.Context())
 
 	var vms []mo.VirtualMachine
-	err = cv.Retrieve(context.TODO(), []string{"VirtualMachine"}, []string{"summary"}, &vms)
+	err = cv.Retrieve(ctx.Context(), []string{"VirtualMachine"}, []string{"summary"}, &vms)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, vm := range vms {
-		cInstance := v.convertToCloudInstance(&vm)
+		cInstance := v.convertToCloudInstance(ctx, &vm)
 
 		cinstances = append(cinstances, *cInstance)
 	}
@@ -256,7 +259,7 @@ func (v *Vsphere) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, err
 	return cinstances, nil
 }
 
-func (v *Vsphere) convertToCloudInstance(vm *mo.VirtualMachine) *lepton.CloudInstance {
+func (v *Vsphere) convertToCloudInstance(ctx *lepton.Context, vm *mo.VirtualMachine) *lepton.CloudInstance {
 	cInstance := lepton.CloudInstance{
 		Name:   vm.Summary.Config.Name,
 		Status: string(vm.Summary.Runtime.PowerState),
@@ -267,7 +270,7 @@ func (v *Vsphere) convertToCloudInstance(vm *mo.VirtualMachine) *lepton.CloudIns
 	}
 
 	if cInstance.Status == "poweredOn" {
-		ip := v.ipFor(vm.Summary.Config.Name)
+		ip := v.ipFor(ctx, vm.Summary.Config.Name)
 		cInstance.PublicIps = []string{ip}
 	}
 
@@ -315,18 +318,18 @@ func (v *Vsphere) ListInstances(ctx *lepton.Context) error {
 //
 // if we get empty string set the following && try again
 // govc host.esxcli system settings advanced set -o /Net/GuestIPHack -i 1
-func (v *Vsphere) ipFor(instancename string) string {
+func (v *Vsphere) ipFor(ctx *lepton.Context, instancename string) string {
 
 	f := find.NewFinder(v.client, true)
 
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 	}
 
 	f.SetDatacenter(dc)
 
-	vm, err := f.VirtualMachine(context.TODO(), instancename)
+	vm, err := f.VirtualMachine(ctx.Context(), instancename)
 	if err != nil {
 		if _, ok := err.(*find.NotFoundError); ok {
 			fmt.Println("can't find vm " + instancename)
@@ -345,10 +348,12 @@ func (v *Vsphere) ipFor(instancename string) string {
 
 		for {
 			select {
+			case <-ctx.Context().Done():
+				return "", ctx.Context().Err()
 			case <-ticker.C:
 
 				if icnt > 3 {
-					v.setGuestIPHack()
+					v.setGuestIPHack(ctx)
 				}
 
 				ip, err := guest.IpAddress(vm)
@@ -375,16 +380,16 @@ func (v *Vsphere) ipFor(instancename string) string {
 	return ip
 }
 
-func (v *Vsphere) findHostPath() string {
+func (v *Vsphere) findHostPath(ctx *lepton.Context) string {
 	f := find.NewFinder(v.client, true)
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 	}
 
 	f.SetDatacenter(dc)
 
-	host, err := f.DefaultHostSystem(context.TODO())
+	host, err := f.DefaultHostSystem(ctx.Context())
 	if err != nil {
 		log.Error(err)
 	}
@@ -392,11 +397,11 @@ func (v *Vsphere) findHostPath() string {
 	return host.InventoryPath
 }
 
-func (v *Vsphere) runCLI(args []string) (*esxcli.Response, error) {
+func (v *Vsphere) runCLI(ctx *lepton.Context, args []string) (*esxcli.Response, error) {
 	f := find.NewFinder(v.client, true)
 
-	hostPath := v.findHostPath()
-	host, err := f.HostSystemOrDefault(context.TODO(), hostPath)
+	hostPath := v.findHostPath(ctx)
+	host, err := f.HostSystemOrDefault(ctx.Context(), hostPath)
 	if err != nil {
 		log.Error(err)
 	}
@@ -409,9 +414,9 @@ func (v *Vsphere) runCLI(args []string) (*esxcli.Response, error) {
 	return e.Run(args)
 }
 
-func (v *Vsphere) iphackEnabled() bool {
+func (v *Vsphere) iphackEnabled(ctx *lepton.Context) bool {
 	args := []string{"system", "settings", "advanced", "list", "-o", "/Net/GuestIPHack"}
-	res, err := v.runCLI(args)
+	res, err := v.runCLI(ctx, args)
 	if err != nil {
 		log.Error(err)
 	}
@@ -427,15 +432,15 @@ func (v *Vsphere) iphackEnabled() bool {
 	return false
 }
 
-func (v *Vsphere) setGuestIPHack() {
-	if v.iphackEnabled() {
+func (v *Vsphere) setGuestIPHack(ctx *lepton.Context) {
+	if v.iphackEnabled(ctx) {
 		log.Info("ip hack enabled")
 	} else {
 		log.Info("setting ip hack")
 
 		args := []string{"system", "settings", "advanced", "set", "-o", "/Net/GuestIPHack", "-i", "1"}
 
-		res, err := v.runCLI(args)
+		res, err := v.runCLI(ctx, args)
 		if err != nil {
 			log.Error(err)
 		}
@@ -456,7 +461,7 @@ func (v *Vsphere) setGuestIPHack() {
 func (v *Vsphere) DeleteInstance(ctx *lepton.Context, instancename string) error {
 	f := find.NewFinder(v.client, true)
 
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -464,7 +469,7 @@ func (v *Vsphere) DeleteInstance(ctx *lepton.Context, instancename string) error
 
 	f.SetDatacenter(dc)
 
-	vms, err := f.VirtualMachineList(context.TODO(), instancename)
+	vms, err := f.VirtualMachineList(ctx.Context(), instancename)
 	if err != nil {
 		if _, ok := err.(*find.NotFoundError); ok {
 			fmt.Println("can't find vm " + instancename)
@@ -474,7 +479,7 @@ func (v *Vsphere) DeleteInstance(ctx *lepton.Context, instancename string) error
 
 	vm := vms[0]
 
-	task, err := vm.PowerOff(context.TODO())
+	task, err := vm.PowerOff(ctx.Context())
 	if err != nil {
 		log.Error(err)
 	}
@@ -482,14 +487,14 @@ func (v *Vsphere) DeleteInstance(ctx *lepton.Context, instancename string) error
 	// Ignore error since the VM may already been in powered off
 	// state.
 	// vm.Destroy will fail if the VM is still powered on.
-	_ = task.Wait(context.TODO())
+	_ = task.Wait(ctx.Context())
 
-	task, err = vm.Destroy(context.TODO())
+	task, err = vm.Destroy(ctx.Context())
 	if err != nil {
 		return err
 	}
 
-	err = task.Wait(context.TODO())
+	err = task.Wait(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -508,7 +513,7 @@ func (v *Vsphere) RebootInstance(ctx *lepton.Context, instanceName string) error
 func (v *Vsphere) StartInstance(ctx *lepton.Context, instancename string) error {
 	f := find.NewFinder(v.client, true)
 
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -516,7 +521,7 @@ func (v *Vsphere) StartInstance(ctx *lepton.Context, instancename string) error
 
 	f.SetDatacenter(dc)
 
-	vms, err := f.VirtualMachineList(context.TODO(), instancename)
+	vms, err := f.VirtualMachineList(ctx.Context(), instancename)
 	if err != nil {
 		if _, ok := err.(*find.NotFoundError); ok {
 			fmt.Println("can't find vm " + instancename)
@@ -524,12 +529,12 @@ func (v *Vsphere) StartInstance(ctx *lepton.Context, instancename string) error
 		log.Error(err)
 	}
 
-	task, err := vms[0].PowerOn(context.TODO())
+	task, err := vms[0].PowerOn(ctx.Context())
 	if err != nil {
 		log.Error(err)
 	}
 
-	_, err = task.WaitForResult(context.TODO(), nil)
+	_, err = task.WaitForResult(ctx.Context(), nil)
 	return err
 }
 
@@ -539,7 +544,7 @@ func (v *Vsphere) StartInstance(ctx *lepton.Context, instancename string) error
 func (v *Vsphere) StopInstance(ctx *lepton.Context, instancename string) error {
 	f := find.NewFinder(v.client, true)
 
-	dc, err := f.DatacenterOrDefault(context.TODO(), v.datacenter)
+	dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -547,7 +552,7 @@ func (v *Vsphere) StopInstance(ctx *lepton.Context, instancename string) error {
 
 	f.SetDatacenter(dc)
 
-	vms, err := f.VirtualMachineList(context.TODO(), instancename)
+	vms, err := f.VirtualMachineList(ctx.Context(), instancename)
 	if err != nil {
 		if _, ok := err.(*find.NotFoundError); ok {
 			fmt.Println("can't find vm " + instancename)
@@ -555,12 +560,12 @@ func (v *Vsphere) StopInstance(ctx *lepton.Context, instancename string) error {
 		log.Error(err)
 	}
 
-	task, err := vms[0].PowerOff(context.TODO())
+	task, err := vms[0].PowerOff(ctx.Context())
 	if err != nil {
 		log.Error(err)
 	}
 
-	_, err = task.WaitForResult(context.TODO(), nil)
+	_, err = task.WaitForResult(ctx.Context(), nil)
 	return err
 }
 
@@ -579,13 +584,13 @@ func (v *Vsphere) PrintInstanceLogs(ctx *lepton.Context, instancename string, wa
 // logs don't appear until you spin up the instance.
 func (v *Vsphere) GetInstanceLogs(ctx *lepton.Context, instancename string) (string, error) {
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		return "", err
 	}
 
 	serialFile := instancename + "/console.log"
-	file, err := ds.Open(context.TODO(), serialFile)
+	file, err := ds.Open(ctx.Context(), serialFile)
 	if err != nil {
 		return "", err
 	}
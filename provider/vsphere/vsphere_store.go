@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
 )
@@ -14,9 +15,9 @@ import (
 type Datastores struct{}
 
 // CopyToBucket converts the raw disk image to a monolithicFlat vmdk.
-func (s *Datastores) CopyToBucket(config *types.Config, archPath string) error {
+func (s *Datastores) CopyToBucket(ctx *lepton.Context, archPath string) error {
 
-	vmdkPath := "/tmp/" + config.CloudConfig.ImageName + ".vmdk"
+	vmdkPath := "/tmp/" + ctx.Config().CloudConfig.ImageName + ".vmdk"
 
 	vmdkPath = strings.ReplaceAll(vmdkPath, "-image", "")
 
@@ -26,7 +27,7 @@ func (s *Datastores) CopyToBucket(config *types.Config, archPath string) error {
 		archPath, vmdkPath,
 	}
 
-	cmd := exec.Command("qemu-img", args...)
+	cmd := exec.CommandContext(ctx.Context(), "qemu-img", args...)
 	err := cmd.Run()
 	if err != nil {
 		log.Error(err)
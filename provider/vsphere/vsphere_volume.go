@@ -3,7 +3,6 @@
 package vsphere
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
@@ -33,7 +32,7 @@ func (v *Vsphere) CreateVolume(ctx *lepton.Context, cv otypes.CloudVolume, data
 	bucket := "volumes"
 	config.CloudConfig.ImageName = vol.Name
 
-	err = v.Storage.CopyToBucket(config, vol.Path)
+	err = v.Storage.CopyToBucket(ctx, vol.Path)
 	if err != nil {
 		return vol, err
 	}
@@ -42,46 +41,61 @@ func (v *Vsphere) CreateVolume(ctx *lepton.Context, cv otypes.CloudVolume, data
 	flatVmdkPath := "/tmp/" + vol.Name + "-flat.vmdk"
 
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		fmt.Println(err)
 		return vol, err
 	}
 
+	// the files are deleted one by one, the second one may not be uploaded yet
+	deleteFile := func(name string) func(ctx *lepton.Context) error {
+		return func(ctx *lepton.Context) error {
+			dc, err := f.DatacenterOrDefault(ctx.Context(), v.datacenter)
+			if err != nil {
+				return err
+			}
+			return ds.NewFileManager(dc, true).DeleteFile(ctx.Context(), name)
+		}
+	}
+
 	p := soap.DefaultUpload
-	err = ds.UploadFile(context.TODO(), vmdkPath, bucket+"/"+vol.Name+".vmdk", &p)
+	descriptorDone := ctx.AddCleanup("volume file "+vol.Name+".vmdk", deleteFile(bucket+"/"+vol.Name+".vmdk"))
+	err = ds.UploadFile(ctx.Context(), vmdkPath, bucket+"/"+vol.Name+".vmdk", &p)
 	if err != nil {
 		return vol, err
 	}
 
-	err = ds.UploadFile(context.TODO(), flatVmdkPath, bucket+"/"+vol.Name+"-flat.vmdk", &p)
+	flatDone := ctx.AddCleanup("volume file "+vol.Name+"-flat.vmdk", deleteFile(bucket+"/"+vol.Name+"-flat.vmdk"))
+	err = ds.UploadFile(ctx.Context(), flatVmdkPath, bucket+"/"+vol.Name+"-flat.vmdk", &p)
 	if err != nil {
 		return vol, err
 	}
 
 	objectManager := vslm.NewObjectManager(ds.Client())
 
-	_, err = objectManager.RegisterDisk(context.TODO(), ds.NewURL("volumes/"+vol.Name+".vmdk").String(), vol.Name)
+	_, err = objectManager.RegisterDisk(ctx.Context(), ds.NewURL("volumes/"+vol.Name+".vmdk").String(), vol.Name)
 	if err != nil {
 		return vol, fmt.Errorf("register disk: %v", err)
 	}
+	flatDone()
+	descriptorDone()
 
 	return vol, nil
 }
 
 // getAllVolumes uses object manager to get volumes registered and return them
-func (v *Vsphere) getAllVolumes(ds *object.Datastore) (*[]types.VStorageObject, error) {
+func (v *Vsphere) getAllVolumes(ctx *lepton.Context, ds *object.Datastore) (*[]types.VStorageObject, error) {
 	disks := &[]types.VStorageObject{}
 
 	objectManager := vslm.NewObjectManager(ds.Client())
 
-	ids, err := objectManager.List(context.TODO(), ds)
+	ids, err := objectManager.List(ctx.Context(), ds)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, id := range ids {
-		obj, err := objectManager.Retrieve(context.TODO(), ds, id.Id)
+		obj, err := objectManager.Retrieve(ctx.Context(), ds, id.Id)
 		if err != nil && err.Error() == "ServerFaultCode: The object or item referred to could not be found." {
 			fmt.Printf("object with id %s not found: %s\n", id.Id, err.Error())
 		} else if err != nil {
@@ -99,26 +113,26 @@ func (v *Vsphere) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, err
 	vols := &[]lepton.NanosVolume{}
 
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		return nil, err
 	}
 
 	// List files inside volumes directory in datastore
 	// TODO: Get all virtual machines and check if volumes are attached to them
-	browser, err := ds.Browser(context.TODO())
+	browser, err := ds.Browser(ctx.Context())
 	if err != nil {
 		return nil, fmt.Errorf("datastore browser: %v", err)
 	}
 
 	spec := &types.HostDatastoreBrowserSearchSpec{}
 
-	task, err := browser.SearchDatastore(context.TODO(), ds.Path("volumes"), spec)
+	task, err := browser.SearchDatastore(ctx.Context(), ds.Path("volumes"), spec)
 	if err != nil {
 		return nil, fmt.Errorf("datastore browser search: %v", err)
 	}
 
-	taskInfo, err := task.WaitForResult(context.TODO())
+	taskInfo, err := task.WaitForResult(ctx.Context())
 	if err != nil {
 		return nil, fmt.Errorf("waiting for datastore search: %v", err)
 	}
@@ -133,7 +147,7 @@ func (v *Vsphere) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, err
 	}
 
 	// TODO: use object manager to get all volumes listed and convert them to nanos volumes, blocked by https://github.com/vmware/govmomi/issues/2174
-	disks, err := v.getAllVolumes(ds)
+	disks, err := v.getAllVolumes(ctx, ds)
 	if err != nil {
 		return nil, fmt.Errorf("get all volumes: %v", disks)
 	}
@@ -144,26 +158,26 @@ func (v *Vsphere) GetAllVolumes(ctx *lepton.Context) (*[]lepton.NanosVolume, err
 // DeleteVolume deletes a volume on vsphere
 func (v *Vsphere) DeleteVolume(ctx *lepton.Context, name string) (err error) {
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		return err
 	}
 
 	objectManager := vslm.NewObjectManager(ds.Client())
 
-	disks, err := v.getAllVolumes(ds)
+	disks, err := v.getAllVolumes(ctx, ds)
 	if err != nil {
 		return fmt.Errorf("get all volumes: %v", disks)
 	}
 
 	for _, disk := range *disks {
 		if disk.Config.Name == name {
-			task, err := objectManager.Delete(context.TODO(), ds, disk.Config.Id.Id)
+			task, err := objectManager.Delete(ctx.Context(), ds, disk.Config.Id.Id)
 			if err != nil {
 				return err
 			}
 
-			err = task.Wait(context.TODO())
+			err = task.Wait(ctx.Context())
 			if err != nil {
 				return fmt.Errorf("deleting %s: %v", disk.Config.Id.Id, err)
 			}
@@ -176,17 +190,17 @@ func (v *Vsphere) DeleteVolume(ctx *lepton.Context, name string) (err error) {
 // AttachVolume attaches a volume to an instance
 func (v *Vsphere) AttachVolume(ctx *lepton.Context, image, name string, attachID int) error {
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		return err
 	}
 
-	vm, err := v.getVirtualMachine(image)
+	vm, err := v.getVirtualMachine(ctx, image)
 	if err != nil {
 		return err
 	}
 
-	devices, err := vm.Device(context.TODO())
+	devices, err := vm.Device(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -198,17 +212,17 @@ func (v *Vsphere) AttachVolume(ctx *lepton.Context, image, name string, attachID
 
 	disk := devices.CreateDisk(controller, ds.Reference(), ds.Path("volumes/"+name))
 
-	err = vm.AddDevice(context.TODO(), disk)
+	err = vm.AddDevice(ctx.Context(), disk)
 	if err != nil {
 		return err
 	}
 
-	task, err := vm.Reset(context.TODO())
+	task, err := vm.Reset(ctx.Context())
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(context.TODO())
+	_, err = task.WaitForResult(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -219,17 +233,17 @@ func (v *Vsphere) AttachVolume(ctx *lepton.Context, image, name string, attachID
 // DetachVolume detaches a volume from an instance
 func (v *Vsphere) DetachVolume(ctx *lepton.Context, image, name string) error {
 	f := find.NewFinder(v.client, true)
-	ds, err := f.DatastoreOrDefault(context.TODO(), v.datastore)
+	ds, err := f.DatastoreOrDefault(ctx.Context(), v.datastore)
 	if err != nil {
 		return err
 	}
 
-	vm, err := v.getVirtualMachine(image)
+	vm, err := v.getVirtualMachine(ctx, image)
 	if err != nil {
 		return err
 	}
 
-	devices, err := vm.Device(context.TODO())
+	devices, err := vm.Device(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -253,17 +267,17 @@ func (v *Vsphere) DetachVolume(ctx *lepton.Context, image, name string) error {
 		return lepton.ErrVolumeNotFound(query)
 	}
 
-	err = vm.RemoveDevice(context.TODO(), true, device)
+	err = vm.RemoveDevice(ctx.Context(), true, device)
 	if err != nil {
 		return err
 	}
 
-	task, err := vm.Reset(context.TODO())
+	task, err := vm.Reset(ctx.Context())
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(context.TODO())
+	_, err = task.WaitForResult(ctx.Context())
 	if err != nil {
 		return err
 	}
@@ -271,18 +285,18 @@ func (v *Vsphere) DetachVolume(ctx *lepton.Context, image, name string) error {
 	return nil
 }
 
-func (v *Vsphere) getVirtualMachine(instanceName string) (*object.VirtualMachine, error) {
+func (v *Vsphere) getVirtualMachine(ctx *lepton.Context, instanceName string) (*object.VirtualMachine, error) {
 	m := view.NewManager(v.client)
 
-	cv, err := m.CreateContainerView(context.TODO(), v.client.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
+	cv, err := m.CreateContainerView(ctx.Context(), v.client.ServiceContent.RootFolder, []string{"VirtualMachine"}, true)
 	if err != nil {
 		return nil, err
 	}
 
-	defer cv.Destroy(context.TODO())
+	defer cv.Destroy(ctx.Context())
 
 	var vms []mo.VirtualMachine
-	err = cv.RetrieveWithFilter(context.TODO(), []string{"VirtualMachine"}, []string{"summary"}, &vms, property.Filter{"name": instanceName})
+	err = cv.RetrieveWithFilter(ctx.Context(), []string{"VirtualMachine"}, []string{"summary"}, &vms, property.Filter{"name": instanceName})
 	if err != nil {
 		return nil, err
 	}
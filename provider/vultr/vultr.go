@@ -39,12 +39,20 @@ func (v *Vultr) Initialize(config *types.ProviderConfig) error {
 	}
 
 	vultrConfig := &oauth2.Config{}
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, lepton.NewHTTPClient(ProviderName))
 	ts := vultrConfig.TokenSource(ctx, &oauth2.Token{AccessToken: apiKey})
 	v.Client = govultr.NewClient(oauth2.NewClient(ctx, ts))
+	v.Client.SetRetryLimit(0)
 	return nil
 }
 
+// Capabilities returns the optional operations supported by Vultr
+func (v *Vultr) Capabilities() lepton.Capabilities {
+	return lepton.Capabilities{
+		lepton.CapabilityDNS,
+	}
+}
+
 // GetStorage returns storage interface for cloud provider
 func (v *Vultr) GetStorage() lepton.Storage {
 	return v.Storage
@@ -3,7 +3,6 @@
 package vultr
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -38,22 +37,23 @@ func (v *Vultr) BuildImageWithPackage(ctx *lepton.Context, pkgpath string) (stri
 	return v.CustomizeImage(ctx)
 }
 
-func (v *Vultr) createImage(key string, bucket string, region string) {
+func (v *Vultr) createImage(ctx *lepton.Context, key string, bucket string, region string) error {
 
 	objURL := v.Storage.getSignedURL(key, bucket, region)
 
-	snap, _, err := v.Client.Snapshot.CreateFromURL(context.TODO(), &govultr.SnapshotURLReq{
+	snap, _, err := v.Client.Snapshot.CreateFromURL(ctx.Context(), &govultr.SnapshotURLReq{
 		URL: objURL,
 	})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	log.Info("snapshot:", snap)
+	return nil
 }
 
-func (v *Vultr) destroyImage(snapshotid string) {
-	err := v.Client.Snapshot.Delete(context.TODO(), snapshotid)
+func (v *Vultr) destroyImage(ctx *lepton.Context, snapshotid string) {
+	err := v.Client.Snapshot.Delete(ctx.Context(), snapshotid)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -61,7 +61,7 @@ func (v *Vultr) destroyImage(snapshotid string) {
 
 // CreateImage - Creates image on v using nanos images
 func (v *Vultr) CreateImage(ctx *lepton.Context, imagePath string) error {
-	err := v.Storage.CopyToBucket(ctx.Config(), imagePath)
+	err := v.Storage.CopyToBucket(ctx, imagePath)
 	if err != nil {
 		return err
 	}
@@ -71,14 +71,12 @@ func (v *Vultr) CreateImage(ctx *lepton.Context, imagePath string) error {
 	key := c.CloudConfig.ImageName
 	zone := c.CloudConfig.Zone
 
-	v.createImage(key, bucket, zone)
-
-	return nil
+	return v.createImage(ctx, key, bucket, zone)
 }
 
 // GetImages return all images on Vultr
 func (v *Vultr) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudImage, error) {
-	snaps, _, _, err := v.Client.Snapshot.List(context.TODO(), &govultr.ListOptions{
+	snaps, _, _, err := v.Client.Snapshot.List(ctx.Context(), &govultr.ListOptions{
 		PerPage: 100,
 		Cursor:  "",
 	})
@@ -106,7 +104,7 @@ func (v *Vultr) GetImages(ctx *lepton.Context, filter string) ([]lepton.CloudIma
 // ListImages lists images on Vultr
 func (v *Vultr) ListImages(ctx *lepton.Context, filter string) error {
 
-	snaps, _, _, err := v.Client.Snapshot.List(context.TODO(), &govultr.ListOptions{
+	snaps, _, _, err := v.Client.Snapshot.List(ctx.Context(), &govultr.ListOptions{
 		PerPage: 100,
 		Cursor:  "",
 	})
@@ -142,7 +140,7 @@ func (v *Vultr) ListImages(ctx *lepton.Context, filter string) error {
 
 // DeleteImage deletes image from v
 func (v *Vultr) DeleteImage(ctx *lepton.Context, snapshotID string) error {
-	v.destroyImage(snapshotID)
+	v.destroyImage(ctx, snapshotID)
 
 	return nil
 }
@@ -3,7 +3,6 @@
 package vultr
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -41,7 +40,7 @@ func (v *Vultr) CreateInstance(ctx *lepton.Context) error {
 
 	cloudConfig := ctx.Config().CloudConfig
 	if cloudConfig.StaticIP != "" {
-		ips, _, _, err := v.Client.ReservedIP.List(context.TODO(), nil)
+		ips, _, _, err := v.Client.ReservedIP.List(ctx.Context(), nil)
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -60,7 +59,7 @@ func (v *Vultr) CreateInstance(ctx *lepton.Context) error {
 		}
 	}
 
-	instance, res, err := v.Client.Instance.Create(context.TODO(), ig)
+	instance, res, err := v.Client.Instance.Create(ctx.Context(), ig)
 	if err != nil {
 		fmt.Println(res)
 		return err
@@ -70,7 +69,7 @@ func (v *Vultr) CreateInstance(ctx *lepton.Context) error {
 		ip := ""
 
 		for i := 0; i < 15; i++ {
-			server, _, err := v.Client.Instance.Get(context.TODO(), instance.ID)
+			server, _, err := v.Client.Instance.Get(ctx.Context(), instance.ID)
 			if err != nil {
 				fmt.Println(err)
 			}
@@ -86,7 +85,7 @@ func (v *Vultr) CreateInstance(ctx *lepton.Context) error {
 		dn := c.CloudConfig.DomainName
 
 		options := &govultr.ListOptions{}
-		records, _, _, err := v.Client.DomainRecord.List(context.TODO(), dn, options)
+		records, _, _, err := v.Client.DomainRecord.List(ctx.Context(), dn, options)
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -106,7 +105,7 @@ func (v *Vultr) CreateInstance(ctx *lepton.Context) error {
 			TTL:      300,
 			Priority: &p,
 		}
-		err = v.Client.DomainRecord.Update(context.TODO(), dn, arec, r)
+		err = v.Client.DomainRecord.Update(ctx.Context(), dn, arec, r)
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -117,7 +116,7 @@ func (v *Vultr) CreateInstance(ctx *lepton.Context) error {
 
 // GetInstanceByName returns instance with given name
 func (v *Vultr) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.CloudInstance, error) {
-	instance, _, err := v.Client.Instance.Get(context.TODO(), name)
+	instance, _, err := v.Client.Instance.Get(ctx.Context(), name)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +133,7 @@ func (v *Vultr) GetInstanceByName(ctx *lepton.Context, name string) (*lepton.Clo
 
 // GetInstances return all instances on Vultr
 func (v *Vultr) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error) {
-	instances, _, _, err := v.Client.Instance.List(context.TODO(), &govultr.ListOptions{
+	instances, _, _, err := v.Client.Instance.List(ctx.Context(), &govultr.ListOptions{
 		PerPage: 100,
 		Cursor:  "",
 		Tag:     "created-by-ops",
@@ -162,7 +161,7 @@ func (v *Vultr) GetInstances(ctx *lepton.Context) ([]lepton.CloudInstance, error
 // ListInstances lists instances on v
 func (v *Vultr) ListInstances(ctx *lepton.Context) error {
 
-	instances, _, _, err := v.Client.Instance.List(context.TODO(), &govultr.ListOptions{
+	instances, _, _, err := v.Client.Instance.List(ctx.Context(), &govultr.ListOptions{
 		PerPage: 100,
 		Cursor:  "",
 		Tag:     "created-by-ops",
@@ -205,7 +204,7 @@ func (v *Vultr) ListInstances(ctx *lepton.Context) error {
 
 // DeleteInstance deletes instance from v
 func (v *Vultr) DeleteInstance(ctx *lepton.Context, instanceID string) error {
-	err := v.Client.Instance.Delete(context.TODO(), instanceID)
+	err := v.Client.Instance.Delete(ctx.Context(), instanceID)
 	if err != nil {
 		return err
 	}
@@ -220,7 +219,7 @@ func (v *Vultr) RebootInstance(ctx *lepton.Context, instanceName string) error {
 // StartInstance starts an instance in v
 func (v *Vultr) StartInstance(ctx *lepton.Context, instanceID string) error {
 
-	err := v.Client.Instance.Start(context.TODO(), instanceID)
+	err := v.Client.Instance.Start(ctx.Context(), instanceID)
 	if err != nil {
 		return err
 	}
@@ -230,7 +229,7 @@ func (v *Vultr) StartInstance(ctx *lepton.Context, instanceID string) error {
 
 // StopInstance halts instance from v
 func (v *Vultr) StopInstance(ctx *lepton.Context, instanceID string) error {
-	err := v.Client.Instance.Halt(context.TODO(), instanceID)
+	err := v.Client.Instance.Halt(ctx.Context(), instanceID)
 	if err != nil {
 		return err
 	}
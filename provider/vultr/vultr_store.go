@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/minio/minio-go"
+	"github.com/nanovms/ops/lepton"
 	"github.com/nanovms/ops/log"
 	"github.com/nanovms/ops/types"
 )
@@ -45,8 +46,8 @@ func (s *Objects) getSignedURL(key string, bucket string, region string) string
 }
 
 // CopyToBucket copies archive to bucket
-func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
-
+func (s *Objects) CopyToBucket(ctx *lepton.Context, archPath string) error {
+	config := ctx.Config()
 	bucket := config.CloudConfig.BucketName
 	zone := config.CloudConfig.Zone
 
@@ -73,9 +74,9 @@ func (s *Objects) CopyToBucket(config *types.Config, archPath string) error {
 		log.Fatal(err)
 	}
 
-	n, err := client.PutObject(bucket, config.CloudConfig.ImageName, file, stat.Size(), minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	n, err := client.PutObjectWithContext(ctx.Context(), bucket, config.CloudConfig.ImageName, file, stat.Size(), minio.PutObjectOptions{ContentType: "application/octet-stream"})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	log.Info("Uploaded", "my-objectname", " of size: ", n, "Successfully.")
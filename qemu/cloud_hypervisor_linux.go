@@ -0,0 +1,220 @@
+package qemu
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nanovms/ops/log"
+	"github.com/nanovms/ops/types"
+)
+
+const cloudHypervisorCommand = "cloud-hypervisor"
+
+func init() {
+	backends[cloudHypervisorCommand] = newCloudHypervisor
+	controllers[cloudHypervisorCommand] = func(mgmt string) Controller {
+		return &cloudHypervisorController{socket: mgmt}
+	}
+}
+
+// cloudHypervisor runs instances in Cloud Hypervisor, which is controlled through its API socket
+type cloudHypervisor struct {
+	cmd    *exec.Cmd
+	socket string
+}
+
+func newCloudHypervisor() Hypervisor {
+	return &cloudHypervisor{}
+}
+
+// CloudHypervisorSocketPath returns the path of the API socket of a Cloud Hypervisor instance
+func CloudHypervisorSocketPath(instanceName string) string {
+	return filepath.Join(os.TempDir(), "ops-cloud-hypervisor-"+instanceName+".sock")
+}
+
+// cloudHypervisorArgs translates a run configuration into Cloud Hypervisor arguments
+func cloudHypervisorArgs(rconfig *types.RunConfig, socket string) ([]string, error) {
+	if rconfig.Kernel == "" {
+		return nil, errors.New("cloud-hypervisor needs the path of the kernel")
+	}
+	memory, err := memoryMiB(rconfig.Memory)
+	if err != nil {
+		return nil, err
+	}
+	cpus := rconfig.CPUs
+	if cpus < 1 {
+		cpus = 1
+	}
+
+	args := []string{
+		"--api-socket", "path=" + socket,
+		"--kernel", rconfig.Kernel,
+		"--cpus", fmt.Sprintf("boot=%d", cpus),
+		"--memory", fmt.Sprintf("size=%dM", memory),
+		"--disk", "path=" + rconfig.ImageName,
+	}
+	// mounted volumes are additional virtio-blk disks
+	for _, mount := range rconfig.Mounts {
+		args = append(args, "path="+mount)
+	}
+
+	if ifaces := tapInterfaces(rconfig); len(ifaces) > 0 {
+		args = append(args, "--net")
+		for _, iface := range ifaces {
+			args = append(args, fmt.Sprintf("tap=%s,mac=%s", iface.tap, iface.mac))
+		}
+	} else if len(rconfig.Ports) > 0 || len(rconfig.UDPPorts) > 0 {
+		log.Warn("cloud-hypervisor has no user mode networking, ports are not forwarded; use a tap device")
+	}
+	if len(rconfig.VirtfsShares) > 0 {
+		log.Warn("cloud-hypervisor does not support VirtFS shares, they are not mounted")
+	}
+
+	if rconfig.Background {
+		args = append(args, "--serial", "file=/tmp/"+rconfig.InstanceName+".log")
+	} else {
+		args = append(args, "--serial", "tty")
+	}
+	args = append(args, "--console", "off")
+
+	return args, nil
+}
+
+func (ch *cloudHypervisor) Command(rconfig *types.RunConfig) *exec.Cmd {
+	name := rconfig.InstanceName
+	if name == "" {
+		name = strconv.Itoa(os.Getpid())
+	}
+	ch.socket = CloudHypervisorSocketPath(name)
+	os.Remove(ch.socket)
+
+	args, err := cloudHypervisorArgs(rconfig, ch.socket)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+	rconfig.Mgmt = ch.socket
+
+	logv(rconfig, cloudHypervisorCommand+" "+strings.Join(args, " "))
+	ch.cmd = exec.Command(cloudHypervisorCommand, args...)
+
+	if rconfig.BackgroundDetach {
+		return ch.cmd
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+	go func(chan os.Signal) {
+		<-c
+		ch.Stop()
+	}(c)
+
+	return ch.cmd
+}
+
+func (ch *cloudHypervisor) Start(rconfig *types.RunConfig) error {
+	if err := kvmAvailable(); err != nil {
+		return fmt.Errorf("cloud-hypervisor needs access to /dev/kvm: %w", err)
+	}
+
+	if ch.cmd == nil {
+		ch.Command(rconfig)
+		if ch.cmd == nil {
+			return errors.New("Failed to create cloud-hypervisor command line")
+		}
+		ch.cmd.Stdin = os.Stdin
+		ch.cmd.Stdout = os.Stdout
+		ch.cmd.Stderr = os.Stderr
+	}
+
+	if rconfig.BackgroundDetach {
+		ch.cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setsid: true,
+		}
+		ch.cmd.Stdin = nil
+		ch.cmd.Stdout = nil
+		ch.cmd.Stderr = nil
+	}
+
+	if rconfig.Background {
+		err := ch.cmd.Start()
+		if err != nil {
+			log.Error(err)
+		}
+	} else {
+		ch.cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid: true,
+		}
+		if err := ch.cmd.Run(); err != nil {
+			log.Error(err)
+		}
+		os.Remove(ch.socket)
+	}
+
+	return nil
+}
+
+func (ch *cloudHypervisor) Stop() {
+	if ch.cmd == nil || ch.cmd.Process == nil {
+		return
+	}
+
+	// ask the guest to shut down before killing the VM
+	if vmmRequest(ch.socket, http.MethodPut, "/api/v1/vm.power-button", nil) == nil {
+		time.Sleep(2 * time.Second)
+	}
+
+	if err := ch.cmd.Process.Kill(); err != nil {
+		log.Error(err)
+	}
+
+	// do not print errors as the command could be started with Run()
+	ch.cmd.Wait()
+	os.Remove(ch.socket)
+}
+
+func (ch *cloudHypervisor) PID() (string, error) {
+	if ch.cmd == nil || ch.cmd.Process == nil {
+		return "", errors.New("No process running")
+	}
+
+	return strconv.Itoa(ch.cmd.Process.Pid), nil
+}
+
+// cloudHypervisorController controls Cloud Hypervisor instances through their API socket
+type cloudHypervisorController struct {
+	socket string
+}
+
+func (c *cloudHypervisorController) action(action string) error {
+	return vmmRequest(c.socket, http.MethodPut, "/api/v1/vm."+action, nil)
+}
+
+func (c *cloudHypervisorController) Pause() error {
+	return c.action("pause")
+}
+
+func (c *cloudHypervisorController) Resume() error {
+	return c.action("resume")
+}
+
+func (c *cloudHypervisorController) Reboot() error {
+	return c.action("reboot")
+}
+
+func (c *cloudHypervisorController) Shutdown() error {
+	return c.action("power-button")
+}
@@ -0,0 +1,56 @@
+package qemu
+
+import (
+	"testing"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudHypervisorArgs(t *testing.T) {
+	_, err := cloudHypervisorArgs(&types.RunConfig{ImageName: "image"}, "api.sock")
+	assert.NotNil(t, err)
+
+	args, err := cloudHypervisorArgs(&types.RunConfig{
+		Kernel:       "kernel.img",
+		ImageName:    "image",
+		InstanceName: "test",
+		CPUs:         2,
+		Memory:       "1G",
+		Mounts:       []string{"vol1", "vol2"},
+		Background:   true,
+	}, "api.sock")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"--api-socket", "path=api.sock",
+		"--kernel", "kernel.img",
+		"--cpus", "boot=2",
+		"--memory", "size=1024M",
+		"--disk", "path=image", "path=vol1", "path=vol2",
+		"--serial", "file=/tmp/test.log",
+		"--console", "off",
+	}, args)
+
+	args, err = cloudHypervisorArgs(&types.RunConfig{Kernel: "kernel.img", ImageName: "image", TapName: "tap0"}, "api.sock")
+	assert.Nil(t, err)
+	assert.Contains(t, args, "--net")
+	assert.Contains(t, args, "tty")
+}
+
+func TestInstanceController(t *testing.T) {
+	for hypervisor, expected := range map[string]Controller{
+		"":                 &qmpController{port: "4000"},
+		"qemu":             &qmpController{port: "4000"},
+		"firecracker":      &firecrackerController{socket: "4000"},
+		"cloud-hypervisor": &cloudHypervisorController{socket: "4000"},
+	} {
+		controller, err := InstanceController(hypervisor, "4000")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, controller)
+	}
+
+	_, err := InstanceController("bhyve", "4000")
+	assert.NotNil(t, err)
+	_, err = InstanceController("qemu", "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,69 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package qemu
+
+import (
+	"fmt"
+
+	"github.com/nanovms/ops/qemu/qmp"
+)
+
+// Controller controls a running instance through the management interface of its hypervisor
+type Controller interface {
+	Pause() error
+	Resume() error
+	Reboot() error
+	Shutdown() error
+}
+
+// controllers create the controllers of instances run by the hypervisors in backends, from the
+// management endpoint of the instance
+var controllers = map[string]func(mgmt string) Controller{}
+
+// InstanceController returns the controller of an instance run by the named hypervisor, where mgmt
+// is the management endpoint saved when the instance was started (RunConfig.Mgmt)
+func InstanceController(hypervisor, mgmt string) (Controller, error) {
+	if mgmt == "" {
+		return nil, fmt.Errorf("instance has no management interface")
+	}
+	if hypervisor == "" || hypervisor == "qemu" {
+		return &qmpController{port: mgmt}, nil
+	}
+	newController, ok := controllers[hypervisor]
+	if !ok {
+		return nil, fmt.Errorf("unknown hypervisor %q", hypervisor)
+	}
+	return newController(mgmt), nil
+}
+
+// qmpController controls qemu instances through QMP
+type qmpController struct {
+	port string
+}
+
+// execute connects to the QMP server of the instance and runs f
+func (c *qmpController) execute(f func(*qmp.Client) error) error {
+	client, err := DialQMP(c.port)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return f(client)
+}
+
+func (c *qmpController) Pause() error {
+	return c.execute((*qmp.Client).Stop)
+}
+
+func (c *qmpController) Resume() error {
+	return c.execute((*qmp.Client).Cont)
+}
+
+func (c *qmpController) Reboot() error {
+	return c.execute((*qmp.Client).SystemReset)
+}
+
+func (c *qmpController) Shutdown() error {
+	return c.execute((*qmp.Client).SystemPowerdown)
+}
@@ -0,0 +1,297 @@
+package qemu
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/nanovms/ops/log"
+	"github.com/nanovms/ops/types"
+)
+
+const firecrackerCommand = "firecracker"
+
+func init() {
+	backends[firecrackerCommand] = newFirecracker
+	controllers[firecrackerCommand] = func(mgmt string) Controller {
+		return &firecrackerController{socket: mgmt}
+	}
+}
+
+// firecracker runs instances in Firecracker microVMs, which are configured and controlled through
+// the Firecracker API socket
+type firecracker struct {
+	cmd    *exec.Cmd
+	socket string
+}
+
+func newFirecracker() Hypervisor {
+	return &firecracker{}
+}
+
+type firecrackerBootSource struct {
+	KernelImagePath string `json:"kernel_image_path"`
+	BootArgs        string `json:"boot_args,omitempty"`
+}
+
+type firecrackerDrive struct {
+	DriveID      string `json:"drive_id"`
+	PathOnHost   string `json:"path_on_host"`
+	IsRootDevice bool   `json:"is_root_device"`
+	IsReadOnly   bool   `json:"is_read_only"`
+}
+
+type firecrackerMachineConfig struct {
+	VcpuCount  int `json:"vcpu_count"`
+	MemSizeMib int `json:"mem_size_mib"`
+}
+
+type firecrackerNetworkInterface struct {
+	IfaceID     string `json:"iface_id"`
+	HostDevName string `json:"host_dev_name"`
+	GuestMac    string `json:"guest_mac,omitempty"`
+}
+
+type firecrackerAction struct {
+	ActionType string `json:"action_type"`
+}
+
+// firecrackerConfig is the configuration of a Firecracker VM, in the format of the Firecracker
+// configuration file
+type firecrackerConfig struct {
+	BootSource        firecrackerBootSource         `json:"boot-source"`
+	Drives            []firecrackerDrive            `json:"drives"`
+	MachineConfig     firecrackerMachineConfig      `json:"machine-config"`
+	NetworkInterfaces []firecrackerNetworkInterface `json:"network-interfaces,omitempty"`
+}
+
+// firecrackerVMConfig translates a run configuration into a Firecracker VM configuration
+func firecrackerVMConfig(rconfig *types.RunConfig) (*firecrackerConfig, error) {
+	if rconfig.Kernel == "" {
+		return nil, errors.New("firecracker needs the path of the kernel")
+	}
+	memory, err := memoryMiB(rconfig.Memory)
+	if err != nil {
+		return nil, err
+	}
+	cpus := rconfig.CPUs
+	if cpus < 1 {
+		cpus = 1
+	}
+	c := &firecrackerConfig{
+		BootSource: firecrackerBootSource{
+			KernelImagePath: rconfig.Kernel,
+			BootArgs:        "console=ttyS0 reboot=k panic=1 pci=off",
+		},
+		Drives: []firecrackerDrive{
+			{DriveID: "hd0", PathOnHost: rconfig.ImageName, IsRootDevice: true},
+		},
+		MachineConfig: firecrackerMachineConfig{
+			VcpuCount:  cpus,
+			MemSizeMib: memory,
+		},
+	}
+	for i, mount := range rconfig.Mounts {
+		c.Drives = append(c.Drives, firecrackerDrive{
+			DriveID:    fmt.Sprintf("hd%d", i+1),
+			PathOnHost: mount,
+		})
+	}
+	for i, iface := range tapInterfaces(rconfig) {
+		c.NetworkInterfaces = append(c.NetworkInterfaces, firecrackerNetworkInterface{
+			IfaceID:     fmt.Sprintf("eth%d", i),
+			HostDevName: iface.tap,
+			GuestMac:    iface.mac,
+		})
+	}
+	if len(c.NetworkInterfaces) == 0 && (len(rconfig.Ports) > 0 || len(rconfig.UDPPorts) > 0) {
+		log.Warn("firecracker has no user mode networking, ports are not forwarded; use a tap device")
+	}
+	if len(rconfig.VirtfsShares) > 0 {
+		log.Warn("firecracker does not support VirtFS shares, they are not mounted")
+	}
+	return c, nil
+}
+
+// FirecrackerSocketPath returns the path of the API socket of a Firecracker instance
+func FirecrackerSocketPath(instanceName string) string {
+	return filepath.Join(os.TempDir(), "ops-firecracker-"+instanceName+".sock")
+}
+
+func (f *firecracker) Command(rconfig *types.RunConfig) *exec.Cmd {
+	name := rconfig.InstanceName
+	if name == "" {
+		name = strconv.Itoa(os.Getpid())
+	}
+	f.socket = FirecrackerSocketPath(name)
+	os.Remove(f.socket)
+	rconfig.Mgmt = f.socket
+
+	logv(rconfig, firecrackerCommand+" --api-sock "+f.socket)
+	f.cmd = exec.Command(firecrackerCommand, "--api-sock", f.socket)
+
+	if rconfig.BackgroundDetach {
+		return f.cmd
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+	go func(chan os.Signal) {
+		<-c
+		f.Stop()
+	}(c)
+
+	return f.cmd
+}
+
+func (f *firecracker) Start(rconfig *types.RunConfig) error {
+	vmConfig, err := firecrackerVMConfig(rconfig)
+	if err != nil {
+		return err
+	}
+	if err = kvmAvailable(); err != nil {
+		return fmt.Errorf("firecracker needs access to /dev/kvm: %w", err)
+	}
+
+	if f.cmd == nil {
+		f.Command(rconfig)
+	}
+	f.cmd.Stdin = os.Stdin
+	f.cmd.Stdout = os.Stdout
+	f.cmd.Stderr = os.Stderr
+
+	if rconfig.Background {
+		// the serial console is the standard output of firecracker
+		logFile, err := os.Create("/tmp/" + rconfig.InstanceName + ".log")
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		f.cmd.Stdin = nil
+		f.cmd.Stdout = logFile
+		f.cmd.Stderr = logFile
+	}
+
+	if rconfig.BackgroundDetach {
+		f.cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setsid: true,
+		}
+	} else if !rconfig.Background {
+		f.cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid: true,
+		}
+	}
+
+	if err = f.cmd.Start(); err != nil {
+		return err
+	}
+
+	err = f.boot(vmConfig)
+	if err != nil {
+		f.cmd.Process.Kill()
+		f.cmd.Wait()
+		os.Remove(f.socket)
+		return err
+	}
+
+	if !rconfig.Background {
+		if err := f.cmd.Wait(); err != nil {
+			log.Error(err)
+		}
+		os.Remove(f.socket)
+	}
+
+	return nil
+}
+
+// boot configures the VM through the API socket and starts it
+func (f *firecracker) boot(c *firecrackerConfig) error {
+	if err := waitVMMSocket(f.socket, 5*time.Second); err != nil {
+		return err
+	}
+	if err := f.put("/boot-source", c.BootSource); err != nil {
+		return err
+	}
+	if err := f.put("/machine-config", c.MachineConfig); err != nil {
+		return err
+	}
+	for _, d := range c.Drives {
+		if err := f.put("/drives/"+d.DriveID, d); err != nil {
+			return err
+		}
+	}
+	for _, n := range c.NetworkInterfaces {
+		if err := f.put("/network-interfaces/"+n.IfaceID, n); err != nil {
+			return err
+		}
+	}
+	return f.put("/actions", firecrackerAction{ActionType: "InstanceStart"})
+}
+
+// put sends a request to the firecracker API
+func (f *firecracker) put(path string, body interface{}) error {
+	return vmmRequest(f.socket, http.MethodPut, path, body)
+}
+
+func (f *firecracker) Stop() {
+	if f.cmd == nil || f.cmd.Process == nil {
+		return
+	}
+
+	// ask the guest to shut down before killing the VM
+	if f.put("/actions", firecrackerAction{ActionType: "SendCtrlAltDel"}) == nil {
+		time.Sleep(2 * time.Second)
+	}
+
+	if err := f.cmd.Process.Kill(); err != nil {
+		log.Error(err)
+	}
+
+	// do not print errors as the process could have been waited for by Start()
+	f.cmd.Wait()
+	os.Remove(f.socket)
+}
+
+func (f *firecracker) PID() (string, error) {
+	if f.cmd == nil || f.cmd.Process == nil {
+		return "", errors.New("No process running")
+	}
+
+	return strconv.Itoa(f.cmd.Process.Pid), nil
+}
+
+// firecrackerController controls firecracker instances through their API socket
+type firecrackerController struct {
+	socket string
+}
+
+func (c *firecrackerController) setState(state string) error {
+	return vmmRequest(c.socket, http.MethodPatch, "/vm", map[string]string{"state": state})
+}
+
+func (c *firecrackerController) Pause() error {
+	return c.setState("Paused")
+}
+
+func (c *firecrackerController) Resume() error {
+	return c.setState("Resumed")
+}
+
+func (c *firecrackerController) Reboot() error {
+	return errors.New("firecracker instances cannot be rebooted")
+}
+
+func (c *firecrackerController) Shutdown() error {
+	return vmmRequest(c.socket, http.MethodPut, "/actions", firecrackerAction{ActionType: "SendCtrlAltDel"})
+}
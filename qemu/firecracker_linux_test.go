@@ -0,0 +1,56 @@
+package qemu
+
+import (
+	"testing"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirecrackerVMConfig(t *testing.T) {
+	_, err := firecrackerVMConfig(&types.RunConfig{ImageName: "image"})
+	assert.NotNil(t, err)
+
+	c, err := firecrackerVMConfig(&types.RunConfig{
+		Kernel:    "kernel.img",
+		ImageName: "image",
+		CPUs:      2,
+		Memory:    "1G",
+		Mounts:    []string{"vol1", "vol2"},
+		TapName:   "tap0",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "kernel.img", c.BootSource.KernelImagePath)
+	assert.Equal(t, firecrackerMachineConfig{VcpuCount: 2, MemSizeMib: 1024}, c.MachineConfig)
+	assert.Equal(t, []firecrackerDrive{
+		{DriveID: "hd0", PathOnHost: "image", IsRootDevice: true},
+		{DriveID: "hd1", PathOnHost: "vol1"},
+		{DriveID: "hd2", PathOnHost: "vol2"},
+	}, c.Drives)
+	assert.Len(t, c.NetworkInterfaces, 1)
+	assert.Equal(t, "tap0", c.NetworkInterfaces[0].HostDevName)
+	assert.NotEmpty(t, c.NetworkInterfaces[0].GuestMac)
+
+	c, err = firecrackerVMConfig(&types.RunConfig{Kernel: "kernel.img", ImageName: "image"})
+	assert.Nil(t, err)
+	assert.Equal(t, firecrackerMachineConfig{VcpuCount: 1, MemSizeMib: 128}, c.MachineConfig)
+	assert.Empty(t, c.NetworkInterfaces)
+
+	c, err = firecrackerVMConfig(&types.RunConfig{
+		Kernel:    "kernel.img",
+		ImageName: "image",
+		TapName:   "tap0",
+		Mac:       "52:54:00:12:34:56",
+		Nics:      []types.Nic{{}, {TapName: "tap1"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []firecrackerNetworkInterface{
+		{IfaceID: "eth0", HostDevName: "tap0", GuestMac: "52:54:00:12:34:56"},
+		{IfaceID: "eth1", HostDevName: "tap1", GuestMac: NicMac("52:54:00:12:34:56", 1)},
+	}, c.NetworkInterfaces)
+}
+
+func TestHypervisorByName(t *testing.T) {
+	_, err := HypervisorByName("bhyve")
+	assert.EqualError(t, err, `unknown hypervisor "bhyve", expected one of cloud-hypervisor, firecracker, qemu`)
+}
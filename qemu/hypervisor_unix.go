@@ -4,7 +4,10 @@
 package qemu
 
 import (
+	"fmt"
 	"os/exec"
+	"sort"
+	"strings"
 
 	"github.com/nanovms/ops/types"
 )
@@ -32,6 +35,34 @@ func HypervisorInstance() Hypervisor {
 	return nil
 }
 
+// backends are the hypervisors that can be selected by name instead of the default qemu
+var backends = map[string]func() Hypervisor{}
+
+// HypervisorByName provides the hypervisor with the given name; an empty name or "qemu" selects
+// the available qemu hypervisor
+func HypervisorByName(name string) (Hypervisor, error) {
+	if name == "" || name == "qemu" {
+		hypervisor := HypervisorInstance()
+		if hypervisor == nil {
+			return nil, fmt.Errorf("No hypervisor found on $PATH")
+		}
+		return hypervisor, nil
+	}
+	newHypervisor, ok := backends[name]
+	if !ok {
+		names := []string{"qemu"}
+		for k := range backends {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown hypervisor %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	if !checkExists(name) {
+		return nil, fmt.Errorf("hypervisor %s not found on $PATH", name)
+	}
+	return newHypervisor(), nil
+}
+
 // Hypervisor interface
 type Hypervisor interface {
 	Start(rconfig *types.RunConfig) error
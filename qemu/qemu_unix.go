@@ -7,6 +7,7 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	mrand "math/rand"
 	"time"
 
@@ -89,14 +90,12 @@ func (q *qemu) SetKernel(kernel string) {
 func (q *qemu) Stop() {
 	if q.cmd != nil {
 
-		commands := []string{
-			`{ "execute": "qmp_capabilities" }`,
-			`{ "execute": "system_powerdown" }`,
-		}
-
 		if q.mgmt != "" {
-			ExecuteQMP(commands, q.mgmt)
-			time.Sleep(2 * time.Second)
+			if err := q.powerdown(); err != nil {
+				log.Error(err)
+			} else {
+				time.Sleep(2 * time.Second)
+			}
 		}
 
 		if err := q.cmd.Process.Kill(); err != nil {
@@ -108,6 +107,16 @@ func (q *qemu) Stop() {
 	}
 }
 
+// powerdown asks the guest to shut down
+func (q *qemu) powerdown() error {
+	c, err := DialQMP(q.mgmt)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.SystemPowerdown()
+}
+
 func logv(rconfig *types.RunConfig, msg string) {
 	if rconfig.Verbose {
 		log.Info(msg)
@@ -282,7 +291,10 @@ func (q *qemu) addNetDevice(devType, ifaceName, mac string, hostPorts []string,
 			id:      "vmnet",
 		}
 
-		dv.mac = generateMac()
+		dv.mac = mac
+		if mac == "" {
+			dv.mac = generateMac()
+		}
 		ndv.ifname = "en0"
 
 		q.devices = append(q.devices, dv)
@@ -304,6 +316,7 @@ func (q *qemu) addNetDevice(devType, ifaceName, mac string, hostPorts []string,
 			id:      id,
 		}
 
+		dv.mac = mac
 		if mac == "" {
 			dv.mac = generateMac()
 		}
@@ -584,11 +597,15 @@ func (q *qemu) setConfig(rconfig *types.RunConfig) error {
 		nics := rconfig.Nics
 
 		if len(nics) > 0 {
-			for i := 0; i < len(nics); i++ {
-				q.addNetDevice(netDevType, ifaceName, "", rconfig.Ports, rconfig.UDPPorts)
+			for i, nic := range nics {
+				if nic.TapName != "" {
+					q.addNetDevice("tap", nic.TapName, nicMac(rconfig, i), rconfig.Ports, rconfig.UDPPorts)
+				} else {
+					q.addNetDevice(netDevType, ifaceName, nicMac(rconfig, i), rconfig.Ports, rconfig.UDPPorts)
+				}
 			}
 		} else {
-			q.addNetDevice(netDevType, ifaceName, "", rconfig.Ports, rconfig.UDPPorts)
+			q.addNetDevice(netDevType, ifaceName, rconfig.Mac, rconfig.Ports, rconfig.UDPPorts)
 		}
 	}
 
@@ -688,6 +705,10 @@ func (q *qemu) Args(rconfig *types.RunConfig) ([]string, error) {
 		args = append(args, "-qmp tcp:localhost:"+rconfig.Mgmt+",server,wait=off")
 	}
 
+	if rconfig.Incoming != "" {
+		args = append(args, "-incoming "+rconfig.Incoming)
+	}
+
 	if isInception() {
 		args = append(args, "-device pci-bridge,bus=pcie.0,id=pci-bridge-0,chassis_nr=1,shpc=off,addr=6,io-reserve=4k,mem-reserve=1m,pref64-reserve=1m")
 	}
@@ -704,6 +725,76 @@ func (q *qemu) PID() (string, error) {
 	return strconv.Itoa(q.cmd.Process.Pid), nil
 }
 
+// GenerateMac returns a random mac address for the network interface of an instance
+func GenerateMac() string {
+	return generateMac()
+}
+
+// instanceMac returns the mac address configured for the first network interface of an instance,
+// or a random one
+func instanceMac(rconfig *types.RunConfig) string {
+	if rconfig.Mac != "" {
+		return rconfig.Mac
+	}
+	return generateMac()
+}
+
+// NicMac returns the mac address of the nic at index of an instance whose first nic has the mac
+// address instanceMac; the address is derived from instanceMac, so that the nics of an instance
+// keep their addresses across relaunches
+func NicMac(instanceMac string, index int) string {
+	if index == 0 {
+		return instanceMac
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", instanceMac, index)))
+	octets := sum[:6]
+	octets[0] |= 2
+	octets[0] &= 0xFE
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x",
+		octets[0], octets[1], octets[2], octets[3], octets[4], octets[5])
+}
+
+// nicMac returns the mac address configured for the nic at index of an instance, or the address
+// derived from the mac address of the instance, or a random one
+func nicMac(rconfig *types.RunConfig, index int) string {
+	if index < len(rconfig.Nics) && rconfig.Nics[index].Mac != "" {
+		return rconfig.Nics[index].Mac
+	}
+	if rconfig.Mac == "" {
+		return generateMac()
+	}
+	return NicMac(rconfig.Mac, index)
+}
+
+// tapInterface is a network interface of an instance attached to a tap device
+type tapInterface struct {
+	tap string
+	mac string
+}
+
+// tapInterfaces returns the network interfaces of an instance attached to tap devices: the nics
+// with a tap device, the first nic defaulting to the tap device of the instance, or the tap
+// device of the instance if it has no nics
+func tapInterfaces(rconfig *types.RunConfig) []tapInterface {
+	if len(rconfig.Nics) == 0 {
+		if rconfig.TapName == "" {
+			return nil
+		}
+		return []tapInterface{{tap: rconfig.TapName, mac: instanceMac(rconfig)}}
+	}
+	var ifaces []tapInterface
+	for i, nic := range rconfig.Nics {
+		tap := nic.TapName
+		if tap == "" && i == 0 {
+			tap = rconfig.TapName
+		}
+		if tap != "" {
+			ifaces = append(ifaces, tapInterface{tap: tap, mac: nicMac(rconfig, i)})
+		}
+	}
+	return ifaces
+}
+
 // Randomly generate Bytes for mac address
 func generateMac() string {
 	octets := make([]byte, 6)
@@ -180,3 +180,16 @@ func checkQemuString(qr Stringer, expected string, t *testing.T) {
 		t.Errorf("Rendered string %q not %q", actual, expected)
 	}
 }
+
+func TestNicMac(t *testing.T) {
+	mac := "52:54:00:12:34:56"
+	if NicMac(mac, 0) != mac {
+		t.Errorf("first nic should have the mac address of the instance, got %s", NicMac(mac, 0))
+	}
+	if NicMac(mac, 1) != NicMac(mac, 1) {
+		t.Errorf("mac address of nic should be deterministic")
+	}
+	if NicMac(mac, 1) == NicMac(mac, 2) || NicMac(mac, 1) == mac {
+		t.Errorf("nics should have distinct mac addresses")
+	}
+}
@@ -5,31 +5,16 @@ package qemu
 
 import (
 	"fmt"
-	"net"
-	"os"
+
+	"github.com/nanovms/ops/qemu/qmp"
 )
 
-// ExecuteQMP ships a list of commands to the QMP to execute.
-// TODO: turn me private and have the actual commands be exportable.
-func ExecuteQMP(commands []string, last string) {
-	c, err := net.Dial("tcp", "localhost:"+last)
+// DialQMP connects to the QMP server of a qemu instance listening on the management port mgmt
+// (RunConfig.Mgmt)
+func DialQMP(mgmt string) (*qmp.Client, error) {
+	c, err := qmp.Dial("tcp", "localhost:"+mgmt)
 	if err != nil {
-		fmt.Println(err)
-		fmt.Println("can't connect to QMP - is it enabled?\nhttps://docs.ops.city/ops/configuration#runconfig.qmp")
-		os.Exit(1)
-	}
-	defer c.Close()
-
-	for i := 0; i < len(commands); i++ {
-		_, err := c.Write([]byte(commands[i] + "\n"))
-		if err != nil {
-			fmt.Println(err)
-		}
-		received := make([]byte, 1024)
-		_, err = c.Read(received)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
+		return nil, fmt.Errorf("%w - is QMP enabled? https://docs.ops.city/ops/configuration#runconfig.qmp", err)
 	}
+	return c, nil
 }
@@ -0,0 +1,185 @@
+package qmp
+
+import "encoding/json"
+
+// Status is the run state of an instance, returned by query-status
+type Status struct {
+	Running    bool   `json:"running"`
+	Singlestep bool   `json:"singlestep"`
+	Status     string `json:"status"`
+}
+
+// MemorySizeSummary is the memory of an instance, returned by query-memory-size-summary
+type MemorySizeSummary struct {
+	BaseMemory    uint64 `json:"base-memory"`
+	PluggedMemory uint64 `json:"plugged-memory"`
+}
+
+// BlockDevice is a block device of an instance, returned by query-block
+type BlockDevice struct {
+	Device   string `json:"device"`
+	QDev     string `json:"qdev"`
+	Inserted *struct {
+		NodeName string `json:"node-name"`
+		File     string `json:"file"`
+		Image    struct {
+			Filename string `json:"filename"`
+		} `json:"image"`
+	} `json:"inserted,omitempty"`
+}
+
+// MigrationInfo is the state of a migration, returned by query-migrate
+type MigrationInfo struct {
+	Status           string `json:"status"`
+	ErrorDesc        string `json:"error-desc,omitempty"`
+	TotalTime        int64  `json:"total-time,omitempty"`
+	Downtime         int64  `json:"downtime,omitempty"`
+	SetupTime        int64  `json:"setup-time,omitempty"`
+	ExpectedDowntime int64  `json:"expected-downtime,omitempty"`
+}
+
+// Device is a device added with device_add; Properties are the device specific properties
+type Device struct {
+	Driver     string
+	ID         string
+	Bus        string
+	Properties map[string]interface{}
+}
+
+// MarshalJSON flattens the device properties into the device_add arguments
+func (d Device) MarshalJSON() ([]byte, error) {
+	args := map[string]interface{}{"driver": d.Driver}
+	for k, v := range d.Properties {
+		args[k] = v
+	}
+	if d.ID != "" {
+		args["id"] = d.ID
+	}
+	if d.Bus != "" {
+		args["bus"] = d.Bus
+	}
+	return json.Marshal(args)
+}
+
+// BlockdevRawFile is a raw format block device backed by a host file, added with blockdev-add
+type BlockdevRawFile struct {
+	NodeName string
+	Filename string
+	ReadOnly bool
+}
+
+// MarshalJSON formats the blockdev-add arguments of the block device
+func (b BlockdevRawFile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"driver":    "raw",
+		"node-name": b.NodeName,
+		"read-only": b.ReadOnly,
+		"file": map[string]string{
+			"driver":   "file",
+			"filename": b.Filename,
+		},
+	})
+}
+
+// QueryStatus returns the run state of the instance
+func (c *Client) QueryStatus() (*Status, error) {
+	s := &Status{}
+	if err := c.Execute("query-status", nil, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SystemPowerdown asks the guest to shut down
+func (c *Client) SystemPowerdown() error {
+	return c.Execute("system_powerdown", nil, nil)
+}
+
+// SystemReset resets the instance
+func (c *Client) SystemReset() error {
+	return c.Execute("system_reset", nil, nil)
+}
+
+// Stop pauses the instance
+func (c *Client) Stop() error {
+	return c.Execute("stop", nil, nil)
+}
+
+// Cont resumes a paused instance
+func (c *Client) Cont() error {
+	return c.Execute("cont", nil, nil)
+}
+
+// DeviceAdd hot plugs a device
+func (c *Client) DeviceAdd(d Device) error {
+	return c.Execute("device_add", d, nil)
+}
+
+// DeviceDel unplugs a device
+func (c *Client) DeviceDel(id string) error {
+	return c.Execute("device_del", map[string]string{"id": id}, nil)
+}
+
+// BlockdevAdd adds a block device backed by a host file
+func (c *Client) BlockdevAdd(b BlockdevRawFile) error {
+	return c.Execute("blockdev-add", b, nil)
+}
+
+// BlockdevDel removes a block device added with BlockdevAdd
+func (c *Client) BlockdevDel(nodeName string) error {
+	return c.Execute("blockdev-del", map[string]string{"node-name": nodeName}, nil)
+}
+
+// QueryBlock returns the block devices of the instance
+func (c *Client) QueryBlock() ([]BlockDevice, error) {
+	var devices []BlockDevice
+	if err := c.Execute("query-block", nil, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// QueryMemorySizeSummary returns the memory of the instance
+func (c *Client) QueryMemorySizeSummary() (*MemorySizeSummary, error) {
+	m := &MemorySizeSummary{}
+	if err := c.Execute("query-memory-size-summary", nil, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Migrate starts migrating the instance to uri; progress is returned by QueryMigrate
+func (c *Client) Migrate(uri string) error {
+	return c.Execute("migrate", map[string]string{"uri": uri}, nil)
+}
+
+// MigrateIncoming starts receiving the state of an instance started with "-incoming defer"
+func (c *Client) MigrateIncoming(uri string) error {
+	return c.Execute("migrate-incoming", map[string]string{"uri": uri}, nil)
+}
+
+// QueryMigrate returns the state of the current migration
+func (c *Client) QueryMigrate() (*MigrationInfo, error) {
+	m := &MigrationInfo{}
+	if err := c.Execute("query-migrate", nil, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// QOMSet sets a property of an object
+func (c *Client) QOMSet(path, property string, value interface{}) error {
+	return c.Execute("qom-set", map[string]interface{}{
+		"path":     path,
+		"property": property,
+		"value":    value,
+	}, nil)
+}
+
+// QOMGet decodes a property of an object into result
+func (c *Client) QOMGet(path, property string, result interface{}) error {
+	return c.Execute("qom-get", map[string]string{
+		"path":     path,
+		"property": property,
+	}, result)
+}
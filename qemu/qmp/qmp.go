@@ -0,0 +1,222 @@
+// Package qmp implements a client for the QEMU Machine Protocol, which controls running qemu
+// instances.
+package qmp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the default time a client waits for the response to a command
+const DefaultTimeout = 30 * time.Second
+
+// ErrClosed is returned for commands executed on a closed client
+var ErrClosed = errors.New("QMP connection closed")
+
+// Error is an error returned by qemu in response to a command
+type Error struct {
+	Class string `json:"class"`
+	Desc  string `json:"desc"`
+}
+
+func (e *Error) Error() string {
+	return e.Class + ": " + e.Desc
+}
+
+// Greeting is the greeting sent by qemu when a client connects
+type Greeting struct {
+	QMP struct {
+		Version struct {
+			Qemu struct {
+				Major int `json:"major"`
+				Minor int `json:"minor"`
+				Micro int `json:"micro"`
+			} `json:"qemu"`
+			Package string `json:"package"`
+		} `json:"version"`
+		Capabilities []string `json:"capabilities"`
+	} `json:"QMP"`
+}
+
+// Event is an asynchronous event sent by qemu
+type Event struct {
+	Event     string          `json:"event"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Timestamp struct {
+		Seconds      int64 `json:"seconds"`
+		Microseconds int64 `json:"microseconds"`
+	} `json:"timestamp"`
+}
+
+// Time returns the time of an event
+func (e *Event) Time() time.Time {
+	return time.Unix(e.Timestamp.Seconds, e.Timestamp.Microseconds*1000)
+}
+
+type request struct {
+	Execute   string      `json:"execute"`
+	Arguments interface{} `json:"arguments,omitempty"`
+	ID        string      `json:"id"`
+}
+
+type message struct {
+	Event
+	Return json.RawMessage `json:"return"`
+	Error  *Error          `json:"error"`
+	ID     string          `json:"id"`
+}
+
+// Client is a QMP client; commands can be executed concurrently
+type Client struct {
+	// Greeting is the greeting received when connecting
+	Greeting Greeting
+
+	// Timeout is the time to wait for the response to a command
+	Timeout time.Duration
+
+	conn    net.Conn
+	events  chan Event
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[string]chan *message
+	err     error
+	done    chan struct{}
+}
+
+// Dial connects to the QMP server of a qemu instance and negotiates capabilities; network is
+// "tcp" or "unix"
+func Dial(network, address string) (*Client, error) {
+	conn, err := net.DialTimeout(network, address, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to QMP: %w", err)
+	}
+	c, err := NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClient creates a client from a connection to a QMP server and negotiates capabilities
+func NewClient(conn net.Conn) (*Client, error) {
+	c := &Client{
+		Timeout: DefaultTimeout,
+		conn:    conn,
+		events:  make(chan Event, 64),
+		pending: make(map[string]chan *message),
+		done:    make(chan struct{}),
+	}
+	dec := json.NewDecoder(conn)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := dec.Decode(&c.Greeting); err != nil {
+		return nil, fmt.Errorf("cannot read QMP greeting: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	go c.read(dec)
+	if err := c.Execute("qmp_capabilities", nil, nil); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("cannot negotiate QMP capabilities: %w", err)
+	}
+	return c, nil
+}
+
+// read dispatches the messages received from the server until the connection is closed
+func (c *Client) read(dec *json.Decoder) {
+	var err error
+	for {
+		m := &message{}
+		if err = dec.Decode(m); err != nil {
+			break
+		}
+		if m.Event.Event != "" {
+			select {
+			case c.events <- m.Event:
+			default:
+				// events are dropped if they are not consumed
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[m.ID]
+		delete(c.pending, m.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- m
+		}
+	}
+
+	c.mu.Lock()
+	c.err = err
+	c.pending = nil
+	c.mu.Unlock()
+	close(c.done)
+	close(c.events)
+}
+
+// Events returns the stream of asynchronous events; events are dropped when the stream buffer is
+// full, and the stream is closed when the connection is closed
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Execute executes a command with optional arguments and decodes its return value into result, if
+// not nil
+func (c *Client) Execute(command string, arguments interface{}, result interface{}) error {
+	c.mu.Lock()
+	if c.pending == nil {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := strconv.FormatUint(c.nextID, 10)
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	data, err := json.Marshal(&request{Execute: command, Arguments: arguments, ID: id})
+	if err == nil {
+		_, err = c.conn.Write(append(data, '\n'))
+	}
+	if err != nil {
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return fmt.Errorf("cannot send QMP command %s: %w", command, err)
+	}
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	select {
+	case m := <-ch:
+		if m.Error != nil {
+			return fmt.Errorf("QMP command %s failed: %w", command, m.Error)
+		}
+		if result != nil && len(m.Return) > 0 {
+			if err := json.Unmarshal(m.Return, result); err != nil {
+				return fmt.Errorf("cannot decode QMP %s response: %w", command, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		if c.err != nil {
+			return fmt.Errorf("QMP command %s: %w: %v", command, ErrClosed, c.err)
+		}
+		return ErrClosed
+	case <-timer.C:
+		c.mu.Lock()
+		if c.pending != nil {
+			delete(c.pending, id)
+		}
+		c.mu.Unlock()
+		return fmt.Errorf("QMP command %s timed out", command)
+	}
+}
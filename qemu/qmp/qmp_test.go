@@ -0,0 +1,109 @@
+package qmp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer answers QMP commands with the responses returned by handle, sending an event before
+// each response
+func fakeServer(t *testing.T, conn net.Conn, handle func(command string, args json.RawMessage) (interface{}, *Error)) {
+	defer conn.Close()
+	enc := json.NewEncoder(conn)
+	enc.Encode(map[string]interface{}{
+		"QMP": map[string]interface{}{
+			"version":      map[string]interface{}{"qemu": map[string]int{"major": 8, "minor": 2}},
+			"capabilities": []string{"oob"},
+		},
+	})
+	r := bufio.NewReader(conn)
+	negotiated := false
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req struct {
+			Execute   string          `json:"execute"`
+			Arguments json.RawMessage `json:"arguments"`
+			ID        string          `json:"id"`
+		}
+		if !assert.Nil(t, json.Unmarshal(line, &req)) {
+			return
+		}
+		enc.Encode(map[string]interface{}{"event": "TEST", "timestamp": map[string]int{"seconds": 1}})
+		if req.Execute == "qmp_capabilities" {
+			negotiated = true
+			enc.Encode(map[string]interface{}{"return": map[string]string{}, "id": req.ID})
+			continue
+		}
+		assert.True(t, negotiated)
+		ret, qerr := handle(req.Execute, req.Arguments)
+		if qerr != nil {
+			enc.Encode(map[string]interface{}{"error": qerr, "id": req.ID})
+		} else {
+			enc.Encode(map[string]interface{}{"return": ret, "id": req.ID})
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	server, conn := net.Pipe()
+	var args []string
+	go fakeServer(t, server, func(command string, a json.RawMessage) (interface{}, *Error) {
+		args = append(args, string(a))
+		switch command {
+		case "query-status":
+			return Status{Running: true, Status: "running"}, nil
+		case "query-memory-size-summary":
+			return MemorySizeSummary{BaseMemory: 2147483648}, nil
+		case "device_add", "blockdev-add", "system_powerdown":
+			return map[string]string{}, nil
+		}
+		return nil, &Error{Class: "CommandNotFound", Desc: "The command " + command + " has not been found"}
+	})
+
+	c, err := NewClient(conn)
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Equal(t, 8, c.Greeting.QMP.Version.Qemu.Major)
+
+	status, err := c.QueryStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, &Status{Running: true, Status: "running"}, status)
+
+	memory, err := c.QueryMemorySizeSummary()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2147483648), memory.BaseMemory)
+
+	err = c.BlockdevAdd(BlockdevRawFile{NodeName: "vol", Filename: "/tmp/vol.raw"})
+	assert.Nil(t, err)
+	err = c.DeviceAdd(Device{Driver: "scsi-hd", ID: "vol", Bus: "scsi0.0", Properties: map[string]interface{}{"drive": "vol"}})
+	assert.Nil(t, err)
+	assert.Nil(t, c.SystemPowerdown())
+
+	err = c.Migrate("tcp:localhost:4444")
+	var qerr *Error
+	assert.True(t, errors.As(err, &qerr))
+	assert.Equal(t, "CommandNotFound", qerr.Class)
+
+	assert.JSONEq(t, `{"driver":"raw","node-name":"vol","read-only":false,"file":{"driver":"file","filename":"/tmp/vol.raw"}}`, args[2])
+	assert.JSONEq(t, `{"driver":"scsi-hd","id":"vol","bus":"scsi0.0","drive":"vol"}`, args[3])
+	assert.JSONEq(t, `{"uri":"tcp:localhost:4444"}`, args[5])
+
+	event := <-c.Events()
+	assert.Equal(t, "TEST", event.Event)
+	assert.Equal(t, int64(1), event.Time().Unix())
+
+	server.Close()
+	for range c.Events() {
+		// wait for the client to see the connection closed
+	}
+	_, err = c.QueryStatus()
+	assert.True(t, errors.Is(err, ErrClosed))
+}
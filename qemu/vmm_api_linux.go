@@ -0,0 +1,100 @@
+package qemu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vmmRequest sends a request to the HTTP API of a virtual machine monitor listening on a unix
+// socket; a nil body sends an empty request
+func vmmRequest(socket, method, path string, body interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		fault := struct {
+			FaultMessage string `json:"fault_message"`
+		}{}
+		msg := strings.TrimSpace(string(respBody))
+		if json.Unmarshal(respBody, &fault) == nil && fault.FaultMessage != "" {
+			msg = fault.FaultMessage
+		} else if msg == "" {
+			msg = resp.Status
+		}
+		return fmt.Errorf("API request %s failed: %s", path, msg)
+	}
+	return nil
+}
+
+// waitVMMSocket waits until a virtual machine monitor accepts connections on its API socket
+func waitVMMSocket(socket string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("cannot connect to API socket: %w", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// memoryMiB parses a memory size in the qemu format (a number of megabytes, optionally with an M or
+// G suffix) and returns it in megabytes; an empty size is the qemu default of 128 MiB
+func memoryMiB(size string) (int, error) {
+	if size == "" {
+		return 128, nil
+	}
+	multiplier := 1
+	digits := size
+	switch strings.ToUpper(size[len(size)-1:]) {
+	case "G":
+		multiplier = 1024
+		digits = size[:len(size)-1]
+	case "M":
+		digits = size[:len(size)-1]
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q", size)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,60 @@
+package qemu
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryMiB(t *testing.T) {
+	sizes := map[string]int{
+		"":     128,
+		"256":  256,
+		"512M": 512,
+		"2g":   2048,
+	}
+	for size, expected := range sizes {
+		mib, err := memoryMiB(size)
+		assert.Nil(t, err, size)
+		assert.Equal(t, expected, mib, size)
+	}
+
+	for _, size := range []string{"G", "1.5G", "-1", "2T"} {
+		_, err := memoryMiB(size)
+		assert.NotNil(t, err, size)
+	}
+}
+
+func TestVMMRequest(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	l, err := net.Listen("unix", socket)
+	assert.Nil(t, err)
+	var requests []string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		if r.URL.Path == "/actions" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"fault_message": "invalid action"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	go server.Serve(l)
+	defer server.Close()
+
+	assert.Nil(t, waitVMMSocket(socket, time.Second))
+	assert.Nil(t, (&firecrackerController{socket: socket}).Pause())
+	assert.Nil(t, (&cloudHypervisorController{socket: socket}).Reboot())
+	assert.EqualError(t, (&firecrackerController{socket: socket}).Shutdown(), "API request /actions failed: invalid action")
+	assert.Equal(t, []string{
+		`PATCH /vm {"state":"Paused"}`,
+		"PUT /api/v1/vm.reboot ",
+		`PUT /actions {"action_type":"SendCtrlAltDel"}`,
+	}, requests)
+}
@@ -0,0 +1,167 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/types"
+)
+
+// Apply makes the changes of a plan. The resources to delete and replace are deleted first, in the
+// reverse order of their dependencies, then the resources to create and replace are created. The
+// state is saved after each change, so that the changes made before an error are recorded.
+func (pl *Planner) Apply(ctx *lepton.Context, plan *Plan) error {
+	for k := len(kindOrder) - 1; k >= 0; k-- {
+		for _, c := range plan.Changes {
+			if c.Resource.Kind != kindOrder[k] || (c.Action != ActionDelete && c.Action != ActionReplace) {
+				continue
+			}
+
+			if c.exists {
+				ctx.Logger().Logf("deleting %s", c.Resource)
+				if err := pl.delete(ctx, c.Resource); err != nil {
+					return fmt.Errorf("cannot delete %s: %w", c.Resource, err)
+				}
+			}
+
+			if c.Action == ActionDelete {
+				pl.State.remove(c.Resource)
+				if err := pl.State.Save(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, kind := range kindOrder {
+		for _, c := range plan.Changes {
+			if c.Resource.Kind != kind || c.Action == ActionDelete {
+				continue
+			}
+
+			if c.Action != ActionAdopt {
+				ctx.Logger().Logf("creating %s", c.Resource)
+				if err := pl.create(ctx, c.Resource); err != nil {
+					return fmt.Errorf("cannot create %s: %w", c.Resource, err)
+				}
+			}
+
+			pl.State.set(c.Resource)
+			if err := pl.State.Save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// create creates a resource of the stack
+func (pl *Planner) create(ctx *lepton.Context, r Resource) error {
+	p := pl.Providers[r.Provider]
+	resources := pl.Stack.Providers[r.Provider]
+	base := ctx.Config()
+
+	switch r.Kind {
+	case KindImage:
+		image := resources.image(r.Name)
+		c, err := pl.Stack.imageConfig(base, r.Provider, image)
+		if err != nil {
+			return err
+		}
+		return pl.BuildImage(p, ctx.WithConfig(c), image)
+
+	case KindVolume:
+		volume := resources.volume(r.Name)
+		c, err := pl.Stack.volumeConfig(base, r.Provider, volume)
+		if err != nil {
+			return err
+		}
+		cv := types.CloudVolume{
+			Name:   volume.Name,
+			Typeof: volume.Typeof,
+		}
+		_, err = p.CreateVolume(ctx.WithConfig(c), cv, pl.Stack.path(volume.Data), r.Provider)
+		return err
+
+	case KindInstance:
+		instance := resources.instance(r.Name)
+		c, err := pl.Stack.instanceConfig(base, r.Provider, instance)
+		if err != nil {
+			return err
+		}
+		ictx := ctx.WithConfig(c)
+		if err = p.CreateInstance(ictx); err != nil {
+			return err
+		}
+		for _, v := range instance.Volumes {
+			if err = p.AttachVolume(ictx, instance.Name, v, -1); err != nil {
+				return fmt.Errorf("cannot attach volume %s: %w", v, err)
+			}
+		}
+		return nil
+
+	case KindDNS:
+		record := resources.dnsRecord(r.Name)
+		c, err := pl.Stack.dnsConfig(base, r.Provider, record)
+		if err != nil {
+			return err
+		}
+		dctx := ctx.WithConfig(c)
+		instance, err := p.GetInstanceByName(dctx, record.Instance)
+		if err != nil {
+			return err
+		}
+		var ip string
+		if len(instance.PublicIps) > 0 {
+			ip = instance.PublicIps[0]
+		} else if len(instance.PrivateIps) > 0 {
+			ip = instance.PrivateIps[0]
+		} else {
+			return fmt.Errorf("instance %s has no address", record.Instance)
+		}
+		dns, err := dnsService(p, r.Provider)
+		if err != nil {
+			return err
+		}
+		return lepton.CreateDNSRecord(dctx, ip, dns)
+	}
+
+	return fmt.Errorf("unknown kind %s", r.Kind)
+}
+
+// delete deletes a resource, which may have been removed from the stack
+func (pl *Planner) delete(ctx *lepton.Context, r Resource) error {
+	p := pl.Providers[r.Provider]
+	c, err := pl.Stack.ProviderConfig(ctx.Config(), r.Provider)
+	if err != nil {
+		return err
+	}
+	pctx := ctx.WithConfig(c)
+
+	switch r.Kind {
+	case KindImage:
+		return p.DeleteImage(pctx, r.Name)
+	case KindVolume:
+		return p.DeleteVolume(pctx, r.Name)
+	case KindInstance:
+		return p.DeleteInstance(pctx, r.Name)
+	case KindDNS:
+		dns, err := dnsService(p, r.Provider)
+		if err != nil {
+			return err
+		}
+		c.CloudConfig.DomainName = r.Name
+		return lepton.DeleteDNSRecord(pctx, dns)
+	}
+
+	return fmt.Errorf("unknown kind %s", r.Kind)
+}
+
+func dnsService(p lepton.Provider, providerName string) (lepton.DNSService, error) {
+	dns, ok := lepton.UnwrapProvider(p).(lepton.DNSService)
+	if !ok {
+		return nil, &lepton.ErrUnsupported{Provider: providerName, Capability: lepton.CapabilityDNS}
+	}
+	return dns, nil
+}
@@ -0,0 +1,293 @@
+package stack
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/nanovms/ops/types"
+)
+
+// cloneConfig returns a copy of a configuration which shares none of its slices and maps
+func cloneConfig(c *types.Config) (*types.Config, error) {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	clone := &types.Config{}
+	if err = json.Unmarshal(body, clone); err != nil {
+		return nil, err
+	}
+	clone.RunConfig.Timeout = c.RunConfig.Timeout
+	return clone, nil
+}
+
+// readConfig overrides a configuration with an ops configuration file
+func readConfig(file string, c *types.Config) error {
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read config: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(c); err != nil {
+		return fmt.Errorf("cannot parse config %s: %w", file, err)
+	}
+
+	c.LocalFilesParentDirectory = path.Dir(file)
+	return nil
+}
+
+// ProviderConfig returns the configuration of a provider, which the provider is initialized with
+// and the configurations of its resources start from
+func (s *Stack) ProviderConfig(base *types.Config, providerName string) (*types.Config, error) {
+	c, err := cloneConfig(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if r := s.Providers[providerName]; r != nil {
+		if r.Config != "" {
+			if err = readConfig(s.path(r.Config), c); err != nil {
+				return nil, err
+			}
+		}
+		if r.Zone != "" {
+			c.CloudConfig.Zone = r.Zone
+		}
+		if r.ProjectID != "" {
+			c.CloudConfig.ProjectID = r.ProjectID
+		}
+		if r.Bucket != "" {
+			c.CloudConfig.BucketName = r.Bucket
+		}
+	}
+
+	c.CloudConfig.Platform = providerName
+	return c, nil
+}
+
+// imageConfig returns the configuration an image is built with
+func (s *Stack) imageConfig(base *types.Config, providerName string, image *Image) (*types.Config, error) {
+	c, err := s.ProviderConfig(base, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if image.Config != "" {
+		if err = readConfig(s.path(image.Config), c); err != nil {
+			return nil, err
+		}
+	}
+
+	if image.Program != "" {
+		c.Program = s.path(image.Program)
+		c.ProgramPath = c.Program
+	}
+	if len(image.Args) > 0 {
+		c.Args = append([]string{}, image.Args...)
+	}
+	for k, v := range image.Env {
+		if c.Env == nil {
+			c.Env = make(map[string]string)
+		}
+		c.Env[k] = v
+	}
+	c.CloudConfig.ImageName = image.Name
+
+	return c, nil
+}
+
+// volumeConfig returns the configuration a volume is created with
+func (s *Stack) volumeConfig(base *types.Config, providerName string, volume *Volume) (*types.Config, error) {
+	c, err := s.ProviderConfig(base, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if volume.Size != "" {
+		c.BaseVolumeSz = volume.Size
+	}
+
+	return c, nil
+}
+
+// instanceConfig returns the configuration an instance is created with
+func (s *Stack) instanceConfig(base *types.Config, providerName string, instance *Instance) (*types.Config, error) {
+	c, err := s.ProviderConfig(base, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.Config != "" {
+		if err = readConfig(s.path(instance.Config), c); err != nil {
+			return nil, err
+		}
+	}
+
+	c.CloudConfig.ImageName = instance.Image
+	c.RunConfig.InstanceName = instance.Name
+	if instance.Flavor != "" {
+		c.CloudConfig.Flavor = instance.Flavor
+	}
+	c.RunConfig.Ports = append(c.RunConfig.Ports, instance.Ports...)
+	c.RunConfig.UDPPorts = append(c.RunConfig.UDPPorts, instance.UDPPorts...)
+
+	if n := s.Providers[providerName].network(instance.Network); n != nil {
+		if n.VPC != "" {
+			c.CloudConfig.VPC = n.VPC
+		}
+		if n.Subnet != "" {
+			c.CloudConfig.Subnet = n.Subnet
+		}
+		if n.Bridge != "" {
+			c.RunConfig.Bridged = true
+			c.RunConfig.BridgeName = n.Bridge
+		}
+	}
+
+	c.RunConfig.Kernel = c.Kernel
+
+	return c, nil
+}
+
+// dnsConfig returns the configuration a DNS record is created with
+func (s *Stack) dnsConfig(base *types.Config, providerName string, record *DNSRecord) (*types.Config, error) {
+	c, err := s.ProviderConfig(base, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.CloudConfig.DomainName = record.Domain
+
+	return c, nil
+}
+
+// digester computes the digests of the specifications of resources, keeping the first error
+type digester struct {
+	s   *Stack
+	err error
+}
+
+// file returns the digest of the content of a file of the stack
+func (d *digester) file(p string) string {
+	if p == "" || d.err != nil {
+		return ""
+	}
+	body, err := os.ReadFile(d.s.path(p))
+	if err != nil {
+		d.err = err
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// dir returns the digest of the names and contents of the files of a directory of the stack
+func (d *digester) dir(p string) string {
+	if p == "" || d.err != nil {
+		return ""
+	}
+	root := d.s.path(p)
+	h := sha256.New()
+	d.err = filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(filepath.ToSlash(rel)))
+		h.Write([]byte{0})
+		switch {
+		case entry.Type().IsRegular():
+			h.Write([]byte(d.file(file)))
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			h.Write([]byte(target))
+		}
+		h.Write([]byte{0})
+		return d.err
+	})
+	if d.err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sum returns the digest of values
+func (d *digester) sum(values ...interface{}) string {
+	h := sha256.New()
+	for _, v := range values {
+		body, err := json.Marshal(v)
+		if err != nil {
+			d.err = err
+			return ""
+		}
+		h.Write(body)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resources returns the resources of the stack, with the digests of their specifications and of
+// the files and resources they depend on, so that an instance is replaced with its image
+func (s *Stack) resources() ([]Resource, error) {
+	d := &digester{s: s}
+
+	var resources []Resource
+	add := func(providerName string, kind Kind, name, digest string) {
+		resources = append(resources, Resource{Provider: providerName, Kind: kind, Name: name, Digest: digest})
+	}
+
+	for _, providerName := range s.providerNames() {
+		r := s.Providers[providerName]
+		if r == nil {
+			continue
+		}
+		provider := d.sum(r.Zone, r.ProjectID, r.Bucket, d.file(r.Config))
+
+		images := map[string]string{}
+		for _, i := range r.Images {
+			images[i.Name] = d.sum(provider, i, d.file(i.Program), d.file(i.Config))
+			add(providerName, KindImage, i.Name, images[i.Name])
+		}
+
+		volumes := map[string]string{}
+		for _, v := range r.Volumes {
+			volumes[v.Name] = d.sum(provider, v, d.dir(v.Data))
+			// the size is recorded as volumes are not replaced when it changes
+			resources = append(resources, Resource{Provider: providerName, Kind: KindVolume, Name: v.Name, Digest: volumes[v.Name], Size: v.Size})
+		}
+
+		instances := map[string]string{}
+		for _, i := range r.Instances {
+			var attached []string
+			for _, v := range i.Volumes {
+				attached = append(attached, volumes[v])
+			}
+			instances[i.Name] = d.sum(provider, i, d.file(i.Config), images[i.Image], r.network(i.Network), attached)
+			add(providerName, KindInstance, i.Name, instances[i.Name])
+		}
+
+		for _, record := range r.DNS {
+			add(providerName, KindDNS, record.Domain, d.sum(provider, record, instances[record.Instance]))
+		}
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("cannot read stack files: %w", d.err)
+	}
+	return resources, nil
+}
@@ -0,0 +1,222 @@
+package stack
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nanovms/ops/lepton"
+)
+
+// Action is a change made to a resource to converge to the stack
+type Action string
+
+// Actions of a plan
+const (
+	// ActionCreate creates a resource of the stack which does not exist
+	ActionCreate Action = "create"
+
+	// ActionReplace deletes and creates again a resource whose specification changed; volumes are
+	// not replaced when their size changes, as replacing them deletes their data
+	ActionReplace Action = "replace"
+
+	// ActionDelete deletes a resource which was removed from the stack
+	ActionDelete Action = "delete"
+
+	// ActionAdopt records in the state a resource of the stack which exists but was not created by
+	// the stack, it is left as it is
+	ActionAdopt Action = "adopt"
+)
+
+var actionSymbols = map[Action]string{
+	ActionCreate:  "+",
+	ActionReplace: "~",
+	ActionDelete:  "-",
+	ActionAdopt:   "=",
+}
+
+// Change is an action on a resource
+type Change struct {
+	Action   Action
+	Resource Resource
+
+	// exists is whether the resource exists on the provider
+	exists bool
+}
+
+// Plan are the changes converging the resources of providers to a stack
+type Plan struct {
+	Changes []Change
+}
+
+// Empty returns whether the resources already match the stack
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Print writes the changes of the plan
+func (p *Plan) Print(w io.Writer) {
+	if p.Empty() {
+		fmt.Fprintln(w, "no changes, the resources match the stack")
+		return
+	}
+
+	counts := map[Action]int{}
+	for _, c := range p.Changes {
+		fmt.Fprintf(w, "%s %s %s\n", actionSymbols[c.Action], c.Action, c.Resource)
+		counts[c.Action]++
+	}
+	fmt.Fprintf(w, "\n%d to create, %d to replace, %d to delete, %d to adopt\n",
+		counts[ActionCreate], counts[ActionReplace], counts[ActionDelete], counts[ActionAdopt])
+}
+
+// Planner computes and applies the changes converging the resources of providers to a stack
+type Planner struct {
+	Stack *Stack
+	State *State
+
+	// Providers are the initialized providers named by ProviderNames
+	Providers map[string]lepton.Provider
+
+	// BuildImage builds an image of the stack and creates it on the provider, the configuration
+	// of ctx being the configuration of the image
+	BuildImage func(p lepton.Provider, ctx *lepton.Context, image *Image) error
+}
+
+// Plan compares the stack to its state and to the images, volumes and instances of the providers.
+// The configuration of ctx is the base of the configurations of the resources.
+func (pl *Planner) Plan(ctx *lepton.Context) (*Plan, error) {
+	desired, err := pl.Stack.resources()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]map[Kind]map[string]bool{}
+	for _, providerName := range ProviderNames(pl.Stack, pl.State) {
+		existing[providerName], err = pl.existingResources(ctx, providerName, desired)
+		if err != nil {
+			return nil, err
+		}
+	}
+	exists := func(r Resource) bool {
+		return existing[r.Provider][r.Kind][r.Name]
+	}
+
+	plan := &Plan{}
+	for _, r := range desired {
+		recorded, managed := pl.State.find(r)
+		switch {
+		case !exists(r):
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Resource: r})
+		case !managed:
+			plan.Changes = append(plan.Changes, Change{Action: ActionAdopt, Resource: r, exists: true})
+		case recorded.Digest != r.Digest:
+			if r.Kind == KindVolume && recorded.Size != r.Size {
+				return nil, fmt.Errorf("%s cannot be resized from %s to %s, it would be replaced and lose its data", r, volumeSize(recorded.Size), volumeSize(r.Size))
+			}
+			plan.Changes = append(plan.Changes, Change{Action: ActionReplace, Resource: r, exists: true})
+		}
+	}
+
+	for _, r := range pl.State.Resources {
+		if !containsResource(desired, r) {
+			plan.Changes = append(plan.Changes, Change{Action: ActionDelete, Resource: r, exists: exists(r)})
+		}
+	}
+
+	return plan, nil
+}
+
+// existingResources returns the names of the resources of a provider which exist, by kind; DNS
+// records are assumed to exist if they are recorded in the state
+func (pl *Planner) existingResources(ctx *lepton.Context, providerName string, desired []Resource) (map[Kind]map[string]bool, error) {
+	p, ok := pl.Providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("provider %s is not initialized", providerName)
+	}
+
+	kinds := map[Kind]bool{}
+	for _, resources := range [][]Resource{desired, pl.State.Resources} {
+		for _, r := range resources {
+			if r.Provider == providerName {
+				kinds[r.Kind] = true
+			}
+		}
+	}
+
+	required := lepton.Capabilities{}
+	if kinds[KindVolume] {
+		required = append(required, lepton.CapabilityVolumes)
+	}
+	if kinds[KindDNS] {
+		required = append(required, lepton.CapabilityDNS)
+	}
+	if err := p.Capabilities().Require(providerName, required...); err != nil {
+		return nil, err
+	}
+
+	c, err := pl.Stack.ProviderConfig(ctx.Config(), providerName)
+	if err != nil {
+		return nil, err
+	}
+	pctx := ctx.WithConfig(c)
+
+	existing := map[Kind]map[string]bool{}
+	for _, k := range kindOrder {
+		existing[k] = map[string]bool{}
+	}
+
+	if kinds[KindImage] {
+		images, err := p.GetImages(pctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("cannot list images of %s: %w", providerName, err)
+		}
+		for _, i := range images {
+			existing[KindImage][i.Name] = true
+		}
+	}
+
+	if kinds[KindVolume] {
+		volumes, err := p.GetAllVolumes(pctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list volumes of %s: %w", providerName, err)
+		}
+		for _, v := range *volumes {
+			existing[KindVolume][v.Name] = true
+		}
+	}
+
+	if kinds[KindInstance] {
+		instances, err := p.GetInstances(pctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list instances of %s: %w", providerName, err)
+		}
+		for _, i := range instances {
+			existing[KindInstance][i.Name] = true
+		}
+	}
+
+	for _, r := range pl.State.Resources {
+		if r.Provider == providerName && r.Kind == KindDNS {
+			existing[KindDNS][r.Name] = true
+		}
+	}
+
+	return existing, nil
+}
+
+// volumeSize returns the size of a volume of the stack for messages
+func volumeSize(size string) string {
+	if size == "" {
+		return "the default size"
+	}
+	return size
+}
+
+func containsResource(resources []Resource, r Resource) bool {
+	for _, o := range resources {
+		if o.is(r) {
+			return true
+		}
+	}
+	return false
+}
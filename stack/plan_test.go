@@ -0,0 +1,211 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nanovms/ops/lepton"
+	"github.com/nanovms/ops/provider/onprem"
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestPlanner returns a planner of a stack on onprem, whose images are built by writing their
+// program to the images directory
+func newTestPlanner(t *testing.T, dir, body string) (*Planner, *lepton.Context, *[]*types.Config) {
+	s, err := Load(writeStack(t, dir, body))
+	assert.Nil(t, err)
+	state, err := LoadState(s.Name)
+	assert.Nil(t, err)
+
+	built := &[]*types.Config{}
+	planner := &Planner{
+		Stack:     s,
+		State:     state,
+		Providers: map[string]lepton.Provider{"onprem": onprem.NewProvider()},
+		BuildImage: func(p lepton.Provider, ctx *lepton.Context, image *Image) error {
+			c := ctx.Config()
+			*built = append(*built, c)
+			images := path.Join(lepton.GetOpsHome(), "images")
+			if err := os.MkdirAll(images, 0755); err != nil {
+				return err
+			}
+			body, err := os.ReadFile(c.Program)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path.Join(images, c.CloudConfig.ImageName), body, 0644)
+		},
+	}
+
+	return planner, lepton.NewContext(&types.Config{VolumesDir: t.TempDir()}), built
+}
+
+func actions(plan *Plan) []string {
+	var actions []string
+	for _, c := range plan.Changes {
+		actions = append(actions, string(c.Action)+" "+c.Resource.String())
+	}
+	return actions
+}
+
+func TestPlanApply(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(path.Join(dir, "web"), []byte("v1"), 0755))
+	assert.Nil(t, os.Mkdir(path.Join(dir, "files"), 0755))
+	assert.Nil(t, os.WriteFile(path.Join(dir, "files", "index.html"), []byte("v1"), 0644))
+
+	spec := `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        program: web
+        args: ["-port", "8080"]
+        env:
+          MODE: prod
+    volumes:
+      - name: data
+        size: 2m
+        data: files
+      - name: logs
+`
+	planner, ctx, built := newTestPlanner(t, dir, spec)
+	op := planner.Providers["onprem"]
+
+	// a volume which was created before the stack is adopted
+	volumesDir := ctx.Config().VolumesDir
+	_, err := op.CreateVolume(ctx, types.CloudVolume{Name: "logs"}, "", "onprem")
+	assert.Nil(t, err)
+
+	plan, err := planner.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"create image onprem/web",
+		"create volume onprem/data",
+		"adopt volume onprem/logs",
+	}, actions(plan))
+
+	assert.Nil(t, planner.Apply(ctx, plan))
+
+	assert.Len(t, *built, 1)
+	c := (*built)[0]
+	assert.Equal(t, path.Join(dir, "web"), c.Program)
+	assert.Equal(t, []string{"-port", "8080"}, c.Args)
+	assert.Equal(t, "prod", c.Env["MODE"])
+	assert.Equal(t, "onprem", c.CloudConfig.Platform)
+
+	images, err := op.GetImages(ctx, "")
+	assert.Nil(t, err)
+	assert.Len(t, images, 1)
+	volumes, err := op.GetAllVolumes(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, *volumes, 2)
+
+	// the state is saved, and the resources match the stack
+	state, err := LoadState("shop")
+	assert.Nil(t, err)
+	assert.Equal(t, planner.State.Resources, state.Resources)
+
+	planner, _, built = newTestPlanner(t, dir, spec)
+	plan, err = planner.Plan(ctx)
+	assert.Nil(t, err)
+	assert.True(t, plan.Empty())
+
+	// the program and the data of a volume changed, and a volume was removed from the stack
+	assert.Nil(t, os.WriteFile(path.Join(dir, "web"), []byte("v2"), 0755))
+	assert.Nil(t, os.WriteFile(path.Join(dir, "files", "index.html"), []byte("v2"), 0644))
+	updated := `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        program: web
+        args: ["-port", "8080"]
+        env:
+          MODE: prod
+    volumes:
+      - name: data
+        size: %s
+        data: files
+`
+	// volumes are not replaced when they are resized
+	planner, _, _ = newTestPlanner(t, dir, fmt.Sprintf(updated, "4m"))
+	_, err = planner.Plan(ctx)
+	assert.EqualError(t, err, "volume onprem/data cannot be resized from 2m to 4m, it would be replaced and lose its data")
+
+	planner, _, built = newTestPlanner(t, dir, fmt.Sprintf(updated, "2m"))
+	plan, err = planner.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"replace image onprem/web",
+		"replace volume onprem/data",
+		"delete volume onprem/logs",
+	}, actions(plan))
+
+	assert.Nil(t, planner.Apply(ctx, plan))
+	assert.Len(t, *built, 1)
+
+	body, err := os.ReadFile(path.Join(lepton.GetOpsHome(), "images", "web"))
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", string(body))
+
+	volumes, err = op.GetAllVolumes(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, *volumes, 1)
+	assert.Equal(t, "data", (*volumes)[0].Name)
+	assert.Equal(t, volumesDir, path.Dir((*volumes)[0].Path))
+
+	// a resource deleted out of band is created again
+	assert.Nil(t, op.DeleteImage(ctx, "web"))
+	plan, err = planner.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create image onprem/web"}, actions(plan))
+
+	// removing the provider from the stack deletes its resources
+	planner, _, _ = newTestPlanner(t, dir, "name: shop\n")
+	plan, err = planner.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"delete image onprem/web",
+		"delete volume onprem/data",
+	}, actions(plan))
+
+	assert.Nil(t, planner.Apply(ctx, plan))
+	volumes, err = op.GetAllVolumes(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, *volumes, 0)
+	state, err = LoadState("shop")
+	assert.Nil(t, err)
+	assert.Empty(t, state.Resources)
+}
+
+func TestPlanUnsupported(t *testing.T) {
+	t.Setenv("OPS_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(path.Join(dir, "web"), nil, 0755))
+
+	planner, ctx, _ := newTestPlanner(t, dir, `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        program: web
+    instances:
+      - name: web-1
+        image: web
+    dns:
+      - domain: www.shop.com
+        instance: web-1
+`)
+
+	_, err := planner.Plan(ctx)
+	assert.EqualError(t, err, "dns is not supported by the onprem provider, see ops provider describe onprem")
+}
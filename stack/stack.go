@@ -0,0 +1,323 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Stack is a declarative description of the images, volumes, instances and DNS records of services
+// on one or more providers, eg:
+//
+//	name: shop
+//	providers:
+//	  gcp:
+//	    projectid: shop-prod
+//	    zone: us-west2-a
+//	    bucket: shop-images
+//	    images:
+//	      - name: web
+//	        program: ./bin/web
+//	        args: ["-port", "8080"]
+//	    volumes:
+//	      - name: uploads
+//	        size: 1g
+//	    networks:
+//	      - name: private
+//	        vpc: shop-vpc
+//	        subnet: shop-subnet
+//	    instances:
+//	      - name: web-1
+//	        image: web
+//	        flavor: e2-small
+//	        ports: ["8080"]
+//	        network: private
+//	        volumes: [uploads]
+//	    dns:
+//	      - domain: www.shop.com
+//	        instance: web-1
+//
+// Relative paths of the stack file are relative to the directory of the file. Networks refer to
+// the VPCs, subnets and bridges which exist on the provider, they are neither created nor deleted
+// by the stack.
+type Stack struct {
+	Name      string                `yaml:"name"`
+	Providers map[string]*Resources `yaml:"providers"`
+
+	// dir is the directory of the stack file
+	dir string
+}
+
+// Resources are the resources of a stack on a provider
+type Resources struct {
+	// Config is an ops configuration file the configurations of the resources start from
+	Config    string `yaml:"config,omitempty"`
+	Zone      string `yaml:"zone,omitempty"`
+	ProjectID string `yaml:"projectid,omitempty"`
+	Bucket    string `yaml:"bucket,omitempty"`
+
+	Images    []Image     `yaml:"images,omitempty"`
+	Volumes   []Volume    `yaml:"volumes,omitempty"`
+	Networks  []Network   `yaml:"networks,omitempty"`
+	Instances []Instance  `yaml:"instances,omitempty"`
+	DNS       []DNSRecord `yaml:"dns,omitempty"`
+}
+
+// Image is an image built from a program or a package
+type Image struct {
+	Name    string            `yaml:"name"`
+	Program string            `yaml:"program,omitempty"`
+	Package string            `yaml:"package,omitempty"`
+	Args    []string          `yaml:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+
+	// Config is an ops configuration file overriding the configuration of the provider
+	Config string `yaml:"config,omitempty"`
+}
+
+// Volume is a volume, created empty or from the files of the Data directory. A volume is replaced,
+// losing the data written to it, when the files of the Data directory change; its size cannot be
+// changed once it is created.
+type Volume struct {
+	Name   string `yaml:"name"`
+	Size   string `yaml:"size,omitempty"`
+	Data   string `yaml:"data,omitempty"`
+	Typeof string `yaml:"typeof,omitempty"`
+}
+
+// Network is an existing network instances are attached to, it is not created by the stack
+type Network struct {
+	Name   string `yaml:"name"`
+	VPC    string `yaml:"vpc,omitempty"`
+	Subnet string `yaml:"subnet,omitempty"`
+
+	// Bridge is the bridge the tap of onprem instances is attached to
+	Bridge string `yaml:"bridge,omitempty"`
+}
+
+// Instance is an instance of an image of the stack
+type Instance struct {
+	Name     string   `yaml:"name"`
+	Image    string   `yaml:"image"`
+	Flavor   string   `yaml:"flavor,omitempty"`
+	Ports    []string `yaml:"ports,omitempty"`
+	UDPPorts []string `yaml:"udp_ports,omitempty"`
+	Network  string   `yaml:"network,omitempty"`
+	Volumes  []string `yaml:"volumes,omitempty"`
+
+	// Config is an ops configuration file overriding the configuration of the provider
+	Config string `yaml:"config,omitempty"`
+}
+
+// DNSRecord is an A record of a domain pointing to the address of an instance of the stack
+type DNSRecord struct {
+	Domain   string `yaml:"domain"`
+	Instance string `yaml:"instance"`
+}
+
+var validStackName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Load reads and validates a stack file
+func Load(file string) (*Stack, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read stack: %w", err)
+	}
+
+	s := &Stack{}
+	if err = yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, fmt.Errorf("cannot parse stack %s: %w", file, err)
+	}
+
+	s.dir, err = filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid stack %s: %w", file, err)
+	}
+
+	return s, nil
+}
+
+// Validate checks that the resources of the stack are named uniquely, and that the resources
+// they refer to are part of the stack
+func (s *Stack) Validate() error {
+	if !validStackName.MatchString(s.Name) {
+		return fmt.Errorf("invalid stack name %q", s.Name)
+	}
+
+	for _, providerName := range s.providerNames() {
+		if err := s.Providers[providerName].validate(); err != nil {
+			return fmt.Errorf("%s: %w", providerName, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Resources) validate() error {
+	if r == nil {
+		return nil
+	}
+
+	images := map[string]bool{}
+	for _, i := range r.Images {
+		if err := checkName(images, "image", i.Name); err != nil {
+			return err
+		}
+		if (i.Program == "") == (i.Package == "") {
+			return fmt.Errorf("image %s requires either a program or a package", i.Name)
+		}
+	}
+
+	volumes := map[string]bool{}
+	for _, v := range r.Volumes {
+		if err := checkName(volumes, "volume", v.Name); err != nil {
+			return err
+		}
+	}
+
+	networks := map[string]bool{}
+	for _, n := range r.Networks {
+		if err := checkName(networks, "network", n.Name); err != nil {
+			return err
+		}
+	}
+
+	instances := map[string]bool{}
+	attached := map[string]string{}
+	for _, i := range r.Instances {
+		if err := checkName(instances, "instance", i.Name); err != nil {
+			return err
+		}
+		if !images[i.Image] {
+			return fmt.Errorf("instance %s uses image %q which is not part of the stack", i.Name, i.Image)
+		}
+		if i.Network != "" && !networks[i.Network] {
+			return fmt.Errorf("instance %s uses network %q which is not part of the stack", i.Name, i.Network)
+		}
+		for _, v := range i.Volumes {
+			if !volumes[v] {
+				return fmt.Errorf("instance %s uses volume %q which is not part of the stack", i.Name, v)
+			}
+			if other, ok := attached[v]; ok {
+				return fmt.Errorf("volume %s is used by instances %s and %s", v, other, i.Name)
+			}
+			attached[v] = i.Name
+		}
+	}
+
+	domains := map[string]bool{}
+	for _, d := range r.DNS {
+		if err := checkName(domains, "dns record", d.Domain); err != nil {
+			return err
+		}
+		if !instances[d.Instance] {
+			return fmt.Errorf("dns record %s uses instance %q which is not part of the stack", d.Domain, d.Instance)
+		}
+	}
+
+	return nil
+}
+
+func checkName(names map[string]bool, kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s without a name", kind)
+	}
+	if names[name] {
+		return fmt.Errorf("duplicate %s %s", kind, name)
+	}
+	names[name] = true
+	return nil
+}
+
+// providerNames returns the names of the providers of the stack in order
+func (s *Stack) providerNames() []string {
+	var names []string
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderNames returns the names of the providers a stack is planned with, which are the providers
+// of the stack and the providers of the resources of its state
+func ProviderNames(s *Stack, state *State) []string {
+	names := s.providerNames()
+	for _, r := range state.Resources {
+		if !contains(names, r.Provider) {
+			names = append(names, r.Provider)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns a path of the stack file relative to the directory of the file
+func (s *Stack) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+func (r *Resources) image(name string) *Image {
+	for i := range r.Images {
+		if r.Images[i].Name == name {
+			return &r.Images[i]
+		}
+	}
+	return nil
+}
+
+func (r *Resources) volume(name string) *Volume {
+	for i := range r.Volumes {
+		if r.Volumes[i].Name == name {
+			return &r.Volumes[i]
+		}
+	}
+	return nil
+}
+
+func (r *Resources) network(name string) *Network {
+	for i := range r.Networks {
+		if r.Networks[i].Name == name {
+			return &r.Networks[i]
+		}
+	}
+	return nil
+}
+
+func (r *Resources) instance(name string) *Instance {
+	for i := range r.Instances {
+		if r.Instances[i].Name == name {
+			return &r.Instances[i]
+		}
+	}
+	return nil
+}
+
+func (r *Resources) dnsRecord(domain string) *DNSRecord {
+	for i := range r.DNS {
+		if r.DNS[i].Domain == domain {
+			return &r.DNS[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package stack
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/nanovms/ops/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeStack(t *testing.T, dir, body string) string {
+	file := path.Join(dir, "stack.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte(body), 0644))
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := writeStack(t, dir, `
+name: shop
+providers:
+  gcp:
+    zone: us-west2-a
+    images:
+      - name: web
+        program: bin/web
+        args: ["-port", "8080"]
+    volumes:
+      - name: uploads
+        size: 1g
+    networks:
+      - name: private
+        vpc: shop
+    instances:
+      - name: web-1
+        image: web
+        ports: ["8080"]
+        network: private
+        volumes: [uploads]
+    dns:
+      - domain: www.shop.com
+        instance: web-1
+`)
+
+	s, err := Load(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "shop", s.Name)
+	assert.Equal(t, []string{"-port", "8080"}, s.Providers["gcp"].Images[0].Args)
+	assert.Equal(t, path.Join(dir, "bin/web"), s.path(s.Providers["gcp"].Images[0].Program))
+
+	c, err := s.instanceConfig(&types.Config{Kernel: "kernel.img"}, "gcp", &s.Providers["gcp"].Instances[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "gcp", c.CloudConfig.Platform)
+	assert.Equal(t, "us-west2-a", c.CloudConfig.Zone)
+	assert.Equal(t, "web", c.CloudConfig.ImageName)
+	assert.Equal(t, "web-1", c.RunConfig.InstanceName)
+	assert.Equal(t, "shop", c.CloudConfig.VPC)
+	assert.Equal(t, []string{"8080"}, c.RunConfig.Ports)
+	assert.Equal(t, "kernel.img", c.RunConfig.Kernel)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"field binary not found": `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        binary: web
+`,
+		"invalid stack name": `
+name: ../shop
+`,
+		"image web requires either a program or a package": `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+`,
+		"duplicate image web": `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        program: web
+      - name: web
+        program: web
+`,
+		`image "api" which is not part of the stack`: `
+name: shop
+providers:
+  onprem:
+    instances:
+      - name: api-1
+        image: api
+`,
+		"volume data is used by instances web-1 and web-2": `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        program: web
+    volumes:
+      - name: data
+    instances:
+      - name: web-1
+        image: web
+        volumes: [data]
+      - name: web-2
+        image: web
+        volumes: [data]
+`,
+	}
+
+	for expected, body := range tests {
+		t.Run(expected, func(t *testing.T) {
+			_, err := Load(writeStack(t, t.TempDir(), body))
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
+
+func TestResourcesDigest(t *testing.T) {
+	dir := t.TempDir()
+	program := path.Join(dir, "web")
+	assert.Nil(t, os.WriteFile(program, []byte("v1"), 0755))
+	data := path.Join(dir, "files", "static")
+	assert.Nil(t, os.MkdirAll(data, 0755))
+	assert.Nil(t, os.WriteFile(path.Join(data, "index.html"), []byte("v1"), 0644))
+	s, err := Load(writeStack(t, dir, `
+name: shop
+providers:
+  onprem:
+    images:
+      - name: web
+        program: web
+      - name: api
+        program: web
+    volumes:
+      - name: static
+        data: files
+    instances:
+      - name: web-1
+        image: web
+      - name: api-1
+        image: api
+`))
+	assert.Nil(t, err)
+
+	before, err := s.resources()
+	assert.Nil(t, err)
+	assert.Len(t, before, 5)
+
+	// changing a program replaces its images and their instances, and changing the data of a
+	// volume replaces the volume
+	assert.Nil(t, os.WriteFile(program, []byte("v2"), 0755))
+	assert.Nil(t, os.WriteFile(path.Join(data, "index.html"), []byte("v2"), 0644))
+
+	after, err := s.resources()
+	assert.Nil(t, err)
+	for i := range before {
+		assert.NotEqual(t, before[i].Digest, after[i].Digest, before[i].String())
+	}
+
+	s.Providers["onprem"].Images[0].Program = "missing"
+	_, err = s.resources()
+	assert.ErrorContains(t, err, "cannot read stack files")
+}
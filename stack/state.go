@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/nanovms/ops/lepton"
+)
+
+// Kind is a kind of resource of a stack
+type Kind string
+
+// Kinds of resources, in the order they are created
+const (
+	KindImage    Kind = "image"
+	KindVolume   Kind = "volume"
+	KindInstance Kind = "instance"
+	KindDNS      Kind = "dns"
+)
+
+var kindOrder = []Kind{KindImage, KindVolume, KindInstance, KindDNS}
+
+// Resource identifies a resource of a stack on a provider, with the digest of its specification
+type Resource struct {
+	Provider string `json:"provider"`
+	Kind     Kind   `json:"kind"`
+	Name     string `json:"name"`
+	Digest   string `json:"digest"`
+
+	// Size is the size of a volume
+	Size string `json:"size,omitempty"`
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Provider, r.Name)
+}
+
+func (r Resource) is(o Resource) bool {
+	return r.Provider == o.Provider && r.Kind == o.Kind && r.Name == o.Name
+}
+
+// State records the resources created or adopted by applying a stack
+type State struct {
+	Resources []Resource `json:"resources"`
+
+	file string
+}
+
+// StatePath returns the path of the state of a stack
+func StatePath(name string) string {
+	return path.Join(lepton.GetOpsHome(), "stacks", name+".json")
+}
+
+// LoadState reads the state of a stack, which is empty if the stack was never applied
+func LoadState(name string) (*State, error) {
+	s := &State{file: StatePath(name)}
+
+	body, err := os.ReadFile(s.file)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read state of stack %s: %w", name, err)
+	}
+
+	if err = json.Unmarshal(body, s); err != nil {
+		return nil, fmt.Errorf("cannot parse state of stack %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// Save writes the state
+func (s *State) Save() error {
+	if err := os.MkdirAll(path.Dir(s.file), 0755); err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err = os.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+func (s *State) find(r Resource) (Resource, bool) {
+	for _, sr := range s.Resources {
+		if sr.is(r) {
+			return sr, true
+		}
+	}
+	return Resource{}, false
+}
+
+func (s *State) set(r Resource) {
+	for i := range s.Resources {
+		if s.Resources[i].is(r) {
+			s.Resources[i] = r
+			return
+		}
+	}
+	s.Resources = append(s.Resources, r)
+}
+
+func (s *State) remove(r Resource) {
+	for i := range s.Resources {
+		if s.Resources[i].is(r) {
+			s.Resources = append(s.Resources[:i], s.Resources[i+1:]...)
+			return
+		}
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/json"
 	"reflect"
+	"time"
 )
 
 // Config for Build
@@ -116,6 +117,19 @@ type Config struct {
 	// TFSv4 forces use of the deprecated TFS version 4 encoding
 	TFSv4 bool `json:",omitempty"`
 
+	// Incremental keeps a content index next to the image and, on rebuilds,
+	// only writes the files that changed since the previous build.
+	Incremental bool `json:",omitempty"`
+
+	// Reproducible builds produce the same image bytes from the same inputs:
+	// filesystem UUIDs are derived from the image contents, and file times
+	// are set from the SOURCE_DATE_EPOCH environment variable if defined.
+	Reproducible bool `json:",omitempty"`
+
+	// SBOM is the format of the software bill of materials written next to
+	// the image: spdx or cyclonedx. No SBOM is written if empty.
+	SBOM string `json:",omitempty"`
+
 	// Version
 	Version string `json:",omitempty"`
 
@@ -168,6 +182,14 @@ type ProviderConfig struct {
 	// ImageName
 	ImageName string `cloud:"imagename" json:",omitempty"`
 
+	// ImageSigningKey is the path of a PEM private key used to sign images
+	// after they are built.
+	ImageSigningKey string `json:",omitempty"`
+
+	// ImageVerifyKey is the path of the PEM public key used to verify image
+	// signatures when RequireSignedImages is set.
+	ImageVerifyKey string `json:",omitempty"`
+
 	// InstanceProfile is a container for an IAM role
 	// you can use to pass role information to an EC2 instance when the instance starts.
 	InstanceProfile string `json:",omitempty"`
@@ -184,6 +206,10 @@ type ProviderConfig struct {
 	// to gcp.
 	ProjectID string `cloud:"projectid" json:",omitempty"`
 
+	// RequireSignedImages makes image and instance creation fail for images
+	// without a valid signature from ImageVerifyKey.
+	RequireSignedImages bool `json:",omitempty"`
+
 	// RootVolume are specific settings for the root volume.
 	RootVolume CloudVolume `cloud:"root_volume" json:",omitempty"`
 
@@ -287,6 +313,10 @@ type RunConfig struct {
 	// Accel defines whether hardware acceleration should be enabled.
 	Accel bool `json:",omitempty"`
 
+	// AllowedSources restricts the sources allowed to reach the Ports and UDPPorts of instances
+	// on a tap device to a list of addresses or CIDRs; any source is allowed if empty.
+	AllowedSources []string `json:",omitempty"`
+
 	// AtExit allows hooks to be ran after instance stops.
 	AtExit string `json:",omitempty"`
 
@@ -319,6 +349,10 @@ type RunConfig struct {
 	// GdbPort
 	GdbPort int `json:",omitempty"`
 
+	// Hypervisor selects the local hypervisor running the instance: qemu (default), firecracker or
+	// cloud-hypervisor.
+	Hypervisor string `json:",omitempty"`
+
 	// ImageName
 	ImageName string `json:",omitempty"`
 
@@ -337,12 +371,17 @@ type RunConfig struct {
 	// Kernel
 	Kernel string `json:",omitempty"`
 
+	// Mac is the mac address of the first network interface of onprem instances, generated if
+	// empty
+	Mac string `json:",omitempty"`
+
 	// Memory configures the amount of memory to allocate to qemu (default
 	// is 128 MiB). Optionally, a suffix of "M" or "G" can be used to
 	// signify a value in megabytes or gigabytes respectively.
 	Memory string `json:",omitempty"`
 
-	// Mgmt is an optional mgmt port for onprem QMP access.
+	// Mgmt is an optional mgmt port for onprem QMP access, or the API socket path of instances
+	// run by firecracker or cloud-hypervisor.
 	Mgmt string `json:",omitempty"`
 
 	// Vga whether to emulate a VGA output device
@@ -362,7 +401,7 @@ type RunConfig struct {
 
 	// Nics is a list of pre-configured network cards
 	// Meant to eventually deprecate the existing single-nic configuration
-	// Currently only supported for Proxmox
+	// Supported for Proxmox and onprem, where each nic is attached to its own tap device and bridge
 	Nics []Nic `json:",omitempty"`
 
 	// Background runs unikernel in background
@@ -374,12 +413,25 @@ type RunConfig struct {
 	// instead of 'ops'.
 	BackgroundDetach bool `json:",omitempty"`
 
-	// Ports specifies a list of port to expose.
+	// Ports specifies a list of port to expose. On a tap device, the other ports of the instance
+	// are filtered by the firewall of the host.
 	Ports []string `json:",omitempty"`
 
 	// QMP optionally turns on a QMP interface for the onprem target.
 	QMP bool `json:",omitempty"`
 
+	// Restart is the restart policy of onprem instances: no (default), on-failure or always;
+	// instances with a policy other than no are run by a supervisor process.
+	Restart string `json:",omitempty"`
+
+	// RestartMaxRetries limits the number of restarts of instances with the on-failure policy,
+	// unlimited if 0.
+	RestartMaxRetries int `json:",omitempty"`
+
+	// Incoming makes qemu wait for the state of the instance to be migrated in instead of booting
+	// it, eg: "defer" to wait for the migrate-incoming QMP command; used to restore snapshots
+	Incoming string `json:"-"`
+
 	// ShowDebug
 	ShowDebug bool `json:",omitempty"`
 
@@ -395,9 +447,17 @@ type RunConfig struct {
 	// TapName
 	TapName string `json:",omitempty"`
 
+	// Timeout cancels the provider operations of a command, whose partially created resources
+	// are then removed, if they take longer; operations are not limited if zero.
+	Timeout time.Duration `json:"-"`
+
 	// UDPPorts
 	UDPPorts []string `json:",omitempty"`
 
+	// UnfilteredPorts launches instances on a tap device even if their ports cannot be filtered,
+	// leaving every port of the instances reachable.
+	UnfilteredPorts bool `json:",omitempty"`
+
 	// Verbose enables logging for the runtime environment.
 	Verbose bool `json:",omitempty"`
 
@@ -428,6 +488,14 @@ type Nic struct {
 
 	// BridgeName
 	BridgeName string `json:",omitempty"`
+
+	// TapName is the tap device of the nic of onprem instances, derived from the mac address of
+	// the nic if empty
+	TapName string `json:",omitempty"`
+
+	// Mac is the mac address of the nic of onprem instances, derived from the mac address of the
+	// instance if empty
+	Mac string `json:",omitempty"`
 }
 
 // MarshalJSON ...